@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/MosinEvgeny/task-tracker/internal/config"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/postgres"
+)
+
+const adminUsage = "usage: task-tracker admin grant|revoke <email>"
+
+// runAdmin выполняет подкоманду admin: grant выдает пользователю с указанным
+// email права администратора, revoke отзывает их.
+func runAdmin(cfg config.Config, args []string) error {
+	if len(args) != 2 {
+		return errors.New(adminUsage)
+	}
+
+	var isAdmin bool
+	switch args[0] {
+	case "grant":
+		isAdmin = true
+	case "revoke":
+		isAdmin = false
+	default:
+		return errors.New(adminUsage)
+	}
+
+	db, err := postgres.NewPostgresDB(cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %w", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	users := postgres.NewUserRepository(db)
+	user, err := users.GetByEmail(ctx, args[1])
+	if err != nil {
+		return fmt.Errorf("failed to find user %s: %w", args[1], err)
+	}
+	if err := users.SetAdmin(ctx, user.ID, isAdmin); err != nil {
+		return err
+	}
+
+	if isAdmin {
+		log.Printf("Granted admin rights to %s", user.Email)
+	} else {
+		log.Printf("Revoked admin rights from %s", user.Email)
+	}
+	return nil
+}
@@ -2,13 +2,31 @@ package main
 
 import (
 	"log"
+	"os"
 
 	"github.com/MosinEvgeny/task-tracker/internal/app"
 	"github.com/MosinEvgeny/task-tracker/internal/config"
 )
 
 func main() {
-	cfg := config.LoadConfig()
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		if err := runAdmin(cfg, os.Args[2:]); err != nil {
+			log.Fatalf("Admin command failed: %v", err)
+		}
+		return
+	}
 
 	app, err := app.NewApp(cfg)
 	if err != nil {
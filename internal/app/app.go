@@ -2,16 +2,26 @@ package app
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
 	"github.com/MosinEvgeny/task-tracker/internal/config"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/MosinEvgeny/task-tracker/internal/handlers"
+	"github.com/MosinEvgeny/task-tracker/internal/mail"
+	"github.com/MosinEvgeny/task-tracker/internal/migrations"
+	"github.com/MosinEvgeny/task-tracker/internal/oidc"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
 	"github.com/MosinEvgeny/task-tracker/internal/repository/postgres"
 	"github.com/MosinEvgeny/task-tracker/internal/service"
 	"github.com/gorilla/mux"
@@ -19,73 +29,326 @@ import (
 )
 
 type App struct {
-	config config.Config
-	router *mux.Router
-	db     *postgres.PostgresDB
+	config   config.Config
+	router   *mux.Router
+	db       *postgres.PostgresDB // nil, если данные хранятся в памяти
+	repos    repositories
+	workflow *domain.Workflow
+	keys     *auth.KeySet
+	issuer   *auth.Issuer
+	mailer   mail.Mailer
+
+	passwords   service.Passwords
+	emailTokens *auth.EmailTokens
+	challenges  *auth.MFAChallenges
+	throttle    service.LoginThrottleConfig
+	oauth       service.OAuthConfig
+
+	// oidcClient и oidcStates равны nil, если вход через провайдера
+	// OpenID Connect не настроен.
+	oidcClient *oidc.Client
+	oidcStates *auth.OIDCStates
 }
 
-func NewApp(config config.Config) (*App, error) {
-	db, err := postgres.NewPostgresDB(config.DatabaseURL)
+// repositories объединяет репозитории выбранного хранилища.
+type repositories struct {
+	users         repository.UserRepository
+	tasks         repository.TaskRepository
+	labels        repository.LabelRepository
+	refreshTokens repository.RefreshTokenRepository
+	sessions      repository.SessionRepository
+	denyList      repository.DenyListRepository
+
+	passwordResets repository.PasswordResetRepository
+	totp           repository.TOTPRepository
+	loginThrottles repository.LoginThrottleRepository
+	audit          repository.AuditRepository
+	personalTokens repository.PersonalAccessTokenRepository
+	oauthClients   repository.OAuthClientRepository
+	oauthCodes     repository.OAuthCodeRepository
+
+	externalIdentities repository.ExternalIdentityRepository
+}
+
+func NewApp(cfg config.Config) (*App, error) {
+	workflow := domain.DefaultWorkflow()
+	if cfg.TaskWorkflow != "" {
+		var err error
+		workflow, err = domain.ParseWorkflow(cfg.TaskWorkflow, cfg.TaskCompletedStatuses)
+		if err != nil {
+			return nil, fmt.Errorf("invalid task workflow: %w", err)
+		}
+	}
+
+	keys, err := loadKeys(cfg)
+	if err != nil {
+		return nil, err
+	}
+	issuer, err := auth.NewIssuer(keys, auth.IssuerConfig{
+		Issuer:   cfg.JWTIssuer,
+		Audience: cfg.JWTAudience,
+		TTL:      cfg.AccessTokenTTL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token settings: %w", err)
+	}
+	if cfg.RefreshTokenTTL <= 0 {
+		return nil, fmt.Errorf("invalid refresh token lifetime: %s", cfg.RefreshTokenTTL)
+	}
+	if cfg.PasswordResetTTL <= 0 {
+		return nil, fmt.Errorf("invalid password reset lifetime: %s", cfg.PasswordResetTTL)
+	}
+
+	passwords, err := newPasswords(cfg)
+	if err != nil {
+		return nil, err
+	}
+	emailTokens, err := auth.NewEmailTokens(keys, cfg.JWTIssuer, cfg.EmailVerificationTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid email verification settings: %w", err)
+	}
+	challenges, err := auth.NewMFAChallenges(keys, cfg.JWTIssuer, cfg.MFAChallengeTTL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MFA settings: %w", err)
+	}
+	throttle := service.LoginThrottleConfig{
+		MaxFailures:   cfg.LoginMaxFailures,
+		MaxIPFailures: cfg.LoginMaxIPFailures,
+		Lockout:       cfg.LoginLockoutTTL,
+		MaxLockout:    cfg.LoginMaxLockoutTTL,
+		FailureWindow: cfg.LoginFailureWindow,
+	}
+	if err := throttle.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid login throttling settings: %w", err)
+	}
+
+	if cfg.OAuthCodeTTL <= 0 {
+		return nil, fmt.Errorf("invalid OAuth authorization code lifetime: %s", cfg.OAuthCodeTTL)
+	}
+
+	mailer, err := newMailer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	oidcClient, oidcStates, err := newOIDC(cfg, keys)
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize database: %w", err)
+		return nil, err
+	}
+
+	app := &App{
+		config:   cfg,
+		router:   mux.NewRouter(),
+		workflow: workflow,
+		keys:     keys,
+		issuer:   issuer,
+		mailer:   mailer,
+
+		passwords:   passwords,
+		emailTokens: emailTokens,
+		challenges:  challenges,
+		throttle:    throttle,
+		oauth:       service.OAuthConfig{CodeTTL: cfg.OAuthCodeTTL},
+
+		oidcClient: oidcClient,
+		oidcStates: oidcStates,
+	}
+
+	switch cfg.Storage {
+	case "", config.StoragePostgres:
+		db, err := postgres.NewPostgresDB(cfg.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize database: %w", err)
+		}
+
+		if cfg.AutoMigrate {
+			if err := migrate(db); err != nil {
+				db.Close()
+				return nil, err
+			}
+		}
+
+		app.db = db
+		app.repos = repositories{
+			users:         postgres.NewUserRepository(db),
+			tasks:         postgres.NewTaskRepository(db),
+			labels:        postgres.NewLabelRepository(db),
+			refreshTokens: postgres.NewRefreshTokenRepository(db),
+			sessions:      postgres.NewSessionRepository(db),
+			denyList:      postgres.NewDenyListRepository(db),
+
+			passwordResets: postgres.NewPasswordResetRepository(db),
+			totp:           postgres.NewTOTPRepository(db),
+			loginThrottles: postgres.NewLoginThrottleRepository(db),
+			audit:          postgres.NewAuditRepository(db),
+			personalTokens: postgres.NewPersonalAccessTokenRepository(db),
+			oauthClients:   postgres.NewOAuthClientRepository(db),
+			oauthCodes:     postgres.NewOAuthCodeRepository(db),
+
+			externalIdentities: postgres.NewExternalIdentityRepository(db),
+		}
+	case config.StorageMemory:
+		log.Println("Using in-memory storage, data will be lost on restart")
+		store := memory.NewStore()
+		app.repos = repositories{
+			users:         memory.NewUserRepository(store),
+			tasks:         memory.NewTaskRepository(store),
+			labels:        memory.NewLabelRepository(store),
+			refreshTokens: memory.NewRefreshTokenRepository(store),
+			sessions:      memory.NewSessionRepository(store),
+			denyList:      memory.NewDenyListRepository(store),
+
+			passwordResets: memory.NewPasswordResetRepository(store),
+			totp:           memory.NewTOTPRepository(store),
+			loginThrottles: memory.NewLoginThrottleRepository(store),
+			audit:          memory.NewAuditRepository(store),
+			personalTokens: memory.NewPersonalAccessTokenRepository(store),
+			oauthClients:   memory.NewOAuthClientRepository(store),
+			oauthCodes:     memory.NewOAuthCodeRepository(store),
+
+			externalIdentities: memory.NewExternalIdentityRepository(store),
+		}
+	default:
+		return nil, fmt.Errorf("unknown storage %q", cfg.Storage)
 	}
 
-	return &App{
-		config: config,
-		router: mux.NewRouter(),
-		db:     db,
-	}, nil
+	return app, nil
 }
 
-func (a *App) Run() error {
+// Handler настраивает маршруты и возвращает обработчик HTTP приложения.
+func (a *App) Handler() http.Handler {
 	// Инициализация зависимостей
-	userRepo := postgres.NewUserRepository(a.db)
-	userService := service.NewUserService(userRepo)
+	auditService := service.NewAuditService(a.repos.audit)
+	throttleService := service.NewLoginThrottleService(a.repos.loginThrottles, a.repos.users, auditService, a.throttle)
+	verificationService := service.NewEmailVerificationService(a.repos.users, a.emailTokens, a.mailer, throttleService, service.EmailVerificationConfig{
+		URL:      a.config.EmailVerificationURL,
+		Required: a.config.RequireEmailVerification,
+	})
+	userService := service.NewUserService(a.repos.users, verificationService, a.passwords)
+	refreshTokenService := service.NewRefreshTokenService(a.repos.refreshTokens, a.repos.sessions, a.config.RefreshTokenTTL)
 
-	refreshTokenRepo := postgres.NewRefreshTokenRepository(a.db)
-	refreshTokenService := service.NewRefreshTokenService(refreshTokenRepo)
+	revocationService := service.NewTokenRevocationService(a.repos.users, a.repos.denyList, a.repos.oauthClients, a.repos.sessions)
+	passwordService := service.NewPasswordService(a.repos.users, a.repos.passwordResets, refreshTokenService, revocationService, throttleService, a.passwords, a.mailer, service.PasswordResetConfig{
+		URL: a.config.PasswordResetURL,
+		TTL: a.config.PasswordResetTTL,
+	})
+	mfaService := service.NewMFAService(a.repos.users, a.repos.totp, a.repos.denyList, throttleService, a.challenges, a.passwords.Hasher, service.MFAConfig{
+		Issuer: a.config.MFAIssuer,
+	})
 
-	userHandler := handlers.NewUserHandler(userService, refreshTokenService, a.config)
+	userHandler := handlers.NewUserHandler(userService, refreshTokenService, revocationService, verificationService, mfaService, throttleService, a.issuer)
+	verificationHandler := handlers.NewEmailVerificationHandler(verificationService)
+	sessionHandler := handlers.NewSessionHandler(refreshTokenService, revocationService)
+	passwordHandler := handlers.NewPasswordHandler(passwordService)
+	mfaHandler := handlers.NewMFAHandler(mfaService)
+	adminHandler := handlers.NewAdminHandler(throttleService, auditService)
+	personalTokenService := service.NewPersonalAccessTokenService(a.repos.personalTokens)
+	tokenHandler := handlers.NewTokenHandler(personalTokenService)
+	oauthService := service.NewOAuthService(a.repos.oauthClients, a.repos.oauthCodes, revocationService, a.issuer, a.oauth)
+	oauthHandler := handlers.NewOAuthHandler(oauthService)
+	jwksHandler := handlers.NewJWKSHandler(a.keys)
 
-	taskRepo := postgres.NewTaskRepository(a.db)
-	taskService := service.NewTaskService(taskRepo)
+	taskService := service.NewTaskService(a.repos.tasks, a.repos.labels, a.workflow)
 	taskHandler := handlers.NewTaskHandler(taskService)
 
-	labelRepo := postgres.NewLabelRepository(a.db)
-	labelService := service.NewLabelService(labelRepo)
+	labelService := service.NewLabelService(a.repos.labels)
 	labelHandler := handlers.NewLabelHandler(labelService)
 
 	// Настройка middleware
-	authMiddleware := handlers.NewAuthMiddleware(userService, a.config)
+	authMiddleware := handlers.NewAuthMiddleware(userService, revocationService, personalTokenService, a.issuer)
 	logMiddleware := handlers.Log
 
 	// Настройка маршрутов
 	a.router.HandleFunc("/register", userHandler.RegisterUser).Methods("POST")
 	a.router.HandleFunc("/login", userHandler.LoginUser).Methods("POST")
+	a.router.HandleFunc("/login/mfa", userHandler.LoginMFA).Methods("POST")
+	if a.oidcClient != nil {
+		oidcService := service.NewOIDCLoginService(a.oidcClient, a.oidcStates, a.repos.users, a.repos.externalIdentities, auditService, service.OIDCLoginConfig{
+			AutoProvision: a.config.OIDCAutoProvision,
+		})
+		oidcHandler := handlers.NewOIDCHandler(oidcService, refreshTokenService, mfaService, a.issuer)
+		a.router.HandleFunc("/login/oidc", oidcHandler.Begin).Methods("GET")
+		a.router.HandleFunc("/login/oidc/callback", oidcHandler.Callback).Methods("GET")
+	}
 	a.router.HandleFunc("/refresh", userHandler.RefreshToken).Methods("POST")
+	a.router.HandleFunc("/.well-known/jwks.json", jwksHandler.GetJWKS).Methods("GET")
+	a.router.HandleFunc("/verify-email", verificationHandler.VerifyEmail).Methods("GET")
+	a.router.HandleFunc("/verify-email/resend", verificationHandler.ResendVerification).Methods("POST")
+	a.router.HandleFunc("/password/forgot", passwordHandler.ForgotPassword).Methods("POST")
+	a.router.HandleFunc("/password/reset", passwordHandler.ResetPassword).Methods("POST")
+
+	// Управление учетной записью, сессиями и токенами доступно только токенам
+	// сессии: персональные токены ограничены задачами и метками
 
 	// Маршрут для отзыва всех refresh токенов
 	userRouter := a.router.PathPrefix("/users").Subrouter()
-	userRouter.Use(authMiddleware.Authenticate)
+	userRouter.Use(authMiddleware.Authenticate, authMiddleware.RequireFullAccess)
 	userRouter.HandleFunc("/{id}", userHandler.GetUser).Methods("GET")
 	userRouter.HandleFunc("/{id}", userHandler.UpdateUser).Methods("PUT")
 	userRouter.HandleFunc("/{id}", userHandler.DeleteUser).Methods("DELETE")
 	userRouter.HandleFunc("/revoke", userHandler.RevokeAllRefreshTokens).Methods("POST")
+	userRouter.HandleFunc("/me/password", passwordHandler.ChangePassword).Methods("POST")
+
+	// Двухфакторная аутентификация текущего пользователя
+	userRouter.HandleFunc("/me/2fa", mfaHandler.GetStatus).Methods("GET")
+	userRouter.HandleFunc("/me/2fa/totp", mfaHandler.BeginTOTPEnrollment).Methods("POST")
+	userRouter.HandleFunc("/me/2fa/totp/confirm", mfaHandler.ConfirmTOTPEnrollment).Methods("POST")
+	userRouter.HandleFunc("/me/2fa/disable", mfaHandler.DisableTOTP).Methods("POST")
+	userRouter.HandleFunc("/me/2fa/recovery-codes", mfaHandler.RegenerateRecoveryCodes).Methods("POST")
+
+	// Сессии (устройства) текущего пользователя
+	sessionRouter := a.router.PathPrefix("/sessions").Subrouter()
+	sessionRouter.Use(authMiddleware.Authenticate, authMiddleware.RequireFullAccess)
+	sessionRouter.HandleFunc("", sessionHandler.ListSessions).Methods("GET")
+	sessionRouter.HandleFunc("/{id}", sessionHandler.RevokeSession).Methods("DELETE")
+
+	a.router.Handle("/logout", authMiddleware.Authenticate(http.HandlerFunc(sessionHandler.Logout))).Methods("POST")
+
+	// Персональные токены доступа текущего пользователя
+	tokenRouter := a.router.PathPrefix("/tokens").Subrouter()
+	tokenRouter.Use(authMiddleware.Authenticate, authMiddleware.RequireFullAccess)
+	tokenRouter.HandleFunc("", tokenHandler.ListTokens).Methods("GET")
+	tokenRouter.HandleFunc("", tokenHandler.CreateToken).Methods("POST")
+	tokenRouter.HandleFunc("/{id}", tokenHandler.RevokeToken).Methods("DELETE")
+
+	// Сервер авторизации OAuth2. Конечные точки для клиентов принимают
+	// application/x-www-form-urlencoded и аутентифицируют клиента, а не
+	// пользователя, поэтому регистрируются до подмаршрутизатора /oauth
+	a.router.HandleFunc("/oauth/token", oauthHandler.Token).Methods("POST")
+	a.router.HandleFunc("/oauth/introspect", oauthHandler.Introspect).Methods("POST")
+	a.router.HandleFunc("/oauth/revoke", oauthHandler.Revoke).Methods("POST")
+
+	// Клиенты OAuth текущего пользователя и экран согласия
+	oauthRouter := a.router.PathPrefix("/oauth").Subrouter()
+	oauthRouter.Use(authMiddleware.Authenticate, authMiddleware.RequireFullAccess)
+	oauthRouter.HandleFunc("/clients", oauthHandler.ListClients).Methods("GET")
+	oauthRouter.HandleFunc("/clients", oauthHandler.RegisterClient).Methods("POST")
+	oauthRouter.HandleFunc("/clients/{id}", oauthHandler.DeleteClient).Methods("DELETE")
+	oauthRouter.HandleFunc("/authorize", oauthHandler.Consent).Methods("GET")
+	oauthRouter.HandleFunc("/authorize", oauthHandler.Authorize).Methods("POST")
 
 	taskRouter := a.router.PathPrefix("/tasks").Subrouter()
-	taskRouter.Use(authMiddleware.Authenticate)
+	taskRouter.Use(authMiddleware.Authenticate, authMiddleware.RequireScope(auth.ScopeTasksRead, auth.ScopeTasksWrite))
+	taskRouter.HandleFunc("", taskHandler.ListTasks).Methods("GET")
 	taskRouter.HandleFunc("", taskHandler.CreateTask).Methods("POST")
 	taskRouter.HandleFunc("/{id}", taskHandler.GetTask).Methods("GET")
 	taskRouter.HandleFunc("/{id}", taskHandler.UpdateTask).Methods("PUT")
 	taskRouter.HandleFunc("/{id}", taskHandler.DeleteTask).Methods("DELETE")
+	taskRouter.HandleFunc("/{id}/transition", taskHandler.TransitionTask).Methods("POST")
 
 	labelRouter := a.router.PathPrefix("/labels").Subrouter()
-	labelRouter.Use(authMiddleware.Authenticate)
+	labelRouter.Use(authMiddleware.Authenticate, authMiddleware.RequireScope(auth.ScopeLabelsRead, auth.ScopeLabelsWrite))
+	labelRouter.HandleFunc("", labelHandler.ListLabels).Methods("GET")
 	labelRouter.HandleFunc("", labelHandler.CreateLabel).Methods("POST")
 	labelRouter.HandleFunc("/{id}", labelHandler.GetLabel).Methods("GET")
 	labelRouter.HandleFunc("/{id}", labelHandler.UpdateLabel).Methods("PUT")
 	labelRouter.HandleFunc("/{id}", labelHandler.DeleteLabel).Methods("DELETE")
 
+	// Администрирование: доступно пользователям с правами администратора
+	adminRouter := a.router.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(authMiddleware.Authenticate, authMiddleware.RequireFullAccess, authMiddleware.RequireAdmin)
+	adminRouter.HandleFunc("/users/{id}/unlock", adminHandler.UnlockUser).Methods("POST")
+	adminRouter.HandleFunc("/audit-events", adminHandler.ListAuditEvents).Methods("GET")
+
 	// Логирование всех запросов
 	a.router.Use(logMiddleware)
 
@@ -98,7 +361,11 @@ func (a *App) Run() error {
 	})
 
 	// CORS middleware в router
-	handler := c.Handler(a.router)
+	return c.Handler(a.router)
+}
+
+func (a *App) Run() error {
+	handler := a.Handler()
 
 	// Запуск сервера
 	server := &http.Server{
@@ -123,6 +390,9 @@ func (a *App) Run() error {
 		}
 		log.Println("Server gracefully stopped")
 
+		if a.db == nil {
+			return
+		}
 		if err := a.db.Close(); err != nil {
 			log.Fatalf("Database connection close failed: %v", err)
 		}
@@ -137,6 +407,128 @@ func (a *App) Run() error {
 	return nil
 }
 
+// loadKeys загружает ключи подписи access токенов. Без файлов ключей токены
+// подписываются HS256 секретом JWT_SECRET; секрет по умолчанию допускается
+// только в режиме разработки.
+func loadKeys(cfg config.Config) (*auth.KeySet, error) {
+	if cfg.JWTSigningKeyFile == "" {
+		if !cfg.DevMode && (cfg.JWTSecret == "" || cfg.JWTSecret == config.DefaultJWTSecret) {
+			return nil, errors.New("refusing to start with the default JWT secret: set JWT_SIGNING_KEY_FILE or JWT_SECRET, or DEV_MODE=true for local development")
+		}
+		return auth.NewHMACKeySet(cfg.JWTSecret), nil
+	}
+
+	var verificationFiles []string
+	for _, path := range strings.Split(cfg.JWTVerificationKeyFiles, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			verificationFiles = append(verificationFiles, path)
+		}
+	}
+
+	keys, err := auth.LoadKeySet(cfg.JWTSigningKeyFile, verificationFiles...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWT keys: %w", err)
+	}
+	return keys, nil
+}
+
+// newPasswords создает хеширование паролей и политику стойкости паролей
+// из настроек.
+func newPasswords(cfg config.Config) (service.Passwords, error) {
+	// Параметры приводятся к uint32 и uint8, поэтому значения вне диапазона
+	// отклоняются, а не усекаются
+	if cfg.PasswordArgon2Memory < 0 || int64(cfg.PasswordArgon2Memory) > math.MaxUint32 ||
+		cfg.PasswordArgon2Iterations < 0 || int64(cfg.PasswordArgon2Iterations) > math.MaxUint32 ||
+		cfg.PasswordArgon2Parallelism < 0 || cfg.PasswordArgon2Parallelism > math.MaxUint8 {
+		return service.Passwords{}, errors.New("invalid password hashing settings: argon2id parameters out of range")
+	}
+	hasher, err := auth.NewPasswordHasher(auth.PasswordHashConfig{
+		Algorithm:         cfg.PasswordHashAlgorithm,
+		Argon2Memory:      uint32(cfg.PasswordArgon2Memory),
+		Argon2Iterations:  uint32(cfg.PasswordArgon2Iterations),
+		Argon2Parallelism: uint8(cfg.PasswordArgon2Parallelism),
+		BcryptCost:        cfg.PasswordBcryptCost,
+	})
+	if err != nil {
+		return service.Passwords{}, fmt.Errorf("invalid password hashing settings: %w", err)
+	}
+
+	policy, err := auth.LoadPasswordPolicy(cfg.PasswordMinLength, cfg.PasswordBreachedListFile)
+	if err != nil {
+		return service.Passwords{}, fmt.Errorf("invalid password policy settings: %w", err)
+	}
+	return service.Passwords{Hasher: hasher, Policy: policy}, nil
+}
+
+// newOIDC создает клиента провайдера OpenID Connect и токены состояния
+// входа. Без OIDC_ISSUER_URL вход через провайдера отключен и возвращаются nil.
+func newOIDC(cfg config.Config, keys *auth.KeySet) (*oidc.Client, *auth.OIDCStates, error) {
+	if cfg.OIDCIssuerURL == "" {
+		return nil, nil, nil
+	}
+
+	client, err := oidc.NewClient(oidc.Config{
+		IssuerURL:    cfg.OIDCIssuerURL,
+		ClientID:     cfg.OIDCClientID,
+		ClientSecret: cfg.OIDCClientSecret,
+		RedirectURL:  cfg.OIDCRedirectURL,
+		Scopes:       strings.Fields(cfg.OIDCScopes),
+	}, &http.Client{Timeout: 10 * time.Second})
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid OpenID Connect settings: %w", err)
+	}
+	states, err := auth.NewOIDCStates(keys, cfg.JWTIssuer, cfg.OIDCStateTTL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid OpenID Connect settings: %w", err)
+	}
+	return client, states, nil
+}
+
+// newMailer создает способ доставки писем, выбранный в настройках.
+func newMailer(cfg config.Config) (mail.Mailer, error) {
+	switch cfg.Mailer {
+	case "", config.MailerLog:
+		return mail.NewLogMailer(nil), nil
+	case config.MailerSMTP:
+		mailer, err := mail.NewSMTPMailer(mail.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize mailer: %w", err)
+		}
+		return mailer, nil
+	case config.MailerFile:
+		mailer, err := mail.NewFileMailer(cfg.MailDir, cfg.MailFrom)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize mailer: %w", err)
+		}
+		return mailer, nil
+	default:
+		return nil, fmt.Errorf("unknown mailer %q", cfg.Mailer)
+	}
+}
+
+// migrate применяет к базе данных все еще не примененные миграции.
+func migrate(db *postgres.PostgresDB) error {
+	migrator, err := migrations.New(db.DB)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %d_%s", m.Version, m.Name)
+	}
+	return nil
+}
+
 func logMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log.Printf("%s %s %s", r.Method, r.RequestURI, r.RemoteAddr)
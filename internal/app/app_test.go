@@ -0,0 +1,1163 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/config"
+	"github.com/MosinEvgeny/task-tracker/internal/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "test-secret"
+
+// testConfig возвращает настройки приложения с хранилищем в памяти.
+func testConfig() config.Config {
+	return config.Config{
+		JWTSecret:       testSecret,
+		JWTIssuer:       "task-tracker",
+		JWTAudience:     "task-tracker",
+		AccessTokenTTL:  time.Hour,
+		RefreshTokenTTL: 24 * time.Hour,
+		Storage:         config.StorageMemory,
+
+		PasswordResetURL: "http://localhost:5173/reset-password",
+		PasswordResetTTL: time.Hour,
+
+		EmailVerificationURL: "http://localhost:8080/verify-email",
+		EmailVerificationTTL: time.Hour,
+
+		MFAIssuer:       "Task Tracker",
+		MFAChallengeTTL: 5 * time.Minute,
+
+		LoginMaxFailures:   3,
+		LoginMaxIPFailures: 10,
+		LoginLockoutTTL:    time.Minute,
+		LoginMaxLockoutTTL: time.Hour,
+		LoginFailureWindow: 24 * time.Hour,
+
+		OAuthCodeTTL: time.Minute,
+
+		OIDCScopes:        "openid email profile",
+		OIDCAutoProvision: true,
+		OIDCStateTTL:      10 * time.Minute,
+
+		PasswordHashAlgorithm:     "argon2id",
+		PasswordArgon2Memory:      64,
+		PasswordArgon2Iterations:  1,
+		PasswordArgon2Parallelism: 1,
+		PasswordBcryptCost:        4,
+		PasswordMinLength:         8,
+	}
+}
+
+// newTestServer запускает приложение с настройками cfg.
+func newTestServer(t *testing.T, cfg config.Config) *httptest.Server {
+	t.Helper()
+
+	app, err := NewApp(cfg)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(app.Handler())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// accessToken выдает access токен HS256, подписанный секретом тестового приложения.
+func accessToken(t *testing.T, userID string) string {
+	t.Helper()
+
+	cfg := testConfig()
+	issuer, err := auth.NewIssuer(auth.NewHMACKeySet(testSecret), auth.IssuerConfig{
+		Issuer: cfg.JWTIssuer, Audience: cfg.JWTAudience, TTL: cfg.AccessTokenTTL,
+	})
+	require.NoError(t, err)
+	token, _, err := issuer.Issue(uuid.MustParse(userID), uuid.Nil, nil)
+	require.NoError(t, err)
+	return token
+}
+
+// tokens — ответ /login и /refresh.
+type tokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// register регистрирует пользователя с паролем password123 и возвращает его ID.
+func register(t *testing.T, server *httptest.Server, email string) string {
+	t.Helper()
+
+	var user struct {
+		ID string `json:"id"`
+	}
+	resp := doJSON(t, http.MethodPost, server.URL+"/register", "", map[string]string{
+		"username": "user", "email": email, "password": "password123",
+	}, &user)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	return user.ID
+}
+
+// login выполняет вход с устройства deviceName.
+func login(t *testing.T, server *httptest.Server, email, deviceName string) tokens {
+	t.Helper()
+
+	var result tokens
+	resp := doJSON(t, http.MethodPost, server.URL+"/login", "", map[string]string{
+		"email": email, "password": "password123", "device_name": deviceName,
+	}, &result)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	return result
+}
+
+// mailTokens возвращает токены из ссылок с путем path в письмах каталога
+// dir, начиная с самого раннего письма.
+func mailTokens(t *testing.T, dir, path string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+
+	link := regexp.MustCompile(regexp.QuoteMeta(path) + `\?token=([A-Za-z0-9_.-]+)`)
+	var tokens []string
+	for _, file := range files {
+		message, err := os.ReadFile(file)
+		require.NoError(t, err)
+		if match := link.FindSubmatch(message); match != nil {
+			tokens = append(tokens, string(match[1]))
+		}
+	}
+	return tokens
+}
+
+// doJSON отправляет запрос с телом в JSON и разбирает ответ в out, если он задан.
+func doJSON(t *testing.T, method, url, token string, body, out any) *http.Response {
+	t.Helper()
+
+	var reader bytes.Buffer
+	if body != nil {
+		require.NoError(t, json.NewEncoder(&reader).Encode(body))
+	}
+	req, err := http.NewRequest(method, url, &reader)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp
+}
+
+// postForm отправляет форму клиента OAuth на адрес target, при заданном
+// clientID — с аутентификацией Basic, и разбирает ответ в out, если он задан.
+func postForm(t *testing.T, target, clientID, clientSecret string, form url.Values, out any) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientID != "" {
+		req.SetBasicAuth(clientID, clientSecret)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	if out != nil {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	}
+	return resp
+}
+
+func TestApp_MemoryStorage(t *testing.T) {
+	server := newTestServer(t, testConfig())
+
+	// Регистрация и вход
+	userID := register(t, server, "alice@example.com")
+	token := login(t, server, "alice@example.com", "Ноутбук").Token
+
+	// Хеш пароля в ответах не раскрывается
+	var user map[string]any
+	resp := doJSON(t, http.MethodGet, server.URL+"/users/"+userID, token, nil, &user)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "alice@example.com", user["email"])
+	assert.NotContains(t, user, "password")
+
+	// Задача с меткой
+	var label struct {
+		ID string `json:"id"`
+	}
+	resp = doJSON(t, http.MethodPost, server.URL+"/labels", token, map[string]string{
+		"name": "работа", "color": "#ff0000",
+	}, &label)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var task struct {
+		ID       string   `json:"id"`
+		Status   string   `json:"status"`
+		LabelIDs []string `json:"label_ids"`
+	}
+	resp = doJSON(t, http.MethodPost, server.URL+"/tasks", token, map[string]any{
+		"title": "Написать отчет", "due_date": "2030-01-01T00:00:00Z", "label_ids": []string{label.ID},
+	}, &task)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "todo", task.Status)
+	assert.Equal(t, []string{label.ID}, task.LabelIDs)
+
+	var page struct {
+		Items []struct {
+			ID string `json:"id"`
+		} `json:"items"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks?label_id="+label.ID, token, nil, &page)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, task.ID, page.Items[0].ID)
+
+	// Повторная регистрация с тем же email
+	resp = doJSON(t, http.MethodPost, server.URL+"/register", "", map[string]string{
+		"username": "alice2", "email": "alice@example.com", "password": "password123",
+	}, nil)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestApp_RevokeAllAccessTokens(t *testing.T) {
+	server := newTestServer(t, testConfig())
+	register(t, server, "bob@example.com")
+	laptop := login(t, server, "bob@example.com", "Ноутбук")
+	phone := login(t, server, "bob@example.com", "Телефон")
+
+	resp := doJSON(t, http.MethodPost, server.URL+"/users/revoke", laptop.Token, nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// Токены обеих сессий больше не принимаются
+	for _, session := range []tokens{laptop, phone} {
+		var problem struct {
+			Code string `json:"code"`
+		}
+		resp = doJSON(t, http.MethodGet, server.URL+"/tasks", session.Token, nil, &problem)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "auth.token_revoked", problem.Code)
+
+		resp = doJSON(t, http.MethodPost, server.URL+"/refresh", "", map[string]string{"refresh_token": session.RefreshToken}, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", login(t, server, "bob@example.com", "Ноутбук").Token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestApp_RefreshReuseRevokesSession(t *testing.T) {
+	server := newTestServer(t, testConfig())
+	register(t, server, "carol@example.com")
+	session := login(t, server, "carol@example.com", "Ноутбук")
+
+	var refreshed tokens
+	resp := doJSON(t, http.MethodPost, server.URL+"/refresh", "", map[string]string{"refresh_token": session.RefreshToken}, &refreshed)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var problem struct {
+		Code string `json:"code"`
+	}
+	resp = doJSON(t, http.MethodPost, server.URL+"/refresh", "", map[string]string{"refresh_token": session.RefreshToken}, &problem)
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "auth.refresh_token_reused", problem.Code)
+
+	// Access токены, выданные в рамках сессии, тоже отозваны
+	for _, token := range []string{session.Token, refreshed.Token} {
+		resp = doJSON(t, http.MethodGet, server.URL+"/tasks", token, nil, &problem)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "auth.token_revoked", problem.Code)
+	}
+}
+
+func TestApp_SessionsAndLogout(t *testing.T) {
+	server := newTestServer(t, testConfig())
+	register(t, server, "dave@example.com")
+	laptop := login(t, server, "dave@example.com", "Ноутбук")
+	phone := login(t, server, "dave@example.com", "Телефон")
+
+	// Обмен refresh токена продолжает ту же сессию
+	var refreshed tokens
+	resp := doJSON(t, http.MethodPost, server.URL+"/refresh", "", map[string]string{"refresh_token": phone.RefreshToken}, &refreshed)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var page struct {
+		Items []struct {
+			DeviceName string `json:"device_name"`
+			Current    bool   `json:"current"`
+		} `json:"items"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/sessions", refreshed.Token, nil, &page)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Items, 2)
+	assert.Equal(t, "Телефон", page.Items[0].DeviceName)
+	assert.True(t, page.Items[0].Current)
+	assert.False(t, page.Items[1].Current)
+
+	// Выход завершает только текущую сессию
+	resp = doJSON(t, http.MethodPost, server.URL+"/logout", refreshed.Token, nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", refreshed.Token, nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", phone.Token, nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "прежние access токены сессии тоже не действуют")
+	resp = doJSON(t, http.MethodPost, server.URL+"/refresh", "", map[string]string{"refresh_token": refreshed.RefreshToken}, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = doJSON(t, http.MethodGet, server.URL+"/sessions", laptop.Token, nil, &page)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "Ноутбук", page.Items[0].DeviceName)
+
+	// Завершение сессии потерянного устройства отзывает ее access токены
+	tablet := login(t, server, "dave@example.com", "Планшет")
+	var sessions struct {
+		Items []struct {
+			ID         string `json:"id"`
+			DeviceName string `json:"device_name"`
+		} `json:"items"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/sessions", laptop.Token, nil, &sessions)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, sessions.Items, 2)
+	require.Equal(t, "Планшет", sessions.Items[0].DeviceName)
+
+	resp = doJSON(t, http.MethodDelete, server.URL+"/sessions/"+sessions.Items[0].ID, laptop.Token, nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	var problem struct {
+		Code string `json:"code"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", tablet.Token, nil, &problem)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "auth.token_revoked", problem.Code)
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", laptop.Token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestApp_ChangeAndResetPassword(t *testing.T) {
+	cfg := testConfig()
+	cfg.Mailer = config.MailerFile
+	cfg.MailDir = t.TempDir()
+	cfg.MailFrom = "noreply@example.com"
+	server := newTestServer(t, cfg)
+	register(t, server, "erin@example.com")
+	session := login(t, server, "erin@example.com", "Ноутбук")
+
+	// Смена пароля требует текущий пароль
+	var problem struct {
+		Code string `json:"code"`
+	}
+	resp := doJSON(t, http.MethodPost, server.URL+"/users/me/password", session.Token, map[string]string{
+		"current_password": "wrong", "new_password": "changed123",
+	}, &problem)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "password.wrong_current", problem.Code)
+
+	resp = doJSON(t, http.MethodPost, server.URL+"/users/me/password", session.Token, map[string]string{
+		"current_password": "password123", "new_password": "changed123",
+	}, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	// После смены пароля сессии завершены
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", session.Token, nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	resp = doJSON(t, http.MethodPost, server.URL+"/refresh", "", map[string]string{"refresh_token": session.RefreshToken}, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Сброс пароля по ссылке из письма
+	resp = doJSON(t, http.MethodPost, server.URL+"/password/forgot", "", map[string]string{"email": "nobody@example.com"}, nil)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp = doJSON(t, http.MethodPost, server.URL+"/password/forgot", "", map[string]string{"email": "erin@example.com"}, nil)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	tokens := mailTokens(t, cfg.MailDir, "/reset-password")
+	require.Len(t, tokens, 1, "письмо отправляется только зарегистрированному пользователю")
+
+	reset := map[string]string{"token": tokens[0], "new_password": "password123"}
+	resp = doJSON(t, http.MethodPost, server.URL+"/password/reset", "", reset, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = doJSON(t, http.MethodPost, server.URL+"/password/reset", "", reset, &problem)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "password.invalid_reset_token", problem.Code)
+
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", login(t, server, "erin@example.com", "Ноутбук").Token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Частые запросы сброса по одному email отклоняются
+	forgot := map[string]string{"email": "erin@example.com"}
+	for i := 1; i < cfg.LoginMaxFailures; i++ {
+		resp = doJSON(t, http.MethodPost, server.URL+"/password/forgot", "", forgot, nil)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+	resp = doJSON(t, http.MethodPost, server.URL+"/password/forgot", "", forgot, &problem)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "auth.too_many_attempts", problem.Code)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	assert.Len(t, mailTokens(t, cfg.MailDir, "/reset-password"), cfg.LoginMaxFailures)
+}
+
+func TestApp_PasswordPolicy(t *testing.T) {
+	breached := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(breached, []byte("qwerty123\n"), 0o600))
+	cfg := testConfig()
+	cfg.PasswordBreachedListFile = breached
+	server := newTestServer(t, cfg)
+
+	type problem struct {
+		Code   string `json:"code"`
+		Errors []struct {
+			Field   string `json:"field"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	// Короткий пароль и пароль из списка утекших при регистрации
+	var short problem
+	resp := doJSON(t, http.MethodPost, server.URL+"/register", "", map[string]string{
+		"username": "user", "email": "frank@example.com", "password": "short",
+	}, &short)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "password.too_short", short.Code)
+	require.Len(t, short.Errors, 1)
+	assert.Equal(t, "password", short.Errors[0].Field)
+	assert.Contains(t, short.Errors[0].Message, "8")
+
+	var leaked problem
+	resp = doJSON(t, http.MethodPost, server.URL+"/register", "", map[string]string{
+		"username": "user", "email": "frank@example.com", "password": "qwerty123",
+	}, &leaked)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "password.breached", leaked.Code)
+
+	// Та же политика при смене пароля
+	register(t, server, "frank@example.com")
+	session := login(t, server, "frank@example.com", "Ноутбук")
+	var change problem
+	resp = doJSON(t, http.MethodPost, server.URL+"/users/me/password", session.Token, map[string]string{
+		"current_password": "password123", "new_password": "qwerty123",
+	}, &change)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "password.breached", change.Code)
+	require.Len(t, change.Errors, 1)
+	assert.Equal(t, "new_password", change.Errors[0].Field)
+
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", session.Token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "отклоненная смена пароля не завершает сессии")
+}
+
+func TestApp_EmailVerification(t *testing.T) {
+	cfg := testConfig()
+	cfg.Mailer = config.MailerFile
+	cfg.MailDir = t.TempDir()
+	cfg.MailFrom = "noreply@example.com"
+	cfg.RequireEmailVerification = true
+	server := newTestServer(t, cfg)
+	userID := register(t, server, "frank@example.com")
+
+	// Без подтверждения email вход запрещен
+	var problem struct {
+		Code string `json:"code"`
+	}
+	credentials := map[string]string{"email": "frank@example.com", "password": "password123"}
+	resp := doJSON(t, http.MethodPost, server.URL+"/login", "", credentials, &problem)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "email.not_verified", problem.Code)
+
+	resp = doJSON(t, http.MethodPost, server.URL+"/verify-email/resend", "", map[string]string{"email": "frank@example.com"}, nil)
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	tokens := mailTokens(t, cfg.MailDir, "/verify-email")
+	require.Len(t, tokens, 2)
+
+	resp = doJSON(t, http.MethodGet, server.URL+"/verify-email?token=garbage", "", nil, &problem)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "email.invalid_verification_token", problem.Code)
+
+	var verified struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/verify-email?token="+tokens[0], "", nil, &verified)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "frank@example.com", verified.Email)
+	assert.True(t, verified.EmailVerified)
+
+	session := login(t, server, "frank@example.com", "Ноутбук")
+
+	// Новый email нужно подтвердить заново
+	resp = doJSON(t, http.MethodPut, server.URL+"/users/"+userID, session.Token, map[string]string{
+		"username": "frank", "email": "frank@example.org",
+	}, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, http.MethodPost, server.URL+"/login", "", map[string]string{"email": "frank@example.org", "password": "password123"}, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Len(t, mailTokens(t, cfg.MailDir, "/verify-email"), 3)
+
+	// Частые запросы повторной отправки по одному email отклоняются
+	resend := map[string]string{"email": "frank@example.org"}
+	for i := 0; i < cfg.LoginMaxFailures; i++ {
+		resp = doJSON(t, http.MethodPost, server.URL+"/verify-email/resend", "", resend, nil)
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	}
+	resp = doJSON(t, http.MethodPost, server.URL+"/verify-email/resend", "", resend, &problem)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "auth.too_many_attempts", problem.Code)
+	assert.NotEmpty(t, resp.Header.Get("Retry-After"))
+	assert.Len(t, mailTokens(t, cfg.MailDir, "/verify-email"), 3+cfg.LoginMaxFailures)
+}
+
+func TestNewApp_UnknownStorage(t *testing.T) {
+	cfg := testConfig()
+	cfg.Storage = "redis"
+
+	_, err := NewApp(cfg)
+
+	assert.EqualError(t, err, `unknown storage "redis"`)
+}
+
+func TestNewApp_DefaultSecret(t *testing.T) {
+	cfg := testConfig()
+	cfg.JWTSecret = config.DefaultJWTSecret
+
+	_, err := NewApp(cfg)
+	assert.ErrorContains(t, err, "default JWT secret")
+
+	cfg.DevMode = true
+	_, err = NewApp(cfg)
+	assert.NoError(t, err)
+}
+
+func TestApp_TwoFactor(t *testing.T) {
+	server := newTestServer(t, testConfig())
+	register(t, server, "frank@example.com")
+	session := login(t, server, "frank@example.com", "Ноутбук")
+
+	var problem struct {
+		Code string `json:"code"`
+	}
+	var status struct {
+		Enabled                bool `json:"enabled"`
+		RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+	}
+	resp := doJSON(t, http.MethodGet, server.URL+"/users/me/2fa", session.Token, nil, &status)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, status.Enabled)
+
+	// Подключение: секрет, затем подтверждение кодом из приложения
+	var enrollment struct {
+		Secret string `json:"secret"`
+		URI    string `json:"otpauth_uri"`
+	}
+	resp = doJSON(t, http.MethodPost, server.URL+"/users/me/2fa/totp", session.Token, nil, &enrollment)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Contains(t, enrollment.URI, "otpauth://totp/Task%20Tracker:frank@example.com?")
+
+	step := auth.TOTPStep(time.Now())
+	code := func(step int64) string {
+		code, err := auth.TOTPCode(enrollment.Secret, step)
+		require.NoError(t, err)
+		return code
+	}
+	var confirmed struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	resp = doJSON(t, http.MethodPost, server.URL+"/users/me/2fa/totp/confirm", session.Token, map[string]string{"code": code(step)}, &confirmed)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, confirmed.RecoveryCodes, 10)
+
+	// Первый шаг входа возвращает только токен второго шага
+	var challenge struct {
+		MFARequired bool   `json:"mfa_required"`
+		MFAToken    string `json:"mfa_token"`
+		Token       string `json:"token"`
+	}
+	startLogin := func() string {
+		resp := doJSON(t, http.MethodPost, server.URL+"/login", "", map[string]string{
+			"email": "frank@example.com", "password": "password123",
+		}, &challenge)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.True(t, challenge.MFARequired)
+		assert.Empty(t, challenge.Token)
+		return challenge.MFAToken
+	}
+	mfaToken := startLogin()
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", mfaToken, nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode, "токен второго шага не дает доступа к API")
+
+	// Второй шаг: код восстановления используется один раз
+	var result tokens
+	resp = doJSON(t, http.MethodPost, server.URL+"/login/mfa", "", map[string]string{
+		"mfa_token": mfaToken, "code": confirmed.RecoveryCodes[0], "device_name": "Телефон",
+	}, &result)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", result.Token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = doJSON(t, http.MethodPost, server.URL+"/login/mfa", "", map[string]string{
+		"mfa_token": startLogin(), "code": confirmed.RecoveryCodes[0],
+	}, &problem)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "mfa.invalid_code", problem.Code)
+
+	// Код из приложения; использованный токен второго шага больше не принимается
+	mfaToken = startLogin()
+	resp = doJSON(t, http.MethodPost, server.URL+"/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": code(step + 1)}, &result)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, http.MethodPost, server.URL+"/login/mfa", "", map[string]string{"mfa_token": mfaToken, "code": confirmed.RecoveryCodes[1]}, &problem)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "mfa.invalid_challenge", problem.Code)
+
+	// Отключение требует пароль и код
+	disable := map[string]string{"current_password": "wrong", "code": confirmed.RecoveryCodes[2]}
+	resp = doJSON(t, http.MethodPost, server.URL+"/users/me/2fa/disable", session.Token, disable, &problem)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "password.wrong_current", problem.Code)
+
+	disable["current_password"] = "password123"
+	resp = doJSON(t, http.MethodPost, server.URL+"/users/me/2fa/disable", session.Token, disable, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = doJSON(t, http.MethodGet, server.URL+"/users/me/2fa", session.Token, nil, &status)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, status.Enabled)
+	login(t, server, "frank@example.com", "Ноутбук")
+}
+
+func TestApp_ChangePasswordLockout(t *testing.T) {
+	cfg := testConfig()
+	server := newTestServer(t, cfg)
+	register(t, server, "heidi@example.com")
+	session := login(t, server, "heidi@example.com", "Ноутбук")
+
+	var problem struct {
+		Code string `json:"code"`
+	}
+	change := map[string]string{"current_password": "wrong", "new_password": "changed123"}
+	for i := 0; i < cfg.LoginMaxFailures; i++ {
+		resp := doJSON(t, http.MethodPost, server.URL+"/users/me/password", session.Token, change, &problem)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "password.wrong_current", problem.Code)
+	}
+
+	// Подбор текущего пароля по украденному токену ограничен
+	change["current_password"] = "password123"
+	resp := doJSON(t, http.MethodPost, server.URL+"/users/me/password", session.Token, change, &problem)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "auth.too_many_attempts", problem.Code)
+	assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+}
+
+func TestApp_LoginLockout(t *testing.T) {
+	app, err := NewApp(testConfig())
+	require.NoError(t, err)
+	server := httptest.NewServer(app.Handler())
+	t.Cleanup(server.Close)
+
+	victimID := register(t, server, "grace@example.com")
+	adminID := register(t, server, "admin@example.com")
+	require.NoError(t, app.repos.users.SetAdmin(context.Background(), uuid.MustParse(adminID), true))
+	admin := login(t, server, "admin@example.com", "Ноутбук")
+
+	var problem struct {
+		Code string `json:"code"`
+	}
+	wrongPassword := map[string]string{"email": "grace@example.com", "password": "wrong-password"}
+	for i := 0; i < 3; i++ {
+		resp := doJSON(t, http.MethodPost, server.URL+"/login", "", wrongPassword, &problem)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	}
+
+	// После блокировки не принимается даже верный пароль
+	resp := doJSON(t, http.MethodPost, server.URL+"/login", "", map[string]string{
+		"email": "grace@example.com", "password": "password123",
+	}, &problem)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "auth.too_many_attempts", problem.Code)
+	assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+
+	// Журнал аудита и снятие блокировки доступны только администратору
+	victimToken := accessToken(t, victimID)
+	resp = doJSON(t, http.MethodGet, server.URL+"/admin/audit-events", victimToken, nil, &problem)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "auth.admin_required", problem.Code)
+
+	var events struct {
+		Items []struct {
+			Type   string `json:"type"`
+			UserID string `json:"user_id"`
+		} `json:"items"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/admin/audit-events?limit=10", admin.Token, nil, &events)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, events.Items)
+	assert.Equal(t, "login.lockout", events.Items[0].Type)
+	assert.Equal(t, victimID, events.Items[0].UserID)
+
+	resp = doJSON(t, http.MethodPost, server.URL+"/admin/users/"+victimID+"/unlock", victimToken, nil, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = doJSON(t, http.MethodPost, server.URL+"/admin/users/"+victimID+"/unlock", admin.Token, nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	login(t, server, "grace@example.com", "Ноутбук")
+
+	resp = doJSON(t, http.MethodGet, server.URL+"/admin/audit-events", admin.Token, nil, &events)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "account.unlock", events.Items[0].Type)
+}
+
+func TestApp_PersonalAccessTokens(t *testing.T) {
+	server := newTestServer(t, testConfig())
+	userID := register(t, server, "henry@example.com")
+	session := login(t, server, "henry@example.com", "Ноутбук")
+
+	var problem struct {
+		Code string `json:"code"`
+	}
+	var created struct {
+		ID     string   `json:"id"`
+		Token  string   `json:"token"`
+		Scopes []string `json:"scopes"`
+	}
+	resp := doJSON(t, http.MethodPost, server.URL+"/tokens", session.Token, map[string]any{
+		"name": "CI", "scopes": []string{"tasks:read"},
+	}, &created)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NotEmpty(t, created.Token)
+	assert.Equal(t, []string{"tasks:read"}, created.Scopes)
+
+	// Токен дает доступ только к действиям из своих разрешений
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", created.Token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, http.MethodPost, server.URL+"/tasks", created.Token, map[string]string{"title": "Задача"}, &problem)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "auth.insufficient_scope", problem.Code)
+	resp = doJSON(t, http.MethodGet, server.URL+"/labels", created.Token, nil, &problem)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = doJSON(t, http.MethodGet, server.URL+"/users/"+userID, created.Token, nil, &problem)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "auth.session_required", problem.Code)
+	resp = doJSON(t, http.MethodPost, server.URL+"/tokens", created.Token, map[string]any{
+		"name": "Эскалация", "scopes": []string{"tasks:write"},
+	}, &problem)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "персональный токен не выдает новые токены")
+
+	// В списке нет самих токенов, но есть отметка использования
+	var list struct {
+		Items []map[string]any `json:"items"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/tokens", session.Token, nil, &list)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, list.Items, 1)
+	assert.NotContains(t, list.Items[0], "token")
+	assert.NotContains(t, list.Items[0], "token_hash")
+	assert.NotNil(t, list.Items[0]["last_used_at"])
+
+	resp = doJSON(t, http.MethodPost, server.URL+"/tokens", session.Token, map[string]any{
+		"name": "CI", "scopes": []string{"everything"},
+	}, &problem)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "token.invalid_scope", problem.Code)
+
+	// Отозванный токен не принимается
+	resp = doJSON(t, http.MethodDelete, server.URL+"/tokens/"+created.ID, session.Token, nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", created.Token, nil, &problem)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "auth.invalid_token", problem.Code)
+}
+
+func TestApp_OAuth(t *testing.T) {
+	server := newTestServer(t, testConfig())
+	register(t, server, "irene@example.com")
+	session := login(t, server, "irene@example.com", "Ноутбук")
+
+	type client struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Confidential bool   `json:"confidential"`
+	}
+	type oauthToken struct {
+		AccessToken string `json:"access_token"`
+		Scope       string `json:"scope"`
+	}
+	var oauthError struct {
+		Error string `json:"error"`
+	}
+
+	// Публичный клиент получает токен по коду авторизации с PKCE
+	var public client
+	resp := doJSON(t, http.MethodPost, server.URL+"/oauth/clients", session.Token, map[string]any{
+		"name": "Отчеты", "redirect_uris": []string{"http://localhost:9000/callback"}, "scopes": []string{"tasks:read", "tasks:write"},
+	}, &public)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Empty(t, public.ClientSecret)
+	assert.False(t, public.Confidential)
+
+	verifier := "dBjftJeZ4CVP-1rJ0Lr6ZWqIRhVBuTy8JEoYrmQ9bOkmOE4"
+	authorization := map[string]any{
+		"client_id": public.ClientID, "response_type": "code", "scope": "tasks:read", "state": "xyz",
+		"code_challenge": auth.PKCEChallenge(verifier), "code_challenge_method": "S256",
+	}
+	var consent struct {
+		ClientName string   `json:"client_name"`
+		Scopes     []string `json:"scopes"`
+	}
+	query := url.Values{}
+	for key, value := range authorization {
+		query.Set(key, value.(string))
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/oauth/authorize?"+query.Encode(), session.Token, nil, &consent)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "Отчеты", consent.ClientName)
+	assert.Equal(t, []string{"tasks:read"}, consent.Scopes)
+
+	authorization["approved"] = true
+	var approved struct {
+		RedirectTo string `json:"redirect_to"`
+	}
+	resp = doJSON(t, http.MethodPost, server.URL+"/oauth/authorize", session.Token, authorization, &approved)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	redirect, err := url.Parse(approved.RedirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, "xyz", redirect.Query().Get("state"))
+
+	var userToken oauthToken
+	resp = postForm(t, server.URL+"/oauth/token", "", "", url.Values{
+		"grant_type": {"authorization_code"}, "client_id": {public.ClientID}, "code": {redirect.Query().Get("code")},
+		"redirect_uri": {"http://localhost:9000/callback"}, "code_verifier": {verifier},
+	}, &userToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, "tasks:read", userToken.Scope)
+
+	// Токен клиента ограничен выданными разрешениями
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", userToken.AccessToken, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, http.MethodPost, server.URL+"/tasks", userToken.AccessToken, map[string]string{"title": "Задача"}, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp = doJSON(t, http.MethodGet, server.URL+"/oauth/clients", userToken.AccessToken, nil, nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "токен клиента не управляет клиентами")
+
+	resp = postForm(t, server.URL+"/oauth/token", "", "", url.Values{
+		"grant_type": {"client_credentials"}, "client_id": {public.ClientID},
+	}, &oauthError)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "unauthorized_client", oauthError.Error)
+
+	// Конфиденциальный клиент получает токен по своим учетным данным
+	var confidential client
+	resp = doJSON(t, http.MethodPost, server.URL+"/oauth/clients", session.Token, map[string]any{
+		"name": "Синхронизация", "redirect_uris": []string{"https://sync.example.com/cb"}, "scopes": []string{"labels:read"}, "confidential": true,
+	}, &confidential)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	require.NotEmpty(t, confidential.ClientSecret)
+
+	resp = postForm(t, server.URL+"/oauth/token", confidential.ClientID, "wrong", url.Values{"grant_type": {"client_credentials"}}, &oauthError)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "invalid_client", oauthError.Error)
+	assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+
+	var serviceToken oauthToken
+	resp = postForm(t, server.URL+"/oauth/token", confidential.ClientID, confidential.ClientSecret, url.Values{"grant_type": {"client_credentials"}}, &serviceToken)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, http.MethodGet, server.URL+"/labels", serviceToken.AccessToken, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Интроспекция и отзыв
+	var introspection struct {
+		Active   bool   `json:"active"`
+		Scope    string `json:"scope"`
+		ClientID string `json:"client_id"`
+	}
+	resp = postForm(t, server.URL+"/oauth/introspect", confidential.ClientID, confidential.ClientSecret, url.Values{"token": {serviceToken.AccessToken}}, &introspection)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, introspection.Active)
+	assert.Equal(t, "labels:read", introspection.Scope)
+	assert.Equal(t, confidential.ClientID, introspection.ClientID)
+
+	// Токены другого клиента и сессий пользователя клиенту не раскрываются
+	for _, token := range []string{userToken.AccessToken, session.Token} {
+		introspection.Active, introspection.ClientID = true, ""
+		resp = postForm(t, server.URL+"/oauth/introspect", confidential.ClientID, confidential.ClientSecret, url.Values{"token": {token}}, &introspection)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.False(t, introspection.Active)
+		assert.Empty(t, introspection.ClientID)
+	}
+
+	resp = postForm(t, server.URL+"/oauth/revoke", confidential.ClientID, confidential.ClientSecret, url.Values{"token": {serviceToken.AccessToken}}, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = postForm(t, server.URL+"/oauth/introspect", confidential.ClientID, confidential.ClientSecret, url.Values{"token": {serviceToken.AccessToken}}, &introspection)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.False(t, introspection.Active)
+	resp = doJSON(t, http.MethodGet, server.URL+"/labels", serviceToken.AccessToken, nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Удаление клиента отзывает выданные ему токены
+	resp = doJSON(t, http.MethodDelete, server.URL+"/oauth/clients/"+public.ClientID, session.Token, nil, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	var problem struct {
+		Code string `json:"code"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", userToken.AccessToken, nil, &problem)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "auth.token_revoked", problem.Code)
+	var list struct {
+		Items []map[string]any `json:"items"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/oauth/clients", session.Token, nil, &list)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, list.Items, 1)
+	assert.NotContains(t, list.Items[0], "client_secret")
+	assert.NotContains(t, list.Items[0], "secret_hash")
+}
+
+// newOIDCTestServer запускает приложение со входом через провайдера
+// provider. Адрес возврата известен только после выбора порта, поэтому
+// приложение создается для уже открытого слушателя.
+func newOIDCTestServer(t *testing.T, provider *oidctest.Provider, cfg config.Config) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewUnstartedServer(nil)
+	cfg.OIDCIssuerURL = provider.Issuer()
+	cfg.OIDCClientID = provider.ClientID
+	cfg.OIDCClientSecret = provider.ClientSecret
+	cfg.OIDCRedirectURL = "http://" + server.Listener.Addr().String() + "/login/oidc/callback"
+	app, err := NewApp(cfg)
+	require.NoError(t, err)
+
+	server.Config.Handler = app.Handler()
+	server.Start()
+	t.Cleanup(server.Close)
+	return server
+}
+
+// oidcLogin проходит вход через провайдера браузером с cookie и разбирает
+// ответ адреса возврата в out.
+func oidcLogin(t *testing.T, server *httptest.Server, deviceName string, out any) *http.Response {
+	t.Helper()
+
+	jar, err := cookiejar.New(nil)
+	require.NoError(t, err)
+	browser := &http.Client{Jar: jar}
+
+	resp, err := browser.Get(server.URL + "/login/oidc?device_name=" + url.QueryEscape(deviceName))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "/login/oidc/callback", resp.Request.URL.Path, "провайдер возвращает браузер на адрес возврата")
+
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(out))
+	return resp
+}
+
+func TestApp_OIDCLogin(t *testing.T) {
+	provider := oidctest.NewProvider(t, "task-tracker", "client-secret")
+	cfg := testConfig()
+	cfg.Mailer = config.MailerFile
+	cfg.MailDir = t.TempDir()
+	cfg.MailFrom = "noreply@example.com"
+	server := newOIDCTestServer(t, provider, cfg)
+	var problem struct {
+		Code string `json:"code"`
+	}
+
+	// Пользователь с неподтвержденным email не связывается: его мог
+	// зарегистрировать кто угодно
+	graceID := register(t, server, "grace@example.com")
+	provider.User = oidctest.User{Subject: "grace", Email: "grace@example.com", EmailVerified: true, PreferredUsername: "grace"}
+	resp := oidcLogin(t, server, "Браузер", &problem)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "auth.oidc_account_unverified", problem.Code)
+
+	// После подтверждения email пользователь связывается с учетной записью
+	// провайдера
+	links := mailTokens(t, cfg.MailDir, "/verify-email")
+	require.Len(t, links, 1)
+	resp = doJSON(t, http.MethodGet, server.URL+"/verify-email?token="+links[0], "", nil, nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var grace tokens
+	resp = oidcLogin(t, server, "Браузер", &grace)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEmpty(t, grace.RefreshToken)
+	resp = doJSON(t, http.MethodGet, server.URL+"/users/"+graceID, grace.Token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, "токен выдан тому же пользователю")
+
+	var page struct {
+		Items []struct {
+			DeviceName string `json:"device_name"`
+		} `json:"items"`
+	}
+	resp = doJSON(t, http.MethodGet, server.URL+"/sessions", grace.Token, nil, &page)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, page.Items, 1)
+	assert.Equal(t, "Браузер", page.Items[0].DeviceName)
+
+	// Нового пользователя создает первый вход; пароля у него нет
+	provider.User = oidctest.User{Subject: "heidi", Email: "heidi@example.com", EmailVerified: true, Name: "Heidi"}
+	var heidi tokens
+	resp = oidcLogin(t, server, "", &heidi)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", heidi.Token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = doJSON(t, http.MethodPost, server.URL+"/login", "", map[string]string{"email": "heidi@example.com", "password": ""}, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	// Отказ у провайдера
+	provider.Error = "access_denied"
+	resp = oidcLogin(t, server, "", &problem)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "auth.oidc_denied", problem.Code)
+	provider.Error = ""
+
+	// Адрес возврата без cookie состояния, например подделанный
+	resp = doJSON(t, http.MethodGet, server.URL+"/login/oidc/callback?state=state&code=code", "", nil, &problem)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, "auth.oidc_failed", problem.Code)
+
+	// Без OIDC_ISSUER_URL маршрутов входа через провайдера нет
+	disabled := newTestServer(t, testConfig())
+	resp = doJSON(t, http.MethodGet, disabled.URL+"/login/oidc", "", nil, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestNewApp_InvalidLifetimes(t *testing.T) {
+	cfg := testConfig()
+	cfg.AccessTokenTTL = 0
+	_, err := NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid access token settings")
+
+	cfg = testConfig()
+	cfg.RefreshTokenTTL = -time.Hour
+	_, err = NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid refresh token lifetime")
+
+	cfg = testConfig()
+	cfg.PasswordResetTTL = 0
+	_, err = NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid password reset lifetime")
+
+	cfg = testConfig()
+	cfg.EmailVerificationTTL = 0
+	_, err = NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid email verification settings")
+
+	cfg = testConfig()
+	cfg.MFAChallengeTTL = 0
+	_, err = NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid MFA settings")
+
+	cfg = testConfig()
+	cfg.LoginMaxLockoutTTL = time.Second
+	_, err = NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid login throttling settings")
+
+	cfg = testConfig()
+	cfg.OAuthCodeTTL = 0
+	_, err = NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid OAuth authorization code lifetime")
+
+	cfg = testConfig()
+	cfg.OIDCIssuerURL = "https://idp.example.com"
+	cfg.OIDCClientID = "task-tracker"
+	cfg.OIDCRedirectURL = "http://localhost:8080/login/oidc/callback"
+	cfg.OIDCStateTTL = 0
+	_, err = NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid OpenID Connect settings")
+
+	cfg = testConfig()
+	cfg.PasswordHashAlgorithm = "md5"
+	_, err = NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid password hashing settings")
+
+	for _, tune := range []func(*config.Config){
+		func(cfg *config.Config) { cfg.PasswordArgon2Memory = math.MaxUint32 + 1 },
+		func(cfg *config.Config) { cfg.PasswordArgon2Iterations = math.MaxUint32 + 1 },
+		func(cfg *config.Config) { cfg.PasswordArgon2Parallelism = math.MaxUint8 + 1 },
+		func(cfg *config.Config) { cfg.PasswordArgon2Memory = -1 },
+	} {
+		cfg = testConfig()
+		tune(&cfg)
+		_, err = NewApp(cfg)
+		assert.ErrorContains(t, err, "invalid password hashing settings")
+	}
+
+	cfg = testConfig()
+	cfg.PasswordBreachedListFile = filepath.Join(t.TempDir(), "missing.txt")
+	_, err = NewApp(cfg)
+	assert.ErrorContains(t, err, "invalid password policy settings")
+}
+
+func TestApp_SigningKeyFromFile(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "signing.pem")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600))
+
+	// Секрет по умолчанию не мешает запуску: токены подписываются ключом из файла
+	cfg := testConfig()
+	cfg.JWTSecret = config.DefaultJWTSecret
+	cfg.JWTSigningKeyFile = keyFile
+	server := newTestServer(t, cfg)
+
+	// Открытый ключ опубликован в JWKS
+	var jwks struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			ID      string `json:"kid"`
+			Alg     string `json:"alg"`
+			X       string `json:"x"`
+		} `json:"keys"`
+	}
+	resp := doJSON(t, http.MethodGet, server.URL+"/.well-known/jwks.json", "", nil, &jwks)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, jwks.Keys, 1)
+	assert.Equal(t, "OKP", jwks.Keys[0].KeyType)
+	assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString(private.Public().(ed25519.PublicKey)), jwks.Keys[0].X)
+
+	userID := register(t, server, "carol@example.com")
+	token := login(t, server, "carol@example.com", "Ноутбук").Token
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "EdDSA", parsed.Header["alg"])
+	assert.Equal(t, jwks.Keys[0].ID, parsed.Header["kid"])
+
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", token, nil, nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Токен HS256 больше не принимается
+	resp = doJSON(t, http.MethodGet, server.URL+"/tasks", accessToken(t, userID), nil, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
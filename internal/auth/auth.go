@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -18,3 +19,35 @@ func UserIDFromContext(ctx context.Context) (uuid.UUID, bool) {
 	userID, ok := ctx.Value(UserContextKey{}).(uuid.UUID)
 	return userID, ok
 }
+
+type SessionContextKey struct{}
+
+// ContextWithSession добавляет ID сессии, для которой выдан токен, в контекст.
+func ContextWithSession(ctx context.Context, sessionID uuid.UUID) context.Context {
+	return context.WithValue(ctx, SessionContextKey{}, sessionID)
+}
+
+// SessionIDFromContext извлекает ID сессии из контекста.
+func SessionIDFromContext(ctx context.Context) (uuid.UUID, bool) {
+	sessionID, ok := ctx.Value(SessionContextKey{}).(uuid.UUID)
+	return sessionID, ok
+}
+
+type AccessTokenContextKey struct{}
+
+// AccessToken описывает access токен, которым аутентифицирован запрос.
+type AccessToken struct {
+	ID        uuid.UUID // claim jti
+	ExpiresAt time.Time
+}
+
+// ContextWithAccessToken добавляет сведения об access токене в контекст.
+func ContextWithAccessToken(ctx context.Context, token AccessToken) context.Context {
+	return context.WithValue(ctx, AccessTokenContextKey{}, token)
+}
+
+// AccessTokenFromContext извлекает сведения об access токене из контекста.
+func AccessTokenFromContext(ctx context.Context) (AccessToken, bool) {
+	token, ok := ctx.Value(AccessTokenContextKey{}).(AccessToken)
+	return token, ok
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// emailVerificationAudience — получатель (aud) токенов подтверждения email.
+// Отличается от получателя access токенов, поэтому один тип токена нельзя
+// предъявить вместо другого.
+const emailVerificationAudience = "email-verification"
+
+// emailClaims — содержимое токена подтверждения email.
+type emailClaims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+}
+
+// EmailTokens выдает и проверяет подписанные токены для ссылок
+// подтверждения email. Токен не хранится на сервере: в нем подписаны ID
+// пользователя и подтверждаемый адрес, поэтому после смены email прежние
+// ссылки перестают подходить.
+type EmailTokens struct {
+	keys   *KeySet
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewEmailTokens создает EmailTokens, подписывающий токены ключами keys.
+func NewEmailTokens(keys *KeySet, issuer string, ttl time.Duration) (*EmailTokens, error) {
+	if issuer == "" {
+		return nil, errors.New("не задан издатель токенов подтверждения email")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("неверный срок действия ссылки подтверждения email: %s", ttl)
+	}
+	return &EmailTokens{keys: keys, issuer: issuer, ttl: ttl, now: time.Now}, nil
+}
+
+// Issue выдает токен подтверждения адреса email пользователя userID.
+func (t *EmailTokens) Issue(userID uuid.UUID, email string) (string, error) {
+	now := t.now()
+	claims := &emailClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    t.issuer,
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{emailVerificationAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(t.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		Email: email,
+	}
+	return t.keys.Sign(claims)
+}
+
+// Parse проверяет подпись и срок действия токена и возвращает ID
+// пользователя и подтверждаемый email.
+func (t *EmailTokens) Parse(token string) (uuid.UUID, string, error) {
+	claims := &emailClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, t.keys.Keyfunc,
+		jwt.WithValidMethods(t.keys.Methods()),
+		jwt.WithIssuer(t.issuer),
+		jwt.WithAudience(emailVerificationAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(t.now),
+	)
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, "", fmt.Errorf("неверный claim sub: %w", err)
+	}
+	if claims.Email == "" {
+		return uuid.Nil, "", errors.New("отсутствует claim email")
+	}
+	return userID, claims.Email, nil
+}
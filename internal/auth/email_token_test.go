@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmailTokens_IssueAndParse(t *testing.T) {
+	// 1. Arrange
+	tokens, err := NewEmailTokens(NewHMACKeySet("secret"), "task-tracker", time.Hour)
+	require.NoError(t, err)
+	userID := uuid.New()
+
+	// 2. Act
+	token, err := tokens.Issue(userID, "alice@example.com")
+	require.NoError(t, err)
+	parsedUserID, email, err := tokens.Parse(token)
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, userID, parsedUserID)
+	assert.Equal(t, "alice@example.com", email)
+}
+
+func TestEmailTokens_ParseRejects(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	tokens, err := NewEmailTokens(keys, "task-tracker", time.Hour)
+	require.NoError(t, err)
+	emailToken, err := tokens.Issue(uuid.New(), "alice@example.com")
+	require.NoError(t, err)
+
+	expired, err := NewEmailTokens(keys, "task-tracker", time.Hour)
+	require.NoError(t, err)
+	expired.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+
+	issuer := newTestIssuer(t, IssuerConfig{Issuer: "task-tracker", Audience: "task-tracker", TTL: time.Hour})
+	accessToken, _, err := issuer.Issue(uuid.New(), uuid.Nil, nil)
+	require.NoError(t, err)
+
+	otherKey, err := NewEmailTokens(NewHMACKeySet("other"), "task-tracker", time.Hour)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		tokens *EmailTokens
+		token  string
+	}{
+		{"истек срок действия", expired, emailToken},
+		{"access токен", tokens, accessToken},
+		{"другой ключ", otherKey, emailToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.tokens.Parse(tt.token)
+
+			assert.Error(t, err)
+		})
+	}
+
+	_, err = issuer.Parse(emailToken)
+	assert.Error(t, err, "токен подтверждения email не принимается как access токен")
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+func init() {
+	// iat сравнивается с моментом отзыва всех токенов пользователя, поэтому
+	// время в токенах хранится с той же точностью, что и в базе данных.
+	jwt.TimePrecision = time.Microsecond
+}
+
+// Claims — содержимое access токена.
+type Claims struct {
+	// Subject — ID пользователя, ID — jti для отзыва токена.
+	jwt.RegisteredClaims
+	// SessionID — сессия, для которой выдан токен (пусто у токенов без сессии).
+	SessionID string `json:"sid,omitempty"`
+	// ClientID — OAuth-клиент, которому выдан токен (пусто у токенов входа).
+	ClientID string   `json:"client_id,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+}
+
+// UserID возвращает ID пользователя из claim sub.
+func (c *Claims) UserID() (uuid.UUID, error) {
+	return uuid.Parse(c.Subject)
+}
+
+// TokenID возвращает jti токена.
+func (c *Claims) TokenID() (uuid.UUID, error) {
+	return uuid.Parse(c.ID)
+}
+
+// IssuerConfig — параметры выдачи access токенов.
+type IssuerConfig struct {
+	Issuer   string        // claim iss
+	Audience string        // claim aud
+	TTL      time.Duration // срок действия
+}
+
+// Issuer выдает и проверяет access токены. Это единственное место, где
+// формируются claims и срок действия токенов.
+type Issuer struct {
+	keys   *KeySet
+	config IssuerConfig
+	now    func() time.Time
+}
+
+// NewIssuer создает Issuer и проверяет параметры выдачи токенов.
+func NewIssuer(keys *KeySet, config IssuerConfig) (*Issuer, error) {
+	if config.Issuer == "" || config.Audience == "" {
+		return nil, errors.New("не заданы издатель или получатель access токенов")
+	}
+	if config.TTL <= 0 {
+		return nil, fmt.Errorf("неверный срок действия access токенов: %s", config.TTL)
+	}
+	return &Issuer{keys: keys, config: config, now: time.Now}, nil
+}
+
+// Issue выдает access токен пользователю для сессии sessionID (uuid.Nil —
+// токен без сессии) с разрешениями scopes.
+func (i *Issuer) Issue(userID, sessionID uuid.UUID, scopes []string) (string, *Claims, error) {
+	return i.issue(userID, sessionID, "", scopes)
+}
+
+// IssueForClient выдает OAuth-клиенту clientID access токен, который
+// действует от имени пользователя userID с разрешениями scopes.
+func (i *Issuer) IssueForClient(userID uuid.UUID, clientID string, scopes []string) (string, *Claims, error) {
+	return i.issue(userID, uuid.Nil, clientID, scopes)
+}
+
+// TTL возвращает срок действия выдаваемых access токенов.
+func (i *Issuer) TTL() time.Duration {
+	return i.config.TTL
+}
+
+func (i *Issuer) issue(userID, sessionID uuid.UUID, clientID string, scopes []string) (string, *Claims, error) {
+	now := i.now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    i.config.Issuer,
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{i.config.Audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(i.config.TTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
+		ClientID: clientID,
+		Scopes:   scopes,
+	}
+	if sessionID != uuid.Nil {
+		claims.SessionID = sessionID.String()
+	}
+
+	token, err := i.keys.Sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+	return token, claims, nil
+}
+
+// Parse проверяет подпись, издателя, получателя и срок действия токена и
+// возвращает его claims. Токены без sub, jti или iat не принимаются.
+func (i *Issuer) Parse(token string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, i.keys.Keyfunc,
+		jwt.WithValidMethods(i.keys.Methods()),
+		jwt.WithIssuer(i.config.Issuer),
+		jwt.WithAudience(i.config.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithTimeFunc(i.now),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := claims.UserID(); err != nil {
+		return nil, fmt.Errorf("неверный claim sub: %w", err)
+	}
+	if _, err := claims.TokenID(); err != nil {
+		return nil, fmt.Errorf("неверный claim jti: %w", err)
+	}
+	if claims.IssuedAt == nil {
+		return nil, errors.New("отсутствует claim iat")
+	}
+	if claims.SessionID != "" {
+		if _, err := uuid.Parse(claims.SessionID); err != nil {
+			return nil, fmt.Errorf("неверный claim sid: %w", err)
+		}
+	}
+
+	return claims, nil
+}
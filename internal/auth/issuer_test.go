@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestIssuer(t *testing.T, config IssuerConfig) *Issuer {
+	t.Helper()
+
+	issuer, err := NewIssuer(NewHMACKeySet("secret"), config)
+	require.NoError(t, err)
+	return issuer
+}
+
+var testIssuerConfig = IssuerConfig{Issuer: "task-tracker", Audience: "task-tracker", TTL: 15 * time.Minute}
+
+func TestIssuer_IssueAndParse(t *testing.T) {
+	// 1. Arrange
+	issuer := newTestIssuer(t, testIssuerConfig)
+	userID, sessionID := uuid.New(), uuid.New()
+
+	// 2. Act
+	token, issued, err := issuer.Issue(userID, sessionID, []string{"tasks:read"})
+	require.NoError(t, err)
+	claims, err := issuer.Parse(token)
+
+	// 3. Assert
+	require.NoError(t, err)
+	parsedUserID, err := claims.UserID()
+	require.NoError(t, err)
+	assert.Equal(t, userID, parsedUserID)
+	assert.Equal(t, sessionID.String(), claims.SessionID)
+	assert.Equal(t, issued.ID, claims.ID)
+	assert.Equal(t, "task-tracker", claims.Issuer)
+	assert.Equal(t, []string{"task-tracker"}, []string(claims.Audience))
+	assert.Equal(t, []string{"tasks:read"}, claims.Scopes)
+	assert.Equal(t, 15*time.Minute, claims.ExpiresAt.Sub(claims.IssuedAt.Time))
+}
+
+func TestIssuer_ParseRejects(t *testing.T) {
+	issuer := newTestIssuer(t, testIssuerConfig)
+	token, _, err := issuer.Issue(uuid.New(), uuid.Nil, nil)
+	require.NoError(t, err)
+
+	expired := newTestIssuer(t, testIssuerConfig)
+	expired.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	tests := []struct {
+		name   string
+		issuer *Issuer
+	}{
+		{"истек срок действия", expired},
+		{"другой издатель", newTestIssuer(t, IssuerConfig{Issuer: "other", Audience: "task-tracker", TTL: time.Minute})},
+		{"другой получатель", newTestIssuer(t, IssuerConfig{Issuer: "task-tracker", Audience: "other", TTL: time.Minute})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.issuer.Parse(token)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewIssuer_Validation(t *testing.T) {
+	_, err := NewIssuer(NewHMACKeySet("secret"), IssuerConfig{Issuer: "task-tracker", Audience: "task-tracker"})
+	assert.Error(t, err)
+
+	_, err = NewIssuer(NewHMACKeySet("secret"), IssuerConfig{TTL: time.Minute})
+	assert.Error(t, err)
+}
@@ -0,0 +1,300 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSABits — минимальный размер RSA-ключа подписи.
+const minRSABits = 2048
+
+// signingKey — ключ подписи access токенов. У ключей для проверки private равен nil.
+type signingKey struct {
+	// id — значение заголовка kid: отпечаток открытого ключа по RFC 7638.
+	// У ключа HS256 id пустой.
+	id      string
+	method  jwt.SigningMethod
+	public  any
+	private any
+}
+
+// KeySet — ключ, которым подписываются новые токены, и ключи, которыми
+// проверяются выданные. Для ротации новый ключ становится ключом подписи, а
+// прежний остается в наборе для проверки, пока не истекут подписанные им
+// токены.
+type KeySet struct {
+	signing *signingKey
+	keys    []*signingKey
+	byID    map[string]*signingKey
+}
+
+// NewHMACKeySet создает набор из одного симметричного ключа HS256. Токены
+// подписываются без заголовка kid, а открытых ключей для публикации нет.
+func NewHMACKeySet(secret string) *KeySet {
+	key := &signingKey{method: jwt.SigningMethodHS256, public: []byte(secret), private: []byte(secret)}
+	set := &KeySet{signing: key, byID: make(map[string]*signingKey)}
+	set.add(key)
+	return set
+}
+
+// LoadKeySet загружает ключ подписи и дополнительные ключи для проверки из
+// PEM-файлов. Поддерживаются ключи RSA (RS256) и Ed25519 (EdDSA); ключи для
+// проверки могут быть как открытыми, так и закрытыми.
+func LoadKeySet(signingPath string, verificationPaths ...string) (*KeySet, error) {
+	signing, err := loadKey(signingPath)
+	if err != nil {
+		return nil, err
+	}
+	if signing.private == nil {
+		return nil, fmt.Errorf("ключ подписи %s должен быть закрытым", signingPath)
+	}
+
+	set := &KeySet{signing: signing, byID: make(map[string]*signingKey)}
+	set.add(signing)
+	for _, path := range verificationPaths {
+		key, err := loadKey(path)
+		if err != nil {
+			return nil, err
+		}
+		set.add(key)
+	}
+
+	return set, nil
+}
+
+// add добавляет ключ в набор, пропуская повторы.
+func (s *KeySet) add(key *signingKey) {
+	if _, ok := s.byID[key.id]; ok {
+		return
+	}
+	s.byID[key.id] = key
+	s.keys = append(s.keys, key)
+}
+
+// Sign подписывает claims ключом подписи и указывает его kid в заголовке.
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.signing.method, claims)
+	if s.signing.id != "" {
+		token.Header["kid"] = s.signing.id
+	}
+
+	signed, err := token.SignedString(s.signing.private)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при подписи токена: %w", err)
+	}
+	return signed, nil
+}
+
+// Keyfunc выбирает ключ для проверки токена по заголовку kid. Алгоритм токена
+// должен совпадать с алгоритмом ключа.
+func (s *KeySet) Keyfunc(token *jwt.Token) (any, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.byID[kid]
+	if !ok {
+		return nil, fmt.Errorf("неизвестный ключ подписи: %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("неверный алгоритм подписи: %v", token.Header["alg"])
+	}
+	return key.public, nil
+}
+
+// Methods возвращает алгоритмы подписи ключей набора.
+func (s *KeySet) Methods() []string {
+	var methods []string
+	seen := make(map[string]bool)
+	for _, key := range s.keys {
+		if alg := key.method.Alg(); !seen[alg] {
+			seen[alg] = true
+			methods = append(methods, alg)
+		}
+	}
+	return methods
+}
+
+// JWK — открытый ключ в формате JSON Web Key (RFC 7517).
+type JWK struct {
+	KeyType   string `json:"kty"`
+	ID        string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+}
+
+// JWKS — набор открытых ключей, который публикуется для проверки токенов
+// другими сервисами.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS возвращает открытые ключи набора. Симметричный ключ не публикуется.
+func (s *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+	for _, key := range s.keys {
+		if jwk, ok := publicJWK(key.public); ok {
+			jwk.ID = key.id
+			jwk.Use = "sig"
+			jwk.Algorithm = key.method.Alg()
+			jwks.Keys = append(jwks.Keys, jwk)
+		}
+	}
+	return jwks
+}
+
+// publicJWK описывает открытый ключ в формате JWK без kid, use и alg.
+func publicJWK(public any) (JWK, bool) {
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: "RSA",
+			N:       base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JWK{KeyType: "OKP", Curve: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public)}, true
+	default:
+		return JWK{}, false
+	}
+}
+
+// PublicKey разбирает открытый ключ RSA (RS256) или Ed25519 (EdDSA) из
+// JWK, например из JWKS внешнего провайдера OpenID Connect, и возвращает его
+// вместе с алгоритмом подписи.
+func (j JWK) PublicKey() (any, jwt.SigningMethod, error) {
+	if j.Use != "" && j.Use != "sig" {
+		return nil, nil, fmt.Errorf("ключ %q не предназначен для подписи", j.ID)
+	}
+
+	var key any
+	var method jwt.SigningMethod
+	switch j.KeyType {
+	case "RSA":
+		n, errN := base64.RawURLEncoding.DecodeString(j.N)
+		e, errE := base64.RawURLEncoding.DecodeString(j.E)
+		if errN != nil || errE != nil || len(e) == 0 || len(e) > 4 {
+			return nil, nil, fmt.Errorf("неверные параметры RSA-ключа %q", j.ID)
+		}
+		public := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if public.N.BitLen() < minRSABits {
+			return nil, nil, fmt.Errorf("размер RSA-ключа %d бит меньше %d", public.N.BitLen(), minRSABits)
+		}
+		key, method = public, jwt.SigningMethodRS256
+	case "OKP":
+		x, err := base64.RawURLEncoding.DecodeString(j.X)
+		if j.Curve != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("неверные параметры ключа Ed25519 %q", j.ID)
+		}
+		key, method = ed25519.PublicKey(x), jwt.SigningMethodEdDSA
+	default:
+		return nil, nil, fmt.Errorf("неподдерживаемый тип ключа %q", j.KeyType)
+	}
+
+	if j.Algorithm != "" && j.Algorithm != method.Alg() {
+		return nil, nil, fmt.Errorf("неподдерживаемый алгоритм %q ключа %q", j.Algorithm, j.ID)
+	}
+	return key, method, nil
+}
+
+// thumbprint вычисляет отпечаток открытого ключа по RFC 7638: SHA-256 от
+// обязательных полей JWK в лексикографическом порядке.
+func thumbprint(jwk JWK) string {
+	var fields any
+	switch jwk.KeyType {
+	case "RSA":
+		fields = struct {
+			E       string `json:"e"`
+			KeyType string `json:"kty"`
+			N       string `json:"n"`
+		}{jwk.E, jwk.KeyType, jwk.N}
+	default:
+		fields = struct {
+			Curve   string `json:"crv"`
+			KeyType string `json:"kty"`
+			X       string `json:"x"`
+		}{jwk.Curve, jwk.KeyType, jwk.X}
+	}
+
+	// Значения полей — base64url и идентификаторы, экранирование не требуется
+	data, _ := json.Marshal(fields)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// loadKey читает ключ RSA или Ed25519 из PEM-файла.
+func loadKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении ключа: %w", err)
+	}
+
+	key, err := parseKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при разборе ключа %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// parseKey разбирает PEM-блок с закрытым (PKCS#8, PKCS#1) или открытым
+// (PKIX, PKCS#1) ключом.
+func parseKey(data []byte) (*signingKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("PEM-блок не найден")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип PEM-блока: %s", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{}
+	switch parsed := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.method, key.public, key.private = jwt.SigningMethodRS256, &parsed.PublicKey, parsed
+	case *rsa.PublicKey:
+		key.method, key.public = jwt.SigningMethodRS256, parsed
+	case ed25519.PrivateKey:
+		key.method, key.public, key.private = jwt.SigningMethodEdDSA, parsed.Public(), parsed
+	case ed25519.PublicKey:
+		key.method, key.public = jwt.SigningMethodEdDSA, parsed
+	default:
+		return nil, fmt.Errorf("неподдерживаемый тип ключа: %T", parsed)
+	}
+
+	if public, ok := key.public.(*rsa.PublicKey); ok && public.N.BitLen() < minRSABits {
+		return nil, fmt.Errorf("размер RSA-ключа %d бит меньше %d", public.N.BitLen(), minRSABits)
+	}
+
+	jwk, _ := publicJWK(key.public)
+	key.id = thumbprint(jwk)
+	return key, nil
+}
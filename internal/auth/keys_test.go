@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKey сохраняет ключ в PEM-файл во временном каталоге теста.
+func writeKey(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func writePrivateKey(t *testing.T, name string, key any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return writeKey(t, name, "PRIVATE KEY", der)
+}
+
+func writePublicKey(t *testing.T, name string, key any) string {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return writeKey(t, name, "PUBLIC KEY", der)
+}
+
+func parseWith(keys *KeySet, token string) error {
+	_, err := jwt.Parse(token, keys.Keyfunc, jwt.WithValidMethods(keys.Methods()))
+	return err
+}
+
+func TestKeySet_RS256(t *testing.T) {
+	// 1. Arrange
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keys, err := LoadKeySet(writePrivateKey(t, "rsa.pem", private))
+	require.NoError(t, err)
+
+	// 2. Act
+	token, err := keys.Sign(jwt.MapClaims{"sub": "user"})
+
+	// 3. Assert
+	require.NoError(t, err)
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+	require.NoError(t, err)
+	assert.Equal(t, "RS256", parsed.Header["alg"])
+	assert.Equal(t, keys.JWKS().Keys[0].ID, parsed.Header["kid"])
+	assert.NoError(t, parseWith(keys, token))
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	// 1. Arrange
+	_, oldPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	oldKeys, err := LoadKeySet(writePrivateKey(t, "old.pem", oldPrivate))
+	require.NoError(t, err)
+	oldToken, err := oldKeys.Sign(jwt.MapClaims{"sub": "user"})
+	require.NoError(t, err)
+
+	_, newPrivate, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	newPath := writePrivateKey(t, "new.pem", newPrivate)
+
+	// 2. Act
+	rotated, err := LoadKeySet(newPath, writePublicKey(t, "old.pub", oldPrivate.Public()))
+	require.NoError(t, err)
+	withoutOld, err := LoadKeySet(newPath)
+	require.NoError(t, err)
+
+	// 3. Assert
+	newToken, err := rotated.Sign(jwt.MapClaims{"sub": "user"})
+	require.NoError(t, err)
+	assert.NoError(t, parseWith(rotated, newToken))
+	assert.NoError(t, parseWith(rotated, oldToken), "токены прежнего ключа действуют до удаления ключа из набора")
+	assert.Error(t, parseWith(withoutOld, oldToken))
+	assert.Len(t, rotated.JWKS().Keys, 2)
+}
+
+func TestKeySet_RejectsOtherAlgorithms(t *testing.T) {
+	// 1. Arrange
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	keys, err := LoadKeySet(writePrivateKey(t, "ed25519.pem", private))
+	require.NoError(t, err)
+
+	// Токен HS256 с тем же kid, подписанный открытым ключом как секретом
+	jwk := keys.JWKS().Keys[0]
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user"})
+	forged.Header["kid"] = jwk.ID
+	token, err := forged.SignedString([]byte(jwk.X))
+	require.NoError(t, err)
+
+	// 2. Act
+	err = parseWith(keys, token)
+
+	// 3. Assert
+	assert.Error(t, err)
+}
+
+func TestKeySet_HMAC(t *testing.T) {
+	// 1. Arrange
+	keys := NewHMACKeySet("secret")
+
+	// 2. Act
+	token, err := keys.Sign(jwt.MapClaims{"sub": "user"})
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.NoError(t, parseWith(keys, token))
+	assert.Empty(t, keys.JWKS().Keys, "симметричный ключ не публикуется")
+}
+
+func TestLoadKeySet_Errors(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"нет файла", filepath.Join(t.TempDir(), "missing.pem")},
+		{"не PEM", writeKey(t, "garbage.pem", "CERTIFICATE", []byte("garbage"))},
+		{"короткий RSA-ключ", writePrivateKey(t, "small.pem", small)},
+		{"открытый ключ подписи", writePublicKey(t, "public.pem", public)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadKeySet(tt.path)
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// Пример из RFC 7638, раздел 3.1
+	n := "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	require.NoError(t, err)
+	jwk, ok := publicJWK(&rsa.PublicKey{N: new(big.Int).SetBytes(modulus), E: 65537})
+	require.True(t, ok)
+
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint(jwk))
+}
+
+func TestJWK_PublicKey(t *testing.T) {
+	// 1. Arrange
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaJWK, _ := publicJWK(&rsaKey.PublicKey)
+	edJWK, _ := publicJWK(edPublic)
+
+	// 2. Act
+	rsaPublic, rsaMethod, rsaErr := rsaJWK.PublicKey()
+	edParsed, edMethod, edErr := edJWK.PublicKey()
+
+	// 3. Assert
+	require.NoError(t, rsaErr)
+	assert.True(t, rsaKey.PublicKey.Equal(rsaPublic))
+	assert.Equal(t, jwt.SigningMethodRS256, rsaMethod)
+	require.NoError(t, edErr)
+	assert.Equal(t, edPublic, edParsed)
+	assert.Equal(t, jwt.SigningMethodEdDSA, edMethod)
+}
+
+func TestJWK_PublicKeyRejects(t *testing.T) {
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	smallJWK, _ := publicJWK(&small.PublicKey)
+	edPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	edJWK, _ := publicJWK(edPublic)
+	encryption, wrongAlg := edJWK, edJWK
+	encryption.Use = "enc"
+	wrongAlg.Algorithm = "RS256"
+
+	tests := []struct {
+		name string
+		jwk  JWK
+	}{
+		{"короткий RSA-ключ", smallJWK},
+		{"ключ шифрования", encryption},
+		{"алгоритм не совпадает с типом ключа", wrongAlg},
+		{"неизвестный тип ключа", JWK{KeyType: "EC", Curve: "P-256"}},
+		{"неверная кривая", JWK{KeyType: "OKP", Curve: "X25519", X: edJWK.X}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.jwk.PublicKey()
+
+			assert.Error(t, err)
+		})
+	}
+}
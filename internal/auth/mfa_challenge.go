@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// mfaChallengeAudience — получатель (aud) токенов второго шага входа.
+const mfaChallengeAudience = "mfa-challenge"
+
+// MFAChallenge — проверенный токен второго шага входа.
+type MFAChallenge struct {
+	UserID    uuid.UUID
+	ID        uuid.UUID // jti: после успешного входа токен отзывается
+	ExpiresAt time.Time
+}
+
+// MFAChallenges выдает и проверяет короткоживущие токены, которые первый шаг
+// входа (email и пароль) возвращает пользователям с двухфакторной
+// аутентификацией. Токен подтверждает только пароль и не дает доступа к API.
+type MFAChallenges struct {
+	keys   *KeySet
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewMFAChallenges создает MFAChallenges, подписывающий токены ключами keys.
+func NewMFAChallenges(keys *KeySet, issuer string, ttl time.Duration) (*MFAChallenges, error) {
+	if issuer == "" {
+		return nil, errors.New("не задан издатель токенов второго шага входа")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("неверный срок действия токена второго шага входа: %s", ttl)
+	}
+	return &MFAChallenges{keys: keys, issuer: issuer, ttl: ttl, now: time.Now}, nil
+}
+
+// Issue выдает токен второго шага входа пользователю userID.
+func (c *MFAChallenges) Issue(userID uuid.UUID) (string, error) {
+	now := c.now()
+	claims := &jwt.RegisteredClaims{
+		Issuer:    c.issuer,
+		Subject:   userID.String(),
+		Audience:  jwt.ClaimStrings{mfaChallengeAudience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(c.ttl)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ID:        uuid.NewString(),
+	}
+	return c.keys.Sign(claims)
+}
+
+// Parse проверяет подпись и срок действия токена второго шага входа.
+func (c *MFAChallenges) Parse(token string) (*MFAChallenge, error) {
+	claims := &jwt.RegisteredClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, c.keys.Keyfunc,
+		jwt.WithValidMethods(c.keys.Methods()),
+		jwt.WithIssuer(c.issuer),
+		jwt.WithAudience(mfaChallengeAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(c.now),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("неверный claim sub: %w", err)
+	}
+	id, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("неверный claim jti: %w", err)
+	}
+	return &MFAChallenge{UserID: userID, ID: id, ExpiresAt: claims.ExpiresAt.Time}, nil
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMFAChallenges_IssueAndParse(t *testing.T) {
+	// 1. Arrange
+	challenges, err := NewMFAChallenges(NewHMACKeySet("secret"), "task-tracker", 5*time.Minute)
+	require.NoError(t, err)
+	userID := uuid.New()
+
+	// 2. Act
+	token, err := challenges.Issue(userID)
+	require.NoError(t, err)
+	challenge, err := challenges.Parse(token)
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, userID, challenge.UserID)
+	assert.NotEqual(t, uuid.Nil, challenge.ID)
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), challenge.ExpiresAt, time.Second)
+}
+
+func TestMFAChallenges_ParseRejects(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	challenges, err := NewMFAChallenges(keys, "task-tracker", 5*time.Minute)
+	require.NoError(t, err)
+	challenge, err := challenges.Issue(uuid.New())
+	require.NoError(t, err)
+
+	expired, err := NewMFAChallenges(keys, "task-tracker", 5*time.Minute)
+	require.NoError(t, err)
+	expired.now = func() time.Time { return time.Now().Add(10 * time.Minute) }
+
+	emailTokens, err := NewEmailTokens(keys, "task-tracker", time.Hour)
+	require.NoError(t, err)
+	emailToken, err := emailTokens.Issue(uuid.New(), "alice@example.com")
+	require.NoError(t, err)
+
+	_, err = expired.Parse(challenge)
+	assert.Error(t, err, "истек срок действия")
+	_, err = challenges.Parse(emailToken)
+	assert.Error(t, err, "токен другого назначения")
+
+	issuer := newTestIssuer(t, IssuerConfig{Issuer: "task-tracker", Audience: "task-tracker", TTL: time.Hour})
+	_, err = issuer.Parse(challenge)
+	assert.Error(t, err, "токен второго шага не принимается как access токен")
+}
@@ -0,0 +1,109 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// oidcStateAudience — получатель (aud) токенов состояния входа через
+// OpenID Connect.
+const oidcStateAudience = "oidc-state"
+
+// OIDCLogin — незавершенный вход через внешнего провайдера OpenID Connect.
+type OIDCLogin struct {
+	// State возвращается провайдером в адресе возврата и защищает от CSRF.
+	State string `json:"state"`
+	// Nonce должен совпасть с claim nonce в ID токене провайдера.
+	Nonce string `json:"nonce"`
+	// CodeVerifier — секрет PKCE для обмена кода авторизации на токены.
+	CodeVerifier string `json:"code_verifier"`
+	// DeviceName — название устройства для сессии после входа.
+	DeviceName string `json:"device_name,omitempty"`
+}
+
+// oidcStateClaims — содержимое токена состояния входа.
+type oidcStateClaims struct {
+	jwt.RegisteredClaims
+	OIDCLogin
+}
+
+// OIDCStates выдает и проверяет подписанные токены состояния входа через
+// OpenID Connect. Токен хранится в cookie браузера на время входа у
+// провайдера, поэтому сервер ничего не хранит.
+type OIDCStates struct {
+	keys   *KeySet
+	issuer string
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+// NewOIDCStates создает OIDCStates, подписывающий токены ключами keys.
+func NewOIDCStates(keys *KeySet, issuer string, ttl time.Duration) (*OIDCStates, error) {
+	if issuer == "" {
+		return nil, errors.New("не задан издатель токенов состояния входа")
+	}
+	if ttl <= 0 {
+		return nil, fmt.Errorf("неверный срок действия входа через OpenID Connect: %s", ttl)
+	}
+	return &OIDCStates{keys: keys, issuer: issuer, ttl: ttl, now: time.Now}, nil
+}
+
+// TTL возвращает срок действия токенов состояния входа.
+func (s *OIDCStates) TTL() time.Duration {
+	return s.ttl
+}
+
+// Issue начинает вход с устройства deviceName: создает state, nonce и
+// code_verifier и возвращает их вместе с подписанным токеном.
+func (s *OIDCStates) Issue(deviceName string) (*OIDCLogin, string, error) {
+	login := &OIDCLogin{DeviceName: deviceName}
+	for _, value := range []*string{&login.State, &login.Nonce, &login.CodeVerifier} {
+		var err error
+		if *value, err = NewToken(); err != nil {
+			return nil, "", err
+		}
+	}
+
+	now := s.now()
+	claims := &oidcStateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.issuer,
+			Audience:  jwt.ClaimStrings{oidcStateAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.NewString(),
+		},
+		OIDCLogin: *login,
+	}
+	token, err := s.keys.Sign(claims)
+	if err != nil {
+		return nil, "", err
+	}
+	return login, token, nil
+}
+
+// Parse проверяет подпись и срок действия токена и сравнивает его state со
+// значением state из адреса возврата.
+func (s *OIDCStates) Parse(token, state string) (*OIDCLogin, error) {
+	claims := &oidcStateClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, s.keys.Keyfunc,
+		jwt.WithValidMethods(s.keys.Methods()),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(oidcStateAudience),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.State == "" || subtle.ConstantTimeCompare([]byte(claims.State), []byte(state)) != 1 {
+		return nil, errors.New("state не совпадает")
+	}
+	return &claims.OIDCLogin, nil
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOIDCStates_IssueAndParse(t *testing.T) {
+	// 1. Arrange
+	states, err := NewOIDCStates(NewHMACKeySet("secret"), "task-tracker", 10*time.Minute)
+	require.NoError(t, err)
+
+	// 2. Act
+	login, token, err := states.Issue("Ноутбук")
+	require.NoError(t, err)
+	parsed, err := states.Parse(token, login.State)
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, login, parsed)
+	assert.Equal(t, "Ноутбук", parsed.DeviceName)
+	assert.NotEqual(t, login.State, login.Nonce)
+	assert.True(t, VerifyPKCE(login.CodeVerifier, PKCEChallenge(login.CodeVerifier)), "code_verifier подходит для PKCE")
+}
+
+func TestOIDCStates_ParseRejects(t *testing.T) {
+	keys := NewHMACKeySet("secret")
+	states, err := NewOIDCStates(keys, "task-tracker", 10*time.Minute)
+	require.NoError(t, err)
+	login, token, err := states.Issue("")
+	require.NoError(t, err)
+
+	expired, err := NewOIDCStates(keys, "task-tracker", 10*time.Minute)
+	require.NoError(t, err)
+	expired.now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	challenges, err := NewMFAChallenges(keys, "task-tracker", time.Minute)
+	require.NoError(t, err)
+	challenge, err := challenges.Issue(uuid.New())
+	require.NoError(t, err)
+
+	_, err = states.Parse(token, "other-state")
+	assert.Error(t, err, "state не совпадает")
+	_, err = states.Parse(token, "")
+	assert.Error(t, err, "нет state")
+	_, err = expired.Parse(token, login.State)
+	assert.Error(t, err, "истек срок действия")
+	_, err = states.Parse(challenge, "")
+	assert.Error(t, err, "токен другого назначения")
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Алгоритмы хеширования паролей.
+const (
+	// PasswordArgon2id — argon2id (RFC 9106). Хеш хранится в формате PHC:
+	// $argon2id$v=19$m=<память, КиБ>,t=<итерации>,p=<потоки>$<соль>$<хеш>.
+	PasswordArgon2id = "argon2id"
+	// PasswordBcrypt — bcrypt. Хеш хранится в формате $2a$<cost>$..., так
+	// что хеши, созданные до появления argon2id, остаются действительными.
+	PasswordBcrypt = "bcrypt"
+)
+
+// Длина соли и хеша argon2id (RFC 9106, раздел 4).
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// bcryptMaxPasswordBytes — bcrypt учитывает только первые 72 байта пароля.
+const bcryptMaxPasswordBytes = 72
+
+var (
+	// ErrPasswordMismatch возвращается, если пароль не совпадает с хешем или
+	// хеша нет, например у пользователя, созданного при входе через
+	// внешнего провайдера.
+	ErrPasswordMismatch = errors.New("пароль не совпадает")
+	// ErrPasswordTooLong возвращается, если пароль длиннее, чем может
+	// учесть выбранный алгоритм.
+	ErrPasswordTooLong = errors.New("пароль слишком длинный")
+)
+
+// PasswordHashConfig — алгоритм хеширования новых паролей и его параметры.
+type PasswordHashConfig struct {
+	// Algorithm — PasswordArgon2id или PasswordBcrypt.
+	Algorithm string
+
+	// Параметры argon2id: объем памяти в КиБ, число итераций и потоков.
+	Argon2Memory      uint32
+	Argon2Iterations  uint32
+	Argon2Parallelism uint8
+
+	// BcryptCost — стоимость bcrypt (от bcrypt.MinCost до bcrypt.MaxCost).
+	BcryptCost int
+}
+
+// PasswordHasher хеширует пароли выбранным алгоритмом и проверяет хеши
+// любого поддерживаемого алгоритма: алгоритм и параметры записаны в самом
+// хеше.
+type PasswordHasher struct {
+	config PasswordHashConfig
+}
+
+// NewPasswordHasher создает PasswordHasher с параметрами config.
+func NewPasswordHasher(config PasswordHashConfig) (*PasswordHasher, error) {
+	switch config.Algorithm {
+	case PasswordArgon2id:
+		if config.Argon2Iterations < 1 || config.Argon2Parallelism < 1 {
+			return nil, fmt.Errorf("неверные параметры argon2id: t=%d, p=%d", config.Argon2Iterations, config.Argon2Parallelism)
+		}
+		if config.Argon2Memory < 8*uint32(config.Argon2Parallelism) {
+			return nil, fmt.Errorf("неверные параметры argon2id: память %d КиБ меньше 8 КиБ на поток", config.Argon2Memory)
+		}
+	case PasswordBcrypt:
+		if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("неверная стоимость bcrypt: %d", config.BcryptCost)
+		}
+	default:
+		return nil, fmt.Errorf("неизвестный алгоритм хеширования паролей %q", config.Algorithm)
+	}
+	return &PasswordHasher{config: config}, nil
+}
+
+// Hash возвращает хеш пароля с новой случайной солью.
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	if h.config.Algorithm == PasswordBcrypt {
+		if len(password) > bcryptMaxPasswordBytes {
+			return "", ErrPasswordTooLong
+		}
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), h.config.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("ошибка при хешировании пароля: %w", err)
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("ошибка при генерации соли: %w", err)
+	}
+	params := argon2Params{
+		memory:      h.config.Argon2Memory,
+		iterations:  h.config.Argon2Iterations,
+		parallelism: h.config.Argon2Parallelism,
+	}
+	key := params.key(password, salt, argon2KeyLength)
+	return params.encode(salt, key), nil
+}
+
+// Verify сравнивает пароль с хешем. rehash сообщает, что хеш создан другим
+// алгоритмом или с другими параметрами и после успешной проверки его стоит
+// заменить хешем Hash.
+func (h *PasswordHasher) Verify(hash, password string) (rehash bool, err error) {
+	switch {
+	case hash == "":
+		return false, ErrPasswordMismatch
+	case strings.HasPrefix(hash, "$argon2id$"):
+		params, salt, key, err := decodeArgon2(hash)
+		if err != nil {
+			return false, err
+		}
+		if subtle.ConstantTimeCompare(params.key(password, salt, uint32(len(key))), key) != 1 {
+			return false, ErrPasswordMismatch
+		}
+		return h.config.Algorithm != PasswordArgon2id ||
+			params.memory != h.config.Argon2Memory ||
+			params.iterations != h.config.Argon2Iterations ||
+			params.parallelism != h.config.Argon2Parallelism ||
+			len(salt) != argon2SaltLength || len(key) != argon2KeyLength, nil
+	case strings.HasPrefix(hash, "$2"):
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, ErrPasswordMismatch
+			}
+			return false, fmt.Errorf("неверный хеш bcrypt: %w", err)
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return false, fmt.Errorf("неверный хеш bcrypt: %w", err)
+		}
+		return h.config.Algorithm != PasswordBcrypt || cost != h.config.BcryptCost, nil
+	default:
+		return false, errors.New("неизвестный формат хеша пароля")
+	}
+}
+
+// argon2Params — параметры argon2id из хеша.
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+func (p argon2Params) key(password string, salt []byte, length uint32) []byte {
+	return argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, length)
+}
+
+func (p argon2Params) encode(salt, key []byte) string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.memory, p.iterations, p.parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+// decodeArgon2 разбирает хеш argon2id в формате PHC.
+func decodeArgon2(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("неверный хеш argon2id")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("неподдерживаемая версия argon2id: %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil ||
+		params.iterations < 1 || params.parallelism < 1 {
+		return params, nil, nil, fmt.Errorf("неверные параметры argon2id: %q", parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("неверная соль argon2id: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errors.New("неверный хеш argon2id")
+	}
+	return params, salt, key, nil
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrPasswordTooShort возвращается для пароля короче минимальной длины.
+	ErrPasswordTooShort = errors.New("пароль слишком короткий")
+	// ErrPasswordBreached возвращается для пароля из списка утекших паролей.
+	ErrPasswordBreached = errors.New("пароль найден в списке утекших паролей")
+)
+
+// sha1Line — строка списка в формате Pwned Passwords: SHA-1 пароля и,
+// через двоеточие, число утечек.
+var sha1Line = regexp.MustCompile(`^[0-9A-Fa-f]{40}(:\d+)?$`)
+
+// PasswordPolicy проверяет стойкость новых паролей: минимальную длину и
+// отсутствие пароля в списке утекших паролей.
+type PasswordPolicy struct {
+	minLength int
+	// breached — SHA-1 утекших паролей в шестнадцатеричном виде в верхнем
+	// регистре. Хранятся хеши, а не сами пароли, как в Pwned Passwords.
+	breached map[string]struct{}
+}
+
+// NewPasswordPolicy создает политику с минимальной длиной minLength
+// символов без списка утекших паролей.
+func NewPasswordPolicy(minLength int) (*PasswordPolicy, error) {
+	if minLength < 1 {
+		return nil, fmt.Errorf("неверная минимальная длина пароля: %d", minLength)
+	}
+	return &PasswordPolicy{minLength: minLength, breached: make(map[string]struct{})}, nil
+}
+
+// LoadPasswordPolicy создает политику с минимальной длиной minLength и
+// списком утекших паролей из файла path. Пустой path отключает проверку по
+// списку.
+func LoadPasswordPolicy(minLength int, path string) (*PasswordPolicy, error) {
+	policy, err := NewPasswordPolicy(minLength)
+	if err != nil || path == "" {
+		return policy, err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при чтении списка утекших паролей: %w", err)
+	}
+	defer file.Close()
+
+	if err := policy.AddBreached(file); err != nil {
+		return nil, fmt.Errorf("ошибка при чтении списка утекших паролей %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// AddBreached добавляет пароли из списка r: по одному на строку, открытым
+// текстом или в формате Pwned Passwords (SHA-1 и число утечек через
+// двоеточие). Пустые строки пропускаются.
+func (p *PasswordPolicy) AddBreached(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if sha1Line.MatchString(line) {
+			p.breached[strings.ToUpper(line[:40])] = struct{}{}
+		} else {
+			p.breached[passwordSHA1(line)] = struct{}{}
+		}
+	}
+	return scanner.Err()
+}
+
+// MinLength возвращает минимальную длину пароля в символах.
+func (p *PasswordPolicy) MinLength() int {
+	return p.minLength
+}
+
+// Check возвращает ErrPasswordTooShort или ErrPasswordBreached, если пароль
+// не соответствует политике.
+func (p *PasswordPolicy) Check(password string) error {
+	if utf8.RuneCountInString(password) < p.minLength {
+		return ErrPasswordTooShort
+	}
+	if _, ok := p.breached[passwordSHA1(password)]; ok {
+		return ErrPasswordBreached
+	}
+	return nil
+}
+
+func passwordSHA1(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
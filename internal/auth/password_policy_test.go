@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	// 1. Arrange
+	dir := t.TempDir()
+	path := filepath.Join(dir, "breached.txt")
+	list := "password123\r\n" +
+		"\n" +
+		// SHA-1 пароля qwertyuiop в формате Pwned Passwords
+		"b0399d2029f64d445bd131ffaa399a42d2f8e7dc:3810555\n"
+	require.NoError(t, os.WriteFile(path, []byte(list), 0o600))
+
+	// 2. Act
+	policy, err := LoadPasswordPolicy(8, path)
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, 8, policy.MinLength())
+	assert.ErrorIs(t, policy.Check("short"), ErrPasswordTooShort)
+	assert.ErrorIs(t, policy.Check("пароль"), ErrPasswordTooShort, "длина считается в символах")
+	assert.NoError(t, policy.Check("парольчик"))
+	assert.ErrorIs(t, policy.Check("password123"), ErrPasswordBreached)
+	assert.ErrorIs(t, policy.Check("qwertyuiop"), ErrPasswordBreached)
+	assert.NoError(t, policy.Check("Password123"), "сравнение с учетом регистра")
+}
+
+func TestLoadPasswordPolicy_Errors(t *testing.T) {
+	_, err := LoadPasswordPolicy(0, "")
+	assert.Error(t, err, "неверная минимальная длина")
+
+	_, err = LoadPasswordPolicy(8, filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err, "нет файла")
+
+	policy, err := LoadPasswordPolicy(8, "")
+	require.NoError(t, err)
+	assert.NoError(t, policy.Check("password123"), "без файла список пуст")
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Config — параметры argon2id, достаточно быстрые для тестов.
+var testArgon2Config = PasswordHashConfig{Algorithm: PasswordArgon2id, Argon2Memory: 64, Argon2Iterations: 1, Argon2Parallelism: 1}
+
+func newTestHasher(t *testing.T, config PasswordHashConfig) *PasswordHasher {
+	t.Helper()
+
+	hasher, err := NewPasswordHasher(config)
+	require.NoError(t, err)
+	return hasher
+}
+
+func TestPasswordHasher_Argon2id(t *testing.T) {
+	// 1. Arrange
+	hasher := newTestHasher(t, testArgon2Config)
+
+	// 2. Act
+	hash, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+	other, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+
+	// 3. Assert
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+	assert.NotEqual(t, hash, other, "соль случайная")
+	rehash, err := hasher.Verify(hash, "correct horse")
+	require.NoError(t, err)
+	assert.False(t, rehash)
+	_, err = hasher.Verify(hash, "wrong horse")
+	assert.ErrorIs(t, err, ErrPasswordMismatch)
+}
+
+func TestPasswordHasher_Bcrypt(t *testing.T) {
+	// 1. Arrange
+	hasher := newTestHasher(t, PasswordHashConfig{Algorithm: PasswordBcrypt, BcryptCost: bcrypt.MinCost})
+
+	// 2. Act
+	hash, err := hasher.Hash("correct horse")
+	require.NoError(t, err)
+
+	// 3. Assert
+	assert.True(t, strings.HasPrefix(hash, "$2a$04$"), hash)
+	rehash, err := hasher.Verify(hash, "correct horse")
+	require.NoError(t, err)
+	assert.False(t, rehash)
+	_, err = hasher.Verify(hash, "wrong horse")
+	assert.ErrorIs(t, err, ErrPasswordMismatch)
+	_, err = hasher.Hash(strings.Repeat("a", 73))
+	assert.ErrorIs(t, err, ErrPasswordTooLong, "bcrypt учитывает только 72 байта")
+}
+
+func TestPasswordHasher_Rehash(t *testing.T) {
+	// 1. Arrange
+	legacy, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	require.NoError(t, err)
+	weakArgon2 := newTestHasher(t, testArgon2Config)
+	weakHash, err := weakArgon2.Hash("password")
+	require.NoError(t, err)
+
+	stronger := testArgon2Config
+	stronger.Argon2Iterations = 2
+	hasher := newTestHasher(t, stronger)
+	strongBcrypt := newTestHasher(t, PasswordHashConfig{Algorithm: PasswordBcrypt, BcryptCost: bcrypt.MinCost + 1})
+
+	// 2. Act
+	legacyRehash, legacyErr := hasher.Verify(string(legacy), "password")
+	weakRehash, weakErr := hasher.Verify(weakHash, "password")
+	costRehash, costErr := strongBcrypt.Verify(string(legacy), "password")
+
+	// 3. Assert
+	require.NoError(t, legacyErr, "хеши bcrypt проверяются и после перехода на argon2id")
+	assert.True(t, legacyRehash, "другой алгоритм")
+	require.NoError(t, weakErr)
+	assert.True(t, weakRehash, "другие параметры argon2id")
+	require.NoError(t, costErr)
+	assert.True(t, costRehash, "другая стоимость bcrypt")
+}
+
+func TestPasswordHasher_VerifyRejects(t *testing.T) {
+	hasher := newTestHasher(t, testArgon2Config)
+
+	_, err := hasher.Verify("", "")
+	assert.ErrorIs(t, err, ErrPasswordMismatch, "нет пароля")
+
+	for _, hash := range []string{
+		"plaintext",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHQ$aGFzaA",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$aGFzaA",
+		"$2a$10$short",
+	} {
+		_, err := hasher.Verify(hash, "password")
+		assert.Error(t, err, hash)
+	}
+}
+
+func TestNewPasswordHasher_Validation(t *testing.T) {
+	_, err := NewPasswordHasher(PasswordHashConfig{Algorithm: "md5"})
+	assert.Error(t, err, "неизвестный алгоритм")
+
+	_, err = NewPasswordHasher(PasswordHashConfig{Algorithm: PasswordArgon2id, Argon2Memory: 64, Argon2Iterations: 0, Argon2Parallelism: 1})
+	assert.Error(t, err, "нет итераций")
+
+	_, err = NewPasswordHasher(PasswordHashConfig{Algorithm: PasswordArgon2id, Argon2Memory: 8, Argon2Iterations: 1, Argon2Parallelism: 2})
+	assert.Error(t, err, "мало памяти на поток")
+
+	_, err = NewPasswordHasher(PasswordHashConfig{Algorithm: PasswordBcrypt, BcryptCost: 3})
+	assert.Error(t, err, "стоимость bcrypt меньше минимальной")
+}
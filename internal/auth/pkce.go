@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// pkceVerifier — допустимый code_verifier (RFC 7636, раздел 4.1).
+var pkceVerifier = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+
+// PKCEChallenge возвращает code_challenge для code_verifier по методу S256.
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPKCE проверяет, что code_verifier соответствует code_challenge,
+// полученному при авторизации (метод S256).
+func VerifyPKCE(verifier, challenge string) bool {
+	if !pkceVerifier.MatchString(verifier) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(PKCEChallenge(verifier)), []byte(challenge)) == 1
+}
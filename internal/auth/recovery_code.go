@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// recoveryCodeAlphabet — символы кодов восстановления без легко путаемых
+// 0/o и 1/l.
+const recoveryCodeAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+
+// recoveryCodeLength — число символов кода восстановления (около 50 бит).
+const recoveryCodeLength = 10
+
+// NewRecoveryCode возвращает случайный одноразовый код восстановления вида
+// "xxxxx-xxxxx" для входа без приложения-аутентификатора.
+func NewRecoveryCode() (string, error) {
+	alphabetSize := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	code := make([]byte, recoveryCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", fmt.Errorf("ошибка при генерации кода восстановления: %w", err)
+		}
+		code[i] = recoveryCodeAlphabet[n.Int64()]
+	}
+
+	half := recoveryCodeLength / 2
+	return string(code[:half]) + "-" + string(code[half:]), nil
+}
+
+// HashRecoveryCode возвращает хеш кода восстановления. Регистр, пробелы и
+// дефисы не учитываются.
+func HashRecoveryCode(code string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+	return HashToken(normalized)
+}
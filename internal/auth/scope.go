@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"slices"
+)
+
+// Разрешения (scopes) токенов доступа. Разрешение на запись не включает
+// разрешение на чтение.
+const (
+	ScopeTasksRead   = "tasks:read"
+	ScopeTasksWrite  = "tasks:write"
+	ScopeLabelsRead  = "labels:read"
+	ScopeLabelsWrite = "labels:write"
+)
+
+// Scopes — все известные разрешения.
+var Scopes = []string{ScopeTasksRead, ScopeTasksWrite, ScopeLabelsRead, ScopeLabelsWrite}
+
+// ValidScope сообщает, известно ли разрешение scope.
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+type ScopesContextKey struct{}
+
+// ContextWithScopes ограничивает запрос разрешениями scopes. Запрос без
+// ограничений (токен сессии после входа по паролю) имеет полный доступ.
+func ContextWithScopes(ctx context.Context, scopes []string) context.Context {
+	return context.WithValue(ctx, ScopesContextKey{}, scopes)
+}
+
+// ScopesFromContext возвращает разрешения запроса. ok = false, если запрос
+// не ограничен разрешениями.
+func ScopesFromContext(ctx context.Context) (scopes []string, ok bool) {
+	scopes, ok = ctx.Value(ScopesContextKey{}).([]string)
+	return scopes, ok
+}
+
+// HasScope сообщает, разрешено ли запросу действие scope.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, ok := ScopesFromContext(ctx)
+	return !ok || slices.Contains(scopes, scope)
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// tokenBytes — длина случайных секретных токенов (256 бит).
+const tokenBytes = 32
+
+// NewToken возвращает криптографически случайный токен в кодировке base64url.
+func NewToken() (string, error) {
+	b := make([]byte, tokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("ошибка при генерации токена: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken возвращает SHA-256 токена в шестнадцатеричном виде. В хранилище
+// сохраняется только хеш, поэтому утечка базы данных не раскрывает токены.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHOTP_RFC4226(t *testing.T) {
+	// Тестовые значения из RFC 4226, приложение D
+	key := []byte("12345678901234567890")
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range expected {
+		assert.Equal(t, code, hotp(key, uint64(counter), 6), "counter %d", counter)
+	}
+}
+
+func TestHOTP_RFC6238(t *testing.T) {
+	// Тестовые значения SHA1 из RFC 6238, приложение B
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		step := TOTPStep(time.Unix(tt.unix, 0))
+		assert.Equal(t, tt.code, hotp(key, uint64(step), 8), "time %d", tt.unix)
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	// 1. Arrange
+	secret, err := NewTOTPSecret()
+	require.NoError(t, err)
+	now := time.Now()
+	step := TOTPStep(now)
+	previous, err := TOTPCode(secret, step-1)
+	require.NoError(t, err)
+	stale, err := TOTPCode(secret, step-3)
+	require.NoError(t, err)
+
+	// 2. Act
+	matched, ok := ValidateTOTP(secret, previous, now)
+
+	// 3. Assert
+	assert.True(t, ok, "код соседнего шага принимается")
+	assert.Equal(t, step-1, matched)
+	_, ok = ValidateTOTP(secret, stale, now)
+	assert.False(t, ok)
+	_, ok = ValidateTOTP(secret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("Task Tracker", "alice@example.com", "JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Task Tracker:alice@example.com", uri.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	assert.Equal(t, "Task Tracker", uri.Query().Get("issuer"))
+}
+
+func TestRecoveryCode(t *testing.T) {
+	code, err := NewRecoveryCode()
+	require.NoError(t, err)
+
+	assert.Regexp(t, regexp.MustCompile(`^[2-9a-z]{5}-[2-9a-z]{5}$`), code)
+	assert.Equal(t, HashRecoveryCode(code), HashRecoveryCode(" "+code[:5]+code[6:]+" "))
+	assert.NotEqual(t, HashRecoveryCode(code), HashRecoveryCode(code[:9]))
+}
@@ -1,29 +1,310 @@
 package config
 
 import (
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// DefaultJWTSecret — секрет JWT по умолчанию. С ним приложение запускается
+// только в режиме разработки.
+const DefaultJWTSecret = "secret"
+
+// Хранилища данных.
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// Способы доставки писем.
+const (
+	MailerLog  = "log"
+	MailerFile = "file"
+	MailerSMTP = "smtp"
+)
+
 type Config struct {
 	AppPort     string
 	DatabaseURL string
 	JWTSecret   string
+
+	// Ключи подписи access токенов в PEM (RSA или Ed25519). Если
+	// JWTSigningKeyFile пуст, токены подписываются HS256 секретом JWTSecret.
+	// JWTVerificationKeyFiles — список файлов через запятую с прежними
+	// ключами, которыми еще проверяются выданные токены после ротации.
+	JWTSigningKeyFile       string
+	JWTVerificationKeyFiles string
+
+	// Параметры выдачи токенов: издатель (iss) и получатель (aud) access
+	// токенов, срок действия access токена и сессии (refresh токенов).
+	JWTIssuer       string
+	JWTAudience     string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Сброс пароля: адрес страницы, на которую ведет ссылка из письма (токен
+	// передается в параметре token), и срок действия ссылки.
+	PasswordResetURL string
+	PasswordResetTTL time.Duration
+
+	// Подтверждение email: адрес GET /verify-email для ссылки из письма,
+	// срок действия ссылки и запрет входа с неподтвержденным email.
+	EmailVerificationURL     string
+	EmailVerificationTTL     time.Duration
+	RequireEmailVerification bool
+
+	// Хеширование паролей: алгоритм новых хешей (argon2id или bcrypt),
+	// параметры argon2id (память в КиБ, итерации, потоки) и
+	// стоимость bcrypt. Хеши с другими параметрами заменяются при входе.
+	PasswordHashAlgorithm     string
+	PasswordArgon2Memory      int
+	PasswordArgon2Iterations  int
+	PasswordArgon2Parallelism int
+	PasswordBcryptCost        int
+
+	// Политика стойкости новых паролей: минимальная длина в символах и файл
+	// со списком утекших паролей (пустой путь отключает проверку).
+	PasswordMinLength        int
+	PasswordBreachedListFile string
+
+	// Двухфакторная аутентификация: название сервиса в приложении-
+	// аутентификаторе и срок действия токена второго шага входа.
+	MFAIssuer       string
+	MFAChallengeTTL time.Duration
+
+	// Защита входа от перебора паролей: число неудачных попыток до
+	// блокировки для email и для IP-адреса, первая и наибольшая длительность
+	// блокировки (каждая следующая вдвое дольше) и окно, после которого
+	// счетчик неудачных попыток начинается заново.
+	LoginMaxFailures   int
+	LoginMaxIPFailures int
+	LoginLockoutTTL    time.Duration
+	LoginMaxLockoutTTL time.Duration
+	LoginFailureWindow time.Duration
+
+	// OAuthCodeTTL — срок действия кода авторизации OAuth2.
+	OAuthCodeTTL time.Duration
+
+	// Вход через внешнего провайдера OpenID Connect. Пустой OIDCIssuerURL
+	// отключает вход. OIDCScopes перечисляются через пробел.
+	// OIDCAutoProvision создает пользователя при первом входе, если
+	// пользователя с подтвержденным провайдером email нет. OIDCStateTTL —
+	// время на вход у провайдера.
+	OIDCIssuerURL     string
+	OIDCClientID      string
+	OIDCClientSecret  string
+	OIDCRedirectURL   string
+	OIDCScopes        string
+	OIDCAutoProvision bool
+	OIDCStateTTL      time.Duration
+
+	// Mailer выбирает способ доставки писем: MailerSMTP отправляет их через
+	// SMTP-сервер, MailerLog пишет в лог, MailerFile сохраняет в файлы .eml
+	// в каталоге (outbox) MailDir.
+	Mailer   string
+	MailDir  string
+	MailFrom string
+
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// DevMode разрешает запуск с секретом JWT по умолчанию.
+	DevMode bool
+
+	// Storage выбирает хранилище данных: StoragePostgres или StorageMemory.
+	// Данные в памяти теряются при перезапуске.
+	Storage string
+
+	// AutoMigrate включает применение миграций схемы при запуске приложения.
+	AutoMigrate bool
+
+	// Рабочий процесс задач (см. domain.ParseWorkflow). Если TaskWorkflow
+	// пуст, используется процесс по умолчанию.
+	TaskWorkflow          string
+	TaskCompletedStatuses string
 }
 
-func LoadConfig() Config {
+// LoadConfig читает настройки из переменных окружения и файла .env. Ошибка
+// возвращается для значений неверного формата.
+func LoadConfig() (Config, error) {
 	err := godotenv.Load()
 	if err != nil {
 		log.Println("Error loading .env file:", err)
 	}
 
+	// По умолчанию access токен действует 24h, как до появления настройки:
+	// клиенты, которые не обновляют токен, продолжают работать
+	accessTokenTTL, err := getDuration("ACCESS_TOKEN_EXPIRE_TIME", 24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	refreshTokenTTL, err := getDuration("REFRESH_TOKEN_EXPIRE_TIME", 30*24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	passwordResetTTL, err := getDuration("PASSWORD_RESET_EXPIRE_TIME", time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	emailVerificationTTL, err := getDuration("EMAIL_VERIFICATION_EXPIRE_TIME", 24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	passwordArgon2Memory, err := getInt("PASSWORD_ARGON2_MEMORY", 64*1024)
+	if err != nil {
+		return Config{}, err
+	}
+	passwordArgon2Iterations, err := getInt("PASSWORD_ARGON2_ITERATIONS", 3)
+	if err != nil {
+		return Config{}, err
+	}
+	passwordArgon2Parallelism, err := getInt("PASSWORD_ARGON2_PARALLELISM", 4)
+	if err != nil {
+		return Config{}, err
+	}
+	passwordBcryptCost, err := getInt("PASSWORD_BCRYPT_COST", 12)
+	if err != nil {
+		return Config{}, err
+	}
+	passwordMinLength, err := getInt("PASSWORD_MIN_LENGTH", 8)
+	if err != nil {
+		return Config{}, err
+	}
+	mfaChallengeTTL, err := getDuration("MFA_CHALLENGE_EXPIRE_TIME", 5*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	loginMaxFailures, err := getInt("LOGIN_MAX_FAILURES", 5)
+	if err != nil {
+		return Config{}, err
+	}
+	loginMaxIPFailures, err := getInt("LOGIN_MAX_IP_FAILURES", 20)
+	if err != nil {
+		return Config{}, err
+	}
+	loginLockoutTTL, err := getDuration("LOGIN_LOCKOUT_TIME", time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	loginMaxLockoutTTL, err := getDuration("LOGIN_MAX_LOCKOUT_TIME", time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	loginFailureWindow, err := getDuration("LOGIN_FAILURE_WINDOW", 24*time.Hour)
+	if err != nil {
+		return Config{}, err
+	}
+	oauthCodeTTL, err := getDuration("OAUTH_CODE_EXPIRE_TIME", time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	oidcStateTTL, err := getDuration("OIDC_STATE_EXPIRE_TIME", 10*time.Minute)
+	if err != nil {
+		return Config{}, err
+	}
+	appPort := getEnv("APP_PORT", "8080")
+
 	return Config{
-		AppPort:     getEnv("APP_PORT", "8080"),
+		AppPort:     appPort,
 		DatabaseURL: getEnv("DATABASE_URL", ""),
-		JWTSecret:   getEnv("JWT_SECRET", "secret"),
+		JWTSecret:   getEnv("JWT_SECRET", DefaultJWTSecret),
+
+		JWTSigningKeyFile:       getEnv("JWT_SIGNING_KEY_FILE", ""),
+		JWTVerificationKeyFiles: getEnv("JWT_VERIFICATION_KEY_FILES", ""),
+
+		JWTIssuer:       getEnv("JWT_ISSUER", "task-tracker"),
+		JWTAudience:     getEnv("JWT_AUDIENCE", "task-tracker"),
+		AccessTokenTTL:  accessTokenTTL,
+		RefreshTokenTTL: refreshTokenTTL,
+
+		PasswordResetURL: getEnv("PASSWORD_RESET_URL", "http://localhost:5173/reset-password"),
+		PasswordResetTTL: passwordResetTTL,
+
+		EmailVerificationURL:     getEnv("EMAIL_VERIFICATION_URL", "http://localhost:"+appPort+"/verify-email"),
+		EmailVerificationTTL:     emailVerificationTTL,
+		RequireEmailVerification: getEnv("REQUIRE_EMAIL_VERIFICATION", "false") == "true",
+
+		PasswordHashAlgorithm:     getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		PasswordArgon2Memory:      passwordArgon2Memory,
+		PasswordArgon2Iterations:  passwordArgon2Iterations,
+		PasswordArgon2Parallelism: passwordArgon2Parallelism,
+		PasswordBcryptCost:        passwordBcryptCost,
+
+		PasswordMinLength:        passwordMinLength,
+		PasswordBreachedListFile: getEnv("PASSWORD_BREACHED_LIST_FILE", ""),
+
+		MFAIssuer:       getEnv("MFA_ISSUER", "Task Tracker"),
+		MFAChallengeTTL: mfaChallengeTTL,
+
+		LoginMaxFailures:   loginMaxFailures,
+		LoginMaxIPFailures: loginMaxIPFailures,
+		LoginLockoutTTL:    loginLockoutTTL,
+		LoginMaxLockoutTTL: loginMaxLockoutTTL,
+		LoginFailureWindow: loginFailureWindow,
+
+		OAuthCodeTTL: oauthCodeTTL,
+
+		OIDCIssuerURL:     getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:      getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:  getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:   getEnv("OIDC_REDIRECT_URL", "http://localhost:"+appPort+"/login/oidc/callback"),
+		OIDCScopes:        getEnv("OIDC_SCOPES", "openid email profile"),
+		OIDCAutoProvision: getEnv("OIDC_AUTO_PROVISION", "true") == "true",
+		OIDCStateTTL:      oidcStateTTL,
+
+		Mailer:   getEnv("MAILER", MailerLog),
+		MailDir:  getEnv("MAIL_DIR", "mail"),
+		MailFrom: getEnv("MAIL_FROM", "Task Tracker <noreply@localhost>"),
+
+		SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+
+		DevMode: getEnv("DEV_MODE", "false") == "true",
+
+		Storage: getEnv("STORAGE", StoragePostgres),
+
+		AutoMigrate: getEnv("AUTO_MIGRATE", "false") == "true",
+
+		TaskWorkflow:          getEnv("TASK_WORKFLOW", ""),
+		TaskCompletedStatuses: getEnv("TASK_COMPLETED_STATUSES", "done"),
+	}, nil
+}
+
+// getDuration читает длительность в формате time.ParseDuration (например,
+// "15m" или "720h").
+func getDuration(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a positive duration such as 15m or 720h", key, value)
+	}
+	return duration, nil
+}
+
+// getInt читает положительное целое число.
+func getInt(key string, defaultValue int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid %s %q: expected a positive integer", key, value)
 	}
+	return n, nil
 }
 
 func getEnv(key string, defaultValue string) string {
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Типы событий журнала аудита.
+const (
+	// AuditLoginLockout — вход временно заблокирован после неудачных попыток.
+	AuditLoginLockout = "login.lockout"
+	// AuditMailThrottle — запросы писем сброса пароля или подтверждения
+	// email временно ограничены после частых запросов.
+	AuditMailThrottle = "mail.throttle"
+	// AuditAccountUnlock — администратор снял блокировку входа.
+	AuditAccountUnlock = "account.unlock"
+	// AuditExternalIdentityLink — учетная запись внешнего провайдера
+	// связана с пользователем при первом входе через OpenID Connect.
+	AuditExternalIdentityLink = "external_identity.link"
+)
+
+// AuditEvent — запись журнала аудита событий безопасности.
+type AuditEvent struct {
+	ID   uuid.UUID `json:"id"`
+	Type string    `json:"type"`
+	// UserID — пользователь, которого касается событие (nil, если он неизвестен).
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	// ActorID — пользователь, выполнивший действие (nil для действий системы).
+	ActorID *uuid.UUID `json:"actor_id,omitempty"`
+	// Subject — ключ блокировки (email, IP-адрес или ID пользователя) или
+	// email пользователя, которого касается событие.
+	Subject   string    `json:"subject,omitempty"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
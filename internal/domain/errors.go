@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Категории ошибок предметной области. Сервисы и репозитории возвращают
+// ошибки, которые оборачивают одну из них, а обработчики HTTP по ним
+// выбирают код ответа.
+var (
+	// ErrNotFound означает, что запись не найдена или недоступна текущему пользователю.
+	ErrNotFound = errors.New("запись не найдена")
+	// ErrValidation означает, что входные данные не прошли проверку.
+	ErrValidation = errors.New("ошибка валидации")
+	// ErrConflict означает конфликт с текущим состоянием данных (дубликат, недопустимый переход).
+	ErrConflict = errors.New("конфликт данных")
+	// ErrForbidden означает, что действие запрещено текущему пользователю.
+	ErrForbidden = errors.New("доступ запрещен")
+	// ErrUnauthorized означает, что пользователь не аутентифицирован или учетные данные неверны.
+	ErrUnauthorized = errors.New("требуется аутентификация")
+	// ErrTooManyRequests означает, что действие временно заблокировано из-за
+	// слишком частых попыток.
+	ErrTooManyRequests = errors.New("слишком много запросов")
+)
+
+// Error — ошибка предметной области с сообщением для клиента. Kind указывает
+// категорию ошибки и проверяется через errors.Is. Code — стабильный
+// машиночитаемый код, по которому клиент и слой HTTP могут локализовать
+// сообщение; Params подставляются в локализованный текст вместо {имя}.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Params  map[string]string
+	Fields  []FieldError
+}
+
+// FieldError описывает ошибку в отдельном поле запроса.
+type FieldError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+// NewError создает ошибку заданной категории.
+func NewError(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NewFieldError создает ошибку валидации одного поля запроса.
+func NewFieldError(field, code, message string) *Error {
+	return &Error{
+		Kind:    ErrValidation,
+		Code:    code,
+		Message: message,
+		Fields:  []FieldError{{Field: field, Code: code, Message: message}},
+	}
+}
+
+// WithParams возвращает копию ошибки с параметрами сообщения.
+func (e *Error) WithParams(params map[string]string) *Error {
+	copied := *e
+	copied.Params = params
+	return &copied
+}
+
+// WithFields возвращает копию ошибки с ошибками отдельных полей.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	copied := *e
+	copied.Fields = fields
+	return &copied
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Is сравнивает ошибки по коду, поэтому копия с параметрами или полями
+// (WithParams, WithFields) совпадает с исходной ошибкой.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code != "" && t.Code == e.Code && t.Kind == e.Kind
+}
+
+// FormatMessage подставляет параметры в шаблон сообщения вида "метка {id} не найдена".
+func FormatMessage(template string, params map[string]string) string {
+	for name, value := range params {
+		template = strings.ReplaceAll(template, "{"+name+"}", value)
+	}
+	return template
+}
@@ -0,0 +1,25 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError_Is(t *testing.T) {
+	// 1. Arrange
+	base := NewError(ErrTooManyRequests, "auth.too_many_attempts", "повторите через {retry_after} с")
+	withParams := base.WithParams(map[string]string{"retry_after": "60"})
+	other := NewError(ErrTooManyRequests, "other", "другая ошибка")
+
+	// 2. Act
+	wrapped := fmt.Errorf("ошибка входа: %w", withParams)
+
+	// 3. Assert
+	assert.True(t, errors.Is(wrapped, base), "копия с параметрами совпадает с исходной ошибкой")
+	assert.True(t, errors.Is(wrapped, ErrTooManyRequests))
+	assert.False(t, errors.Is(wrapped, other))
+	assert.False(t, errors.Is(other, base))
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExternalIdentity связывает пользователя с учетной записью у внешнего
+// провайдера OpenID Connect. Учетная запись определяется издателем (iss) и
+// неизменным ID пользователя у провайдера (sub), а не email, который у
+// провайдера может смениться.
+type ExternalIdentity struct {
+	Issuer    string
+	Subject   string
+	UserID    uuid.UUID
+	CreatedAt time.Time
+}
@@ -4,9 +4,10 @@ import (
 	"github.com/google/uuid"
 )
 
+// Label — метка, которой пользователь группирует свои задачи.
 type Label struct {
-	ID     uuid.UUID `json:"id"`
-	Name   string    `json:"name"`
-	Color  string    `json:"color"`
-	UserID uuid.UUID `json:"user_id"`
+	ID     uuid.UUID
+	Name   string
+	Color  string
+	UserID uuid.UUID
 }
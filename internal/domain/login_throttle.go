@@ -0,0 +1,19 @@
+package domain
+
+import "time"
+
+// LoginThrottle — счетчик неудачных попыток входа по одному ключу: email
+// аккаунта, IP-адресу клиента или второму шагу входа пользователя.
+type LoginThrottle struct {
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	// LockedUntil — момент окончания временной блокировки; nil, если ключ
+	// не блокировался.
+	LockedUntil *time.Time
+}
+
+// Locked сообщает, заблокирован ли ключ в момент now.
+func (t *LoginThrottle) Locked(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClient — зарегистрированное стороннее приложение (клиент OAuth2).
+// Конфиденциальный клиент аутентифицируется секретом; в хранилище
+// сохраняется только его хеш SecretHash. У публичного клиента секрета нет.
+type OAuthClient struct {
+	ID           uuid.UUID `json:"client_id"`
+	OwnerID      uuid.UUID `json:"owner_id"` // пользователь, зарегистрировавший клиента
+	Name         string    `json:"name"`
+	SecretHash   string    `json:"-"`
+	RedirectURIs []string  `json:"redirect_uris"`
+	Scopes       []string  `json:"scopes"` // разрешения, которые клиент может запросить
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Confidential сообщает, есть ли у клиента секрет.
+func (c *OAuthClient) Confidential() bool {
+	return c.SecretHash != ""
+}
+
+// OAuthAuthorizationCode — одноразовый код авторизации, который клиент
+// обменивает на access токен. Хранится только хеш кода CodeHash.
+type OAuthAuthorizationCode struct {
+	CodeHash string
+	ClientID uuid.UUID
+	UserID   uuid.UUID
+	// RedirectURI — redirect_uri из запроса авторизации; пустой, если
+	// клиент его не передал. Переданный адрес клиент обязан повторить при
+	// обмене кода (RFC 6749, раздел 4.1.3).
+	RedirectURI   string
+	Scopes        []string
+	CodeChallenge string // PKCE, метод S256
+	ExpiresAt     time.Time
+}
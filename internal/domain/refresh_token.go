@@ -6,9 +6,20 @@ import (
 	"github.com/google/uuid"
 )
 
+// RefreshToken — refresh токен сессии пользователя. Токены, выданные при
+// одном входе и последующих ротациях, образуют семейство с общим FamilyID,
+// который совпадает с ID сессии (см. Session). UsedAt заполняется, когда
+// токен обменян на новый; повторное предъявление такого токена означает
+// его кражу.
+//
+// Сам токен (Token) известен только в момент выдачи и передается клиенту;
+// в хранилище сохраняется его хеш TokenHash.
 type RefreshToken struct {
-	ID         uuid.UUID `json:"id"`
-	UserID     uuid.UUID `json:"user_id"`
-	Token      string    `json:"token"`
-	ExpiryDate time.Time `json:"expiry_date"`
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	FamilyID   uuid.UUID  `json:"family_id"`
+	Token      string     `json:"token,omitempty"`
+	TokenHash  string     `json:"-"`
+	ExpiryDate time.Time  `json:"expiry_date"`
+	UsedAt     *time.Time `json:"used_at,omitempty"`
 }
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Device описывает устройство, с которого выполнен вход.
+type Device struct {
+	Name      string `json:"device_name"` // Название, переданное клиентом
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+}
+
+// Session — сессия пользователя на одном устройстве. ID сессии совпадает с
+// FamilyID ее refresh токенов; удаление сессии отзывает все ее токены.
+type Session struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"user_id"`
+	Device
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
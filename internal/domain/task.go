@@ -6,11 +6,26 @@ import (
 	"github.com/google/uuid"
 )
 
+// TaskStatus — статус задачи в рабочем процессе.
+type TaskStatus string
+
+// Статусы рабочего процесса по умолчанию.
+const (
+	TaskStatusTodo       TaskStatus = "todo"
+	TaskStatusInProgress TaskStatus = "in_progress"
+	TaskStatusDone       TaskStatus = "done"
+	TaskStatusCancelled  TaskStatus = "cancelled"
+)
+
+// Task — задача пользователя.
 type Task struct {
-	ID          uuid.UUID `json:"id"`
-	Title       string    `json:"title"`
-	Description string    `json:"description"`
-	DueDate     time.Time `json:"due_date"`
-	UserID      uuid.UUID `json:"user_id"`
-	LabelIDs    []uuid.UUID `json:"label_ids"`
-}
\ No newline at end of file
+	ID          uuid.UUID
+	Title       string
+	Description string
+	DueDate     time.Time
+	Status      TaskStatus
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UserID      uuid.UUID
+	LabelIDs    []uuid.UUID
+}
@@ -0,0 +1,20 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTP — секрет двухфакторной аутентификации пользователя (RFC 6238).
+// Двухфакторная аутентификация включена, когда Confirmed равно true: до
+// этого пользователь должен ввести код из приложения-аутентификатора.
+type TOTP struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Secret    string    `json:"-"`
+	Confirmed bool      `json:"confirmed"`
+	// LastUsedStep — последний принятый шаг TOTP. Коды этого и более ранних
+	// шагов повторно не принимаются.
+	LastUsedStep int64     `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
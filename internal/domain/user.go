@@ -1,27 +1,27 @@
 package domain
 
 import (
+	"time"
+
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// User — пользователь в хранилище. В ответы API он не сериализуется
+// напрямую: обработчики отдают только открытые поля.
 type User struct {
-	ID       uuid.UUID `json:"id"`
-	Username string    `json:"username"`
-	Email    string    `json:"email"`
-	Password string    `json:"password"`
-}
-
-func (u *User) HashPassword(password string) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-	if err != nil {
-		return err
-	}
-	u.Password = string(hashedPassword)
-	return nil
+	ID       uuid.UUID
+	Username string
+	Email    string
+	// Password — хеш пароля в формате auth.PasswordHasher; пустой, если
+	// пользователь входит только через внешнего провайдера.
+	Password string
+	// EmailVerified — подтвержден ли email по ссылке из письма. При смене
+	// email сбрасывается.
+	EmailVerified bool
+	// IsAdmin дает доступ к маршрутам администрирования /admin. Назначается
+	// командой task-tracker admin grant.
+	IsAdmin bool
+	// TokensValidAfter — момент отзыва всех access токенов пользователя.
+	// Токены, выданные раньше, не принимаются.
+	TokensValidAfter *time.Time
 }
-
-// ComparePassword сравнивает переданный пароль с хешем пароля пользователя.
-func (u *User) ComparePassword(password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-}
\ No newline at end of file
@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Workflow описывает статусы задачи и допустимые переходы между ними.
+type Workflow struct {
+	Initial     TaskStatus                  // Статус новой задачи
+	Transitions map[TaskStatus][]TaskStatus // Допустимые переходы из каждого статуса
+	Completed   map[TaskStatus]bool         // Статусы, при переходе в которые задача считается выполненной
+}
+
+// DefaultWorkflow возвращает рабочий процесс по умолчанию:
+// todo → in_progress → done, с возможностью отмены и повторного открытия.
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Initial: TaskStatusTodo,
+		Transitions: map[TaskStatus][]TaskStatus{
+			TaskStatusTodo:       {TaskStatusInProgress, TaskStatusCancelled},
+			TaskStatusInProgress: {TaskStatusDone, TaskStatusTodo, TaskStatusCancelled},
+			TaskStatusDone:       {TaskStatusInProgress},
+			TaskStatusCancelled:  {TaskStatusTodo},
+		},
+		Completed: map[TaskStatus]bool{TaskStatusDone: true},
+	}
+}
+
+// ParseWorkflow разбирает описание рабочего процесса вида
+// "todo:in_progress,cancelled;in_progress:done;done;cancelled:todo".
+// Каждый элемент задает статус и список статусов, в которые из него можно
+// перейти. Первый статус становится начальным. completed — список статусов
+// через запятую, означающих выполнение задачи.
+func ParseWorkflow(spec, completed string) (*Workflow, error) {
+	workflow := &Workflow{
+		Transitions: make(map[TaskStatus][]TaskStatus),
+		Completed:   make(map[TaskStatus]bool),
+	}
+
+	for _, item := range strings.Split(spec, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		from, targets, _ := strings.Cut(item, ":")
+		status := TaskStatus(strings.TrimSpace(from))
+		if status == "" {
+			return nil, fmt.Errorf("пустой статус в описании рабочего процесса")
+		}
+		if _, ok := workflow.Transitions[status]; ok {
+			return nil, fmt.Errorf("статус %s описан повторно", status)
+		}
+		if workflow.Initial == "" {
+			workflow.Initial = status
+		}
+
+		workflow.Transitions[status] = []TaskStatus{}
+		for _, target := range strings.Split(targets, ",") {
+			if target = strings.TrimSpace(target); target != "" {
+				workflow.Transitions[status] = append(workflow.Transitions[status], TaskStatus(target))
+			}
+		}
+	}
+
+	if workflow.Initial == "" {
+		return nil, fmt.Errorf("рабочий процесс не содержит статусов")
+	}
+
+	for from, targets := range workflow.Transitions {
+		for _, to := range targets {
+			if !workflow.Has(to) {
+				return nil, fmt.Errorf("переход из %s в неизвестный статус %s", from, to)
+			}
+		}
+	}
+
+	for _, status := range strings.Split(completed, ",") {
+		if status = strings.TrimSpace(status); status == "" {
+			continue
+		}
+		if !workflow.Has(TaskStatus(status)) {
+			return nil, fmt.Errorf("неизвестный статус выполнения %s", status)
+		}
+		workflow.Completed[TaskStatus(status)] = true
+	}
+
+	return workflow, nil
+}
+
+// Has сообщает, входит ли статус в рабочий процесс.
+func (w *Workflow) Has(status TaskStatus) bool {
+	_, ok := w.Transitions[status]
+	return ok
+}
+
+// CanTransition сообщает, допустим ли переход между статусами.
+func (w *Workflow) CanTransition(from, to TaskStatus) bool {
+	for _, target := range w.Transitions[from] {
+		if target == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCompleted сообщает, означает ли статус выполнение задачи.
+func (w *Workflow) IsCompleted(status TaskStatus) bool {
+	return w.Completed[status]
+}
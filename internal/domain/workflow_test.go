@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseWorkflow(t *testing.T) {
+	// 1. Act
+	workflow, err := ParseWorkflow("backlog:todo; todo:in_progress,backlog; in_progress:review; review:done,in_progress; done", "done")
+
+	// 2. Assert
+	assert.NoError(t, err)
+	assert.Equal(t, TaskStatus("backlog"), workflow.Initial)
+	assert.True(t, workflow.Has("review"))
+	assert.True(t, workflow.CanTransition("review", "done"))
+	assert.False(t, workflow.CanTransition("todo", "done"))
+	assert.False(t, workflow.CanTransition("done", "todo"))
+	assert.True(t, workflow.IsCompleted("done"))
+	assert.False(t, workflow.IsCompleted("review"))
+}
+
+func TestParseWorkflow_UnknownTarget(t *testing.T) {
+	// 1. Act
+	workflow, err := ParseWorkflow("todo:done", "")
+
+	// 2. Assert
+	assert.Nil(t, workflow)
+	assert.EqualError(t, err, "переход из todo в неизвестный статус done")
+}
+
+func TestParseWorkflow_UnknownCompleted(t *testing.T) {
+	// 1. Act
+	workflow, err := ParseWorkflow("todo:done;done", "closed")
+
+	// 2. Assert
+	assert.Nil(t, workflow)
+	assert.EqualError(t, err, "неизвестный статус выполнения closed")
+}
+
+func TestDefaultWorkflow(t *testing.T) {
+	workflow := DefaultWorkflow()
+
+	assert.Equal(t, TaskStatusTodo, workflow.Initial)
+	assert.True(t, workflow.CanTransition(TaskStatusTodo, TaskStatusInProgress))
+	assert.True(t, workflow.CanTransition(TaskStatusInProgress, TaskStatusDone))
+	assert.True(t, workflow.CanTransition(TaskStatusTodo, TaskStatusCancelled))
+	assert.False(t, workflow.CanTransition(TaskStatusCancelled, TaskStatusDone))
+	assert.True(t, workflow.IsCompleted(TaskStatusDone))
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MosinEvgeny/task-tracker/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// AdminHandler обрабатывает HTTP-запросы администраторов: снятие блокировки
+// входа и просмотр журнала аудита.
+type AdminHandler struct {
+	throttleService service.LoginThrottleService
+	auditService    service.AuditService
+}
+
+// NewAdminHandler создает новый экземпляр AdminHandler.
+func NewAdminHandler(throttleService service.LoginThrottleService, auditService service.AuditService) *AdminHandler {
+	return &AdminHandler{throttleService: throttleService, auditService: auditService}
+}
+
+// UnlockUser снимает блокировку входа пользователя по email и по кодам
+// второго шага. Блокировки IP-адресов не снимаются.
+func (h *AdminHandler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, errInvalidUserID)
+		return
+	}
+
+	if err := h.throttleService.Unlock(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAuditEvents возвращает последние события журнала аудита.
+func (h *AdminHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryLimit(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	events, err := h.auditService.ListEvents(r.Context(), limit)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listResponse{Items: events})
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MosinEvgeny/task-tracker/internal/service"
+)
+
+// EmailVerificationHandler обрабатывает HTTP-запросы для подтверждения email.
+type EmailVerificationHandler struct {
+	verificationService service.EmailVerificationService
+}
+
+// NewEmailVerificationHandler создает новый экземпляр EmailVerificationHandler.
+func NewEmailVerificationHandler(verificationService service.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{verificationService: verificationService}
+}
+
+// VerifyEmail подтверждает email по токену из ссылки в письме.
+func (h *EmailVerificationHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	email, err := h.verificationService.VerifyEmail(r.Context(), r.URL.Query().Get("token"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{"email": email, "email_verified": true})
+}
+
+// ResendVerification повторно отправляет ссылку для подтверждения email.
+// Ответ не зависит от того, зарегистрирован ли email. Частые запросы
+// отклоняются с кодом 429.
+func (h *EmailVerificationHandler) ResendVerification(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	if err := h.verificationService.ResendVerification(r.Context(), body.Email, clientIP(r)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
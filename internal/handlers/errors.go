@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/i18n"
+)
+
+// problemContentType — тип содержимого ответов с ошибкой (RFC 7807).
+const problemContentType = "application/problem+json"
+
+// problemTypePrefix задает URI типа проблемы; к нему добавляется код ошибки.
+const problemTypePrefix = "urn:task-tracker:problem:"
+
+// Ошибки разбора запроса, общие для всех обработчиков.
+var (
+	errInvalidBody     = domain.NewError(domain.ErrValidation, "request.invalid_body", "Неверный формат запроса")
+	errNoUserInContext = errors.New("не удалось получить ID пользователя из контекста")
+	errInvalidTaskID   = domain.NewFieldError("id", "task.invalid_id", "Неверный ID задачи")
+	errInvalidLabelID  = domain.NewFieldError("id", "label.invalid_id", "Неверный ID метки")
+	errInvalidUserID   = domain.NewFieldError("id", "user.invalid_id", "Неверный ID пользователя")
+)
+
+// Problem — тело ответа с ошибкой в формате RFC 7807. Code — стабильный
+// машиночитаемый код ошибки, Errors — ошибки отдельных полей запроса.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Errors   []FieldProblem `json:"errors,omitempty"`
+}
+
+// FieldProblem описывает ошибку в отдельном поле запроса.
+type FieldProblem struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// errorStatus возвращает HTTP-статус, соответствующий категории ошибки сервиса.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, domain.ErrTooManyRequests):
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// statusCode возвращает общий код ошибки для HTTP-статуса. Он используется,
+// если ошибка не содержит собственного кода.
+func statusCode(status int) string {
+	switch status {
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusBadRequest:
+		return "validation_failed"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusTooManyRequests:
+		return "too_many_requests"
+	default:
+		return "internal_error"
+	}
+}
+
+// newProblem строит описание ошибки на языке lang. Текст внутренних ошибок
+// не раскрывается клиенту.
+func newProblem(err error, lang i18n.Lang) Problem {
+	status := errorStatus(err)
+	problem := Problem{
+		Status: status,
+		Title:  i18n.Translate(lang, "title."+strconv.Itoa(status), nil, http.StatusText(status)),
+		Code:   statusCode(status),
+	}
+
+	var domainErr *domain.Error
+	if status != http.StatusInternalServerError && errors.As(err, &domainErr) && domainErr.Code != "" {
+		problem.Code = domainErr.Code
+		problem.Detail = i18n.Translate(lang, domainErr.Code, domainErr.Params, domainErr.Message)
+		for _, field := range domainErr.Fields {
+			problem.Errors = append(problem.Errors, FieldProblem{
+				Field:   field.Field,
+				Code:    field.Code,
+				Message: i18n.Translate(lang, field.Code, domainErr.Params, field.Message),
+			})
+		}
+	} else {
+		problem.Detail = i18n.Translate(lang, problem.Code, nil, http.StatusText(status))
+	}
+
+	problem.Type = problemTypePrefix + problem.Code
+	return problem
+}
+
+// writeError отправляет ошибку клиенту в формате application/problem+json на
+// языке из заголовка Accept-Language. Внутренние ошибки записываются в лог.
+// Для ответа 429 заголовок Retry-After берется из параметра retry_after.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	problem := newProblem(err, lang)
+	problem.Instance = r.URL.Path
+	if problem.Status == http.StatusInternalServerError {
+		log.Printf("internal error: %v", err)
+	}
+
+	var domainErr *domain.Error
+	if problem.Status == http.StatusTooManyRequests && errors.As(err, &domainErr) {
+		if retryAfter, ok := domainErr.Params["retry_after"]; ok {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+	}
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.Header().Set("Content-Language", string(lang))
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// invalidParamError возвращает ошибку валидации параметра запроса key.
+func invalidParamError(key string) error {
+	return domain.NewFieldError(key, "query.invalid_param", fmt.Sprintf("неверный формат параметра %s", key)).
+		WithParams(map[string]string{"param": key})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorStatus(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{domain.NewError(domain.ErrNotFound, "task.not_found", "задача не найдена"), http.StatusNotFound},
+		{domain.NewFieldError("title", "task.title_required", "необходимо указать название задачи"), http.StatusBadRequest},
+		{domain.NewError(domain.ErrConflict, "user.email_taken", "пользователь с таким email уже существует"), http.StatusConflict},
+		{domain.NewError(domain.ErrForbidden, "forbidden", "доступ запрещен"), http.StatusForbidden},
+		{domain.NewError(domain.ErrUnauthorized, "auth.invalid_credentials", "Неверный email или пароль"), http.StatusUnauthorized},
+		{domain.NewError(domain.ErrTooManyRequests, "auth.too_many_attempts", "слишком много неудачных попыток"), http.StatusTooManyRequests},
+		{fmt.Errorf("ошибка при удалении задачи: %w", domain.ErrNotFound), http.StatusNotFound},
+		{errors.New("connection refused"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.status, errorStatus(tt.err), tt.err.Error())
+	}
+}
+
+// decodeProblem проверяет тип содержимого ответа и разбирает тело ошибки.
+func decodeProblem(t *testing.T, rec *httptest.ResponseRecorder) Problem {
+	t.Helper()
+	assert.Equal(t, problemContentType, rec.Header().Get("Content-Type"))
+
+	var problem Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("тело ответа не является problem+json: %v", err)
+	}
+	return problem
+}
+
+func TestWriteError_RetryAfter(t *testing.T) {
+	// 1. Arrange
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.Header.Set("Accept-Language", "en")
+	err := domain.NewError(domain.ErrTooManyRequests, "auth.too_many_attempts", "слишком много неудачных попыток, повторите через {retry_after} с").
+		WithParams(map[string]string{"retry_after": "120"})
+
+	// 2. Act
+	writeError(rec, req, err)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "120", rec.Header().Get("Retry-After"))
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "auth.too_many_attempts", problem.Code)
+	assert.Equal(t, "Too Many Requests", problem.Title)
+	assert.Equal(t, "Too many failed attempts, try again in 120 s", problem.Detail)
+}
+
+func TestWriteError_HidesInternalErrors(t *testing.T) {
+	// 1. Arrange
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+
+	// 2. Act
+	writeError(rec, req, errors.New("pq: password authentication failed"))
+
+	// 3. Assert
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "pq:")
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "internal_error", problem.Code)
+}
+
+func TestWriteError_Problem(t *testing.T) {
+	// 1. Arrange
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/tasks/1/transition", nil)
+	err := domain.NewError(domain.ErrConflict, "task.invalid_transition", "недопустимый переход из статуса done в todo").
+		WithParams(map[string]string{"from": "done", "to": "todo"})
+
+	// 2. Act
+	writeError(rec, req, err)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Equal(t, "ru", rec.Header().Get("Content-Language"))
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, Problem{
+		Type:     "urn:task-tracker:problem:task.invalid_transition",
+		Title:    "Конфликт",
+		Status:   http.StatusConflict,
+		Detail:   "Недопустимый переход из статуса done в todo",
+		Instance: "/tasks/1/transition",
+		Code:     "task.invalid_transition",
+	}, problem)
+}
+
+func TestWriteError_FieldErrorsInEnglish(t *testing.T) {
+	// 1. Arrange
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/register", nil)
+	req.Header.Set("Accept-Language", "de;q=0.9, en-US;q=0.8, ru;q=0.5")
+	err := domain.NewError(domain.ErrValidation, "user.fields_required", "необходимо заполнить все поля").
+		WithFields(domain.FieldError{Field: "email", Code: "field_required", Message: "поле обязательно для заполнения"})
+
+	// 2. Act
+	writeError(rec, req, err)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "en", rec.Header().Get("Content-Language"))
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, "Bad Request", problem.Title)
+	assert.Equal(t, "All fields are required", problem.Detail)
+	assert.Equal(t, []FieldProblem{{Field: "email", Code: "field_required", Message: "This field is required"}}, problem.Errors)
+}
+
+func TestWriteError_WrappedKindWithoutCode(t *testing.T) {
+	// 1. Arrange
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodDelete, "/tasks/1", nil)
+
+	// 2. Act
+	writeError(rec, req, fmt.Errorf("ошибка при удалении задачи: %w", domain.ErrNotFound))
+
+	// 3. Assert
+	problem := decodeProblem(t, rec)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "not_found", problem.Code)
+	assert.Equal(t, "Запись не найдена", problem.Detail)
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+)
+
+// JWKSHandler публикует открытые ключи, которыми другие сервисы проверяют
+// access токены без общего секрета.
+type JWKSHandler struct {
+	keys *auth.KeySet
+}
+
+// NewJWKSHandler создает новый экземпляр JWKSHandler.
+func NewJWKSHandler(keys *auth.KeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS возвращает набор ключей в формате JWK Set (RFC 7517). Во время
+// ротации в наборе есть и новый, и прежний ключ.
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(h.keys.JWKS())
+}
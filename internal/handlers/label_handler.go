@@ -3,7 +3,10 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
 	"github.com/MosinEvgeny/task-tracker/internal/service"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -19,87 +22,156 @@ func NewLabelHandler(labelService service.LabelService) *LabelHandler {
 	return &LabelHandler{labelService: labelService}
 }
 
+// labelRequest — тело запросов создания и обновления метки.
+type labelRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// labelResponse — метка в ответах API.
+type labelResponse struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Color  string    `json:"color"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+func newLabelResponse(label *domain.Label) labelResponse {
+	return labelResponse{ID: label.ID, Name: label.Name, Color: label.Color, UserID: label.UserID}
+}
+
+// CreateLabel создает метку текущего пользователя. Владелец берется из
+// контекста аутентификации, поле user_id в теле запроса игнорируется.
 func (h *LabelHandler) CreateLabel(w http.ResponseWriter, r *http.Request) {
-	var labelData struct {
-		Name   string    `json:"name"`
-		Color  string    `json:"color"`
-		UserID uuid.UUID `json:"user_id"`
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
 	}
 
+	var labelData labelRequest
+
 	if err := json.NewDecoder(r.Body).Decode(&labelData); err != nil {
-		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		writeError(w, r, errInvalidBody)
 		return
 	}
 
-	createdLabel, err := h.labelService.CreateLabel(r.Context(), labelData.Name, labelData.Color, labelData.UserID)
+	createdLabel, err := h.labelService.CreateLabel(r.Context(), labelData.Name, labelData.Color, userID)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdLabel)
+	json.NewEncoder(w).Encode(newLabelResponse(createdLabel))
+}
+
+// ListLabels возвращает страницу меток текущего пользователя.
+func (h *LabelHandler) ListLabels(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	filter, err := parseLabelFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	labels, nextCursor, err := h.labelService.ListLabels(r.Context(), userID, filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items := make([]labelResponse, 0, len(labels))
+	for _, label := range labels {
+		items = append(items, newLabelResponse(label))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listResponse{Items: items, NextCursor: nextCursor})
 }
 
 func (h *LabelHandler) GetLabel(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Неверный ID метки", http.StatusBadRequest)
+		writeError(w, r, errInvalidLabelID)
 		return
 	}
 
 	label, err := h.labelService.GetLabelByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Метка не найдена", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(label)
+	json.NewEncoder(w).Encode(newLabelResponse(label))
 }
 
 func (h *LabelHandler) UpdateLabel(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Неверный ID метки", http.StatusBadRequest)
+		writeError(w, r, errInvalidLabelID)
 		return
 	}
 
-	var labelData struct {
-		Name  string `json:"name"`
-		Color string `json:"color"`
-	}
+	var labelData labelRequest
 	if err := json.NewDecoder(r.Body).Decode(&labelData); err != nil {
-		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		writeError(w, r, errInvalidBody)
 		return
 	}
 
 	updatedLabel, err := h.labelService.UpdateLabel(r.Context(), id, labelData.Name, labelData.Color)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedLabel)
+	json.NewEncoder(w).Encode(newLabelResponse(updatedLabel))
 }
 
 func (h *LabelHandler) DeleteLabel(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Неверный ID метки", http.StatusBadRequest)
+		writeError(w, r, errInvalidLabelID)
 		return
 	}
 
 	err = h.labelService.DeleteLabel(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// parseLabelFilter собирает фильтр меток из параметров запроса.
+func parseLabelFilter(query url.Values) (repository.LabelFilter, error) {
+	var filter repository.LabelFilter
+	var err error
+
+	if filter.Sort, err = repository.ParseLabelSort(query.Get("sort")); err != nil {
+		return filter, err
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		if filter.After, err = repository.DecodeCursor(cursor, filter.Sort); err != nil {
+			return filter, err
+		}
+	}
+	if filter.Limit, err = queryLimit(query); err != nil {
+		return filter, err
+	}
+	filter.Search = query.Get("q")
+
+	return filter, nil
+}
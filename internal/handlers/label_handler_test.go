@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockLabelService - это mock для LabelService.
+type MockLabelService struct {
+	mock.Mock
+}
+
+func (m *MockLabelService) CreateLabel(ctx context.Context, name, color string, userID uuid.UUID) (*domain.Label, error) {
+	args := m.Called(ctx, name, color, userID)
+	label, ok := args.Get(0).(*domain.Label)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return label, args.Error(1)
+}
+
+func (m *MockLabelService) GetLabelByID(ctx context.Context, id uuid.UUID) (*domain.Label, error) {
+	args := m.Called(ctx, id)
+	label, ok := args.Get(0).(*domain.Label)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return label, args.Error(1)
+}
+
+func (m *MockLabelService) GetAllLabelsByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Label, error) {
+	args := m.Called(ctx, userID)
+	labels, ok := args.Get(0).([]*domain.Label)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return labels, args.Error(1)
+}
+
+func (m *MockLabelService) ListLabels(ctx context.Context, userID uuid.UUID, filter repository.LabelFilter) ([]*domain.Label, string, error) {
+	args := m.Called(ctx, userID, filter)
+	labels, ok := args.Get(0).([]*domain.Label)
+	if !ok {
+		return nil, "", args.Error(2)
+	}
+	return labels, args.String(1), args.Error(2)
+}
+
+func (m *MockLabelService) UpdateLabel(ctx context.Context, id uuid.UUID, name, color string) (*domain.Label, error) {
+	args := m.Called(ctx, id, name, color)
+	label, ok := args.Get(0).(*domain.Label)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return label, args.Error(1)
+}
+
+func (m *MockLabelService) DeleteLabel(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestCreateLabel_OwnerFromContext(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockLabelService)
+	labelHandler := NewLabelHandler(mockService)
+
+	userID := uuid.New()
+	spoofedUserID := uuid.New()
+	body := `{"name": "Important", "color": "#FF0000", "user_id": "` + spoofedUserID.String() + `"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/labels", strings.NewReader(body))
+	req = req.WithContext(auth.ContextWithUser(req.Context(), userID))
+	rec := httptest.NewRecorder()
+
+	// Сервис должен получить ID пользователя из контекста, а не из тела запроса
+	mockService.On("CreateLabel", mock.Anything, "Important", "#FF0000", userID).
+		Return(&domain.Label{ID: uuid.New(), Name: "Important", Color: "#FF0000", UserID: userID}, nil)
+
+	// 2. Act
+	labelHandler.CreateLabel(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.NotContains(t, rec.Body.String(), spoofedUserID.String())
+
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "CreateLabel", mock.Anything, mock.Anything, mock.Anything, spoofedUserID)
+}
+
+func TestCreateLabel_NoUserInContext(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockLabelService)
+	labelHandler := NewLabelHandler(mockService)
+
+	body := `{"name": "Important", "color": "#FF0000", "user_id": "` + uuid.New().String() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/labels", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	// 2. Act
+	labelHandler.CreateLabel(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	mockService.AssertNotCalled(t, "CreateLabel", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListLabels_ResponseFields(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockLabelService)
+	labelHandler := NewLabelHandler(mockService)
+	userID := uuid.New()
+	label := &domain.Label{ID: uuid.New(), Name: "Important", Color: "#FF0000", UserID: userID}
+
+	req := httptest.NewRequest(http.MethodGet, "/labels", nil)
+	req = req.WithContext(auth.ContextWithUser(req.Context(), userID))
+	rec := httptest.NewRecorder()
+
+	mockService.On("ListLabels", mock.Anything, userID, mock.Anything).Return([]*domain.Label{label}, "next", nil)
+
+	// 2. Act
+	labelHandler.ListLabels(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]any{
+		"items": []any{map[string]any{
+			"id":      label.ID.String(),
+			"name":    "Important",
+			"color":   "#FF0000",
+			"user_id": userID.String(),
+		}},
+		"next_cursor": "next",
+	}, responseFields(t, rec))
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MosinEvgeny/task-tracker/internal/service"
+)
+
+// MFAHandler обрабатывает HTTP-запросы для настройки двухфакторной
+// аутентификации текущего пользователя.
+type MFAHandler struct {
+	mfaService service.MFAService
+}
+
+// NewMFAHandler создает новый экземпляр MFAHandler.
+func NewMFAHandler(mfaService service.MFAService) *MFAHandler {
+	return &MFAHandler{mfaService: mfaService}
+}
+
+// reauthRequest — тело запросов, которые требуют повторной аутентификации:
+// текущий пароль и код из приложения или код восстановления.
+type reauthRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+// GetStatus возвращает, включена ли двухфакторная аутентификация, и сколько
+// осталось кодов восстановления.
+func (h *MFAHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	status, err := h.mfaService.Status(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// BeginTOTPEnrollment создает секрет TOTP и возвращает его вместе с URI
+// otpauth:// для QR-кода.
+func (h *MFAHandler) BeginTOTPEnrollment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	enrollment, err := h.mfaService.BeginTOTPEnrollment(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(enrollment)
+}
+
+// ConfirmTOTPEnrollment включает двухфакторную аутентификацию по коду из
+// приложения и возвращает коды восстановления.
+func (h *MFAHandler) ConfirmTOTPEnrollment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmTOTPEnrollment(r.Context(), userID, body.Code)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
+
+// DisableTOTP отключает двухфакторную аутентификацию после проверки пароля и кода.
+func (h *MFAHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	var body reauthRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	if err := h.mfaService.DisableTOTP(r.Context(), userID, body.CurrentPassword, body.Code); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления после проверки пароля и кода.
+func (h *MFAHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	var body reauthRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(r.Context(), userID, body.CurrentPassword, body.Code)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"recovery_codes": codes})
+}
@@ -1,93 +1,193 @@
 package handlers
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/MosinEvgeny/task-tracker/internal/auth"
-	"github.com/MosinEvgeny/task-tracker/internal/config"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/MosinEvgeny/task-tracker/internal/service"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
 // Middleware описывает интерфейс middleware.
 type Middleware interface {
 	Authenticate(next http.Handler) http.Handler
+	RequireAdmin(next http.Handler) http.Handler
+	RequireScope(read, write string) func(http.Handler) http.Handler
+	RequireFullAccess(next http.Handler) http.Handler
 }
 
+// Ошибки аутентификации запроса.
+var (
+	errMissingAuthHeader = domain.NewError(domain.ErrUnauthorized, "auth.missing_header", "Отсутствует заголовок Authorization")
+	errInvalidAuthHeader = domain.NewError(domain.ErrUnauthorized, "auth.invalid_header", "Неверный формат заголовка Authorization")
+	errInvalidToken      = domain.NewError(domain.ErrUnauthorized, "auth.invalid_token", "Неверный токен")
+	errAdminRequired     = domain.NewError(domain.ErrForbidden, "auth.admin_required", "Требуются права администратора")
+	errInsufficientScope = domain.NewError(domain.ErrForbidden, "auth.insufficient_scope", "Токену не хватает разрешения {scope}")
+	errSessionRequired   = domain.NewError(domain.ErrForbidden, "auth.session_required", "Действие недоступно для токена с ограниченными разрешениями")
+)
+
 type AuthMiddleware struct {
-	userService service.UserService
-	config      config.Config
+	userService          service.UserService
+	revocationService    service.TokenRevocationService
+	personalTokenService service.PersonalAccessTokenService
+	issuer               *auth.Issuer
 }
 
-func NewAuthMiddleware(userService service.UserService, config config.Config) *AuthMiddleware {
+func NewAuthMiddleware(userService service.UserService, revocationService service.TokenRevocationService, personalTokenService service.PersonalAccessTokenService, issuer *auth.Issuer) *AuthMiddleware {
 	return &AuthMiddleware{
-		userService: userService,
-		config:      config,
+		userService:          userService,
+		revocationService:    revocationService,
+		personalTokenService: personalTokenService,
+		issuer:               issuer,
 	}
 }
 
-// Authenticate проверяет JWT токен в заголовке Authorization и добавляет ID пользователя в контекст.
+// Authenticate проверяет JWT токен или персональный токен доступа в
+// заголовке Authorization и добавляет ID пользователя в контекст. Если токен
+// выдан для сессии (claim sid), ее ID тоже добавляется в контекст.
+// Разрешения токена (claim scopes или разрешения персонального токена)
+// ограничивают запрос; их проверяют RequireScope и RequireFullAccess.
+// Отозванные токены не принимаются.
 func (m *AuthMiddleware) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// 1. Получение токена из заголовка Authorization
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
-			http.Error(w, "Отсутствует заголовок Authorization", http.StatusUnauthorized)
+			writeError(w, r, errMissingAuthHeader)
 			return
 		}
 
 		// 2. Проверка формата заголовка (Bearer <token>)
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			http.Error(w, "Неверный формат заголовка Authorization", http.StatusUnauthorized)
+			writeError(w, r, errInvalidAuthHeader)
 			return
 		}
 
 		tokenString := parts[1]
+		if strings.HasPrefix(tokenString, service.PersonalAccessTokenPrefix) {
+			m.authenticatePersonalToken(w, r, next, tokenString)
+			return
+		}
 
-		// 3. Валидация токена
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Проверяем алгоритм подписи
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("неверный алгоритм подписи: %v", token.Header["alg"])
-			}
-
-			// Возвращаем секретный ключ
-			return []byte(m.config.JWTSecret), nil
-		})
-
+		// 3. Валидация токена: подпись, издатель, получатель, срок действия
+		claims, err := m.issuer.Parse(tokenString)
 		if err != nil {
-			http.Error(w, "Неверный токен", http.StatusUnauthorized)
+			writeError(w, r, errInvalidToken)
 			return
 		}
 
-		// 4. Извлечение ID пользователя из токена
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userIDString, ok := claims["user_id"].(string)
-			if !ok {
-				http.Error(w, "Неверный формат ID пользователя в токене", http.StatusInternalServerError)
+		// 4. Проверка отзыва токена
+		userID, err := claims.UserID()
+		if err != nil {
+			writeError(w, r, errInvalidToken)
+			return
+		}
+		tokenID, err := claims.TokenID()
+		if err != nil {
+			writeError(w, r, errInvalidToken)
+			return
+		}
+		var sessionID uuid.UUID
+		if claims.SessionID != "" {
+			if sessionID, err = uuid.Parse(claims.SessionID); err != nil {
+				writeError(w, r, errInvalidToken)
 				return
 			}
+		}
+		if err := m.revocationService.CheckAccessToken(r.Context(), userID, tokenID, claims.SessionID, claims.ClientID, claims.IssuedAt.Time); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		// 5. Добавление ID пользователя, сведений о токене и сессии в контекст
+		ctx := auth.ContextWithUser(r.Context(), userID)
+		ctx = auth.ContextWithAccessToken(ctx, auth.AccessToken{ID: tokenID, ExpiresAt: claims.ExpiresAt.Time})
+		if sessionID != uuid.Nil {
+			ctx = auth.ContextWithSession(ctx, sessionID)
+		}
+		if claims.Scopes != nil {
+			ctx = auth.ContextWithScopes(ctx, claims.Scopes)
+		}
+
+		// 6. Передача управления следующему обработчику
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticatePersonalToken аутентифицирует запрос персональным токеном
+// доступа. Запрос ограничивается разрешениями токена.
+func (m *AuthMiddleware) authenticatePersonalToken(w http.ResponseWriter, r *http.Request, next http.Handler, value string) {
+	token, err := m.personalTokenService.Authenticate(r.Context(), value)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-			userID, err := uuid.Parse(userIDString)
-			if err != nil {
-				http.Error(w, "Неверный ID пользователя в токене", http.StatusInternalServerError)
+	ctx := auth.ContextWithUser(r.Context(), token.UserID)
+	ctx = auth.ContextWithScopes(ctx, token.Scopes)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// RequireScope проверяет разрешения токена запроса: для GET и HEAD нужно
+// разрешение read, для остальных методов — write. Запросы без ограничений
+// (токен сессии) пропускаются. Используется после Authenticate.
+func (m *AuthMiddleware) RequireScope(read, write string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scope := write
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				scope = read
+			}
+			if !auth.HasScope(r.Context(), scope) {
+				writeError(w, r, errInsufficientScope.WithParams(map[string]string{"scope": scope}))
 				return
 			}
 
-			// 5. Добавление ID пользователя в контекст
-			ctx := auth.ContextWithUser(r.Context(), userID)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireFullAccess пропускает только запросы без ограничения разрешениями.
+// Управление учетной записью, сессиями и токенами недоступно персональным
+// токенам. Используется после Authenticate.
+func (m *AuthMiddleware) RequireFullAccess(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, restricted := auth.ScopesFromContext(r.Context()); restricted {
+			writeError(w, r, errSessionRequired)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireAdmin пропускает только запросы администраторов. Используется после
+// Authenticate.
+func (m *AuthMiddleware) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := GetUserIDFromRequest(r)
+		if !ok {
+			writeError(w, r, errNoUserInContext)
+			return
+		}
 
-			// 6. Передача управления следующему обработчику
-			next.ServeHTTP(w, r.WithContext(ctx))
-		} else {
-			http.Error(w, "Неверный токен", http.StatusUnauthorized)
+		user, err := m.userService.GetUserByID(r.Context(), userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if !user.IsAdmin {
+			writeError(w, r, errAdminRequired)
+			return
 		}
+
+		next.ServeHTTP(w, r)
 	})
 }
 
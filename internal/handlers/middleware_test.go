@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/service"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockTokenRevocationService - это mock для TokenRevocationService.
+type MockTokenRevocationService struct {
+	mock.Mock
+}
+
+func (m *MockTokenRevocationService) RevokeAccessToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	args := m.Called(ctx, jti, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationService) RevokeAllAccessTokens(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationService) CheckAccessToken(ctx context.Context, userID, jti uuid.UUID, sessionID, clientID string, issuedAt time.Time) error {
+	args := m.Called(ctx, userID, jti, sessionID, clientID, issuedAt)
+	return args.Error(0)
+}
+
+// newTestIssuer создает Issuer с ключом HS256 "secret".
+func newTestIssuer(t *testing.T) *auth.Issuer {
+	t.Helper()
+
+	issuer, err := auth.NewIssuer(auth.NewHMACKeySet("secret"), auth.IssuerConfig{Issuer: "test", Audience: "test", TTL: time.Hour})
+	require.NoError(t, err)
+	return issuer
+}
+
+// signToken подписывает claims ключом HS256 "secret".
+func signToken(t *testing.T, claims jwt.Claims) string {
+	t.Helper()
+
+	token, err := auth.NewHMACKeySet("secret").Sign(claims)
+	require.NoError(t, err)
+	return token
+}
+
+func TestAuthenticate_Problems(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		code   string
+	}{
+		{"без заголовка", "", "auth.missing_header"},
+		{"неверная схема", "Basic dXNlcjpwYXNz", "auth.invalid_header"},
+		{"неверный токен", "Bearer not-a-jwt", "auth.invalid_token"},
+	}
+
+	middleware := NewAuthMiddleware(nil, nil, nil, newTestIssuer(t))
+	handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("обработчик не должен вызываться")
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Arrange
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			// 2. Act
+			handler.ServeHTTP(rec, req)
+
+			// 3. Assert
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, tt.code, decodeProblem(t, rec).Code)
+		})
+	}
+}
+
+func TestAuthenticate_Revocation(t *testing.T) {
+	userID, jti := uuid.New(), uuid.New()
+	// Целые секунды точно переживают преобразование во float в JSON
+	issuedAt := time.Now().Truncate(time.Second)
+	expiresAt := issuedAt.Add(time.Hour)
+	claims := func(jti, audience string) *auth.Claims {
+		return &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "test",
+			Subject:   userID.String(),
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(issuedAt),
+			ID:        jti,
+		}}
+	}
+
+	withSession := func(claims *auth.Claims, sessionID string) *auth.Claims {
+		claims.SessionID = sessionID
+		return claims
+	}
+
+	tests := []struct {
+		name   string
+		claims *auth.Claims
+		err    error
+		status int
+		code   string
+	}{
+		{"действующий токен", claims(jti.String(), "test"), nil, http.StatusOK, ""},
+		{"отозванный токен", claims(jti.String(), "test"), service.ErrAccessTokenRevoked, http.StatusUnauthorized, "auth.token_revoked"},
+		{"токен без jti", claims("", "test"), nil, http.StatusUnauthorized, "auth.invalid_token"},
+		{"токен другого сервиса", claims(jti.String(), "billing"), nil, http.StatusUnauthorized, "auth.invalid_token"},
+		{"токен с неверным sid", withSession(claims(jti.String(), "test"), "not-a-uuid"), nil, http.StatusUnauthorized, "auth.invalid_token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Arrange
+			mockService := new(MockTokenRevocationService)
+			mockService.On("CheckAccessToken", mock.Anything, userID, jti, "", "", mock.MatchedBy(issuedAt.Equal)).Return(tt.err)
+			middleware := NewAuthMiddleware(nil, mockService, nil, newTestIssuer(t))
+
+			var accessToken auth.AccessToken
+			handler := middleware.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accessToken, _ = auth.AccessTokenFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+			req.Header.Set("Authorization", "Bearer "+signToken(t, tt.claims))
+			rec := httptest.NewRecorder()
+
+			// 2. Act
+			handler.ServeHTTP(rec, req)
+
+			// 3. Assert
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.code != "" {
+				assert.Equal(t, tt.code, decodeProblem(t, rec).Code)
+				return
+			}
+			assert.Equal(t, jti, accessToken.ID)
+			assert.True(t, expiresAt.Equal(accessToken.ExpiresAt))
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		scopes []string // nil — запрос без ограничений
+		status int
+	}{
+		{"токен сессии", http.MethodPost, nil, http.StatusOK},
+		{"чтение с разрешением", http.MethodGet, []string{auth.ScopeTasksRead}, http.StatusOK},
+		{"запись с разрешением", http.MethodDelete, []string{auth.ScopeTasksWrite}, http.StatusOK},
+		{"запись без разрешения", http.MethodPost, []string{auth.ScopeTasksRead}, http.StatusForbidden},
+		{"чтение без разрешения", http.MethodGet, []string{auth.ScopeTasksWrite}, http.StatusForbidden},
+	}
+
+	middleware := NewAuthMiddleware(nil, nil, nil, newTestIssuer(t))
+	handler := middleware.RequireScope(auth.ScopeTasksRead, auth.ScopeTasksWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Arrange
+			req := httptest.NewRequest(tt.method, "/tasks", nil)
+			if tt.scopes != nil {
+				req = req.WithContext(auth.ContextWithScopes(req.Context(), tt.scopes))
+			}
+			rec := httptest.NewRecorder()
+
+			// 2. Act
+			handler.ServeHTTP(rec, req)
+
+			// 3. Assert
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusForbidden {
+				assert.Equal(t, "auth.insufficient_scope", decodeProblem(t, rec).Code)
+			}
+		})
+	}
+}
+
+func TestRequireFullAccess(t *testing.T) {
+	// 1. Arrange
+	middleware := NewAuthMiddleware(nil, nil, nil, newTestIssuer(t))
+	handler := middleware.RequireFullAccess(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	session := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	restricted := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	restricted = restricted.WithContext(auth.ContextWithScopes(restricted.Context(), []string{auth.ScopeTasksRead}))
+	sessionRec, restrictedRec := httptest.NewRecorder(), httptest.NewRecorder()
+
+	// 2. Act
+	handler.ServeHTTP(sessionRec, session)
+	handler.ServeHTTP(restrictedRec, restricted)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusOK, sessionRec.Code)
+	assert.Equal(t, http.StatusForbidden, restrictedRec.Code)
+	assert.Equal(t, "auth.session_required", decodeProblem(t, restrictedRec).Code)
+}
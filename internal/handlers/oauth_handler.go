@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/i18n"
+	"github.com/MosinEvgeny/task-tracker/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// oauthErrorPrefix — префикс кодов ошибок протокола OAuth2. Остаток кода
+// возвращается клиенту в поле error (RFC 6749, раздел 5.2).
+const oauthErrorPrefix = "oauth."
+
+var errInvalidOAuthClientID = domain.NewFieldError("id", "oauth_client.invalid_id", "Неверный ID клиента OAuth")
+
+// OAuthHandler обрабатывает HTTP-запросы сервера авторизации OAuth2:
+// управление клиентами и экран согласия для пользователя, а также конечные
+// точки токенов, интроспекции и отзыва для клиентов.
+type OAuthHandler struct {
+	oauthService service.OAuthService
+}
+
+// NewOAuthHandler создает новый экземпляр OAuthHandler.
+func NewOAuthHandler(oauthService service.OAuthService) *OAuthHandler {
+	return &OAuthHandler{oauthService: oauthService}
+}
+
+// registerClientRequest — тело запроса POST /oauth/clients.
+type registerClientRequest struct {
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	Scopes       []string `json:"scopes"`
+	Confidential bool     `json:"confidential"`
+}
+
+// oauthClientResponse — клиент OAuth в ответах API. Секрет показывается
+// только в ответе на регистрацию.
+type oauthClientResponse struct {
+	*domain.OAuthClient
+	Confidential bool   `json:"confidential"`
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+// authorizeRequest — тело запроса POST /oauth/authorize: параметры запроса
+// авторизации и решение пользователя.
+type authorizeRequest struct {
+	ClientID            string `json:"client_id"`
+	RedirectURI         string `json:"redirect_uri"`
+	ResponseType        string `json:"response_type"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method"`
+	Approved            bool   `json:"approved"`
+}
+
+// consentResponse — данные для экрана согласия.
+type consentResponse struct {
+	ClientID    uuid.UUID `json:"client_id"`
+	ClientName  string    `json:"client_name"`
+	RedirectURI string    `json:"redirect_uri"`
+	Scopes      []string  `json:"scopes"`
+	State       string    `json:"state,omitempty"`
+}
+
+// OAuthError — тело ответа с ошибкой протокола OAuth2 (RFC 6749, раздел 5.2).
+type OAuthError struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func newOAuthClientResponse(client *domain.OAuthClient, secret string) oauthClientResponse {
+	return oauthClientResponse{OAuthClient: client, Confidential: client.Confidential(), ClientSecret: secret}
+}
+
+// RegisterClient регистрирует клиента OAuth текущего пользователя.
+func (h *OAuthHandler) RegisterClient(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	var req registerClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	secret, client, err := h.oauthService.RegisterClient(r.Context(), userID, service.RegisterOAuthClient{
+		Name:         req.Name,
+		RedirectURIs: req.RedirectURIs,
+		Scopes:       req.Scopes,
+		Confidential: req.Confidential,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(newOAuthClientResponse(client, secret))
+}
+
+// ListClients возвращает клиентов OAuth текущего пользователя без секретов.
+func (h *OAuthHandler) ListClients(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	clients, err := h.oauthService.ListClients(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items := make([]oauthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		items = append(items, newOAuthClientResponse(client, ""))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listResponse{Items: items})
+}
+
+// DeleteClient удаляет клиента OAuth текущего пользователя вместе с его
+// неиспользованными кодами авторизации.
+func (h *OAuthHandler) DeleteClient(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, errInvalidOAuthClientID)
+		return
+	}
+
+	if err := h.oauthService.DeleteClient(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Consent проверяет запрос авторизации из параметров адреса и возвращает
+// данные для экрана согласия: клиента и запрошенные разрешения.
+func (h *OAuthHandler) Consent(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	prompt, err := h.oauthService.PrepareAuthorization(r.Context(), service.AuthorizationRequest{
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		ResponseType:        query.Get("response_type"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(consentResponse{
+		ClientID:    prompt.Client.ID,
+		ClientName:  prompt.Client.Name,
+		RedirectURI: prompt.RedirectURI,
+		Scopes:      prompt.Scopes,
+		State:       prompt.State,
+	})
+}
+
+// Authorize принимает решение пользователя на экране согласия и возвращает
+// адрес возврата клиента с кодом авторизации или ошибкой access_denied.
+func (h *OAuthHandler) Authorize(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	var req authorizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	redirectTo, err := h.oauthService.Authorize(r.Context(), userID, service.AuthorizationRequest{
+		ClientID:            req.ClientID,
+		RedirectURI:         req.RedirectURI,
+		ResponseType:        req.ResponseType,
+		Scope:               req.Scope,
+		State:               req.State,
+		CodeChallenge:       req.CodeChallenge,
+		CodeChallengeMethod: req.CodeChallengeMethod,
+	}, req.Approved)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"redirect_to": redirectTo})
+}
+
+// Token выдает access токен по коду авторизации или учетным данным клиента
+// (RFC 6749, разделы 4.1.3 и 4.4.2).
+func (h *OAuthHandler) Token(w http.ResponseWriter, r *http.Request) {
+	credentials, err := oauthClientCredentials(r)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	token, err := h.oauthService.Token(r.Context(), service.TokenRequest{
+		Client:       credentials,
+		GrantType:    r.PostForm.Get("grant_type"),
+		Code:         r.PostForm.Get("code"),
+		RedirectURI:  r.PostForm.Get("redirect_uri"),
+		CodeVerifier: r.PostForm.Get("code_verifier"),
+		Scope:        r.PostForm.Get("scope"),
+	})
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(token)
+}
+
+// Introspect сообщает клиенту, действует ли access токен (RFC 7662).
+func (h *OAuthHandler) Introspect(w http.ResponseWriter, r *http.Request) {
+	credentials, err := oauthClientCredentials(r)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	introspection, err := h.oauthService.Introspect(r.Context(), credentials, r.PostForm.Get("token"))
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(introspection)
+}
+
+// Revoke отзывает access токен клиента (RFC 7009).
+func (h *OAuthHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	credentials, err := oauthClientCredentials(r)
+	if err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	if err := h.oauthService.Revoke(r.Context(), credentials, r.PostForm.Get("token")); err != nil {
+		writeOAuthError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// oauthClientCredentials разбирает тело запроса в формате
+// application/x-www-form-urlencoded и извлекает учетные данные клиента из
+// заголовка Authorization (Basic) или из полей client_id и client_secret.
+func oauthClientCredentials(r *http.Request) (service.ClientCredentials, error) {
+	if err := r.ParseForm(); err != nil {
+		return service.ClientCredentials{}, service.ErrOAuthInvalidRequest
+	}
+
+	if id, secret, ok := r.BasicAuth(); ok {
+		// Значения в Basic предварительно кодируются (RFC 6749, раздел 2.3.1)
+		id, idErr := url.QueryUnescape(id)
+		secret, secretErr := url.QueryUnescape(secret)
+		if idErr != nil || secretErr != nil || r.PostForm.Get("client_id") != "" {
+			return service.ClientCredentials{}, service.ErrOAuthInvalidRequest
+		}
+		return service.ClientCredentials{ID: id, Secret: secret}, nil
+	}
+
+	id := r.PostForm.Get("client_id")
+	if id == "" {
+		return service.ClientCredentials{}, service.ErrOAuthInvalidClient
+	}
+	return service.ClientCredentials{ID: id, Secret: r.PostForm.Get("client_secret")}, nil
+}
+
+// writeOAuthError отправляет ошибку протокола OAuth2 в формате RFC 6749,
+// раздел 5.2. Неверные учетные данные клиента возвращаются со статусом 401,
+// внутренние ошибки — как server_error и записываются в лог.
+func writeOAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	lang := i18n.Negotiate(r.Header.Get("Accept-Language"))
+	status := http.StatusBadRequest
+	body := OAuthError{Error: "server_error"}
+
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) && strings.HasPrefix(domainErr.Code, oauthErrorPrefix) {
+		body.Error = strings.TrimPrefix(domainErr.Code, oauthErrorPrefix)
+		body.Description = i18n.Translate(lang, domainErr.Code, domainErr.Params, domainErr.Message)
+		if errors.Is(err, service.ErrOAuthInvalidClient) {
+			status = http.StatusUnauthorized
+			w.Header().Set("WWW-Authenticate", `Basic realm="task-tracker"`)
+		}
+	} else {
+		log.Printf("internal error: %v", err)
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Language", string(lang))
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
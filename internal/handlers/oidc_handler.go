@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/service"
+)
+
+// oidcStateCookie — cookie с токеном состояния входа через провайдера.
+// Она доступна только маршрутам /login/oidc и не видна JavaScript.
+const oidcStateCookie = "oidc_state"
+
+// OIDCHandler обрабатывает вход через внешнего провайдера OpenID Connect.
+type OIDCHandler struct {
+	loginSessions
+	oidcService service.OIDCLoginService
+}
+
+// NewOIDCHandler создает новый экземпляр OIDCHandler.
+func NewOIDCHandler(oidcService service.OIDCLoginService, refreshTokenService service.RefreshTokenService, mfaService service.MFAService, issuer *auth.Issuer) *OIDCHandler {
+	return &OIDCHandler{
+		loginSessions: loginSessions{
+			refreshTokenService: refreshTokenService,
+			mfaService:          mfaService,
+			issuer:              issuer,
+		},
+		oidcService: oidcService,
+	}
+}
+
+// Begin перенаправляет браузер к провайдеру. Состояние входа сохраняется в
+// cookie и проверяется в Callback.
+func (h *OIDCHandler) Begin(w http.ResponseWriter, r *http.Request) {
+	authURL, stateToken, err := h.oidcService.Begin(r.Context(), r.URL.Query().Get("device_name"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.setStateCookie(w, r, stateToken, int(h.oidcService.StateTTL().Seconds()))
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback — адрес возврата от провайдера. После проверки кода авторизации
+// вход завершается так же, как POST /login.
+func (h *OIDCHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	// Состояние одноразовое: cookie удаляется при любом исходе
+	cookie, err := r.Cookie(oidcStateCookie)
+	h.setStateCookie(w, r, "", -1)
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		writeError(w, r, service.ErrOIDCDenied.WithParams(map[string]string{"error": providerError}))
+		return
+	}
+	if err != nil {
+		writeError(w, r, service.ErrOIDCLoginFailed)
+		return
+	}
+
+	result, err := h.oidcService.Complete(r.Context(), cookie.Value, query.Get("state"), query.Get("code"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.completeLogin(w, r, result.User.ID, result.DeviceName)
+}
+
+// setStateCookie устанавливает cookie состояния входа или удаляет ее при
+// отрицательном maxAge. SameSite=Lax нужен, чтобы браузер отправил cookie
+// при перенаправлении от провайдера.
+func (h *OIDCHandler) setStateCookie(w http.ResponseWriter, r *http.Request, value string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/login/oidc",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/MosinEvgeny/task-tracker/internal/service"
+)
+
+// PasswordHandler обрабатывает HTTP-запросы для смены и сброса пароля.
+type PasswordHandler struct {
+	passwordService service.PasswordService
+}
+
+// NewPasswordHandler создает новый экземпляр PasswordHandler.
+func NewPasswordHandler(passwordService service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{passwordService: passwordService}
+}
+
+// ChangePassword меняет пароль текущего пользователя. После смены все его
+// сессии завершаются, включая текущую.
+func (h *PasswordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	var body struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	if err := h.passwordService.ChangePassword(r.Context(), userID, body.CurrentPassword, body.NewPassword); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword отправляет ссылку для сброса пароля. Ответ не зависит от
+// того, зарегистрирован ли email. Частые запросы отклоняются с кодом 429.
+func (h *PasswordHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	if err := h.passwordService.RequestPasswordReset(r.Context(), body.Email, clientIP(r)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPassword задает новый пароль по токену из письма.
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(r.Context(), body.Token, body.NewPassword); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// listResponse — ответ для постраничных коллекций.
+type listResponse struct {
+	Items      any    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// queryTime разбирает необязательный параметр запроса в формате RFC 3339.
+func queryTime(query url.Values, key string) (*time.Time, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, invalidParamError(key)
+	}
+	return &t, nil
+}
+
+// queryUUIDs собирает UUID из повторяющегося параметра запроса. Каждое
+// значение может содержать несколько ID через запятую.
+func queryUUIDs(query url.Values, key string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, value := range query[key] {
+		for _, part := range strings.Split(value, ",") {
+			if part == "" {
+				continue
+			}
+			id, err := uuid.Parse(part)
+			if err != nil {
+				return nil, invalidParamError(key)
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// queryLimit разбирает необязательный параметр limit.
+func queryLimit(query url.Values) (int, error) {
+	value := query.Get("limit")
+	if value == "" {
+		return 0, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		return 0, invalidParamError("limit")
+	}
+	return limit, nil
+}
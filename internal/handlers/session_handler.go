@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Ошибки управления сессиями.
+var (
+	errInvalidSessionID = domain.NewError(domain.ErrValidation, "session.invalid_id", "Неверный ID сессии")
+	errNoSession        = domain.NewError(domain.ErrUnauthorized, "auth.no_session", "Токен не привязан к сессии")
+)
+
+// SessionHandler обрабатывает HTTP-запросы для управления сессиями
+// (устройствами) текущего пользователя.
+type SessionHandler struct {
+	refreshTokenService service.RefreshTokenService
+	revocationService   service.TokenRevocationService
+}
+
+// NewSessionHandler создает новый экземпляр SessionHandler.
+func NewSessionHandler(refreshTokenService service.RefreshTokenService, revocationService service.TokenRevocationService) *SessionHandler {
+	return &SessionHandler{refreshTokenService: refreshTokenService, revocationService: revocationService}
+}
+
+// sessionResponse — сессия в ответе API. Current отмечает сессию, для которой
+// выдан токен текущего запроса.
+type sessionResponse struct {
+	*domain.Session
+	Current bool `json:"current"`
+}
+
+// ListSessions возвращает активные сессии текущего пользователя, начиная с
+// последней использованной.
+func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	sessions, err := h.refreshTokenService.ListSessions(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	currentID, _ := auth.SessionIDFromContext(r.Context())
+	items := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		items = append(items, sessionResponse{Session: session, Current: session.ID == currentID})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listResponse{Items: items})
+}
+
+// RevokeSession завершает одну сессию текущего пользователя. Ее refresh
+// токены перестают действовать, остальные сессии не затрагиваются.
+func (h *SessionHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		writeError(w, r, errInvalidSessionID)
+		return
+	}
+
+	if err := h.refreshTokenService.RevokeSession(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Logout завершает сессию, для которой выдан токен текущего запроса, и
+// отзывает сам токен.
+func (h *SessionHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := auth.SessionIDFromContext(r.Context())
+	if !ok {
+		writeError(w, r, errNoSession)
+		return
+	}
+
+	if err := h.refreshTokenService.RevokeSession(r.Context(), sessionID); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if accessToken, ok := auth.AccessTokenFromContext(r.Context()); ok {
+		if err := h.revocationService.RevokeAccessToken(r.Context(), accessToken.ID, accessToken.ExpiresAt); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientIP возвращает IP-адрес клиента из RemoteAddr без порта.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockRefreshTokenService - это mock для RefreshTokenService.
+type MockRefreshTokenService struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenService) CreateRefreshToken(ctx context.Context, userID uuid.UUID, device domain.Device) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, userID, device)
+	token, ok := args.Get(0).(*domain.RefreshToken)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return token, args.Error(1)
+}
+
+func (m *MockRefreshTokenService) GetRefreshToken(ctx context.Context, token string) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, token)
+	refreshToken, ok := args.Get(0).(*domain.RefreshToken)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return refreshToken, args.Error(1)
+}
+
+func (m *MockRefreshTokenService) RotateRefreshToken(ctx context.Context, token string) (*domain.RefreshToken, error) {
+	args := m.Called(ctx, token)
+	refreshToken, ok := args.Get(0).(*domain.RefreshToken)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return refreshToken, args.Error(1)
+}
+
+func (m *MockRefreshTokenService) DeleteRefreshToken(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenService) DeleteAllRefreshTokensByUserID(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenService) ListSessions(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error) {
+	args := m.Called(ctx, userID)
+	sessions, ok := args.Get(0).([]*domain.Session)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return sessions, args.Error(1)
+}
+
+func (m *MockRefreshTokenService) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestListSessions_MarksCurrent(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockRefreshTokenService)
+	sessionHandler := NewSessionHandler(mockService, nil)
+
+	userID := uuid.New()
+	current := &domain.Session{ID: uuid.New(), UserID: userID, Device: domain.Device{Name: "Телефон"}}
+	other := &domain.Session{ID: uuid.New(), UserID: userID}
+	mockService.On("ListSessions", mock.Anything, userID).Return([]*domain.Session{current, other}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+	ctx := auth.ContextWithSession(auth.ContextWithUser(req.Context(), userID), current.ID)
+	rec := httptest.NewRecorder()
+
+	// 2. Act
+	sessionHandler.ListSessions(rec, req.WithContext(ctx))
+
+	// 3. Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var response struct {
+		Items []struct {
+			ID         uuid.UUID `json:"id"`
+			DeviceName string    `json:"device_name"`
+			Current    bool      `json:"current"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Items, 2)
+	assert.Equal(t, current.ID, response.Items[0].ID)
+	assert.Equal(t, "Телефон", response.Items[0].DeviceName)
+	assert.True(t, response.Items[0].Current)
+	assert.False(t, response.Items[1].Current)
+	mockService.AssertExpectations(t)
+}
+
+func TestRevokeSession_InvalidID(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockRefreshTokenService)
+	sessionHandler := NewSessionHandler(mockService, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/sessions/not-a-uuid", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "not-a-uuid"})
+	rec := httptest.NewRecorder()
+
+	// 2. Act
+	sessionHandler.RevokeSession(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "session.invalid_id", decodeProblem(t, rec).Code)
+	mockService.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything)
+}
+
+func TestLogout_RevokesCurrentSession(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockRefreshTokenService)
+	revocationService := new(MockTokenRevocationService)
+	sessionHandler := NewSessionHandler(mockService, revocationService)
+
+	sessionID := uuid.New()
+	accessToken := auth.AccessToken{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	mockService.On("RevokeSession", mock.Anything, sessionID).Return(nil)
+	revocationService.On("RevokeAccessToken", mock.Anything, accessToken.ID, accessToken.ExpiresAt).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	ctx := auth.ContextWithSession(auth.ContextWithUser(req.Context(), uuid.New()), sessionID)
+	ctx = auth.ContextWithAccessToken(ctx, accessToken)
+	rec := httptest.NewRecorder()
+
+	// 2. Act
+	sessionHandler.Logout(rec, req.WithContext(ctx))
+
+	// 3. Assert
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	mockService.AssertExpectations(t)
+	revocationService.AssertExpectations(t)
+}
+
+func TestLogout_WithoutSession(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockRefreshTokenService)
+	sessionHandler := NewSessionHandler(mockService, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	rec := httptest.NewRecorder()
+
+	// 2. Act
+	sessionHandler.Logout(rec, req.WithContext(auth.ContextWithUser(req.Context(), uuid.New())))
+
+	// 3. Assert
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, "auth.no_session", decodeProblem(t, rec).Code)
+	mockService.AssertNotCalled(t, "RevokeSession", mock.Anything, mock.Anything)
+}
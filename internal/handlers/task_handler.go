@@ -3,8 +3,12 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
 	"github.com/MosinEvgeny/task-tracker/internal/service"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -20,89 +24,216 @@ func NewTaskHandler(taskService service.TaskService) *TaskHandler {
 	return &TaskHandler{taskService: taskService}
 }
 
+// taskRequest — тело запросов создания и обновления задачи.
+type taskRequest struct {
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	DueDate     time.Time   `json:"due_date"`
+	LabelIDs    []uuid.UUID `json:"label_ids"`
+}
+
+// taskResponse — задача в ответах API.
+type taskResponse struct {
+	ID          uuid.UUID         `json:"id"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	DueDate     time.Time         `json:"due_date"`
+	Status      domain.TaskStatus `json:"status"`
+	CompletedAt *time.Time        `json:"completed_at"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UserID      uuid.UUID         `json:"user_id"`
+	LabelIDs    []uuid.UUID       `json:"label_ids"`
+}
+
+func newTaskResponse(task *domain.Task) taskResponse {
+	return taskResponse{
+		ID:          task.ID,
+		Title:       task.Title,
+		Description: task.Description,
+		DueDate:     task.DueDate,
+		Status:      task.Status,
+		CompletedAt: task.CompletedAt,
+		CreatedAt:   task.CreatedAt,
+		UserID:      task.UserID,
+		LabelIDs:    task.LabelIDs,
+	}
+}
+
+// CreateTask создает задачу текущего пользователя. Владелец берется из
+// контекста аутентификации, поле user_id в теле запроса игнорируется.
 func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
-	var taskData struct {
-		Title       string    `json:"title"`
-		Description string    `json:"description"`
-		DueDate     time.Time `json:"due_date"`
-		UserID      uuid.UUID `json:"user_id"`
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
 	}
 
+	var taskData taskRequest
+
 	if err := json.NewDecoder(r.Body).Decode(&taskData); err != nil {
-		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		writeError(w, r, errInvalidBody)
 		return
 	}
 
-	createdTask, err := h.taskService.CreateTask(r.Context(), taskData.Title, taskData.Description, taskData.DueDate, taskData.UserID)
+	createdTask, err := h.taskService.CreateTask(r.Context(), taskData.Title, taskData.Description, taskData.DueDate, userID, taskData.LabelIDs)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdTask)
+	json.NewEncoder(w).Encode(newTaskResponse(createdTask))
+}
+
+// ListTasks возвращает страницу задач текущего пользователя.
+func (h *TaskHandler) ListTasks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	filter, err := parseTaskFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	tasks, nextCursor, err := h.taskService.ListTasks(r.Context(), userID, filter)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	items := make([]taskResponse, 0, len(tasks))
+	for _, task := range tasks {
+		items = append(items, newTaskResponse(task))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listResponse{Items: items, NextCursor: nextCursor})
 }
 
 func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Неверный ID задачи", http.StatusBadRequest)
+		writeError(w, r, errInvalidTaskID)
 		return
 	}
 
 	task, err := h.taskService.GetTaskByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Задача не найдена", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(task)
+	json.NewEncoder(w).Encode(newTaskResponse(task))
 }
 
 func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Неверный ID задачи", http.StatusBadRequest)
+		writeError(w, r, errInvalidTaskID)
 		return
 	}
 
-	var taskData struct {
-		Title       string    `json:"title"`
-		Description string    `json:"description"`
-		DueDate     time.Time `json:"due_date"`
-	}
+	var taskData taskRequest
 	if err := json.NewDecoder(r.Body).Decode(&taskData); err != nil {
-		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		writeError(w, r, errInvalidBody)
 		return
 	}
 
-	updatedTask, err := h.taskService.UpdateTask(r.Context(), id, taskData.Title, taskData.Description, taskData.DueDate)
+	updatedTask, err := h.taskService.UpdateTask(r.Context(), id, taskData.Title, taskData.Description, taskData.DueDate, taskData.LabelIDs)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedTask)
+	json.NewEncoder(w).Encode(newTaskResponse(updatedTask))
 }
 
 func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Неверный ID задачи", http.StatusBadRequest)
+		writeError(w, r, errInvalidTaskID)
 		return
 	}
 
 	err = h.taskService.DeleteTask(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// TransitionTask переводит задачу в другой статус рабочего процесса.
+func (h *TaskHandler) TransitionTask(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := uuid.Parse(vars["id"])
+	if err != nil {
+		writeError(w, r, errInvalidTaskID)
+		return
+	}
+
+	var transitionData struct {
+		Status domain.TaskStatus `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&transitionData); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	task, err := h.taskService.TransitionTask(r.Context(), id, transitionData.Status)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newTaskResponse(task))
+}
+
+// parseTaskFilter собирает фильтр задач из параметров запроса.
+func parseTaskFilter(query url.Values) (repository.TaskFilter, error) {
+	var filter repository.TaskFilter
+	var err error
+
+	if filter.DueBefore, err = queryTime(query, "due_before"); err != nil {
+		return filter, err
+	}
+	if filter.DueAfter, err = queryTime(query, "due_after"); err != nil {
+		return filter, err
+	}
+	if filter.LabelIDs, err = queryUUIDs(query, "label_id"); err != nil {
+		return filter, err
+	}
+	for _, value := range query["status"] {
+		for _, status := range strings.Split(value, ",") {
+			if status != "" {
+				filter.Statuses = append(filter.Statuses, domain.TaskStatus(status))
+			}
+		}
+	}
+	if filter.Sort, err = repository.ParseTaskSort(query.Get("sort")); err != nil {
+		return filter, err
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		if filter.After, err = repository.DecodeCursor(cursor, filter.Sort); err != nil {
+			return filter, err
+		}
+	}
+	if filter.Limit, err = queryLimit(query); err != nil {
+		return filter, err
+	}
+	filter.Search = query.Get("q")
+
+	return filter, nil
+}
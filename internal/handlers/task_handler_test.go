@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockTaskService - это mock для TaskService.
+type MockTaskService struct {
+	mock.Mock
+}
+
+func (m *MockTaskService) CreateTask(ctx context.Context, title, description string, dueDate time.Time, userID uuid.UUID, labelIDs []uuid.UUID) (*domain.Task, error) {
+	args := m.Called(ctx, title, description, dueDate, userID, labelIDs)
+	task, ok := args.Get(0).(*domain.Task)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return task, args.Error(1)
+}
+
+func (m *MockTaskService) GetTaskByID(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	args := m.Called(ctx, id)
+	task, ok := args.Get(0).(*domain.Task)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return task, args.Error(1)
+}
+
+func (m *MockTaskService) GetAllTasksByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	args := m.Called(ctx, userID)
+	tasks, ok := args.Get(0).([]*domain.Task)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return tasks, args.Error(1)
+}
+
+func (m *MockTaskService) ListTasks(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter) ([]*domain.Task, string, error) {
+	args := m.Called(ctx, userID, filter)
+	tasks, ok := args.Get(0).([]*domain.Task)
+	if !ok {
+		return nil, "", args.Error(2)
+	}
+	return tasks, args.String(1), args.Error(2)
+}
+
+func (m *MockTaskService) UpdateTask(ctx context.Context, id uuid.UUID, title, description string, dueDate time.Time, labelIDs []uuid.UUID) (*domain.Task, error) {
+	args := m.Called(ctx, id, title, description, dueDate, labelIDs)
+	task, ok := args.Get(0).(*domain.Task)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return task, args.Error(1)
+}
+
+func (m *MockTaskService) DeleteTask(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockTaskService) TransitionTask(ctx context.Context, id uuid.UUID, status domain.TaskStatus) (*domain.Task, error) {
+	args := m.Called(ctx, id, status)
+	task, ok := args.Get(0).(*domain.Task)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return task, args.Error(1)
+}
+
+func TestCreateTask_OwnerFromContext(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockTaskService)
+	taskHandler := NewTaskHandler(mockService)
+
+	userID := uuid.New()
+	spoofedUserID := uuid.New()
+	body := `{"title": "Test Task", "due_date": "2024-03-15T12:00:00Z", "user_id": "` + spoofedUserID.String() + `"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	req = req.WithContext(auth.ContextWithUser(req.Context(), userID))
+	rec := httptest.NewRecorder()
+
+	// Сервис должен получить ID пользователя из контекста, а не из тела запроса
+	mockService.On("CreateTask", mock.Anything, "Test Task", "", mock.Anything, userID, mock.Anything).
+		Return(&domain.Task{ID: uuid.New(), Title: "Test Task", UserID: userID}, nil)
+
+	// 2. Act
+	taskHandler.CreateTask(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.NotContains(t, rec.Body.String(), spoofedUserID.String())
+
+	mockService.AssertExpectations(t)
+	mockService.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything, spoofedUserID, mock.Anything)
+}
+
+func TestCreateTask_NoUserInContext(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockTaskService)
+	taskHandler := NewTaskHandler(mockService)
+
+	body := `{"title": "Test Task", "user_id": "` + uuid.New().String() + `"}`
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	// 2. Act
+	taskHandler.CreateTask(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	mockService.AssertNotCalled(t, "CreateTask", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestGetTask_ResponseFields(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockTaskService)
+	taskHandler := NewTaskHandler(mockService)
+
+	createdAt := time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC)
+	task := &domain.Task{
+		ID:          uuid.New(),
+		Title:       "Test Task",
+		Description: "Описание",
+		DueDate:     time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC),
+		Status:      domain.TaskStatusTodo,
+		CreatedAt:   createdAt,
+		UserID:      uuid.New(),
+		LabelIDs:    []uuid.UUID{uuid.New()},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks/"+task.ID.String(), nil)
+	req = mux.SetURLVars(req, map[string]string{"id": task.ID.String()})
+	rec := httptest.NewRecorder()
+
+	mockService.On("GetTaskByID", mock.Anything, task.ID).Return(task, nil)
+
+	// 2. Act
+	taskHandler.GetTask(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]any{
+		"id":           task.ID.String(),
+		"title":        "Test Task",
+		"description":  "Описание",
+		"due_date":     "2025-03-15T12:00:00Z",
+		"status":       "todo",
+		"completed_at": nil,
+		"created_at":   "2025-03-01T09:00:00Z",
+		"user_id":      task.UserID.String(),
+		"label_ids":    []any{task.LabelIDs[0].String()},
+	}, responseFields(t, rec))
+
+	mockService.AssertExpectations(t)
+}
+
+func TestListTasks_EmptyPage(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockTaskService)
+	taskHandler := NewTaskHandler(mockService)
+	userID := uuid.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/tasks", nil)
+	req = req.WithContext(auth.ContextWithUser(req.Context(), userID))
+	rec := httptest.NewRecorder()
+
+	mockService.On("ListTasks", mock.Anything, userID, mock.Anything).Return([]*domain.Task{}, "", nil)
+
+	// 2. Act
+	taskHandler.ListTasks(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, map[string]any{"items": []any{}}, responseFields(t, rec))
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/service"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+var errInvalidTokenID = domain.NewFieldError("id", "token.invalid_id", "Неверный ID токена")
+
+// TokenHandler обрабатывает HTTP-запросы для управления персональными
+// токенами доступа текущего пользователя.
+type TokenHandler struct {
+	tokenService service.PersonalAccessTokenService
+}
+
+// NewTokenHandler создает новый экземпляр TokenHandler.
+func NewTokenHandler(tokenService service.PersonalAccessTokenService) *TokenHandler {
+	return &TokenHandler{tokenService: tokenService}
+}
+
+// createTokenRequest — тело запроса POST /tokens.
+type createTokenRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// createdTokenResponse — ответ на создание токена. Сам токен показывается
+// только в этом ответе.
+type createdTokenResponse struct {
+	*domain.PersonalAccessToken
+	Token string `json:"token"`
+}
+
+// ListTokens возвращает персональные токены текущего пользователя без самих
+// токенов.
+func (h *TokenHandler) ListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	tokens, err := h.tokenService.ListTokens(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listResponse{Items: tokens})
+}
+
+// CreateToken выдает новый персональный токен текущему пользователю.
+func (h *TokenHandler) CreateToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := GetUserIDFromRequest(r)
+	if !ok {
+		writeError(w, r, errNoUserInContext)
+		return
+	}
+
+	var req createTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	value, token, err := h.tokenService.CreateToken(r.Context(), userID, service.CreatePersonalAccessToken{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdTokenResponse{PersonalAccessToken: token, Token: value})
+}
+
+// RevokeToken отзывает персональный токен текущего пользователя.
+func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, r, errInvalidTokenID)
+		return
+	}
+
+	if err := h.tokenService.RevokeToken(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,81 +2,218 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/MosinEvgeny/task-tracker/internal/auth"
-	"github.com/MosinEvgeny/task-tracker/internal/config"
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/MosinEvgeny/task-tracker/internal/service"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 )
 
+// errInvalidCredentials отдается клиенту с кодом 401 при неудачном входе.
+var errInvalidCredentials = domain.NewError(domain.ErrUnauthorized, "auth.invalid_credentials", "Неверный email или пароль")
+
 type UserHandler struct {
+	loginSessions
 	userService         service.UserService
+	revocationService   service.TokenRevocationService
+	verificationService service.EmailVerificationService
+	throttleService     service.LoginThrottleService
+}
+
+func NewUserHandler(userService service.UserService, refreshTokenService service.RefreshTokenService, revocationService service.TokenRevocationService, verificationService service.EmailVerificationService, mfaService service.MFAService, throttleService service.LoginThrottleService, issuer *auth.Issuer) *UserHandler {
+	return &UserHandler{
+		loginSessions: loginSessions{
+			refreshTokenService: refreshTokenService,
+			mfaService:          mfaService,
+			issuer:              issuer,
+		},
+		userService:         userService,
+		revocationService:   revocationService,
+		verificationService: verificationService,
+		throttleService:     throttleService,
+	}
+}
+
+// loginSessions выдает токены после входа по паролю или через внешнего
+// провайдера.
+type loginSessions struct {
 	refreshTokenService service.RefreshTokenService
-	config              config.Config
+	mfaService          service.MFAService
+	issuer              *auth.Issuer
+}
+
+// registerUserRequest — тело запроса регистрации.
+type registerUserRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// updateUserRequest — тело запроса обновления пользователя. Пароль меняется
+// отдельным запросом POST /users/me/password.
+type updateUserRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
 }
 
-func NewUserHandler(userService service.UserService, refreshTokenService service.RefreshTokenService, config config.Config) *UserHandler {
-	return &UserHandler{userService: userService, refreshTokenService: refreshTokenService, config: config}
+// userResponse — пользователь в ответах API. Хеш пароля и служебные поля
+// domain.User клиенту не отдаются.
+type userResponse struct {
+	ID            uuid.UUID `json:"id"`
+	Username      string    `json:"username"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"email_verified"`
+	IsAdmin       bool      `json:"is_admin"`
+}
+
+func newUserResponse(user *domain.User) userResponse {
+	return userResponse{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		IsAdmin:       user.IsAdmin,
+	}
 }
 
 func (h *UserHandler) RegisterUser(w http.ResponseWriter, r *http.Request) {
-	var user domain.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+	var userData registerUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&userData); err != nil {
+		writeError(w, r, errInvalidBody)
 		return
 	}
 
-	createdUser, err := h.userService.CreateUser(r.Context(), user.Username, user.Email, user.Password)
+	createdUser, err := h.userService.CreateUser(r.Context(), userData.Username, userData.Email, userData.Password)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(createdUser)
+	json.NewEncoder(w).Encode(newUserResponse(createdUser))
 }
 
 func (h *UserHandler) LoginUser(w http.ResponseWriter, r *http.Request) {
 	var loginData struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email      string `json:"email"`
+		Password   string `json:"password"`
+		DeviceName string `json:"device_name"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&loginData); err != nil {
-		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	// Перебор паролей ограничивается по email и IP-адресу клиента
+	ip := clientIP(r)
+	if err := h.throttleService.CheckLogin(r.Context(), loginData.Email, ip); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	user, err := h.userService.GetUserByEmail(r.Context(), loginData.Email)
 	if err != nil {
-		http.Error(w, "Неверный email или пароль", http.StatusUnauthorized)
+		if errors.Is(err, domain.ErrNotFound) {
+			h.loginFailed(w, r, loginData.Email, ip)
+			return
+		}
+		writeError(w, r, err)
+		return
+	}
+
+	if err := h.userService.VerifyPassword(r.Context(), user, loginData.Password); err != nil {
+		h.loginFailed(w, r, loginData.Email, ip)
+		return
+	}
+	if err := h.throttleService.LoginSucceeded(r.Context(), loginData.Email); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.verificationService.CheckVerified(user); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	h.completeLogin(w, r, user.ID, loginData.DeviceName)
+}
+
+// loginFailed учитывает неудачную попытку входа и отвечает 401.
+func (h *UserHandler) loginFailed(w http.ResponseWriter, r *http.Request, email, ip string) {
+	if err := h.throttleService.LoginFailed(r.Context(), email, ip); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeError(w, r, errInvalidCredentials)
+}
+
+// LoginMFA — второй шаг входа: проверяет токен первого шага и код из
+// приложения-аутентификатора или код восстановления.
+func (h *UserHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var loginData struct {
+		MFAToken   string `json:"mfa_token"`
+		Code       string `json:"code"`
+		DeviceName string `json:"device_name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&loginData); err != nil {
+		writeError(w, r, errInvalidBody)
+		return
+	}
+
+	userID, err := h.mfaService.CompleteChallenge(r.Context(), loginData.MFAToken, loginData.Code)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err := user.ComparePassword(loginData.Password); err != nil {
-		http.Error(w, "Неверный email или пароль", http.StatusUnauthorized)
+	h.startSession(w, r, userID, loginData.DeviceName)
+}
+
+// completeLogin завершает первый шаг входа. С двухфакторной
+// аутентификацией он дает только токен второго шага, иначе сразу создается
+// сессия.
+func (h *loginSessions) completeLogin(w http.ResponseWriter, r *http.Request, userID uuid.UUID, deviceName string) {
+	enabled, err := h.mfaService.IsEnabled(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if enabled {
+		mfaToken, err := h.mfaService.NewChallenge(userID)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"mfa_required": true, "mfa_token": mfaToken})
 		return
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID.String(),
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
+	h.startSession(w, r, userID, deviceName)
+}
 
-	tokenString, err := token.SignedString([]byte(h.config.JWTSecret))
+// startSession создает сессию на устройстве клиента и отдает access и
+// refresh токены.
+func (h *loginSessions) startSession(w http.ResponseWriter, r *http.Request, userID uuid.UUID, deviceName string) {
+	device := domain.Device{
+		Name:      deviceName,
+		UserAgent: r.UserAgent(),
+		IP:        clientIP(r),
+	}
+	refreshToken, err := h.refreshTokenService.CreateRefreshToken(r.Context(), userID, device)
 	if err != nil {
-		http.Error(w, "Ошибка при создании токена", http.StatusInternalServerError)
+		writeError(w, r, fmt.Errorf("ошибка при создании refresh токена: %w", err))
 		return
 	}
 
-	refreshToken, err := h.refreshTokenService.CreateRefreshToken(r.Context(), user.ID)
+	tokenString, _, err := h.issuer.Issue(refreshToken.UserID, refreshToken.FamilyID, nil)
 	if err != nil {
-		http.Error(w, "Ошибка при создании refresh токена", http.StatusInternalServerError)
+		writeError(w, r, fmt.Errorf("ошибка при создании токена: %w", err))
 		return
 	}
 
@@ -89,46 +226,42 @@ func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		RefreshToken string `json:"refresh_token"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&refreshTokenData); err != nil {
-		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+		writeError(w, r, errInvalidBody)
 		return
 	}
 
-	refreshToken, err := h.refreshTokenService.GetRefreshToken(r.Context(), refreshTokenData.RefreshToken)
+	refreshToken, err := h.refreshTokenService.RotateRefreshToken(r.Context(), refreshTokenData.RefreshToken)
 	if err != nil {
-		http.Error(w, "Неверный refresh токен", http.StatusUnauthorized)
+		writeError(w, r, err)
 		return
 	}
 
-	if refreshToken.ExpiryDate.Before(time.Now().UTC()) {
-		http.Error(w, "Срок действия refresh токена истек", http.StatusUnauthorized)
-		return
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": refreshToken.UserID.String(),
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
-
-	tokenString, err := token.SignedString([]byte(h.config.JWTSecret))
+	tokenString, _, err := h.issuer.Issue(refreshToken.UserID, refreshToken.FamilyID, nil)
 	if err != nil {
-		http.Error(w, "Ошибка при создании токена", http.StatusInternalServerError)
+		writeError(w, r, fmt.Errorf("ошибка при создании токена: %w", err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"token": tokenString})
+	json.NewEncoder(w).Encode(map[string]string{"token": tokenString, "refresh_token": refreshToken.Token})
 }
 
 func (h *UserHandler) RevokeAllRefreshTokens(w http.ResponseWriter, r *http.Request) {
 	userID, ok := GetUserIDFromRequest(r)
 	if !ok {
-		http.Error(w, "Не удалось получить ID пользователя из контекста", http.StatusInternalServerError)
+		writeError(w, r, errNoUserInContext)
 		return
 	}
 
 	err := h.refreshTokenService.DeleteAllRefreshTokensByUserID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "Ошибка при отзыве refresh токенов", http.StatusInternalServerError)
+		writeError(w, r, err)
+		return
+	}
+
+	// Уже выданные access токены тоже перестают действовать
+	if err := h.revocationService.RevokeAllAccessTokens(r.Context(), userID); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -139,55 +272,55 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Неверный ID пользователя", http.StatusBadRequest)
+		writeError(w, r, errInvalidUserID)
 		return
 	}
 
 	user, err := h.userService.GetUserByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "Пользователь не найден", http.StatusNotFound)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(user)
+	json.NewEncoder(w).Encode(newUserResponse(user))
 }
 
 func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Неверный ID пользователя", http.StatusBadRequest)
+		writeError(w, r, errInvalidUserID)
 		return
 	}
 
-	var user domain.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Неверный формат запроса", http.StatusBadRequest)
+	var userData updateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&userData); err != nil {
+		writeError(w, r, errInvalidBody)
 		return
 	}
 
-	updatedUser, err := h.userService.UpdateUser(r.Context(), id, user.Username, user.Email)
+	updatedUser, err := h.userService.UpdateUser(r.Context(), id, userData.Username, userData.Email)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(updatedUser)
+	json.NewEncoder(w).Encode(newUserResponse(updatedUser))
 }
 
 func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := uuid.Parse(vars["id"])
 	if err != nil {
-		http.Error(w, "Неверный ID пользователя", http.StatusBadRequest)
+		writeError(w, r, errInvalidUserID)
 		return
 	}
 
 	err = h.userService.DeleteUser(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
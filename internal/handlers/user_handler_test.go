@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockUserService - это mock для UserService.
+type MockUserService struct {
+	mock.Mock
+}
+
+func (m *MockUserService) CreateUser(ctx context.Context, username, email, password string) (*domain.User, error) {
+	args := m.Called(ctx, username, email, password)
+	user, ok := args.Get(0).(*domain.User)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return user, args.Error(1)
+}
+
+func (m *MockUserService) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(ctx, id)
+	user, ok := args.Get(0).(*domain.User)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return user, args.Error(1)
+}
+
+func (m *MockUserService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	args := m.Called(ctx, email)
+	user, ok := args.Get(0).(*domain.User)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return user, args.Error(1)
+}
+
+func (m *MockUserService) UpdateUser(ctx context.Context, id uuid.UUID, username, email string) (*domain.User, error) {
+	args := m.Called(ctx, id, username, email)
+	user, ok := args.Get(0).(*domain.User)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return user, args.Error(1)
+}
+
+func (m *MockUserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserService) VerifyPassword(ctx context.Context, user *domain.User, password string) error {
+	args := m.Called(ctx, user, password)
+	return args.Error(0)
+}
+
+// storedUser возвращает пользователя со всеми полями хранилища, включая
+// хеш пароля и момент отзыва токенов.
+func storedUser() *domain.User {
+	revokedAt := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	return &domain.User{
+		ID:               uuid.New(),
+		Username:         "alice",
+		Email:            "alice@example.com",
+		Password:         "$argon2id$v=19$m=65536,t=3,p=4$c2FsdHNhbHRzYWx0c2FsdA$aGFzaGhhc2hoYXNoaGFzaGhhc2hoYXNoaGFzaGhhc2g",
+		EmailVerified:    true,
+		TokensValidAfter: &revokedAt,
+	}
+}
+
+// responseFields разбирает JSON объект из тела ответа.
+func responseFields(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+
+	var fields map[string]any
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&fields))
+	return fields
+}
+
+// assertUserResponse проверяет, что ответ содержит только открытые поля
+// пользователя и не раскрывает хеш пароля.
+func assertUserResponse(t *testing.T, rec *httptest.ResponseRecorder, user *domain.User) {
+	t.Helper()
+
+	assert.NotContains(t, rec.Body.String(), user.Password)
+	assert.Equal(t, map[string]any{
+		"id":             user.ID.String(),
+		"username":       user.Username,
+		"email":          user.Email,
+		"email_verified": user.EmailVerified,
+		"is_admin":       user.IsAdmin,
+	}, responseFields(t, rec))
+}
+
+func TestRegisterUser_ResponseHasNoSecrets(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockUserService)
+	userHandler := NewUserHandler(mockService, nil, nil, nil, nil, nil, nil)
+	user := storedUser()
+	body := `{"username": "alice", "email": "alice@example.com", "password": "password123"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/register", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	mockService.On("CreateUser", mock.Anything, "alice", "alice@example.com", "password123").Return(user, nil)
+
+	// 2. Act
+	userHandler.RegisterUser(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "password123")
+	assertUserResponse(t, rec, user)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestGetUser_ResponseHasNoSecrets(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockUserService)
+	userHandler := NewUserHandler(mockService, nil, nil, nil, nil, nil, nil)
+	user := storedUser()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/"+user.ID.String(), nil)
+	req = req.WithContext(auth.ContextWithUser(req.Context(), user.ID))
+	req = mux.SetURLVars(req, map[string]string{"id": user.ID.String()})
+	rec := httptest.NewRecorder()
+
+	mockService.On("GetUserByID", mock.Anything, user.ID).Return(user, nil)
+
+	// 2. Act
+	userHandler.GetUser(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assertUserResponse(t, rec, user)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestUpdateUser_IgnoresPasswordInBody(t *testing.T) {
+	// 1. Arrange
+	mockService := new(MockUserService)
+	userHandler := NewUserHandler(mockService, nil, nil, nil, nil, nil, nil)
+	user := storedUser()
+	body := `{"username": "alice", "email": "alice@example.com", "password": "changed123", "is_admin": true}`
+
+	req := httptest.NewRequest(http.MethodPut, "/users/"+user.ID.String(), strings.NewReader(body))
+	req = req.WithContext(auth.ContextWithUser(req.Context(), user.ID))
+	req = mux.SetURLVars(req, map[string]string{"id": user.ID.String()})
+	rec := httptest.NewRecorder()
+
+	// Пароль и права администратора запросом обновления не меняются
+	mockService.On("UpdateUser", mock.Anything, user.ID, "alice", "alice@example.com").Return(user, nil)
+
+	// 2. Act
+	userHandler.UpdateUser(rec, req)
+
+	// 3. Assert
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assertUserResponse(t, rec, user)
+
+	mockService.AssertExpectations(t)
+}
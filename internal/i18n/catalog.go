@@ -0,0 +1,132 @@
+package i18n
+
+// catalog содержит сообщения по стабильным кодам ошибок. Коды совпадают с
+// полем Code ошибок domain.Error; параметры подставляются вместо {имя}.
+var catalog = map[string]map[Lang]string{
+	// Заголовки ответов по HTTP-статусу
+	"title.400": {Russian: "Некорректный запрос", English: "Bad Request"},
+	"title.401": {Russian: "Требуется аутентификация", English: "Unauthorized"},
+	"title.403": {Russian: "Доступ запрещен", English: "Forbidden"},
+	"title.404": {Russian: "Не найдено", English: "Not Found"},
+	"title.409": {Russian: "Конфликт", English: "Conflict"},
+	"title.429": {Russian: "Слишком много запросов", English: "Too Many Requests"},
+	"title.500": {Russian: "Внутренняя ошибка сервера", English: "Internal Server Error"},
+
+	// Общие коды по категориям ошибок
+	"internal_error":    {Russian: "Внутренняя ошибка сервера", English: "Internal server error"},
+	"not_found":         {Russian: "Запись не найдена", English: "Resource not found"},
+	"validation_failed": {Russian: "Ошибка валидации", English: "Validation failed"},
+	"conflict":          {Russian: "Конфликт данных", English: "Conflict with the current state"},
+	"forbidden":         {Russian: "Доступ запрещен", English: "Access denied"},
+	"unauthorized":      {Russian: "Требуется аутентификация", English: "Authentication required"},
+	"too_many_requests": {Russian: "Слишком много запросов", English: "Too many requests"},
+	"field_required":    {Russian: "Поле обязательно для заполнения", English: "This field is required"},
+
+	// Запрос
+	"request.invalid_body":       {Russian: "Неверный формат запроса", English: "Malformed request body"},
+	"query.invalid_param":        {Russian: "Неверный формат параметра {param}", English: "Invalid value of parameter {param}"},
+	"query.invalid_sort":         {Russian: "Недопустимое поле сортировки: {field}", English: "Unsupported sort field: {field}"},
+	"query.invalid_cursor":       {Russian: "Неверный курсор", English: "Invalid cursor"},
+	"query.cursor_sort_mismatch": {Russian: "Курсор не соответствует сортировке", English: "Cursor does not match the sort order"},
+
+	// Аутентификация
+	"auth.missing_header":          {Russian: "Отсутствует заголовок Authorization", English: "Authorization header is missing"},
+	"auth.invalid_header":          {Russian: "Неверный формат заголовка Authorization", English: "Malformed Authorization header"},
+	"auth.invalid_token":           {Russian: "Неверный токен", English: "Invalid token"},
+	"auth.invalid_credentials":     {Russian: "Неверный email или пароль", English: "Invalid email or password"},
+	"auth.invalid_refresh_token":   {Russian: "Неверный refresh токен", English: "Invalid refresh token"},
+	"auth.refresh_token_expired":   {Russian: "Срок действия refresh токена истек", English: "Refresh token has expired"},
+	"auth.refresh_token_not_found": {Russian: "Refresh токен не найден", English: "Refresh token not found"},
+	"auth.refresh_token_used":      {Russian: "Refresh токен уже использован", English: "Refresh token has already been used"},
+	"auth.refresh_token_reused":    {Russian: "Refresh токен уже использован, сессия завершена", English: "Refresh token was reused, the session has been revoked"},
+	"auth.refresh_token_exists":    {Russian: "Refresh токен уже существует", English: "Refresh token already exists"},
+	"auth.no_session":              {Russian: "Токен не привязан к сессии", English: "Token is not bound to a session"},
+	"auth.token_revoked":           {Russian: "Токен отозван", English: "Token has been revoked"},
+	"auth.too_many_attempts":       {Russian: "Слишком много неудачных попыток, повторите через {retry_after} с", English: "Too many failed attempts, try again in {retry_after} s"},
+	"auth.admin_required":          {Russian: "Требуются права администратора", English: "Administrator privileges required"},
+	"auth.insufficient_scope":      {Russian: "Токену не хватает разрешения {scope}", English: "Token is missing the {scope} scope"},
+	"auth.session_required":        {Russian: "Действие недоступно для токена с ограниченными разрешениями", English: "This action is not available to tokens with limited scopes"},
+	"auth.oidc_failed":             {Russian: "Не удалось выполнить вход через внешнего провайдера", English: "Sign-in with the external identity provider failed"},
+	"auth.oidc_denied":             {Russian: "Провайдер отклонил вход: {error}", English: "The identity provider denied the sign-in: {error}"},
+	"auth.oidc_email_unverified":   {Russian: "Email не подтвержден провайдером", English: "The email address is not verified by the identity provider"},
+	"auth.oidc_not_provisioned":    {Russian: "Пользователь не зарегистрирован", English: "The user is not registered"},
+	"auth.oidc_account_unverified": {Russian: "Пользователь с таким email уже зарегистрирован, но email не подтвержден. Подтвердите email по ссылке из письма и повторите вход", English: "A user with this email is already registered but the email is not verified. Verify it using the link from the email and sign in again"},
+
+	// Сессии
+	"session.not_found":  {Russian: "Сессия не найдена", English: "Session not found"},
+	"session.invalid_id": {Russian: "Неверный ID сессии", English: "Invalid session ID"},
+
+	// Персональные токены доступа
+	"token.not_found":       {Russian: "Токен доступа не найден", English: "Access token not found"},
+	"token.invalid_id":      {Russian: "Неверный ID токена", English: "Invalid token ID"},
+	"token.name_required":   {Russian: "Необходимо указать название токена", English: "Token name is required"},
+	"token.scopes_required": {Russian: "Необходимо указать разрешения токена", English: "Token scopes are required"},
+	"token.invalid_scope":   {Russian: "Неизвестное разрешение {scope}", English: "Unknown scope {scope}"},
+	"token.invalid_expiry":  {Russian: "Срок действия токена должен быть в будущем", English: "Token expiry must be in the future"},
+
+	// Клиенты OAuth
+	"oauth_client.not_found":             {Russian: "Клиент OAuth не найден", English: "OAuth client not found"},
+	"oauth_client.invalid_id":            {Russian: "Неверный ID клиента OAuth", English: "Invalid OAuth client ID"},
+	"oauth_client.name_required":         {Russian: "Необходимо указать название клиента", English: "Client name is required"},
+	"oauth_client.redirect_uri_required": {Russian: "Необходимо указать redirect_uri", English: "At least one redirect URI is required"},
+	"oauth_client.invalid_redirect_uri":  {Russian: "Неверный redirect_uri {uri}: нужен абсолютный адрес http или https без фрагмента", English: "Invalid redirect URI {uri}: an absolute http or https URL without a fragment is required"},
+	"oauth_client.scopes_required":       {Russian: "Необходимо указать разрешения клиента", English: "Client scopes are required"},
+	"oauth_client.invalid_scope":         {Russian: "Неизвестное разрешение {scope}", English: "Unknown scope {scope}"},
+
+	// Протокол OAuth2
+	"oauth.invalid_request":           {Russian: "В запросе нет обязательного параметра или он указан неверно", English: "The request is missing a required parameter or is malformed"},
+	"oauth.invalid_client":            {Russian: "Неизвестный клиент или неверный секрет", English: "Unknown client or invalid client secret"},
+	"oauth.invalid_grant":             {Russian: "Код авторизации недействителен, устарел или выдан другому клиенту", English: "The authorization code is invalid, expired or was issued to another client"},
+	"oauth.unauthorized_client":       {Russian: "Клиенту не разрешено это действие", English: "The client is not allowed to perform this action"},
+	"oauth.unsupported_grant_type":    {Russian: "Неподдерживаемый grant_type", English: "Unsupported grant type"},
+	"oauth.unsupported_response_type": {Russian: "Неподдерживаемый response_type", English: "Unsupported response type"},
+	"oauth.invalid_scope":             {Russian: "Запрошены разрешения, недоступные клиенту", English: "The requested scope is not available to the client"},
+	"oauth.invalid_redirect_uri":      {Russian: "redirect_uri не зарегистрирован для клиента", English: "The redirect URI is not registered for the client"},
+	"oauth.pkce_required":             {Russian: "Требуется PKCE: code_challenge с методом S256", English: "PKCE is required: provide a code_challenge with the S256 method"},
+
+	// Пароли
+	"password.wrong_current":         {Russian: "Неверный текущий пароль", English: "Current password is incorrect"},
+	"password.new_required":          {Russian: "Необходимо указать новый пароль", English: "New password is required"},
+	"password.invalid_reset_token":   {Russian: "Ссылка для сброса пароля недействительна или устарела", English: "Password reset link is invalid or has expired"},
+	"password.reset_token_not_found": {Russian: "Токен сброса пароля не найден", English: "Password reset token not found"},
+	"password.too_short":             {Russian: "Пароль должен содержать не менее {min_length} символов", English: "Password must be at least {min_length} characters long"},
+	"password.too_long":              {Russian: "Пароль слишком длинный", English: "Password is too long"},
+	"password.breached":              {Russian: "Пароль встречается в утечках данных, выберите другой", English: "Password appears in known data breaches, choose another one"},
+
+	// Подтверждение email
+	"email.invalid_verification_token": {Russian: "Ссылка для подтверждения email недействительна или устарела", English: "Email verification link is invalid or has expired"},
+	"email.not_verified":               {Russian: "Email не подтвержден", English: "Email address is not verified"},
+
+	// Двухфакторная аутентификация
+	"mfa.invalid_code":            {Russian: "Неверный код подтверждения", English: "Invalid verification code"},
+	"mfa.code_used":               {Russian: "Код уже использован", English: "Code has already been used"},
+	"mfa.recovery_code_not_found": {Russian: "Код восстановления не найден", English: "Recovery code not found"},
+	"mfa.already_enabled":         {Russian: "Двухфакторная аутентификация уже включена", English: "Two-factor authentication is already enabled"},
+	"mfa.not_enabled":             {Russian: "Двухфакторная аутентификация не включена", English: "Two-factor authentication is not enabled"},
+	"mfa.enrollment_not_started":  {Russian: "Подключение двухфакторной аутентификации не начато", English: "Two-factor authentication setup has not been started"},
+	"mfa.invalid_challenge":       {Russian: "Токен второго шага входа недействителен или устарел", English: "Two-factor login token is invalid or has expired"},
+
+	// Пользователи
+	"user.not_found":       {Russian: "Пользователь не найден", English: "User not found"},
+	"user.invalid_id":      {Russian: "Неверный ID пользователя", English: "Invalid user ID"},
+	"user.fields_required": {Russian: "Необходимо заполнить все поля", English: "All fields are required"},
+	"user.invalid_email":   {Russian: "Неверный формат email", English: "Invalid email format"},
+	"user.email_taken":     {Russian: "Пользователь с таким email уже существует", English: "A user with this email already exists"},
+
+	// Задачи
+	"task.not_found":          {Russian: "Задача не найдена", English: "Task not found"},
+	"task.invalid_id":         {Russian: "Неверный ID задачи", English: "Invalid task ID"},
+	"task.title_required":     {Russian: "Необходимо указать название задачи", English: "Task title is required"},
+	"task.user_required":      {Russian: "Необходимо указать пользователя", English: "Task owner is required"},
+	"task.label_not_found":    {Russian: "Метка {id} не найдена", English: "Label {id} not found"},
+	"task.unknown_status":     {Russian: "Неизвестный статус задачи: {status}", English: "Unknown task status: {status}"},
+	"task.invalid_transition": {Russian: "Недопустимый переход из статуса {from} в {to}", English: "Transition from {from} to {to} is not allowed"},
+
+	// Метки
+	"label.not_found":      {Russian: "Метка не найдена", English: "Label not found"},
+	"label.invalid_id":     {Russian: "Неверный ID метки", English: "Invalid label ID"},
+	"label.name_required":  {Russian: "Необходимо указать название метки", English: "Label name is required"},
+	"label.color_required": {Russian: "Необходимо указать цвет метки", English: "Label color is required"},
+	"label.user_required":  {Russian: "Необходимо указать пользователя", English: "Label owner is required"},
+	"label.invalid_color":  {Russian: "Неверный формат цвета (HEX)", English: "Invalid color format (HEX expected)"},
+}
@@ -0,0 +1,75 @@
+// Package i18n содержит каталог сообщений для клиентов API и выбор языка
+// по заголовку Accept-Language.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+)
+
+// Lang — поддерживаемый язык сообщений.
+type Lang string
+
+const (
+	Russian Lang = "ru"
+	English Lang = "en"
+)
+
+// DefaultLang используется, если клиент не запросил поддерживаемый язык.
+const DefaultLang = Russian
+
+// Negotiate выбирает язык ответа по значению заголовка Accept-Language
+// с учетом весов q. Регион языка ("en-US") не учитывается.
+func Negotiate(header string) Lang {
+	type candidate struct {
+		lang    Lang
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		if quality <= 0 {
+			continue
+		}
+
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		switch Lang(base) {
+		case Russian, English:
+			candidates = append(candidates, candidate{lang: Lang(base), quality: quality})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return DefaultLang
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].lang
+}
+
+// Translate возвращает сообщение с кодом code на языке lang и подставляет
+// в него параметры. Если код отсутствует в каталоге, возвращается fallback.
+func Translate(lang Lang, code string, params map[string]string, fallback string) string {
+	messages, ok := catalog[code]
+	if !ok {
+		return fallback
+	}
+	message, ok := messages[lang]
+	if !ok {
+		message = messages[DefaultLang]
+	}
+	return domain.FormatMessage(message, params)
+}
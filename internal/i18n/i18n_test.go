@@ -0,0 +1,43 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header string
+		lang   Lang
+	}{
+		{"", Russian},
+		{"en", English},
+		{"en-US,en;q=0.9", English},
+		{"ru-RU, en;q=0.8", Russian},
+		{"de, en;q=0.5, ru;q=0.7", Russian},
+		{"fr, de", Russian},
+		{"en;q=0, ru;q=0.1", Russian},
+		{"RU", Russian},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.lang, Negotiate(tt.header), tt.header)
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	params := map[string]string{"status": "archived"}
+
+	assert.Equal(t, "Unknown task status: archived", Translate(English, "task.unknown_status", params, ""))
+	assert.Equal(t, "Неизвестный статус задачи: archived", Translate(Russian, "task.unknown_status", params, ""))
+	assert.Equal(t, "запасной текст", Translate(English, "no.such_code", nil, "запасной текст"))
+}
+
+func TestCatalogHasAllLanguages(t *testing.T) {
+	for code, messages := range catalog {
+		for _, lang := range []Lang{Russian, English} {
+			assert.NotEmpty(t, messages[lang], "нет перевода %s для %s", lang, code)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// Package mail отправляет письма пользователям. Способ доставки выбирается
+// настройкой MAILER: письма отправляются через SMTP-сервер, а для локального
+// запуска пишутся в лог или в каталог outbox.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message — текстовое письмо одному получателю.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer доставляет письма.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogMailer выводит письма в лог приложения вместо отправки.
+type LogMailer struct {
+	logger *log.Logger
+}
+
+// NewLogMailer создает LogMailer. Если logger равен nil, используется
+// стандартный лог.
+func NewLogMailer(logger *log.Logger) *LogMailer {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &LogMailer{logger: logger}
+}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Printf("Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileMailer сохраняет каждое письмо в отдельный файл .eml в каталоге dir
+// (outbox) вместо отправки. Файлы открываются любым почтовым клиентом.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer создает FileMailer и каталог для писем.
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("ошибка при создании каталога писем: %w", err)
+	}
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405.000000000"), uuid.NewString())
+
+	if err := os.WriteFile(filepath.Join(m.dir, name), format(m.from, msg, now), 0o600); err != nil {
+		return fmt.Errorf("ошибка при сохранении письма: %w", err)
+	}
+	return nil
+}
+
+// format формирует письмо в формате RFC 5322 с текстом в UTF-8.
+func format(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
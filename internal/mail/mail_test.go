@@ -0,0 +1,55 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"mime"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	// 1. Arrange
+	dir := filepath.Join(t.TempDir(), "mail")
+	mailer, err := NewFileMailer(dir, "noreply@example.com")
+	require.NoError(t, err)
+
+	// 2. Act
+	err = mailer.Send(context.Background(), Message{To: "alice@example.com", Subject: "Сброс пароля", Body: "Строка 1\nСтрока 2"})
+
+	// 3. Assert
+	require.NoError(t, err)
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	msg, err := mail.ReadMessage(bytes.NewReader(data))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Сброс пароля", subject)
+	assert.Equal(t, "alice@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "noreply@example.com", msg.Header.Get("From"))
+	assert.Contains(t, string(data), "Строка 1\r\nСтрока 2")
+}
+
+func TestLogMailer_Send(t *testing.T) {
+	// 1. Arrange
+	var out bytes.Buffer
+	mailer := NewLogMailer(log.New(&out, "", 0))
+
+	// 2. Act
+	err := mailer.Send(context.Background(), Message{To: "alice@example.com", Subject: "Тема", Body: "Текст"})
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Mail to alice@example.com: Тема\nТекст\n", out.String())
+}
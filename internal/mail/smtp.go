@@ -0,0 +1,56 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"time"
+)
+
+// SMTPConfig — параметры SMTP-сервера.
+type SMTPConfig struct {
+	Host string
+	Port string
+	// Username и Password задаются, если сервер требует аутентификации
+	// (AUTH PLAIN). Пароль передается только по TLS или на localhost.
+	Username string
+	Password string
+	// From — отправитель, например "Task Tracker <noreply@example.com>".
+	From string
+}
+
+// SMTPMailer отправляет письма через SMTP-сервер. Если сервер поддерживает
+// STARTTLS, соединение шифруется.
+type SMTPMailer struct {
+	config SMTPConfig
+	sender string
+}
+
+// NewSMTPMailer создает SMTPMailer и проверяет адрес отправителя.
+func NewSMTPMailer(config SMTPConfig) (*SMTPMailer, error) {
+	if config.Host == "" || config.Port == "" {
+		return nil, errors.New("не задан адрес SMTP-сервера")
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("неверный адрес отправителя %q: %w", config.From, err)
+	}
+	return &SMTPMailer{config: config, sender: from.Address}, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	addr := net.JoinHostPort(m.config.Host, m.config.Port)
+	data := format(m.config.From, msg, time.Now().UTC())
+	if err := smtp.SendMail(addr, auth, m.sender, []string{msg.To}, data); err != nil {
+		return fmt.Errorf("ошибка при отправке письма через SMTP: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpSession — команды и письмо, полученные фиктивным SMTP-сервером.
+type smtpSession struct {
+	commands []string
+	data     string
+}
+
+// startSMTPServer запускает SMTP-сервер, который принимает одно письмо без
+// аутентификации и TLS.
+func startSMTPServer(t *testing.T) (string, string, <-chan smtpSession) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var session smtpSession
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.TrimRight(line, "\r\n")
+			session.commands = append(session.commands, command)
+
+			switch verb := strings.ToUpper(strings.SplitN(command, " ", 2)[0]); verb {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "DATA":
+				reply("354 end with <CRLF>.<CRLF>")
+				var data strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				session.data = data.String()
+				reply("250 queued")
+			case "QUIT":
+				reply("221 bye")
+				sessions <- session
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return host, port, sessions
+}
+
+func TestSMTPMailer_Send(t *testing.T) {
+	// 1. Arrange
+	host, port, sessions := startSMTPServer(t)
+	mailer, err := NewSMTPMailer(SMTPConfig{Host: host, Port: port, From: "Task Tracker <noreply@example.com>"})
+	require.NoError(t, err)
+
+	// 2. Act
+	err = mailer.Send(context.Background(), Message{To: "alice@example.com", Subject: "Подтверждение email", Body: "Текст письма"})
+
+	// 3. Assert
+	require.NoError(t, err)
+	session := <-sessions
+	assert.Contains(t, session.commands, "MAIL FROM:<noreply@example.com>")
+	assert.Contains(t, session.commands, "RCPT TO:<alice@example.com>")
+	assert.Contains(t, session.data, "To: alice@example.com\r\n")
+	assert.Contains(t, session.data, "\r\n\r\nТекст письма")
+}
+
+func TestNewSMTPMailer_Validation(t *testing.T) {
+	_, err := NewSMTPMailer(SMTPConfig{Port: "25", From: "noreply@example.com"})
+	assert.Error(t, err)
+
+	_, err = NewSMTPMailer(SMTPConfig{Host: "localhost", Port: "25", From: "not an address"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,72 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad_Embedded(t *testing.T) {
+	migrations, err := load(files)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, migrations)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "init", migrations[0].Name)
+}
+
+func TestLoad_Sorted(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0002_labels.up.sql":   {Data: []byte("CREATE TABLE labels ();")},
+		"sql/0002_labels.down.sql": {Data: []byte("DROP TABLE labels;")},
+		"sql/0001_init.up.sql":     {Data: []byte("CREATE TABLE users ();")},
+		"sql/0001_init.down.sql":   {Data: []byte("DROP TABLE users;")},
+	}
+
+	migrations, err := load(fsys)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "init", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"},
+		{Version: 2, Name: "labels", Up: "CREATE TABLE labels ();", Down: "DROP TABLE labels;"},
+	}, migrations)
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		fsys  fstest.MapFS
+		error string
+	}{
+		{
+			name: "нет файла down",
+			fsys: fstest.MapFS{
+				"sql/0001_init.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			error: "у миграции 1_init нет файла up или down",
+		},
+		{
+			name: "пропущена версия",
+			fsys: fstest.MapFS{
+				"sql/0002_init.up.sql":   {Data: []byte("SELECT 1;")},
+				"sql/0002_init.down.sql": {Data: []byte("SELECT 1;")},
+			},
+			error: "пропущена миграция с версией 1",
+		},
+		{
+			name: "неверное имя",
+			fsys: fstest.MapFS{
+				"sql/init.up.sql": {Data: []byte("SELECT 1;")},
+			},
+			error: "неверное имя файла миграции: sql/init.up.sql",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := load(tt.fsys)
+			assert.EqualError(t, err, tt.error)
+		})
+	}
+}
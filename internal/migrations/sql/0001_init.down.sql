@@ -0,0 +1,5 @@
+DROP TABLE refresh_tokens;
+DROP TABLE task_labels;
+DROP TABLE tasks;
+DROP TABLE labels;
+DROP TABLE users;
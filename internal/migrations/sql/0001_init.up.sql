@@ -0,0 +1,46 @@
+CREATE TABLE users (
+    id       UUID PRIMARY KEY,
+    username TEXT NOT NULL,
+    email    TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL
+);
+
+CREATE TABLE labels (
+    id      UUID PRIMARY KEY,
+    name    TEXT NOT NULL,
+    color   TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX labels_user_name_idx ON labels (user_id, name, id);
+
+CREATE TABLE tasks (
+    id           UUID PRIMARY KEY,
+    title        TEXT NOT NULL,
+    description  TEXT NOT NULL DEFAULT '',
+    due_date     TIMESTAMPTZ NOT NULL,
+    status       TEXT NOT NULL,
+    completed_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX tasks_user_created_idx ON tasks (user_id, created_at, id);
+CREATE INDEX tasks_user_due_date_idx ON tasks (user_id, due_date, id);
+
+CREATE TABLE task_labels (
+    task_id  UUID NOT NULL REFERENCES tasks (id) ON DELETE CASCADE,
+    label_id UUID NOT NULL REFERENCES labels (id) ON DELETE CASCADE,
+    PRIMARY KEY (task_id, label_id)
+);
+
+CREATE INDEX task_labels_label_idx ON task_labels (label_id);
+
+CREATE TABLE refresh_tokens (
+    id          UUID PRIMARY KEY,
+    user_id     UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token       TEXT NOT NULL UNIQUE,
+    expiry_date TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX refresh_tokens_user_idx ON refresh_tokens (user_id);
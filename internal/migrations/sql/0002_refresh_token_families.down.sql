@@ -0,0 +1,4 @@
+DROP INDEX refresh_tokens_family_idx;
+
+ALTER TABLE refresh_tokens DROP COLUMN used_at;
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
@@ -0,0 +1,9 @@
+ALTER TABLE refresh_tokens ADD COLUMN family_id UUID;
+ALTER TABLE refresh_tokens ADD COLUMN used_at TIMESTAMPTZ;
+
+-- Каждый существующий токен становится отдельным семейством
+UPDATE refresh_tokens SET family_id = id;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX refresh_tokens_family_idx ON refresh_tokens (family_id);
@@ -0,0 +1,6 @@
+-- Исходные значения токенов восстановить нельзя: после отката все
+-- refresh токены становятся недействительными.
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens ADD COLUMN token TEXT NOT NULL UNIQUE;
+ALTER TABLE refresh_tokens DROP COLUMN token_hash;
@@ -0,0 +1,9 @@
+-- Токены хранятся только в виде SHA-256. Хеши существующих токенов
+-- вычисляются из их значений, поэтому выданные сессии продолжают работать.
+ALTER TABLE refresh_tokens ADD COLUMN token_hash TEXT;
+
+UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token, 'UTF8')), 'hex');
+
+ALTER TABLE refresh_tokens ALTER COLUMN token_hash SET NOT NULL;
+ALTER TABLE refresh_tokens ADD CONSTRAINT refresh_tokens_token_hash_key UNIQUE (token_hash);
+ALTER TABLE refresh_tokens DROP COLUMN token;
@@ -0,0 +1,18 @@
+CREATE TABLE sessions (
+    id           UUID PRIMARY KEY,
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    device_name  TEXT NOT NULL DEFAULT '',
+    user_agent   TEXT NOT NULL DEFAULT '',
+    ip           TEXT NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_used_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX sessions_user_idx ON sessions (user_id);
+
+-- Существующие семейства refresh токенов становятся сессиями без сведений об устройстве
+INSERT INTO sessions (id, user_id)
+SELECT DISTINCT family_id, user_id FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+    ADD CONSTRAINT refresh_tokens_family_fkey FOREIGN KEY (family_id) REFERENCES sessions (id) ON DELETE CASCADE;
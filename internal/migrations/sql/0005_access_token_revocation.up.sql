@@ -0,0 +1,11 @@
+-- Access токены, выданные раньше этого момента, считаются отозванными
+ALTER TABLE users ADD COLUMN tokens_valid_after TIMESTAMPTZ;
+
+-- Отозванные access токены (deny-list). Запись нужна только до истечения
+-- срока действия токена
+CREATE TABLE denied_tokens (
+    jti        UUID PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX denied_tokens_expires_idx ON denied_tokens (expires_at);
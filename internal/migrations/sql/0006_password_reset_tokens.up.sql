@@ -0,0 +1,9 @@
+-- Одноразовые токены сброса пароля. Хранится только SHA-256 токена
+CREATE TABLE password_reset_tokens (
+    id         UUID PRIMARY KEY,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX password_reset_tokens_user_idx ON password_reset_tokens (user_id);
@@ -0,0 +1,4 @@
+-- Пользователи, зарегистрированные до появления подтверждения email,
+-- считаются подтвердившими его
+ALTER TABLE users ADD COLUMN email_verified BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE users ALTER COLUMN email_verified SET DEFAULT false;
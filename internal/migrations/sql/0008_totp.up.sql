@@ -0,0 +1,15 @@
+-- Секреты двухфакторной аутентификации (TOTP)
+CREATE TABLE user_totp (
+    user_id        UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+    secret         TEXT NOT NULL,
+    confirmed      BOOLEAN NOT NULL DEFAULT false,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Одноразовые коды восстановления. Хранится только SHA-256 кода
+CREATE TABLE recovery_codes (
+    user_id   UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash TEXT NOT NULL,
+    PRIMARY KEY (user_id, code_hash)
+);
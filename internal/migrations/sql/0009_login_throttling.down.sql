@@ -0,0 +1,5 @@
+DROP TABLE audit_events;
+
+DROP TABLE login_throttles;
+
+ALTER TABLE users DROP COLUMN is_admin;
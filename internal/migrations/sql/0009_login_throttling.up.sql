@@ -0,0 +1,24 @@
+-- Администраторы могут снимать блокировку входа и читать журнал аудита
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+-- Счетчики неудачных попыток входа по email, IP-адресу и второму шагу входа
+CREATE TABLE login_throttles (
+    key             TEXT PRIMARY KEY,
+    failures        INTEGER NOT NULL,
+    last_failure_at TIMESTAMPTZ NOT NULL,
+    locked_until    TIMESTAMPTZ
+);
+
+-- Журнал аудита событий безопасности. Записи сохраняются после удаления
+-- пользователя, поэтому внешних ключей на users нет
+CREATE TABLE audit_events (
+    id         UUID PRIMARY KEY,
+    type       TEXT NOT NULL,
+    user_id    UUID,
+    actor_id   UUID,
+    subject    TEXT NOT NULL DEFAULT '',
+    details    TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX audit_events_created_at_idx ON audit_events (created_at DESC, id DESC);
@@ -0,0 +1,14 @@
+-- Персональные токены доступа для скриптов и интеграций. Хранится только
+-- SHA-256 токена
+CREATE TABLE personal_access_tokens (
+    id           UUID PRIMARY KEY,
+    user_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         TEXT NOT NULL,
+    token_hash   TEXT NOT NULL UNIQUE,
+    scopes       TEXT[] NOT NULL,
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX personal_access_tokens_user_idx ON personal_access_tokens (user_id);
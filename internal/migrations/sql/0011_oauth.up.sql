@@ -0,0 +1,23 @@
+-- Клиенты OAuth2. У публичных клиентов secret_hash пуст
+CREATE TABLE oauth_clients (
+    id            UUID PRIMARY KEY,
+    owner_id      UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name          TEXT NOT NULL,
+    secret_hash   TEXT NOT NULL DEFAULT '',
+    redirect_uris TEXT[] NOT NULL,
+    scopes        TEXT[] NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX oauth_clients_owner_idx ON oauth_clients (owner_id);
+
+-- Одноразовые коды авторизации. Хранится только SHA-256 кода
+CREATE TABLE oauth_authorization_codes (
+    code_hash      TEXT PRIMARY KEY,
+    client_id      UUID NOT NULL REFERENCES oauth_clients (id) ON DELETE CASCADE,
+    user_id        UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    redirect_uri   TEXT NOT NULL,
+    scopes         TEXT[] NOT NULL,
+    code_challenge TEXT NOT NULL,
+    expires_at     TIMESTAMPTZ NOT NULL
+);
@@ -0,0 +1,11 @@
+-- Учетные записи внешних провайдеров OpenID Connect, через которые
+-- пользователи входят в систему
+CREATE TABLE external_identities (
+    issuer     TEXT NOT NULL,
+    subject    TEXT NOT NULL,
+    user_id    UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (issuer, subject)
+);
+
+CREATE INDEX external_identities_user_idx ON external_identities (user_id);
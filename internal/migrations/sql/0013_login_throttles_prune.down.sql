@@ -0,0 +1 @@
+DROP INDEX login_throttles_last_failure_idx;
@@ -0,0 +1,3 @@
+-- Счетчики с истекшим окном и блокировкой удаляются при учете неудачных
+-- попыток
+CREATE INDEX login_throttles_last_failure_idx ON login_throttles (last_failure_at);
@@ -0,0 +1,341 @@
+// Package oidc реализует вход через внешнего провайдера OpenID Connect
+// (relying party): получение настроек провайдера (discovery), адрес запроса
+// авторизации, обмен кода авторизации на токены и проверку ID токена по
+// открытым ключам провайдера (JWKS).
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// discoveryPath — адрес настроек провайдера относительно издателя.
+	discoveryPath = "/.well-known/openid-configuration"
+	// maxResponseSize ограничивает размер ответов провайдера.
+	maxResponseSize = 1 << 20
+	// jwksRefreshInterval — как часто можно перечитывать JWKS, встретив
+	// неизвестный kid (провайдер сменил ключ подписи).
+	jwksRefreshInterval = time.Minute
+	// clockSkew — допустимое расхождение часов с провайдером.
+	clockSkew = 30 * time.Second
+)
+
+// ErrInvalidIDToken возвращается, если ID токен не прошел проверку.
+var ErrInvalidIDToken = errors.New("недействительный ID токен")
+
+// Config — регистрация приложения у провайдера.
+type Config struct {
+	// IssuerURL — издатель (iss) провайдера; настройки читаются по адресу
+	// IssuerURL + /.well-known/openid-configuration.
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL — адрес возврата приложения, зарегистрированный у
+	// провайдера.
+	RedirectURL string
+	// Scopes — запрашиваемые разрешения; openid добавляется всегда.
+	Scopes []string
+}
+
+// Metadata — настройки провайдера (OpenID Connect Discovery 1.0).
+type Metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDToken — проверенные claims пользователя из ID токена.
+type IDToken struct {
+	Issuer            string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// idTokenClaims — содержимое ID токена.
+type idTokenClaims struct {
+	jwt.RegisteredClaims
+	Nonce             string `json:"nonce"`
+	AuthorizedParty   string `json:"azp"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+}
+
+// publicKey — открытый ключ провайдера из JWKS.
+type publicKey struct {
+	key    any
+	method jwt.SigningMethod
+}
+
+// Client — приложение, которое входит через провайдера (relying party).
+// Настройки и ключи провайдера читаются при первом входе и кешируются, так
+// что недоступность провайдера не мешает запуску сервера.
+type Client struct {
+	config Config
+	http   *http.Client
+	now    func() time.Time
+
+	mu            sync.Mutex
+	metadata      *Metadata
+	keys          map[string]publicKey
+	keysFetchedAt time.Time
+}
+
+// NewClient создает клиента провайдера с настройками config. Запросы к
+// провайдеру выполняются через httpClient.
+func NewClient(config Config, httpClient *http.Client) (*Client, error) {
+	if config.IssuerURL == "" || config.ClientID == "" {
+		return nil, errors.New("не заданы издатель или client_id провайдера OpenID Connect")
+	}
+	if u, err := url.Parse(config.RedirectURL); err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("неверный адрес возврата OpenID Connect: %q", config.RedirectURL)
+	}
+	if !slices.Contains(config.Scopes, "openid") {
+		config.Scopes = append([]string{"openid"}, config.Scopes...)
+	}
+	return &Client{config: config, http: httpClient, now: time.Now}, nil
+}
+
+// AuthCodeURL возвращает адрес запроса авторизации у провайдера с
+// параметрами state, nonce и code_challenge (PKCE, метод S256).
+func (c *Client) AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error) {
+	metadata, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("неверный authorization_endpoint провайдера: %w", err)
+	}
+	query := u.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", c.config.ClientID)
+	query.Set("redirect_uri", c.config.RedirectURL)
+	query.Set("scope", strings.Join(c.config.Scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Exchange обменивает код авторизации на токены провайдера и возвращает ID
+// токен без проверки.
+func (c *Client) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	metadata, err := c.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("ошибка при создании запроса токенов: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// client_secret_basic: значения предварительно кодируются (RFC 6749, раздел 2.3.1)
+	req.SetBasicAuth(url.QueryEscape(c.config.ClientID), url.QueryEscape(c.config.ClientSecret))
+
+	var body struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := c.do(req, &body)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при обмене кода авторизации: %w", err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("провайдер отклонил код авторизации: %d %s %s", status, body.Error, body.ErrorDescription)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("провайдер не вернул id_token")
+	}
+	return body.IDToken, nil
+}
+
+// Verify проверяет подпись ID токена по JWKS провайдера, издателя,
+// получателя, срок действия и nonce и возвращает claims пользователя.
+func (c *Client) Verify(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	metadata, err := c.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &idTokenClaims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, c.keyfunc(ctx),
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(c.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(c.now),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidIDToken, err)
+	}
+
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: отсутствует claim sub", ErrInvalidIDToken)
+	}
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce не совпадает", ErrInvalidIDToken)
+	}
+	// Токен для нескольких получателей должен быть выдан именно нам
+	// (OpenID Connect Core 1.0, раздел 3.1.3.7)
+	if len(claims.Audience) > 1 && claims.AuthorizedParty != c.config.ClientID {
+		return nil, fmt.Errorf("%w: неверный claim azp", ErrInvalidIDToken)
+	}
+
+	return &IDToken{
+		Issuer:            claims.Issuer,
+		Subject:           claims.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// discover возвращает настройки провайдера, при первом вызове читая их по
+// адресу discovery.
+func (c *Client) discover(ctx context.Context) (*Metadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.metadata != nil {
+		return c.metadata, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.config.IssuerURL, "/")+discoveryPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при создании запроса настроек провайдера: %w", err)
+	}
+	var metadata Metadata
+	status, err := c.do(req, &metadata)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении настроек провайдера: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("ошибка при получении настроек провайдера: статус %d", status)
+	}
+
+	// Настройки должны принадлежать тому же издателю (OpenID Connect
+	// Discovery 1.0, раздел 4.3)
+	if metadata.Issuer != c.config.IssuerURL {
+		return nil, fmt.Errorf("издатель провайдера %q не совпадает с %q", metadata.Issuer, c.config.IssuerURL)
+	}
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("в настройках провайдера нет authorization_endpoint, token_endpoint или jwks_uri")
+	}
+
+	c.metadata = &metadata
+	return c.metadata, nil
+}
+
+// keyfunc выбирает открытый ключ провайдера по заголовку kid. Если ключ
+// неизвестен, JWKS перечитывается не чаще раза в jwksRefreshInterval.
+func (c *Client) keyfunc(ctx context.Context) jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		key, ok := c.lookupKey(kid)
+		if !ok && (c.keys == nil || c.now().Sub(c.keysFetchedAt) >= jwksRefreshInterval) {
+			if err := c.fetchKeys(ctx); err != nil {
+				return nil, err
+			}
+			key, ok = c.lookupKey(kid)
+		}
+		if !ok {
+			return nil, fmt.Errorf("неизвестный ключ подписи провайдера: %q", kid)
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("неверный алгоритм подписи: %v", token.Header["alg"])
+		}
+		return key.key, nil
+	}
+}
+
+// lookupKey ищет ключ по kid. Токен без kid проверяется единственным ключом
+// набора.
+func (c *Client) lookupKey(kid string) (publicKey, bool) {
+	if kid == "" && len(c.keys) == 1 {
+		for _, key := range c.keys {
+			return key, true
+		}
+	}
+	key, ok := c.keys[kid]
+	return key, ok
+}
+
+// fetchKeys читает JWKS провайдера. Ключи неподдерживаемых типов и ключи
+// шифрования пропускаются. Вызывается под c.mu.
+func (c *Client) fetchKeys(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.metadata.JWKSURI, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании запроса JWKS: %w", err)
+	}
+	var jwks auth.JWKS
+	status, err := c.do(req, &jwks)
+	if err != nil {
+		return fmt.Errorf("ошибка при получении JWKS провайдера: %w", err)
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("ошибка при получении JWKS провайдера: статус %d", status)
+	}
+
+	keys := make(map[string]publicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		key, method, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.ID] = publicKey{key: key, method: method}
+	}
+	c.keys = keys
+	c.keysFetchedAt = c.now()
+	return nil
+}
+
+// do выполняет запрос к провайдеру и разбирает ответ в JSON в out.
+func (c *Client) do(req *http.Request, out any) (int, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("неверный ответ провайдера: %w", err)
+	}
+	return resp.StatusCode, nil
+}
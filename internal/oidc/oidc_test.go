@@ -0,0 +1,204 @@
+package oidc
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/oidc/oidctest"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testRedirectURL = "http://localhost:8080/login/oidc/callback"
+	testVerifier    = "dBjftJeZ4CVP-1rJ0Lr6ZWqIRhVBuTy8JEoYrmQ9bOkmOE4"
+)
+
+// newTestClient запускает провайдера и создает клиента для него.
+func newTestClient(t *testing.T) (*Client, *oidctest.Provider) {
+	t.Helper()
+
+	provider := oidctest.NewProvider(t, "task-tracker", "client-secret")
+	client, err := NewClient(Config{
+		IssuerURL:    provider.Issuer(),
+		ClientID:     "task-tracker",
+		ClientSecret: "client-secret",
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"email", "profile"},
+	}, http.DefaultClient)
+	require.NoError(t, err)
+	return client, provider
+}
+
+// authorize выполняет запрос авторизации у провайдера и возвращает код.
+func authorize(t *testing.T, client *Client, nonce string) string {
+	t.Helper()
+
+	authURL, err := client.AuthCodeURL(context.Background(), "state", nonce, auth.PKCEChallenge(testVerifier))
+	require.NoError(t, err)
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query().Get("code")
+}
+
+func TestNewClient_Validation(t *testing.T) {
+	_, err := NewClient(Config{ClientID: "app", RedirectURL: testRedirectURL}, http.DefaultClient)
+	assert.Error(t, err, "нет издателя")
+
+	_, err = NewClient(Config{IssuerURL: "https://idp.example.com", RedirectURL: testRedirectURL}, http.DefaultClient)
+	assert.Error(t, err, "нет client_id")
+
+	_, err = NewClient(Config{IssuerURL: "https://idp.example.com", ClientID: "app", RedirectURL: "/callback"}, http.DefaultClient)
+	assert.Error(t, err, "относительный адрес возврата")
+}
+
+func TestClient_AuthCodeURL(t *testing.T) {
+	// 1. Arrange
+	client, provider := newTestClient(t)
+
+	// 2. Act
+	authURL, err := client.AuthCodeURL(context.Background(), "xyz", "n-0S6_WzA2Mj", "challenge")
+
+	// 3. Assert
+	require.NoError(t, err)
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, provider.Issuer()+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	query := u.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "task-tracker", query.Get("client_id"))
+	assert.Equal(t, testRedirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"), "openid добавляется всегда")
+	assert.Equal(t, "xyz", query.Get("state"))
+	assert.Equal(t, "n-0S6_WzA2Mj", query.Get("nonce"))
+	assert.Equal(t, "challenge", query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+}
+
+func TestClient_DiscoveryIssuerMismatch(t *testing.T) {
+	// 1. Arrange
+	provider := oidctest.NewProvider(t, "task-tracker", "client-secret")
+	client, err := NewClient(Config{IssuerURL: provider.Issuer() + "/", ClientID: "task-tracker", RedirectURL: testRedirectURL}, http.DefaultClient)
+	require.NoError(t, err)
+
+	// 2. Act
+	_, err = client.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+
+	// 3. Assert
+	assert.ErrorContains(t, err, "не совпадает")
+}
+
+func TestClient_ExchangeAndVerify(t *testing.T) {
+	// 1. Arrange
+	client, provider := newTestClient(t)
+	provider.User = oidctest.User{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe", PreferredUsername: "jane"}
+	code := authorize(t, client, "nonce")
+
+	// 2. Act
+	rawIDToken, err := client.Exchange(context.Background(), code, testVerifier)
+	require.NoError(t, err)
+	idToken, err := client.Verify(context.Background(), rawIDToken, "nonce")
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, &IDToken{
+		Issuer:            provider.Issuer(),
+		Subject:           "248289761001",
+		Email:             "jane@example.com",
+		EmailVerified:     true,
+		Name:              "Jane Doe",
+		PreferredUsername: "jane",
+	}, idToken)
+
+	_, err = client.Exchange(context.Background(), code, testVerifier)
+	assert.Error(t, err, "код авторизации одноразовый")
+}
+
+func TestClient_ExchangeRejectsWrongVerifier(t *testing.T) {
+	// 1. Arrange
+	client, _ := newTestClient(t)
+	code := authorize(t, client, "nonce")
+
+	// 2. Act
+	_, err := client.Exchange(context.Background(), code, "wrong-verifier-wrong-verifier-wrong-verifier")
+
+	// 3. Assert
+	assert.ErrorContains(t, err, "invalid_grant")
+}
+
+func TestClient_VerifyRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(claims jwt.MapClaims)
+		nonce  string
+	}{
+		{"другой nonce", func(jwt.MapClaims) {}, "other"},
+		{"другой получатель", func(claims jwt.MapClaims) { claims["aud"] = "other-app" }, "nonce"},
+		{"другой издатель", func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }, "nonce"},
+		{"истек срок действия", func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Hour).Unix() }, "nonce"},
+		{"нет sub", func(claims jwt.MapClaims) { delete(claims, "sub") }, "nonce"},
+		{"несколько получателей без azp", func(claims jwt.MapClaims) { claims["aud"] = []string{"task-tracker", "other-app"} }, "nonce"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Arrange
+			client, provider := newTestClient(t)
+			claims := provider.IDTokenClaims("nonce")
+			tt.modify(claims)
+			rawIDToken := provider.SignIDToken(t, claims)
+
+			// 2. Act
+			_, err := client.Verify(context.Background(), rawIDToken, tt.nonce)
+
+			// 3. Assert
+			assert.ErrorIs(t, err, ErrInvalidIDToken)
+		})
+	}
+}
+
+func TestClient_VerifyRejectsForeignKey(t *testing.T) {
+	// 1. Arrange
+	client, provider := newTestClient(t)
+	other := oidctest.NewProvider(t, "task-tracker", "client-secret")
+	claims := other.IDTokenClaims("nonce")
+	claims["iss"] = provider.Issuer()
+
+	// 2. Act
+	_, err := client.Verify(context.Background(), other.SignIDToken(t, claims), "nonce")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrInvalidIDToken)
+}
+
+func TestClient_KeyRotation(t *testing.T) {
+	// 1. Arrange
+	client, provider := newTestClient(t)
+	now := time.Now()
+	client.now = func() time.Time { return now }
+	_, err := client.Verify(context.Background(), provider.SignIDToken(t, provider.IDTokenClaims("nonce")), "nonce")
+	require.NoError(t, err)
+	provider.RotateKey(t)
+	rotated := provider.SignIDToken(t, provider.IDTokenClaims("nonce"))
+
+	// 2. Act
+	_, tooSoon := client.Verify(context.Background(), rotated, "nonce")
+	now = now.Add(jwksRefreshInterval)
+	_, err = client.Verify(context.Background(), rotated, "nonce")
+
+	// 3. Assert
+	assert.ErrorIs(t, tooSoon, ErrInvalidIDToken, "JWKS не перечитывается на каждый неизвестный kid")
+	assert.NoError(t, err, "после интервала ключи провайдера перечитываются")
+}
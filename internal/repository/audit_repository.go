@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+)
+
+// AuditRepository хранит журнал аудита событий безопасности.
+type AuditRepository interface {
+	Create(ctx context.Context, event *domain.AuditEvent) error
+	// List возвращает не более limit последних событий, начиная с самого нового.
+	List(ctx context.Context, limit int) ([]*domain.AuditEvent, error)
+}
@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DenyListRepository хранит отозванные access токены (по claim jti) до
+// истечения их срока действия.
+type DenyListRepository interface {
+	// Add добавляет токен в список. Если токен уже в списке и срок его
+	// действия не истек, возвращается domain.ErrConflict: так одноразовый
+	// токен можно атомарно отметить использованным.
+	Add(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error
+	// Contains сообщает, отозван ли токен, срок действия которого на момент
+	// now еще не истек.
+	Contains(ctx context.Context, jti uuid.UUID, now time.Time) (bool, error)
+}
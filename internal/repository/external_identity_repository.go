@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+)
+
+// ExternalIdentityRepository определяет интерфейс для работы со связями
+// пользователей с учетными записями внешних провайдеров.
+type ExternalIdentityRepository interface {
+	// Create связывает учетную запись с пользователем. Повторная связь той
+	// же учетной записи возвращает domain.ErrConflict.
+	Create(ctx context.Context, identity *domain.ExternalIdentity) error
+	Get(ctx context.Context, issuer, subject string) (*domain.ExternalIdentity, error)
+}
@@ -12,6 +12,31 @@ type LabelRepository interface {
 	Create(ctx context.Context, label *domain.Label) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Label, error)
 	GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Label, error)
+	List(ctx context.Context, userID uuid.UUID, filter LabelFilter) ([]*domain.Label, error)
 	Update(ctx context.Context, label *domain.Label) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
+
+// LabelFilter описывает параметры выборки меток пользователя.
+type LabelFilter struct {
+	Search string // Подстрока в названии (без учета регистра)
+	Sort   Sort
+	After  *Cursor
+	Limit  int
+}
+
+// Поля сортировки меток.
+const (
+	LabelSortName = "name"
+)
+
+// ParseLabelSort разбирает параметр сортировки меток. Пустая строка означает
+// сортировку по названию.
+func ParseLabelSort(value string) (Sort, error) {
+	return parseSort(value, LabelSortName, LabelSortName)
+}
+
+// LabelSortValue возвращает значение поля сортировки метки для курсора.
+func LabelSortValue(label *domain.Label, field string) string {
+	return label.Name
+}
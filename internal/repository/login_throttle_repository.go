@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+)
+
+// LoginThrottleRepository хранит счетчики неудачных попыток входа.
+type LoginThrottleRepository interface {
+	// Get возвращает счетчик ключа или ошибку domain.ErrNotFound, если
+	// неудачных попыток не было.
+	Get(ctx context.Context, key string) (*domain.LoginThrottle, error)
+	// RecordFailure атомарно увеличивает счетчик и возвращает его новое
+	// значение. Если предыдущая неудачная попытка была раньше resetBefore,
+	// счет начинается заново. Заодно удаляются счетчики других ключей, у
+	// которых последняя попытка раньше resetBefore и блокировка истекла к
+	// моменту at, иначе записи о несуществующих email и разовых IP-адресах
+	// копились бы без ограничений.
+	RecordFailure(ctx context.Context, key string, at, resetBefore time.Time) (*domain.LoginThrottle, error)
+	// Lock блокирует ключ до момента until.
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Delete удаляет счетчики ключей. Отсутствие счетчика не считается ошибкой.
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"slices"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+)
+
+// AuditRepository реализует интерфейс AuditRepository в памяти.
+type AuditRepository struct {
+	store *Store
+}
+
+// NewAuditRepository создает новый экземпляр AuditRepository.
+func NewAuditRepository(store *Store) *AuditRepository {
+	return &AuditRepository{store: store}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.store.auditEvents {
+		if existing.ID == event.ID {
+			return errExists
+		}
+	}
+
+	copied := *event
+	r.store.auditEvents = append(r.store.auditEvents, &copied)
+	return nil
+}
+
+// List возвращает события в порядке убывания времени и ID, как в PostgreSQL.
+func (r *AuditRepository) List(ctx context.Context, limit int) ([]*domain.AuditEvent, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	events := make([]*domain.AuditEvent, 0, len(r.store.auditEvents))
+	for _, event := range r.store.auditEvents {
+		copied := *event
+		events = append(events, &copied)
+	}
+
+	slices.SortFunc(events, func(a, b *domain.AuditEvent) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return compareIDs(b.ID, a.ID)
+	})
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
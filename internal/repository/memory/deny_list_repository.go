@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// DenyListRepository реализует интерфейс DenyListRepository в памяти. Записи
+// живут до истечения срока действия токена (TTL) и удаляются при следующем
+// добавлении.
+type DenyListRepository struct {
+	store *Store
+}
+
+// NewDenyListRepository создает новый экземпляр DenyListRepository.
+func NewDenyListRepository(store *Store) *DenyListRepository {
+	return &DenyListRepository{store: store}
+}
+
+func (r *DenyListRepository) Add(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	now := time.Now()
+	for id, deniedUntil := range r.store.deniedTokens {
+		if !deniedUntil.After(now) {
+			delete(r.store.deniedTokens, id)
+		}
+	}
+
+	if _, ok := r.store.deniedTokens[jti]; ok {
+		return domain.ErrConflict
+	}
+	r.store.deniedTokens[jti] = expiresAt
+	return nil
+}
+
+func (r *DenyListRepository) Contains(ctx context.Context, jti uuid.UUID, now time.Time) (bool, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	expiresAt, ok := r.store.deniedTokens[jti]
+	return ok && expiresAt.After(now), nil
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+)
+
+var errExternalIdentityNotFound = domain.NewError(domain.ErrNotFound, "external_identity.not_found", "связь с внешним провайдером не найдена")
+
+// externalIdentityKey — ключ связи с внешним провайдером в хранилище.
+type externalIdentityKey struct {
+	issuer  string
+	subject string
+}
+
+// ExternalIdentityRepository реализует интерфейс ExternalIdentityRepository
+// в памяти.
+type ExternalIdentityRepository struct {
+	store *Store
+}
+
+// NewExternalIdentityRepository создает новый экземпляр ExternalIdentityRepository.
+func NewExternalIdentityRepository(store *Store) *ExternalIdentityRepository {
+	return &ExternalIdentityRepository{store: store}
+}
+
+func (r *ExternalIdentityRepository) Create(ctx context.Context, identity *domain.ExternalIdentity) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	key := externalIdentityKey{issuer: identity.Issuer, subject: identity.Subject}
+	if _, ok := r.store.externalIdentities[key]; ok {
+		return errExists
+	}
+	if _, ok := r.store.users[identity.UserID]; !ok {
+		return fmt.Errorf("ошибка при создании связи с внешним провайдером: пользователь %s не существует", identity.UserID)
+	}
+
+	copied := *identity
+	r.store.externalIdentities[key] = &copied
+	return nil
+}
+
+func (r *ExternalIdentityRepository) Get(ctx context.Context, issuer, subject string) (*domain.ExternalIdentity, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	identity, ok := r.store.externalIdentities[externalIdentityKey{issuer: issuer, subject: subject}]
+	if !ok {
+		return nil, errExternalIdentityNotFound
+	}
+	copied := *identity
+	return &copied, nil
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+var errLabelNotFound = domain.NewError(domain.ErrNotFound, "label.not_found", "метка не найдена")
+
+// LabelRepository реализует интерфейс LabelRepository в памяти.
+type LabelRepository struct {
+	store *Store
+}
+
+// NewLabelRepository создает новый экземпляр LabelRepository.
+func NewLabelRepository(store *Store) *LabelRepository {
+	return &LabelRepository{store: store}
+}
+
+func (r *LabelRepository) Create(ctx context.Context, label *domain.Label) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.labels[label.ID]; ok {
+		return errExists
+	}
+	if _, ok := r.store.users[label.UserID]; !ok {
+		return fmt.Errorf("ошибка при создании метки: пользователь %s не существует", label.UserID)
+	}
+
+	copied := *label
+	r.store.labels[label.ID] = &copied
+	return nil
+}
+
+func (r *LabelRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Label, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	label, ok := r.store.labels[id]
+	if !ok {
+		return nil, errLabelNotFound
+	}
+
+	copied := *label
+	return &copied, nil
+}
+
+func (r *LabelRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Label, error) {
+	return r.List(ctx, userID, repository.LabelFilter{})
+}
+
+// List возвращает страницу меток пользователя.
+func (r *LabelRepository) List(ctx context.Context, userID uuid.UUID, filter repository.LabelFilter) ([]*domain.Label, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	labels := []*domain.Label{}
+	for _, label := range r.store.labels {
+		if label.UserID != userID {
+			continue
+		}
+		if filter.Search != "" && !containsFold(label.Name, filter.Search) {
+			continue
+		}
+		copied := *label
+		labels = append(labels, &copied)
+	}
+
+	key := func(label *domain.Label) sortKey {
+		return sortKey{value: repository.LabelSortValue(label, filter.Sort.Field), id: label.ID}
+	}
+	return paginate(labels, key, false, filter.Sort, filter.After, filter.Limit), nil
+}
+
+func (r *LabelRepository) Update(ctx context.Context, label *domain.Label) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.labels[label.ID]
+	if !ok {
+		return errLabelNotFound
+	}
+
+	existing.Name = label.Name
+	existing.Color = label.Color
+	return nil
+}
+
+// Delete удаляет метку и отвязывает ее от задач.
+func (r *LabelRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.labels[id]; !ok {
+		return errLabelNotFound
+	}
+	deleteLabel(r.store, id)
+	return nil
+}
+
+// deleteLabel удаляет метку и ее привязки к задачам. Вызывается под блокировкой.
+func deleteLabel(store *Store, id uuid.UUID) {
+	delete(store.labels, id)
+	for _, task := range store.tasks {
+		task.LabelIDs = slices.DeleteFunc(task.LabelIDs, func(labelID uuid.UUID) bool {
+			return labelID == id
+		})
+	}
+}
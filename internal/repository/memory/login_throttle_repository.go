@@ -0,0 +1,89 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+)
+
+var errLoginThrottleNotFound = domain.NewError(domain.ErrNotFound, "not_found", "неудачных попыток входа не было")
+
+// LoginThrottleRepository реализует интерфейс LoginThrottleRepository в памяти.
+type LoginThrottleRepository struct {
+	store *Store
+}
+
+// NewLoginThrottleRepository создает новый экземпляр LoginThrottleRepository.
+func NewLoginThrottleRepository(store *Store) *LoginThrottleRepository {
+	return &LoginThrottleRepository{store: store}
+}
+
+func (r *LoginThrottleRepository) Get(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	throttle, ok := r.store.loginThrottles[key]
+	if !ok {
+		return nil, errLoginThrottleNotFound
+	}
+
+	return copyThrottle(throttle), nil
+}
+
+func (r *LoginThrottleRepository) RecordFailure(ctx context.Context, key string, at, resetBefore time.Time) (*domain.LoginThrottle, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for k, throttle := range r.store.loginThrottles {
+		if throttle.LastFailureAt.Before(resetBefore) && !throttle.Locked(at) {
+			delete(r.store.loginThrottles, k)
+		}
+	}
+
+	throttle, ok := r.store.loginThrottles[key]
+	if !ok {
+		throttle = &domain.LoginThrottle{Key: key}
+		r.store.loginThrottles[key] = throttle
+	}
+	if throttle.LastFailureAt.Before(resetBefore) {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = at
+
+	return copyThrottle(throttle), nil
+}
+
+func (r *LoginThrottleRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	throttle, ok := r.store.loginThrottles[key]
+	if !ok {
+		return errLoginThrottleNotFound
+	}
+
+	throttle.LockedUntil = &until
+	return nil
+}
+
+func (r *LoginThrottleRepository) Delete(ctx context.Context, keys ...string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, key := range keys {
+		delete(r.store.loginThrottles, key)
+	}
+	return nil
+}
+
+// copyThrottle копирует счетчик вместе с моментом окончания блокировки.
+func copyThrottle(throttle *domain.LoginThrottle) *domain.LoginThrottle {
+	copied := *throttle
+	if throttle.LockedUntil != nil {
+		lockedUntil := *throttle.LockedUntil
+		copied.LockedUntil = &lockedUntil
+	}
+	return &copied
+}
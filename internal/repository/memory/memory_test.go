@@ -0,0 +1,31 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/MosinEvgeny/task-tracker/internal/repository/repotest"
+)
+
+func TestRepositories(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		store := NewStore()
+		return repotest.Repositories{
+			Users:         NewUserRepository(store),
+			Tasks:         NewTaskRepository(store),
+			Labels:        NewLabelRepository(store),
+			RefreshTokens: NewRefreshTokenRepository(store),
+			Sessions:      NewSessionRepository(store),
+			DenyList:      NewDenyListRepository(store),
+
+			PasswordResets: NewPasswordResetRepository(store),
+			TOTP:           NewTOTPRepository(store),
+			LoginThrottles: NewLoginThrottleRepository(store),
+			Audit:          NewAuditRepository(store),
+			PersonalTokens: NewPersonalAccessTokenRepository(store),
+			OAuthClients:   NewOAuthClientRepository(store),
+			OAuthCodes:     NewOAuthCodeRepository(store),
+
+			ExternalIdentities: NewExternalIdentityRepository(store),
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	errOAuthClientNotFound = domain.NewError(domain.ErrNotFound, "oauth_client.not_found", "клиент OAuth не найден")
+	errOAuthCodeNotFound   = domain.NewError(domain.ErrNotFound, "oauth.code_not_found", "код авторизации не найден")
+)
+
+// OAuthClientRepository реализует интерфейс OAuthClientRepository в памяти.
+type OAuthClientRepository struct {
+	store *Store
+}
+
+// NewOAuthClientRepository создает новый экземпляр OAuthClientRepository.
+func NewOAuthClientRepository(store *Store) *OAuthClientRepository {
+	return &OAuthClientRepository{store: store}
+}
+
+func (r *OAuthClientRepository) Create(ctx context.Context, client *domain.OAuthClient) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.oauthClients[client.ID]; ok {
+		return errExists
+	}
+	if _, ok := r.store.users[client.OwnerID]; !ok {
+		return fmt.Errorf("ошибка при создании клиента OAuth: пользователь %s не существует", client.OwnerID)
+	}
+
+	r.store.oauthClients[client.ID] = copyOAuthClient(client)
+	return nil
+}
+
+func (r *OAuthClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.OAuthClient, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	client, ok := r.store.oauthClients[id]
+	if !ok {
+		return nil, errOAuthClientNotFound
+	}
+	return copyOAuthClient(client), nil
+}
+
+func (r *OAuthClientRepository) ListByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*domain.OAuthClient, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	clients := []*domain.OAuthClient{}
+	for _, client := range r.store.oauthClients {
+		if client.OwnerID == ownerID {
+			clients = append(clients, copyOAuthClient(client))
+		}
+	}
+
+	slices.SortFunc(clients, func(a, b *domain.OAuthClient) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+	return clients, nil
+}
+
+// Delete удаляет клиента вместе с его кодами авторизации.
+func (r *OAuthClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.oauthClients[id]; !ok {
+		return errOAuthClientNotFound
+	}
+	deleteOAuthClient(r.store, id)
+	return nil
+}
+
+// deleteOAuthClient удаляет клиента и его коды авторизации. Вызывается под
+// блокировкой.
+func deleteOAuthClient(store *Store, id uuid.UUID) {
+	delete(store.oauthClients, id)
+	for hash, code := range store.oauthCodes {
+		if code.ClientID == id {
+			delete(store.oauthCodes, hash)
+		}
+	}
+}
+
+func copyOAuthClient(client *domain.OAuthClient) *domain.OAuthClient {
+	copied := *client
+	copied.RedirectURIs = slices.Clone(client.RedirectURIs)
+	copied.Scopes = slices.Clone(client.Scopes)
+	return &copied
+}
+
+// OAuthCodeRepository реализует интерфейс OAuthCodeRepository в памяти.
+type OAuthCodeRepository struct {
+	store *Store
+}
+
+// NewOAuthCodeRepository создает новый экземпляр OAuthCodeRepository.
+func NewOAuthCodeRepository(store *Store) *OAuthCodeRepository {
+	return &OAuthCodeRepository{store: store}
+}
+
+func (r *OAuthCodeRepository) Create(ctx context.Context, code *domain.OAuthAuthorizationCode) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.oauthCodes[code.CodeHash]; ok {
+		return errExists
+	}
+	if _, ok := r.store.oauthClients[code.ClientID]; !ok {
+		return fmt.Errorf("ошибка при создании кода авторизации: клиент %s не существует", code.ClientID)
+	}
+	if _, ok := r.store.users[code.UserID]; !ok {
+		return fmt.Errorf("ошибка при создании кода авторизации: пользователь %s не существует", code.UserID)
+	}
+
+	copied := *code
+	copied.Scopes = slices.Clone(code.Scopes)
+	r.store.oauthCodes[code.CodeHash] = &copied
+	return nil
+}
+
+func (r *OAuthCodeRepository) Consume(ctx context.Context, codeHash string) (*domain.OAuthAuthorizationCode, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	code, ok := r.store.oauthCodes[codeHash]
+	if !ok {
+		return nil, errOAuthCodeNotFound
+	}
+	delete(r.store.oauthCodes, codeHash)
+	return code, nil
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+var errPasswordResetTokenNotFound = domain.NewError(domain.ErrNotFound, "password.reset_token_not_found", "токен сброса пароля не найден")
+
+// PasswordResetRepository реализует интерфейс PasswordResetRepository в памяти.
+type PasswordResetRepository struct {
+	store *Store
+}
+
+// NewPasswordResetRepository создает новый экземпляр PasswordResetRepository.
+func NewPasswordResetRepository(store *Store) *PasswordResetRepository {
+	return &PasswordResetRepository{store: store}
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.passwordResetTokens[token.ID]; ok {
+		return errExists
+	}
+	if _, ok := r.store.users[token.UserID]; !ok {
+		return fmt.Errorf("ошибка при создании токена сброса пароля: пользователь %s не существует", token.UserID)
+	}
+	for _, existing := range r.store.passwordResetTokens {
+		if existing.TokenHash == token.TokenHash {
+			return errExists
+		}
+	}
+
+	copied := *token
+	r.store.passwordResetTokens[token.ID] = &copied
+	return nil
+}
+
+func (r *PasswordResetRepository) Consume(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, token := range r.store.passwordResetTokens {
+		if token.TokenHash == tokenHash {
+			delete(r.store.passwordResetTokens, id)
+			return token, nil
+		}
+	}
+	return nil, errPasswordResetTokenNotFound
+}
+
+func (r *PasswordResetRepository) DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, token := range r.store.passwordResetTokens {
+		if token.UserID == userID {
+			delete(r.store.passwordResetTokens, id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+var errPersonalAccessTokenNotFound = domain.NewError(domain.ErrNotFound, "token.not_found", "токен доступа не найден")
+
+// PersonalAccessTokenRepository реализует интерфейс
+// PersonalAccessTokenRepository в памяти.
+type PersonalAccessTokenRepository struct {
+	store *Store
+}
+
+// NewPersonalAccessTokenRepository создает новый экземпляр PersonalAccessTokenRepository.
+func NewPersonalAccessTokenRepository(store *Store) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{store: store}
+}
+
+func (r *PersonalAccessTokenRepository) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.personalTokens[token.ID]; ok {
+		return errExists
+	}
+	if _, ok := r.store.users[token.UserID]; !ok {
+		return fmt.Errorf("ошибка при создании токена доступа: пользователь %s не существует", token.UserID)
+	}
+	for _, existing := range r.store.personalTokens {
+		if existing.TokenHash == token.TokenHash {
+			return errExists
+		}
+	}
+
+	r.store.personalTokens[token.ID] = copyPersonalToken(token)
+	return nil
+}
+
+func (r *PersonalAccessTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PersonalAccessToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	token, ok := r.store.personalTokens[id]
+	if !ok {
+		return nil, errPersonalAccessTokenNotFound
+	}
+	return copyPersonalToken(token), nil
+}
+
+func (r *PersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, token := range r.store.personalTokens {
+		if token.TokenHash == tokenHash {
+			return copyPersonalToken(token), nil
+		}
+	}
+	return nil, errPersonalAccessTokenNotFound
+}
+
+func (r *PersonalAccessTokenRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.PersonalAccessToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tokens := []*domain.PersonalAccessToken{}
+	for _, token := range r.store.personalTokens {
+		if token.UserID == userID {
+			tokens = append(tokens, copyPersonalToken(token))
+		}
+	}
+
+	slices.SortFunc(tokens, func(a, b *domain.PersonalAccessToken) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+	return tokens, nil
+}
+
+func (r *PersonalAccessTokenRepository) Touch(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	token, ok := r.store.personalTokens[id]
+	if !ok {
+		return errPersonalAccessTokenNotFound
+	}
+
+	token.LastUsedAt = &lastUsedAt
+	return nil
+}
+
+func (r *PersonalAccessTokenRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.personalTokens[id]; !ok {
+		return errPersonalAccessTokenNotFound
+	}
+	delete(r.store.personalTokens, id)
+	return nil
+}
+
+// copyPersonalToken копирует токен вместе со списком разрешений и
+// указателями на время, чтобы вызывающий код не изменял данные хранилища.
+func copyPersonalToken(token *domain.PersonalAccessToken) *domain.PersonalAccessToken {
+	copied := *token
+	copied.Scopes = slices.Clone(token.Scopes)
+	if token.ExpiresAt != nil {
+		expiresAt := *token.ExpiresAt
+		copied.ExpiresAt = &expiresAt
+	}
+	if token.LastUsedAt != nil {
+		lastUsedAt := *token.LastUsedAt
+		copied.LastUsedAt = &lastUsedAt
+	}
+	return &copied
+}
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+// sortKey — значение поля сортировки записи и ее ID, как в keyset-условии
+// (column, id) > ($1, $2) реализации для PostgreSQL.
+type sortKey struct {
+	value string
+	id    uuid.UUID
+}
+
+// compareKeys сравнивает ключи сортировки. Значения полей времени
+// сравниваются как моменты времени, остальные — как строки.
+func compareKeys(a, b sortKey, isTime bool) int {
+	if c := compareValues(a.value, b.value, isTime); c != 0 {
+		return c
+	}
+	return compareIDs(a.id, b.id)
+}
+
+func compareValues(a, b string, isTime bool) int {
+	if isTime {
+		at, errA := time.Parse(time.RFC3339Nano, a)
+		bt, errB := time.Parse(time.RFC3339Nano, b)
+		if errA == nil && errB == nil {
+			return at.Compare(bt)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// paginate сортирует записи, отбрасывает записи до курсора и ограничивает
+// размер страницы. Нулевой limit означает выборку без ограничения.
+func paginate[T any](items []T, key func(T) sortKey, isTime bool, sort repository.Sort, after *repository.Cursor, limit int) []T {
+	direction := 1
+	if sort.Desc {
+		direction = -1
+	}
+
+	slices.SortFunc(items, func(a, b T) int {
+		return direction * compareKeys(key(a), key(b), isTime)
+	})
+
+	if after != nil {
+		cursor := sortKey{value: after.Value, id: after.ID}
+		items = slices.DeleteFunc(items, func(item T) bool {
+			return direction*compareKeys(key(item), cursor, isTime) <= 0
+		})
+	}
+
+	if limit > 0 && len(items) > limit {
+		items = items[:limit]
+	}
+	return items
+}
+
+// containsFold сообщает, содержит ли s подстроку substr без учета регистра.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	errRefreshTokenNotFound = domain.NewError(domain.ErrNotFound, "auth.refresh_token_not_found", "refresh токен не найден")
+	errRefreshTokenExists   = domain.NewError(domain.ErrConflict, "auth.refresh_token_exists", "refresh токен уже существует")
+	errRefreshTokenUsed     = domain.NewError(domain.ErrConflict, "auth.refresh_token_used", "refresh токен уже использован")
+)
+
+// RefreshTokenRepository реализует интерфейс RefreshTokenRepository в памяти.
+type RefreshTokenRepository struct {
+	store *Store
+}
+
+// NewRefreshTokenRepository создает новый экземпляр RefreshTokenRepository.
+func NewRefreshTokenRepository(store *Store) *RefreshTokenRepository {
+	return &RefreshTokenRepository{store: store}
+}
+
+func (r *RefreshTokenRepository) Create(ctx context.Context, refreshToken *domain.RefreshToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.refreshTokens[refreshToken.ID]; ok {
+		return errRefreshTokenExists
+	}
+	if _, ok := r.store.sessions[refreshToken.FamilyID]; !ok {
+		return fmt.Errorf("ошибка при создании refresh токена: сессия %s не существует", refreshToken.FamilyID)
+	}
+	for _, existing := range r.store.refreshTokens {
+		if existing.TokenHash == refreshToken.TokenHash {
+			return errRefreshTokenExists
+		}
+	}
+
+	copied := *refreshToken
+	copied.Token = ""
+	r.store.refreshTokens[refreshToken.ID] = &copied
+	return nil
+}
+
+func (r *RefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, refreshToken := range r.store.refreshTokens {
+		if refreshToken.TokenHash == tokenHash {
+			copied := *refreshToken
+			return &copied, nil
+		}
+	}
+	return nil, errRefreshTokenNotFound
+}
+
+func (r *RefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	refreshToken, ok := r.store.refreshTokens[id]
+	if !ok || refreshToken.UsedAt != nil {
+		return errRefreshTokenUsed
+	}
+
+	refreshToken.UsedAt = &usedAt
+	return nil
+}
+
+func (r *RefreshTokenRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.refreshTokens[id]; !ok {
+		return errRefreshTokenNotFound
+	}
+	delete(r.store.refreshTokens, id)
+	return nil
+}
+
+func (r *RefreshTokenRepository) DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, refreshToken := range r.store.refreshTokens {
+		if refreshToken.UserID == userID {
+			delete(r.store.refreshTokens, id)
+		}
+	}
+	return nil
+}
+
+func (r *RefreshTokenRepository) DeleteByFamilyID(ctx context.Context, familyID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, refreshToken := range r.store.refreshTokens {
+		if refreshToken.FamilyID == familyID {
+			delete(r.store.refreshTokens, id)
+		}
+	}
+	return nil
+}
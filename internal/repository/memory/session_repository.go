@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+var errSessionNotFound = domain.NewError(domain.ErrNotFound, "session.not_found", "сессия не найдена")
+
+// SessionRepository реализует интерфейс SessionRepository в памяти.
+type SessionRepository struct {
+	store *Store
+}
+
+// NewSessionRepository создает новый экземпляр SessionRepository.
+func NewSessionRepository(store *Store) *SessionRepository {
+	return &SessionRepository{store: store}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.sessions[session.ID]; ok {
+		return errExists
+	}
+	if _, ok := r.store.users[session.UserID]; !ok {
+		return fmt.Errorf("ошибка при создании сессии: пользователь %s не существует", session.UserID)
+	}
+
+	copied := *session
+	r.store.sessions[session.ID] = &copied
+	return nil
+}
+
+func (r *SessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return nil, errSessionNotFound
+	}
+
+	copied := *session
+	return &copied, nil
+}
+
+func (r *SessionRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	sessions := []*domain.Session{}
+	for _, session := range r.store.sessions {
+		if session.UserID == userID {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+
+	slices.SortFunc(sessions, func(a, b *domain.Session) int {
+		if c := b.LastUsedAt.Compare(a.LastUsedAt); c != 0 {
+			return c
+		}
+		return compareIDs(a.ID, b.ID)
+	})
+	return sessions, nil
+}
+
+func (r *SessionRepository) Touch(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	session, ok := r.store.sessions[id]
+	if !ok {
+		return errSessionNotFound
+	}
+
+	session.LastUsedAt = lastUsedAt
+	return nil
+}
+
+// Delete удаляет сессию вместе с ее refresh токенами.
+func (r *SessionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.sessions[id]; !ok {
+		return errSessionNotFound
+	}
+	deleteSession(r.store, id)
+	return nil
+}
+
+func (r *SessionRepository) DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, session := range r.store.sessions {
+		if session.UserID == userID {
+			deleteSession(r.store, id)
+		}
+	}
+	return nil
+}
+
+// deleteSession удаляет сессию и ее refresh токены. Вызывается под блокировкой.
+func deleteSession(store *Store, id uuid.UUID) {
+	delete(store.sessions, id)
+	for tokenID, token := range store.refreshTokens {
+		if token.FamilyID == id {
+			delete(store.refreshTokens, tokenID)
+		}
+	}
+}
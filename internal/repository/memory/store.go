@@ -0,0 +1,73 @@
+// Package memory реализует интерфейсы репозиториев в памяти процесса. Данные
+// теряются при перезапуске; хранилище предназначено для локального запуска
+// и тестов без базы данных. Семантика совпадает с реализацией для PostgreSQL:
+// ошибки domain.ErrNotFound и domain.ErrConflict, каскадное удаление данных
+// пользователя и отвязка удаленных меток от задач.
+package memory
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Store — общее хранилище всех репозиториев. Репозитории одного Store видят
+// данные друг друга, поэтому удаление пользователя удаляет его задачи,
+// метки, сессии, токены (в том числе персональные), секреты двухфакторной
+// аутентификации, клиентов OAuth, коды авторизации и связи с внешними
+// провайдерами, как внешние ключи в базе данных.
+type Store struct {
+	mu            sync.RWMutex
+	users         map[uuid.UUID]*domain.User
+	tasks         map[uuid.UUID]*domain.Task
+	labels        map[uuid.UUID]*domain.Label
+	refreshTokens map[uuid.UUID]*domain.RefreshToken
+	sessions      map[uuid.UUID]*domain.Session
+	deniedTokens  map[uuid.UUID]time.Time // jti отозванного токена -> срок его действия
+
+	passwordResetTokens map[uuid.UUID]*domain.PasswordResetToken
+	totps               map[uuid.UUID]*domain.TOTP
+	recoveryCodes       map[uuid.UUID]map[string]bool // ID пользователя -> хеши кодов
+	personalTokens      map[uuid.UUID]*domain.PersonalAccessToken
+	oauthClients        map[uuid.UUID]*domain.OAuthClient
+	oauthCodes          map[string]*domain.OAuthAuthorizationCode // хеш кода -> код
+	externalIdentities  map[externalIdentityKey]*domain.ExternalIdentity
+
+	// Счетчики попыток входа и журнал аудита не удаляются вместе с пользователем
+	loginThrottles map[string]*domain.LoginThrottle
+	auditEvents    []*domain.AuditEvent
+}
+
+// NewStore создает пустое хранилище.
+func NewStore() *Store {
+	return &Store{
+		users:         make(map[uuid.UUID]*domain.User),
+		tasks:         make(map[uuid.UUID]*domain.Task),
+		labels:        make(map[uuid.UUID]*domain.Label),
+		refreshTokens: make(map[uuid.UUID]*domain.RefreshToken),
+		sessions:      make(map[uuid.UUID]*domain.Session),
+		deniedTokens:  make(map[uuid.UUID]time.Time),
+
+		passwordResetTokens: make(map[uuid.UUID]*domain.PasswordResetToken),
+		totps:               make(map[uuid.UUID]*domain.TOTP),
+		recoveryCodes:       make(map[uuid.UUID]map[string]bool),
+		personalTokens:      make(map[uuid.UUID]*domain.PersonalAccessToken),
+		oauthClients:        make(map[uuid.UUID]*domain.OAuthClient),
+		oauthCodes:          make(map[string]*domain.OAuthAuthorizationCode),
+		externalIdentities:  make(map[externalIdentityKey]*domain.ExternalIdentity),
+
+		loginThrottles: make(map[string]*domain.LoginThrottle),
+	}
+}
+
+// compareIDs сравнивает UUID в том же порядке, что и PostgreSQL.
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// errExists возвращается при повторном создании записи с тем же ID, как
+// нарушение первичного ключа в базе данных.
+var errExists = domain.NewError(domain.ErrConflict, "conflict", "запись уже существует")
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+var errTaskNotFound = domain.NewError(domain.ErrNotFound, "task.not_found", "задача не найдена")
+
+// TaskRepository реализует интерфейс TaskRepository в памяти.
+type TaskRepository struct {
+	store *Store
+}
+
+// NewTaskRepository создает новый экземпляр TaskRepository.
+func NewTaskRepository(store *Store) *TaskRepository {
+	return &TaskRepository{store: store}
+}
+
+func (r *TaskRepository) Create(ctx context.Context, task *domain.Task) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.tasks[task.ID]; ok {
+		return errExists
+	}
+	if _, ok := r.store.users[task.UserID]; !ok {
+		return fmt.Errorf("ошибка при создании задачи: пользователь %s не существует", task.UserID)
+	}
+	labelIDs, err := r.labelIDs(task.LabelIDs)
+	if err != nil {
+		return err
+	}
+
+	copied := *task
+	copied.LabelIDs = labelIDs
+	r.store.tasks[task.ID] = &copied
+	return nil
+}
+
+func (r *TaskRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	task, ok := r.store.tasks[id]
+	if !ok {
+		return nil, errTaskNotFound
+	}
+	return copyTask(task), nil
+}
+
+func (r *TaskRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	return r.List(ctx, userID, repository.TaskFilter{})
+}
+
+// List возвращает страницу задач пользователя.
+func (r *TaskRepository) List(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter) ([]*domain.Task, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	tasks := []*domain.Task{}
+	for _, task := range r.store.tasks {
+		if task.UserID == userID && matchTask(task, filter) {
+			tasks = append(tasks, copyTask(task))
+		}
+	}
+
+	key := func(task *domain.Task) sortKey {
+		return sortKey{value: repository.TaskSortValue(task, filter.Sort.Field), id: task.ID}
+	}
+	isTime := filter.Sort.Field != repository.TaskSortTitle
+	return paginate(tasks, key, isTime, filter.Sort, filter.After, filter.Limit), nil
+}
+
+func (r *TaskRepository) Update(ctx context.Context, task *domain.Task) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.tasks[task.ID]
+	if !ok {
+		return errTaskNotFound
+	}
+	labelIDs, err := r.labelIDs(task.LabelIDs)
+	if err != nil {
+		return err
+	}
+
+	existing.Title = task.Title
+	existing.Description = task.Description
+	existing.DueDate = task.DueDate
+	existing.Status = task.Status
+	existing.CompletedAt = task.CompletedAt
+	existing.LabelIDs = labelIDs
+	return nil
+}
+
+func (r *TaskRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.tasks[id]; !ok {
+		return errTaskNotFound
+	}
+	delete(r.store.tasks, id)
+	return nil
+}
+
+// labelIDs убирает повторы из списка меток и проверяет, что метки
+// существуют. Вызывается под блокировкой.
+func (r *TaskRepository) labelIDs(ids []uuid.UUID) ([]uuid.UUID, error) {
+	unique := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := r.store.labels[id]; !ok {
+			return nil, fmt.Errorf("ошибка при привязке метки к задаче: метка %s не существует", id)
+		}
+		if !slices.Contains(unique, id) {
+			unique = append(unique, id)
+		}
+	}
+	return unique, nil
+}
+
+// matchTask проверяет, подходит ли задача под условия фильтра.
+func matchTask(task *domain.Task, filter repository.TaskFilter) bool {
+	if filter.DueBefore != nil && !task.DueDate.Before(*filter.DueBefore) {
+		return false
+	}
+	if filter.DueAfter != nil && !task.DueDate.After(*filter.DueAfter) {
+		return false
+	}
+	if len(filter.LabelIDs) > 0 && !slices.ContainsFunc(task.LabelIDs, func(id uuid.UUID) bool {
+		return slices.Contains(filter.LabelIDs, id)
+	}) {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, task.Status) {
+		return false
+	}
+	if filter.Search != "" && !containsFold(task.Title, filter.Search) && !containsFold(task.Description, filter.Search) {
+		return false
+	}
+	return true
+}
+
+// copyTask возвращает копию задачи, не разделяющую с ней список меток.
+func copyTask(task *domain.Task) *domain.Task {
+	copied := *task
+	copied.LabelIDs = slices.Clone(task.LabelIDs)
+	if copied.LabelIDs == nil {
+		copied.LabelIDs = []uuid.UUID{}
+	}
+	return &copied
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	errTOTPNotFound         = domain.NewError(domain.ErrNotFound, "mfa.not_enabled", "двухфакторная аутентификация не настроена")
+	errTOTPStepUsed         = domain.NewError(domain.ErrConflict, "mfa.code_used", "код уже использован")
+	errRecoveryCodeNotFound = domain.NewError(domain.ErrNotFound, "mfa.recovery_code_not_found", "код восстановления не найден")
+)
+
+// TOTPRepository реализует интерфейс TOTPRepository в памяти.
+type TOTPRepository struct {
+	store *Store
+}
+
+// NewTOTPRepository создает новый экземпляр TOTPRepository.
+func NewTOTPRepository(store *Store) *TOTPRepository {
+	return &TOTPRepository{store: store}
+}
+
+func (r *TOTPRepository) Save(ctx context.Context, totp *domain.TOTP) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[totp.UserID]; !ok {
+		return fmt.Errorf("ошибка при сохранении секрета TOTP: пользователь %s не существует", totp.UserID)
+	}
+
+	copied := *totp
+	r.store.totps[totp.UserID] = &copied
+	return nil
+}
+
+func (r *TOTPRepository) Get(ctx context.Context, userID uuid.UUID) (*domain.TOTP, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	totp, ok := r.store.totps[userID]
+	if !ok {
+		return nil, errTOTPNotFound
+	}
+
+	copied := *totp
+	return &copied, nil
+}
+
+func (r *TOTPRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	totp, ok := r.store.totps[userID]
+	if !ok {
+		return errTOTPNotFound
+	}
+	if totp.LastUsedStep >= step {
+		return errTOTPStepUsed
+	}
+
+	totp.LastUsedStep = step
+	return nil
+}
+
+func (r *TOTPRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.totps[userID]; !ok {
+		return errTOTPNotFound
+	}
+	delete(r.store.totps, userID)
+	delete(r.store.recoveryCodes, userID)
+	return nil
+}
+
+func (r *TOTPRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[userID]; !ok {
+		return fmt.Errorf("ошибка при сохранении кодов восстановления: пользователь %s не существует", userID)
+	}
+
+	codes := make(map[string]bool, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes[hash] = true
+	}
+	r.store.recoveryCodes[userID] = codes
+	return nil
+}
+
+func (r *TOTPRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if !r.store.recoveryCodes[userID][codeHash] {
+		return errRecoveryCodeNotFound
+	}
+	delete(r.store.recoveryCodes[userID], codeHash)
+	return nil
+}
+
+func (r *TOTPRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	return len(r.store.recoveryCodes[userID]), nil
+}
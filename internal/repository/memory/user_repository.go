@@ -0,0 +1,215 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+var (
+	errUserNotFound = domain.NewError(domain.ErrNotFound, "user.not_found", "пользователь не найден")
+	errEmailTaken   = domain.NewError(domain.ErrConflict, "user.email_taken", "пользователь с таким email уже существует")
+)
+
+// UserRepository реализует интерфейс UserRepository в памяти.
+type UserRepository struct {
+	store *Store
+}
+
+// NewUserRepository создает новый экземпляр UserRepository.
+func NewUserRepository(store *Store) *UserRepository {
+	return &UserRepository{store: store}
+}
+
+func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[user.ID]; ok || r.emailTaken(user.Email, user.ID) {
+		return errEmailTaken
+	}
+
+	copied := *user
+	r.store.users[user.ID] = &copied
+	return nil
+}
+
+func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return nil, errUserNotFound
+	}
+
+	copied := *user
+	return &copied, nil
+}
+
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.store.mu.RLock()
+	defer r.store.mu.RUnlock()
+
+	for _, user := range r.store.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, errUserNotFound
+}
+
+// Update обновляет данные пользователя. Момент отзыва токенов и права
+// администратора меняются только через SetTokensValidAfter и SetAdmin.
+func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing, ok := r.store.users[user.ID]
+	if !ok {
+		return errUserNotFound
+	}
+	if r.emailTaken(user.Email, user.ID) {
+		return errEmailTaken
+	}
+
+	copied := *user
+	copied.Password = existing.Password
+	copied.TokensValidAfter = existing.TokensValidAfter
+	copied.IsAdmin = existing.IsAdmin
+	r.store.users[user.ID] = &copied
+	return nil
+}
+
+func (r *UserRepository) SetTokensValidAfter(ctx context.Context, id uuid.UUID, validAfter time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return errUserNotFound
+	}
+
+	copied := *user
+	copied.TokensValidAfter = &validAfter
+	r.store.users[id] = &copied
+	return nil
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok || user.Email != email {
+		return errUserNotFound
+	}
+
+	copied := *user
+	copied.EmailVerified = true
+	r.store.users[id] = &copied
+	return nil
+}
+
+func (r *UserRepository) ReplacePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok || user.Password != oldHash {
+		return errUserNotFound
+	}
+
+	copied := *user
+	copied.Password = newHash
+	r.store.users[id] = &copied
+	return nil
+}
+
+func (r *UserRepository) SetAdmin(ctx context.Context, id uuid.UUID, isAdmin bool) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	user, ok := r.store.users[id]
+	if !ok {
+		return errUserNotFound
+	}
+
+	copied := *user
+	copied.IsAdmin = isAdmin
+	r.store.users[id] = &copied
+	return nil
+}
+
+// Delete удаляет пользователя вместе с его задачами, метками, сессиями и токенами.
+func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.users[id]; !ok {
+		return errUserNotFound
+	}
+	delete(r.store.users, id)
+
+	for taskID, task := range r.store.tasks {
+		if task.UserID == id {
+			delete(r.store.tasks, taskID)
+		}
+	}
+	for labelID, label := range r.store.labels {
+		if label.UserID == id {
+			deleteLabel(r.store, labelID)
+		}
+	}
+	for sessionID, session := range r.store.sessions {
+		if session.UserID == id {
+			deleteSession(r.store, sessionID)
+		}
+	}
+	for tokenID, token := range r.store.refreshTokens {
+		if token.UserID == id {
+			delete(r.store.refreshTokens, tokenID)
+		}
+	}
+	for tokenID, token := range r.store.passwordResetTokens {
+		if token.UserID == id {
+			delete(r.store.passwordResetTokens, tokenID)
+		}
+	}
+	for tokenID, token := range r.store.personalTokens {
+		if token.UserID == id {
+			delete(r.store.personalTokens, tokenID)
+		}
+	}
+	for clientID, client := range r.store.oauthClients {
+		if client.OwnerID == id {
+			deleteOAuthClient(r.store, clientID)
+		}
+	}
+	for hash, code := range r.store.oauthCodes {
+		if code.UserID == id {
+			delete(r.store.oauthCodes, hash)
+		}
+	}
+	for key, identity := range r.store.externalIdentities {
+		if identity.UserID == id {
+			delete(r.store.externalIdentities, key)
+		}
+	}
+	delete(r.store.totps, id)
+	delete(r.store.recoveryCodes, id)
+	return nil
+}
+
+// emailTaken сообщает, занят ли email другим пользователем. Вызывается под блокировкой.
+func (r *UserRepository) emailTaken(email string, id uuid.UUID) bool {
+	for _, user := range r.store.users {
+		if user.Email == email && user.ID != id {
+			return true
+		}
+	}
+	return false
+}
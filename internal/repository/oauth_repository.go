@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// OAuthClientRepository определяет интерфейс для работы с
+// зарегистрированными клиентами OAuth2.
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *domain.OAuthClient) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.OAuthClient, error)
+	// ListByOwnerID возвращает клиентов пользователя, начиная с последнего созданного.
+	ListByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*domain.OAuthClient, error)
+	// Delete удаляет клиента вместе с его кодами авторизации.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// OAuthCodeRepository определяет интерфейс для работы с кодами авторизации.
+type OAuthCodeRepository interface {
+	Create(ctx context.Context, code *domain.OAuthAuthorizationCode) error
+	// Consume удаляет код с хешем codeHash и возвращает его. Из
+	// одновременных запросов с одним кодом его получает только один.
+	Consume(ctx context.Context, codeHash string) (*domain.OAuthAuthorizationCode, error)
+}
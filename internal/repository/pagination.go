@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Sort задает поле и направление сортировки выборки.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// String возвращает сортировку в формате параметра запроса ("-due_date").
+func (s Sort) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// parseSort разбирает строку вида "field" или "-field" и проверяет, что поле допустимо.
+func parseSort(value, defaultField string, allowed ...string) (Sort, error) {
+	if value == "" {
+		return Sort{Field: defaultField}, nil
+	}
+
+	sort := Sort{Field: value}
+	if strings.HasPrefix(value, "-") {
+		sort = Sort{Field: value[1:], Desc: true}
+	}
+
+	for _, field := range allowed {
+		if sort.Field == field {
+			return sort, nil
+		}
+	}
+	return Sort{}, domain.NewFieldError("sort", "query.invalid_sort", fmt.Sprintf("недопустимое поле сортировки: %s", sort.Field)).
+		WithParams(map[string]string{"field": sort.Field})
+}
+
+// Cursor указывает на последнюю запись страницы при keyset-пагинации.
+// Клиенту курсор передается в непрозрачном виде (см. EncodeCursor).
+type Cursor struct {
+	Sort  string    `json:"s"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// EncodeCursor кодирует курсор в строку для передачи клиенту.
+func EncodeCursor(cursor Cursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// errInvalidCursor возвращается, если курсор поврежден или выдан не этим сервисом.
+var errInvalidCursor = domain.NewFieldError("cursor", "query.invalid_cursor", "неверный курсор")
+
+// DecodeCursor декодирует курсор и проверяет, что он выдан для той же сортировки.
+func DecodeCursor(value string, sort Sort) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, errInvalidCursor
+	}
+	if cursor.Sort != sort.String() {
+		return nil, domain.NewFieldError("cursor", "query.cursor_sort_mismatch", "курсор не соответствует сортировке")
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// PasswordResetRepository определяет интерфейс для работы с токенами сброса пароля.
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *domain.PasswordResetToken) error
+	// Consume удаляет токен с хешем tokenHash и возвращает его. Из
+	// одновременных запросов с одним токеном его получает только один.
+	Consume(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error)
+	DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error
+}
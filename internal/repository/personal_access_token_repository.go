@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// PersonalAccessTokenRepository определяет интерфейс для работы с
+// персональными токенами доступа.
+type PersonalAccessTokenRepository interface {
+	Create(ctx context.Context, token *domain.PersonalAccessToken) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.PersonalAccessToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error)
+	// ListByUserID возвращает токены пользователя, начиная с последнего созданного.
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.PersonalAccessToken, error)
+	Touch(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+)
+
+// AuditRepository реализует интерфейс AuditRepository для работы с журналом
+// аудита в PostgreSQL.
+type AuditRepository struct {
+	db *PostgresDB
+}
+
+// NewAuditRepository создает новый экземпляр AuditRepository.
+func NewAuditRepository(db *PostgresDB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Create(ctx context.Context, event *domain.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (id, type, user_id, actor_id, subject, details, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, event.ID, event.Type, event.UserID, event.ActorID, event.Subject, event.Details, event.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewError(domain.ErrConflict, "conflict", "запись уже существует")
+		}
+		return fmt.Errorf("ошибка при записи события аудита: %w", err)
+	}
+
+	return nil
+}
+
+func (r *AuditRepository) List(ctx context.Context, limit int) ([]*domain.AuditEvent, error) {
+	query := `
+		SELECT id, type, user_id, actor_id, subject, details, created_at
+		FROM audit_events
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1
+	`
+
+	rows, err := r.db.DB.QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении событий аудита: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*domain.AuditEvent{}
+	for rows.Next() {
+		var event domain.AuditEvent
+		if err := rows.Scan(&event.ID, &event.Type, &event.UserID, &event.ActorID, &event.Subject, &event.Details, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании события аудита: %w", err)
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по событиям аудита: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,67 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// DenyListRepository реализует интерфейс DenyListRepository для хранения
+// отозванных access токенов в PostgreSQL.
+type DenyListRepository struct {
+	db *PostgresDB
+}
+
+// NewDenyListRepository создает новый экземпляр DenyListRepository.
+func NewDenyListRepository(db *PostgresDB) *DenyListRepository {
+	return &DenyListRepository{db: db}
+}
+
+// Add добавляет токен в список и удаляет записи об уже истекших токенах.
+// Запись об истекшем токене с тем же jti заменяется.
+func (r *DenyListRepository) Add(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	query := `
+		INSERT INTO denied_tokens (jti, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (jti) DO UPDATE SET expires_at = EXCLUDED.expires_at
+		WHERE denied_tokens.expires_at <= now()
+	`
+
+	result, err := r.db.DB.ExecContext(ctx, query, jti, expiresAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве access токена: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при получении количества затронутых строк: %w", err)
+	}
+
+	_, err = r.db.DB.ExecContext(ctx, `DELETE FROM denied_tokens WHERE expires_at <= now()`)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении истекших отозванных токенов: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrConflict
+	}
+	return nil
+}
+
+func (r *DenyListRepository) Contains(ctx context.Context, jti uuid.UUID, now time.Time) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM denied_tokens
+			WHERE jti = $1 AND expires_at > $2
+		)
+	`
+
+	var denied bool
+	if err := r.db.DB.QueryRowContext(ctx, query, jti, now).Scan(&denied); err != nil {
+		return false, fmt.Errorf("ошибка при проверке отзыва access токена: %w", err)
+	}
+
+	return denied, nil
+}
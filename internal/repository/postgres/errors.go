@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/lib/pq"
+)
+
+// uniqueViolation — код ошибки PostgreSQL при нарушении уникальности.
+const uniqueViolation = "23505"
+
+// isUniqueViolation сообщает, вызвана ли ошибка нарушением ограничения уникальности.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+// notFound возвращает ошибку категории domain.ErrNotFound, если err — sql.ErrNoRows.
+func notFound(err error, code, message string) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.NewError(domain.ErrNotFound, code, message)
+	}
+	return nil
+}
+
+// execer объединяет *sql.DB и *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// execAffecting выполняет запрос и возвращает ошибку категории
+// domain.ErrNotFound, если он не затронул ни одной строки.
+func execAffecting(ctx context.Context, exec execer, code, message string, query string, args ...any) error {
+	result, err := exec.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.NewError(domain.ErrNotFound, code, message)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+)
+
+// ExternalIdentityRepository реализует интерфейс ExternalIdentityRepository
+// для работы со связями с внешними провайдерами в PostgreSQL.
+type ExternalIdentityRepository struct {
+	db *PostgresDB
+}
+
+// NewExternalIdentityRepository создает новый экземпляр ExternalIdentityRepository.
+func NewExternalIdentityRepository(db *PostgresDB) *ExternalIdentityRepository {
+	return &ExternalIdentityRepository{db: db}
+}
+
+func (r *ExternalIdentityRepository) Create(ctx context.Context, identity *domain.ExternalIdentity) error {
+	query := `
+		INSERT INTO external_identities (issuer, subject, user_id, created_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, identity.Issuer, identity.Subject, identity.UserID, identity.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewError(domain.ErrConflict, "conflict", "запись уже существует")
+		}
+		return fmt.Errorf("ошибка при создании связи с внешним провайдером: %w", err)
+	}
+
+	return nil
+}
+
+func (r *ExternalIdentityRepository) Get(ctx context.Context, issuer, subject string) (*domain.ExternalIdentity, error) {
+	query := `
+		SELECT issuer, subject, user_id, created_at
+		FROM external_identities
+		WHERE issuer = $1 AND subject = $2
+	`
+
+	var identity domain.ExternalIdentity
+	err := r.db.DB.QueryRowContext(ctx, query, issuer, subject).
+		Scan(&identity.Issuer, &identity.Subject, &identity.UserID, &identity.CreatedAt)
+	if err != nil {
+		if err := notFound(err, "external_identity.not_found", "связь с внешним провайдером не найдена"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при получении связи с внешним провайдером: %w", err)
+	}
+
+	return &identity, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
 	"github.com/google/uuid"
 )
 
@@ -44,8 +45,8 @@ func (r *LabelRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.La
 
 	var label domain.Label
 	if err := row.Scan(&label.ID, &label.Name, &label.Color, &label.UserID); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("метка не найдена: %w", err)
+		if err := notFound(err, "label.not_found", "метка не найдена"); err != nil {
+			return nil, err
 		}
 		return nil, fmt.Errorf("ошибка при получении метки по ID: %w", err)
 	}
@@ -64,22 +65,34 @@ func (r *LabelRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении меток пользователя: %w", err)
 	}
-	defer rows.Close()
 
-	var labels []*domain.Label
-	for rows.Next() {
-		var label domain.Label
-		if err := rows.Scan(&label.ID, &label.Name, &label.Color, &label.UserID); err != nil {
-			return nil, fmt.Errorf("ошибка при сканировании метки: %w", err)
-		}
-		labels = append(labels, &label)
+	return scanLabels(rows)
+}
+
+// List возвращает страницу меток пользователя.
+func (r *LabelRepository) List(ctx context.Context, userID uuid.UUID, filter repository.LabelFilter) ([]*domain.Label, error) {
+	q := &queryBuilder{}
+	q.where("l.user_id = $%d", userID)
+
+	if filter.Search != "" {
+		q.where("l.name ILIKE $%d", likePattern(filter.Search))
+	}
+	if filter.After != nil {
+		q.keyset("l.name", "l.id", "", filter.Sort.Desc, filter.After)
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("ошибка при итерации по меткам: %w", err)
+	query := `
+		SELECT l.id, l.name, l.color, l.user_id
+		FROM labels l
+	` + q.whereClause() + `
+	` + q.orderBy("l.name", "l.id", filter.Sort.Desc, filter.Limit)
+
+	rows, err := r.db.DB.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении меток пользователя: %w", err)
 	}
 
-	return labels, nil
+	return scanLabels(rows)
 }
 
 func (r *LabelRepository) Update(ctx context.Context, label *domain.Label) error {
@@ -89,7 +102,7 @@ func (r *LabelRepository) Update(ctx context.Context, label *domain.Label) error
 		WHERE id = $1
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, label.ID, label.Name, label.Color)
+	err := execAffecting(ctx, r.db.DB, "label.not_found", "метка не найдена", query, label.ID, label.Name, label.Color)
 	if err != nil {
 		return fmt.Errorf("ошибка при обновлении метки: %w", err)
 	}
@@ -103,10 +116,30 @@ func (r *LabelRepository) Delete(ctx context.Context, id uuid.UUID) error {
 		WHERE id = $1
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, id)
+	err := execAffecting(ctx, r.db.DB, "label.not_found", "метка не найдена", query, id)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении метки: %w", err)
 	}
 
 	return nil
 }
+
+// scanLabels считывает все метки из результата запроса и закрывает его.
+func scanLabels(rows *sql.Rows) ([]*domain.Label, error) {
+	defer rows.Close()
+
+	labels := []*domain.Label{}
+	for rows.Next() {
+		var label domain.Label
+		if err := rows.Scan(&label.ID, &label.Name, &label.Color, &label.UserID); err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании метки: %w", err)
+		}
+		labels = append(labels, &label)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по меткам: %w", err)
+	}
+
+	return labels, nil
+}
@@ -0,0 +1,108 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/lib/pq"
+)
+
+// LoginThrottleRepository реализует интерфейс LoginThrottleRepository для
+// работы со счетчиками неудачных попыток входа в PostgreSQL.
+type LoginThrottleRepository struct {
+	db *PostgresDB
+}
+
+// NewLoginThrottleRepository создает новый экземпляр LoginThrottleRepository.
+func NewLoginThrottleRepository(db *PostgresDB) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+func (r *LoginThrottleRepository) Get(ctx context.Context, key string) (*domain.LoginThrottle, error) {
+	query := `
+		SELECT key, failures, last_failure_at, locked_until
+		FROM login_throttles
+		WHERE key = $1
+	`
+
+	throttle, err := scanThrottle(r.db.DB.QueryRowContext(ctx, query, key))
+	if err != nil {
+		if err := notFound(err, "not_found", "неудачных попыток входа не было"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при получении счетчика попыток входа: %w", err)
+	}
+
+	return throttle, nil
+}
+
+// RecordFailure увеличивает счетчик одним запросом, поэтому одновременные
+// неудачные попытки не теряются, и удаляет устаревшие счетчики.
+func (r *LoginThrottleRepository) RecordFailure(ctx context.Context, key string, at, resetBefore time.Time) (*domain.LoginThrottle, error) {
+	query := `
+		INSERT INTO login_throttles (key, failures, last_failure_at)
+		VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE
+		SET failures = CASE
+				WHEN login_throttles.last_failure_at < $3 THEN 1
+				ELSE login_throttles.failures + 1
+			END,
+			last_failure_at = EXCLUDED.last_failure_at
+		RETURNING key, failures, last_failure_at, locked_until
+	`
+
+	throttle, err := scanThrottle(r.db.DB.QueryRowContext(ctx, query, key, at, resetBefore))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при учете неудачной попытки входа: %w", err)
+	}
+
+	prune := `
+		DELETE FROM login_throttles
+		WHERE last_failure_at < $1 AND (locked_until IS NULL OR locked_until <= $2)
+	`
+
+	if _, err := r.db.DB.ExecContext(ctx, prune, resetBefore, at); err != nil {
+		return nil, fmt.Errorf("ошибка при удалении устаревших счетчиков попыток входа: %w", err)
+	}
+
+	return throttle, nil
+}
+
+func (r *LoginThrottleRepository) Lock(ctx context.Context, key string, until time.Time) error {
+	query := `
+		UPDATE login_throttles
+		SET locked_until = $2
+		WHERE key = $1
+	`
+
+	err := execAffecting(ctx, r.db.DB, "not_found", "неудачных попыток входа не было", query, key, until)
+	if err != nil {
+		return fmt.Errorf("ошибка при блокировке входа: %w", err)
+	}
+
+	return nil
+}
+
+func (r *LoginThrottleRepository) Delete(ctx context.Context, keys ...string) error {
+	query := `
+		DELETE FROM login_throttles
+		WHERE key = ANY($1)
+	`
+
+	if _, err := r.db.DB.ExecContext(ctx, query, pq.Array(keys)); err != nil {
+		return fmt.Errorf("ошибка при удалении счетчиков попыток входа: %w", err)
+	}
+
+	return nil
+}
+
+func scanThrottle(row *sql.Row) (*domain.LoginThrottle, error) {
+	var throttle domain.LoginThrottle
+	if err := row.Scan(&throttle.Key, &throttle.Failures, &throttle.LastFailureAt, &throttle.LockedUntil); err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
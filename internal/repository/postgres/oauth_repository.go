@@ -0,0 +1,170 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// OAuthClientRepository реализует интерфейс OAuthClientRepository для
+// работы с клиентами OAuth2 в PostgreSQL.
+type OAuthClientRepository struct {
+	db *PostgresDB
+}
+
+// NewOAuthClientRepository создает новый экземпляр OAuthClientRepository.
+func NewOAuthClientRepository(db *PostgresDB) *OAuthClientRepository {
+	return &OAuthClientRepository{db: db}
+}
+
+const oauthClientColumns = `id, owner_id, name, secret_hash, redirect_uris, scopes, created_at`
+
+func (r *OAuthClientRepository) Create(ctx context.Context, client *domain.OAuthClient) error {
+	query := `
+		INSERT INTO oauth_clients (` + oauthClientColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, client.ID, client.OwnerID, client.Name, client.SecretHash,
+		pq.StringArray(client.RedirectURIs), pq.StringArray(client.Scopes), client.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewError(domain.ErrConflict, "conflict", "запись уже существует")
+		}
+		return fmt.Errorf("ошибка при создании клиента OAuth: %w", err)
+	}
+
+	return nil
+}
+
+func (r *OAuthClientRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.OAuthClient, error) {
+	query := `
+		SELECT ` + oauthClientColumns + `
+		FROM oauth_clients
+		WHERE id = $1
+	`
+
+	client, err := scanOAuthClient(r.db.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err := notFound(err, "oauth_client.not_found", "клиент OAuth не найден"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при получении клиента OAuth по ID: %w", err)
+	}
+
+	return client, nil
+}
+
+func (r *OAuthClientRepository) ListByOwnerID(ctx context.Context, ownerID uuid.UUID) ([]*domain.OAuthClient, error) {
+	query := `
+		SELECT ` + oauthClientColumns + `
+		FROM oauth_clients
+		WHERE owner_id = $1
+		ORDER BY created_at DESC, id
+	`
+
+	rows, err := r.db.DB.QueryContext(ctx, query, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении клиентов OAuth пользователя: %w", err)
+	}
+	defer rows.Close()
+
+	clients := []*domain.OAuthClient{}
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании клиента OAuth: %w", err)
+		}
+		clients = append(clients, client)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по клиентам OAuth: %w", err)
+	}
+
+	return clients, nil
+}
+
+// Delete удаляет клиента. Его коды авторизации удаляются каскадно.
+func (r *OAuthClientRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `
+		DELETE FROM oauth_clients
+		WHERE id = $1
+	`
+
+	err := execAffecting(ctx, r.db.DB, "oauth_client.not_found", "клиент OAuth не найден", query, id)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении клиента OAuth: %w", err)
+	}
+
+	return nil
+}
+
+// scanOAuthClient считывает клиента OAuth из строки результата.
+func scanOAuthClient(row rowScanner) (*domain.OAuthClient, error) {
+	var client domain.OAuthClient
+	var redirectURIs, scopes pq.StringArray
+	err := row.Scan(&client.ID, &client.OwnerID, &client.Name, &client.SecretHash, &redirectURIs, &scopes, &client.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	client.RedirectURIs = []string(redirectURIs)
+	client.Scopes = []string(scopes)
+	return &client, nil
+}
+
+// OAuthCodeRepository реализует интерфейс OAuthCodeRepository для работы с
+// кодами авторизации в PostgreSQL.
+type OAuthCodeRepository struct {
+	db *PostgresDB
+}
+
+// NewOAuthCodeRepository создает новый экземпляр OAuthCodeRepository.
+func NewOAuthCodeRepository(db *PostgresDB) *OAuthCodeRepository {
+	return &OAuthCodeRepository{db: db}
+}
+
+func (r *OAuthCodeRepository) Create(ctx context.Context, code *domain.OAuthAuthorizationCode) error {
+	query := `
+		INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, code.CodeHash, code.ClientID, code.UserID, code.RedirectURI,
+		pq.StringArray(code.Scopes), code.CodeChallenge, code.ExpiresAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewError(domain.ErrConflict, "conflict", "запись уже существует")
+		}
+		return fmt.Errorf("ошибка при создании кода авторизации: %w", err)
+	}
+
+	return nil
+}
+
+// Consume удаляет код и возвращает его одним запросом, поэтому код нельзя
+// использовать дважды.
+func (r *OAuthCodeRepository) Consume(ctx context.Context, codeHash string) (*domain.OAuthAuthorizationCode, error) {
+	query := `
+		DELETE FROM oauth_authorization_codes
+		WHERE code_hash = $1
+		RETURNING code_hash, client_id, user_id, redirect_uri, scopes, code_challenge, expires_at
+	`
+
+	var code domain.OAuthAuthorizationCode
+	var scopes pq.StringArray
+	err := r.db.DB.QueryRowContext(ctx, query, codeHash).Scan(&code.CodeHash, &code.ClientID, &code.UserID,
+		&code.RedirectURI, &scopes, &code.CodeChallenge, &code.ExpiresAt)
+	if err != nil {
+		if err := notFound(err, "oauth.code_not_found", "код авторизации не найден"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при использовании кода авторизации: %w", err)
+	}
+	code.Scopes = []string(scopes)
+
+	return &code, nil
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// PasswordResetRepository реализует интерфейс PasswordResetRepository для
+// работы с токенами сброса пароля в PostgreSQL.
+type PasswordResetRepository struct {
+	db *PostgresDB
+}
+
+// NewPasswordResetRepository создает новый экземпляр PasswordResetRepository.
+func NewPasswordResetRepository(db *PostgresDB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+func (r *PasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, token.ID, token.UserID, token.TokenHash, token.ExpiresAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewError(domain.ErrConflict, "conflict", "запись уже существует")
+		}
+		return fmt.Errorf("ошибка при создании токена сброса пароля: %w", err)
+	}
+
+	return nil
+}
+
+// Consume удаляет токен и возвращает его одним запросом, поэтому токен
+// нельзя использовать дважды.
+func (r *PasswordResetRepository) Consume(ctx context.Context, tokenHash string) (*domain.PasswordResetToken, error) {
+	query := `
+		DELETE FROM password_reset_tokens
+		WHERE token_hash = $1
+		RETURNING id, user_id, token_hash, expires_at
+	`
+
+	row := r.db.DB.QueryRowContext(ctx, query, tokenHash)
+
+	var token domain.PasswordResetToken
+	if err := row.Scan(&token.ID, &token.UserID, &token.TokenHash, &token.ExpiresAt); err != nil {
+		if err := notFound(err, "password.reset_token_not_found", "токен сброса пароля не найден"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при использовании токена сброса пароля: %w", err)
+	}
+
+	return &token, nil
+}
+
+func (r *PasswordResetRepository) DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		DELETE FROM password_reset_tokens
+		WHERE user_id = $1
+	`
+
+	if _, err := r.db.DB.ExecContext(ctx, query, userID); err != nil {
+		return fmt.Errorf("ошибка при удалении токенов сброса пароля: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PersonalAccessTokenRepository реализует интерфейс
+// PersonalAccessTokenRepository для работы с персональными токенами доступа
+// в PostgreSQL.
+type PersonalAccessTokenRepository struct {
+	db *PostgresDB
+}
+
+// NewPersonalAccessTokenRepository создает новый экземпляр PersonalAccessTokenRepository.
+func NewPersonalAccessTokenRepository(db *PostgresDB) *PersonalAccessTokenRepository {
+	return &PersonalAccessTokenRepository{db: db}
+}
+
+const personalTokenColumns = `id, user_id, name, token_hash, scopes, expires_at, last_used_at, created_at`
+
+func (r *PersonalAccessTokenRepository) Create(ctx context.Context, token *domain.PersonalAccessToken) error {
+	query := `
+		INSERT INTO personal_access_tokens (` + personalTokenColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, token.ID, token.UserID, token.Name, token.TokenHash,
+		pq.StringArray(token.Scopes), token.ExpiresAt, token.LastUsedAt, token.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewError(domain.ErrConflict, "conflict", "запись уже существует")
+		}
+		return fmt.Errorf("ошибка при создании токена доступа: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PersonalAccessTokenRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.PersonalAccessToken, error) {
+	query := `
+		SELECT ` + personalTokenColumns + `
+		FROM personal_access_tokens
+		WHERE id = $1
+	`
+
+	token, err := scanPersonalToken(r.db.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err := notFound(err, "token.not_found", "токен доступа не найден"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при получении токена доступа по ID: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *PersonalAccessTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*domain.PersonalAccessToken, error) {
+	query := `
+		SELECT ` + personalTokenColumns + `
+		FROM personal_access_tokens
+		WHERE token_hash = $1
+	`
+
+	token, err := scanPersonalToken(r.db.DB.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		if err := notFound(err, "token.not_found", "токен доступа не найден"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при получении токена доступа: %w", err)
+	}
+
+	return token, nil
+}
+
+func (r *PersonalAccessTokenRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.PersonalAccessToken, error) {
+	query := `
+		SELECT ` + personalTokenColumns + `
+		FROM personal_access_tokens
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+	`
+
+	rows, err := r.db.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении токенов доступа пользователя: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []*domain.PersonalAccessToken{}
+	for rows.Next() {
+		token, err := scanPersonalToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании токена доступа: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по токенам доступа: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (r *PersonalAccessTokenRepository) Touch(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	query := `
+		UPDATE personal_access_tokens
+		SET last_used_at = $2
+		WHERE id = $1
+	`
+
+	err := execAffecting(ctx, r.db.DB, "token.not_found", "токен доступа не найден", query, id, lastUsedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении токена доступа: %w", err)
+	}
+
+	return nil
+}
+
+func (r *PersonalAccessTokenRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `
+		DELETE FROM personal_access_tokens
+		WHERE id = $1
+	`
+
+	err := execAffecting(ctx, r.db.DB, "token.not_found", "токен доступа не найден", query, id)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении токена доступа: %w", err)
+	}
+
+	return nil
+}
+
+// scanPersonalToken считывает токен доступа из строки результата.
+func scanPersonalToken(row rowScanner) (*domain.PersonalAccessToken, error) {
+	var token domain.PersonalAccessToken
+	var scopes pq.StringArray
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &scopes, &token.ExpiresAt, &token.LastUsedAt, &token.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = []string(scopes)
+	return &token, nil
+}
@@ -0,0 +1,70 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/MosinEvgeny/task-tracker/internal/migrations"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/repotest"
+)
+
+var (
+	testDBOnce sync.Once
+	testDB     *PostgresDB
+	testDBErr  error
+)
+
+// openTestDB подключается к базе из DATABASE_URL и применяет миграции.
+// Без DATABASE_URL тест пропускается.
+func openTestDB(t *testing.T) *PostgresDB {
+	t.Helper()
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("DATABASE_URL не задан")
+	}
+
+	testDBOnce.Do(func() {
+		testDB, testDBErr = NewPostgresDB(databaseURL)
+		if testDBErr != nil {
+			return
+		}
+		var migrator *migrations.Migrator
+		migrator, testDBErr = migrations.New(testDB.DB)
+		if testDBErr != nil {
+			return
+		}
+		_, testDBErr = migrator.Up(context.Background())
+	})
+	if testDBErr != nil {
+		t.Fatalf("не удалось подготовить базу данных: %v", testDBErr)
+	}
+	return testDB
+}
+
+func TestRepositories(t *testing.T) {
+	db := openTestDB(t)
+
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		return repotest.Repositories{
+			Users:         NewUserRepository(db),
+			Tasks:         NewTaskRepository(db),
+			Labels:        NewLabelRepository(db),
+			RefreshTokens: NewRefreshTokenRepository(db),
+			Sessions:      NewSessionRepository(db),
+			DenyList:      NewDenyListRepository(db),
+
+			PasswordResets: NewPasswordResetRepository(db),
+			TOTP:           NewTOTPRepository(db),
+			LoginThrottles: NewLoginThrottleRepository(db),
+			Audit:          NewAuditRepository(db),
+			PersonalTokens: NewPersonalAccessTokenRepository(db),
+			OAuthClients:   NewOAuthClientRepository(db),
+			OAuthCodes:     NewOAuthCodeRepository(db),
+
+			ExternalIdentities: NewExternalIdentityRepository(db),
+		}
+	})
+}
@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// queryBuilder собирает условия WHERE и позиционные параметры запроса.
+type queryBuilder struct {
+	conditions []string
+	args       []any
+}
+
+// where добавляет условие. Формат должен содержать ровно один глагол %d
+// (или %[1]d), вместо которого подставляется номер параметра arg.
+func (q *queryBuilder) where(format string, arg any) {
+	q.args = append(q.args, arg)
+	q.conditions = append(q.conditions, fmt.Sprintf(format, len(q.args)))
+}
+
+// keyset добавляет условие keyset-пагинации: строки после курсора в порядке сортировки.
+func (q *queryBuilder) keyset(column, idColumn, cast string, desc bool, after *repository.Cursor) {
+	op := ">"
+	if desc {
+		op = "<"
+	}
+
+	q.args = append(q.args, after.Value, after.ID)
+	n := len(q.args)
+	q.conditions = append(q.conditions, fmt.Sprintf("(%s, %s) %s ($%d%s, $%d)", column, idColumn, op, n-1, cast, n))
+}
+
+func (q *queryBuilder) whereClause() string {
+	if len(q.conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(q.conditions, " AND ")
+}
+
+// orderBy возвращает ORDER BY с дополнительной сортировкой по ID для
+// однозначного порядка и LIMIT, если он задан.
+func (q *queryBuilder) orderBy(column, idColumn string, desc bool, limit int) string {
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	clause := fmt.Sprintf("ORDER BY %s %s, %s %s", column, direction, idColumn, direction)
+	if limit > 0 {
+		clause += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	return clause
+}
+
+// likePattern экранирует спецсимволы LIKE и оборачивает строку в %...%.
+func likePattern(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + replacer.Replace(s) + "%"
+}
+
+// uuidArray преобразует срез UUID в массив PostgreSQL.
+func uuidArray(ids []uuid.UUID) pq.StringArray {
+	values := make(pq.StringArray, len(ids))
+	for i, id := range ids {
+		values[i] = id.String()
+	}
+	return values
+}
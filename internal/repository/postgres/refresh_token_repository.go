@@ -2,8 +2,8 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/google/uuid"
@@ -22,39 +22,67 @@ func NewRefreshTokenRepository(db *PostgresDB) *RefreshTokenRepository {
 // Create создает новый refresh токен в базе данных.
 func (r *RefreshTokenRepository) Create(ctx context.Context, refreshToken *domain.RefreshToken) error {
 	query := `
-		INSERT INTO refresh_tokens (id, user_id, token, expiry_date)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO refresh_tokens (id, user_id, family_id, token_hash, expiry_date, used_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, refreshToken.ID, refreshToken.UserID, refreshToken.Token, refreshToken.ExpiryDate)
+	_, err := r.db.DB.ExecContext(ctx, query, refreshToken.ID, refreshToken.UserID, refreshToken.FamilyID, refreshToken.TokenHash, refreshToken.ExpiryDate, refreshToken.UsedAt)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewError(domain.ErrConflict, "auth.refresh_token_exists", "refresh токен уже существует")
+		}
 		return fmt.Errorf("ошибка при создании refresh токена: %w", err)
 	}
 
 	return nil
 }
 
-// GetByToken возвращает refresh токен по токену из базы данных.
-func (r *RefreshTokenRepository) GetByToken(ctx context.Context, token string) (*domain.RefreshToken, error) {
+// GetByTokenHash возвращает refresh токен по хешу токена из базы данных.
+func (r *RefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
 	query := `
-		SELECT id, user_id, token, expiry_date
+		SELECT id, user_id, family_id, token_hash, expiry_date, used_at
 		FROM refresh_tokens
-		WHERE token = $1
+		WHERE token_hash = $1
 	`
 
-	row := r.db.DB.QueryRowContext(ctx, query, token)
+	row := r.db.DB.QueryRowContext(ctx, query, tokenHash)
 
 	var refreshToken domain.RefreshToken
-	if err := row.Scan(&refreshToken.ID, &refreshToken.UserID, &refreshToken.Token, &refreshToken.ExpiryDate); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("refresh токен не найден: %w", err)
+	if err := row.Scan(&refreshToken.ID, &refreshToken.UserID, &refreshToken.FamilyID, &refreshToken.TokenHash, &refreshToken.ExpiryDate, &refreshToken.UsedAt); err != nil {
+		if err := notFound(err, "auth.refresh_token_not_found", "refresh токен не найден"); err != nil {
+			return nil, err
 		}
-		return nil, fmt.Errorf("ошибка при получении refresh токена по токену: %w", err)
+		return nil, fmt.Errorf("ошибка при получении refresh токена по хешу: %w", err)
 	}
 
 	return &refreshToken, nil
 }
 
+// MarkUsed отмечает refresh токен как обменянный. Условие used_at IS NULL
+// гарантирует, что из двух одновременных ротаций успешна только одна.
+func (r *RefreshTokenRepository) MarkUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	query := `
+		UPDATE refresh_tokens
+		SET used_at = $2
+		WHERE id = $1 AND used_at IS NULL
+	`
+
+	result, err := r.db.DB.ExecContext(ctx, query, id, usedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при отметке refresh токена: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при отметке refresh токена: %w", err)
+	}
+	if rows == 0 {
+		return domain.NewError(domain.ErrConflict, "auth.refresh_token_used", "refresh токен уже использован")
+	}
+
+	return nil
+}
+
 // Delete удаляет refresh токен из базы данных.
 func (r *RefreshTokenRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `
@@ -62,7 +90,7 @@ func (r *RefreshTokenRepository) Delete(ctx context.Context, id uuid.UUID) error
 		WHERE id = $1
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, id)
+	err := execAffecting(ctx, r.db.DB, "auth.refresh_token_not_found", "refresh токен не найден", query, id)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении refresh токена: %w", err)
 	}
@@ -84,3 +112,18 @@ func (r *RefreshTokenRepository) DeleteAllByUserID(ctx context.Context, userID u
 
 	return nil
 }
+
+// DeleteByFamilyID удаляет все refresh токены семейства из базы данных.
+func (r *RefreshTokenRepository) DeleteByFamilyID(ctx context.Context, familyID uuid.UUID) error {
+	query := `
+		DELETE FROM refresh_tokens
+		WHERE family_id = $1
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, familyID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении семейства refresh токенов: %w", err)
+	}
+
+	return nil
+}
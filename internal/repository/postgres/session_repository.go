@@ -0,0 +1,136 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// SessionRepository реализует интерфейс SessionRepository для работы с сессиями в PostgreSQL.
+type SessionRepository struct {
+	db *PostgresDB
+}
+
+// NewSessionRepository создает новый экземпляр SessionRepository.
+func NewSessionRepository(db *PostgresDB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) Create(ctx context.Context, session *domain.Session) error {
+	query := `
+		INSERT INTO sessions (id, user_id, device_name, user_agent, ip, created_at, last_used_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, session.ID, session.UserID, session.Name, session.UserAgent, session.IP, session.CreatedAt, session.LastUsedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании сессии: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error) {
+	query := `
+		SELECT id, user_id, device_name, user_agent, ip, created_at, last_used_at
+		FROM sessions
+		WHERE id = $1
+	`
+
+	session, err := scanSession(r.db.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err := notFound(err, "session.not_found", "сессия не найдена"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при получении сессии по ID: %w", err)
+	}
+
+	return session, nil
+}
+
+func (r *SessionRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error) {
+	query := `
+		SELECT id, user_id, device_name, user_agent, ip, created_at, last_used_at
+		FROM sessions
+		WHERE user_id = $1
+		ORDER BY last_used_at DESC, id
+	`
+
+	rows, err := r.db.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении сессий пользователя: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []*domain.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании сессии: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по сессиям: %w", err)
+	}
+
+	return sessions, nil
+}
+
+func (r *SessionRepository) Touch(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error {
+	query := `
+		UPDATE sessions
+		SET last_used_at = $2
+		WHERE id = $1
+	`
+
+	err := execAffecting(ctx, r.db.DB, "session.not_found", "сессия не найдена", query, id, lastUsedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении сессии: %w", err)
+	}
+
+	return nil
+}
+
+// Delete удаляет сессию. Refresh токены сессии удаляются каскадно.
+func (r *SessionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	query := `
+		DELETE FROM sessions
+		WHERE id = $1
+	`
+
+	err := execAffecting(ctx, r.db.DB, "session.not_found", "сессия не найдена", query, id)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении сессии: %w", err)
+	}
+
+	return nil
+}
+
+func (r *SessionRepository) DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error {
+	query := `
+		DELETE FROM sessions
+		WHERE user_id = $1
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении сессий пользователя: %w", err)
+	}
+
+	return nil
+}
+
+// scanSession считывает сессию из строки результата.
+func scanSession(row rowScanner) (*domain.Session, error) {
+	var session domain.Session
+	err := row.Scan(&session.ID, &session.UserID, &session.Name, &session.UserAgent, &session.IP, &session.CreatedAt, &session.LastUsedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
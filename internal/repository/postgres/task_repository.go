@@ -7,9 +7,20 @@ import (
 	"time"
 
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// taskSelect выбирает задачи вместе с ID привязанных меток. Запрос должен
+// завершаться GROUP BY t.id.
+const taskSelect = `
+	SELECT t.id, t.title, t.description, t.due_date, t.status, t.completed_at, t.created_at, t.user_id,
+		COALESCE(array_agg(tl.label_id) FILTER (WHERE tl.label_id IS NOT NULL), '{}')
+	FROM tasks t
+	LEFT JOIN task_labels tl ON tl.task_id = t.id
+`
+
 // TaskRepository реализует интерфейс TaskRepository для работы с задачами в PostgreSQL.
 type TaskRepository struct {
 	db *PostgresDB
@@ -21,88 +32,146 @@ func NewTaskRepository(db *PostgresDB) *TaskRepository {
 }
 
 func (r *TaskRepository) Create(ctx context.Context, task *domain.Task) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO tasks (id, title, description, due_date, user_id)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO tasks (id, title, description, due_date, status, completed_at, created_at, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, task.ID, task.Title, task.Description, task.DueDate, task.UserID)
+	_, err = tx.ExecContext(ctx, query, task.ID, task.Title, task.Description, task.DueDate, task.Status, task.CompletedAt, task.CreatedAt, task.UserID)
 	if err != nil {
 		return fmt.Errorf("ошибка при создании задачи: %w", err)
 	}
 
-	// TODO: Обработка label_ids (связь задачи и меток)
+	if err := insertTaskLabels(ctx, tx, task.ID, task.LabelIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
 
 	return nil
 }
 
 func (r *TaskRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
-	query := `
-		SELECT id, title, description, due_date, user_id
-		FROM tasks
-		WHERE id = $1
+	query := taskSelect + `
+		WHERE t.id = $1
+		GROUP BY t.id
 	`
 
 	row := r.db.DB.QueryRowContext(ctx, query, id)
 
-	var task domain.Task
-	var dueDate time.Time
-	if err := row.Scan(&task.ID, &task.Title, &task.Description, &dueDate, &task.UserID); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("задача не найдена: %w", err)
+	task, err := scanTask(row)
+	if err != nil {
+		if err := notFound(err, "task.not_found", "задача не найдена"); err != nil {
+			return nil, err
 		}
 		return nil, fmt.Errorf("ошибка при получении задачи по ID: %w", err)
 	}
 
-	task.DueDate = dueDate
-
-	return &task, nil
+	return task, nil
 }
 
 func (r *TaskRepository) GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
-	query := `
-		SELECT id, title, description, due_date, user_id
-		FROM tasks
-		WHERE user_id = $1
+	query := taskSelect + `
+		WHERE t.user_id = $1
+		GROUP BY t.id
 	`
 
 	rows, err := r.db.DB.QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении задач пользователя: %w", err)
 	}
-	defer rows.Close()
 
-	var tasks []*domain.Task
-	for rows.Next() {
-		var task domain.Task
-		var dueDate time.Time
-		if err := rows.Scan(&task.ID, &task.Title, &task.Description, &dueDate, &task.UserID); err != nil {
-			return nil, fmt.Errorf("ошибка при сканировании задачи: %w", err)
+	return scanTasks(rows)
+}
+
+// List возвращает страницу задач пользователя. Фильтрация, сортировка и
+// keyset-пагинация выполняются на стороне базы данных.
+func (r *TaskRepository) List(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter) ([]*domain.Task, error) {
+	q := &queryBuilder{}
+	q.where("t.user_id = $%d", userID)
+
+	if filter.DueBefore != nil {
+		q.where("t.due_date < $%d", *filter.DueBefore)
+	}
+	if filter.DueAfter != nil {
+		q.where("t.due_date > $%d", *filter.DueAfter)
+	}
+	if len(filter.LabelIDs) > 0 {
+		q.where("EXISTS (SELECT 1 FROM task_labels f WHERE f.task_id = t.id AND f.label_id = ANY($%d::uuid[]))", uuidArray(filter.LabelIDs))
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make(pq.StringArray, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
 		}
-		task.DueDate = dueDate
-		tasks = append(tasks, &task)
+		q.where("t.status = ANY($%d)", statuses)
+	}
+	if filter.Search != "" {
+		q.where("(t.title ILIKE $%[1]d OR t.description ILIKE $%[1]d)", likePattern(filter.Search))
 	}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("ошибка при итерации по задачам: %w", err)
+	column, cast := "t.created_at", "::timestamptz"
+	switch filter.Sort.Field {
+	case repository.TaskSortDueDate:
+		column = "t.due_date"
+	case repository.TaskSortTitle:
+		column, cast = "t.title", ""
 	}
 
-	return tasks, nil
+	if filter.After != nil {
+		q.keyset(column, "t.id", cast, filter.Sort.Desc, filter.After)
+	}
+
+	query := taskSelect + q.whereClause() + `
+		GROUP BY t.id
+	` + q.orderBy(column, "t.id", filter.Sort.Desc, filter.Limit)
+
+	rows, err := r.db.DB.QueryContext(ctx, query, q.args...)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении задач пользователя: %w", err)
+	}
+
+	return scanTasks(rows)
 }
 
 func (r *TaskRepository) Update(ctx context.Context, task *domain.Task) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE tasks
-		SET title = $2, description = $3, due_date = $4
+		SET title = $2, description = $3, due_date = $4, status = $5, completed_at = $6
 		WHERE id = $1
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, task.ID, task.Title, task.Description, task.DueDate)
+	err = execAffecting(ctx, tx, "task.not_found", "задача не найдена", query, task.ID, task.Title, task.Description, task.DueDate, task.Status, task.CompletedAt)
 	if err != nil {
 		return fmt.Errorf("ошибка при обновлении задачи: %w", err)
 	}
 
-	// TODO: Обработка label_ids (обновление связи задачи и меток)
+	_, err = tx.ExecContext(ctx, `DELETE FROM task_labels WHERE task_id = $1`, task.ID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении меток задачи: %w", err)
+	}
+
+	if err := insertTaskLabels(ctx, tx, task.ID, task.LabelIDs); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
 
 	return nil
 }
@@ -113,10 +182,74 @@ func (r *TaskRepository) Delete(ctx context.Context, id uuid.UUID) error {
 		WHERE id = $1
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, id)
+	err := execAffecting(ctx, r.db.DB, "task.not_found", "задача не найдена", query, id)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении задачи: %w", err)
 	}
 
 	return nil
 }
+
+// rowScanner объединяет *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask считывает задачу вместе с ID привязанных меток.
+func scanTask(row rowScanner) (*domain.Task, error) {
+	var task domain.Task
+	var dueDate time.Time
+	var labelIDs pq.StringArray
+	if err := row.Scan(&task.ID, &task.Title, &task.Description, &dueDate, &task.Status, &task.CompletedAt, &task.CreatedAt, &task.UserID, &labelIDs); err != nil {
+		return nil, err
+	}
+	task.DueDate = dueDate
+
+	task.LabelIDs = make([]uuid.UUID, 0, len(labelIDs))
+	for _, labelID := range labelIDs {
+		id, err := uuid.Parse(labelID)
+		if err != nil {
+			return nil, fmt.Errorf("неверный ID метки %q: %w", labelID, err)
+		}
+		task.LabelIDs = append(task.LabelIDs, id)
+	}
+
+	return &task, nil
+}
+
+// scanTasks считывает все задачи из результата запроса и закрывает его.
+func scanTasks(rows *sql.Rows) ([]*domain.Task, error) {
+	defer rows.Close()
+
+	tasks := []*domain.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при сканировании задачи: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при итерации по задачам: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// insertTaskLabels привязывает метки к задаче в рамках транзакции.
+func insertTaskLabels(ctx context.Context, tx *sql.Tx, taskID uuid.UUID, labelIDs []uuid.UUID) error {
+	query := `
+		INSERT INTO task_labels (task_id, label_id)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING
+	`
+
+	for _, labelID := range labelIDs {
+		if _, err := tx.ExecContext(ctx, query, taskID, labelID); err != nil {
+			return fmt.Errorf("ошибка при привязке метки к задаче: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// TOTPRepository реализует интерфейс TOTPRepository для работы с секретами
+// двухфакторной аутентификации и кодами восстановления в PostgreSQL.
+type TOTPRepository struct {
+	db *PostgresDB
+}
+
+// NewTOTPRepository создает новый экземпляр TOTPRepository.
+func NewTOTPRepository(db *PostgresDB) *TOTPRepository {
+	return &TOTPRepository{db: db}
+}
+
+func (r *TOTPRepository) Save(ctx context.Context, totp *domain.TOTP) error {
+	query := `
+		INSERT INTO user_totp (user_id, secret, confirmed, last_used_step, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = EXCLUDED.secret,
+			confirmed = EXCLUDED.confirmed,
+			last_used_step = EXCLUDED.last_used_step,
+			created_at = EXCLUDED.created_at
+	`
+
+	_, err := r.db.DB.ExecContext(ctx, query, totp.UserID, totp.Secret, totp.Confirmed, totp.LastUsedStep, totp.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении секрета TOTP: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TOTPRepository) Get(ctx context.Context, userID uuid.UUID) (*domain.TOTP, error) {
+	query := `
+		SELECT user_id, secret, confirmed, last_used_step, created_at
+		FROM user_totp
+		WHERE user_id = $1
+	`
+
+	row := r.db.DB.QueryRowContext(ctx, query, userID)
+
+	var totp domain.TOTP
+	if err := row.Scan(&totp.UserID, &totp.Secret, &totp.Confirmed, &totp.LastUsedStep, &totp.CreatedAt); err != nil {
+		if err := notFound(err, "mfa.not_enabled", "двухфакторная аутентификация не настроена"); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при получении секрета TOTP: %w", err)
+	}
+
+	return &totp, nil
+}
+
+// UseStep обновляет последний шаг условным запросом, поэтому один код нельзя
+// принять дважды даже при одновременных запросах.
+func (r *TOTPRepository) UseStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	query := `
+		UPDATE user_totp
+		SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2
+	`
+
+	result, err := r.db.DB.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return fmt.Errorf("ошибка при использовании кода TOTP: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("ошибка при использовании кода TOTP: %w", err)
+	}
+	if rows == 0 {
+		if _, err := r.Get(ctx, userID); err != nil {
+			return err
+		}
+		return domain.NewError(domain.ErrConflict, "mfa.code_used", "код уже использован")
+	}
+
+	return nil
+}
+
+// Delete удаляет секрет и коды восстановления в одной транзакции.
+func (r *TOTPRepository) Delete(ctx context.Context, userID uuid.UUID) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("ошибка при удалении кодов восстановления: %w", err)
+	}
+	err = execAffecting(ctx, tx, "mfa.not_enabled", "двухфакторная аутентификация не настроена", `DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении секрета TOTP: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TOTPRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	tx, err := r.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при начале транзакции: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("ошибка при удалении кодов восстановления: %w", err)
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.ExecContext(ctx, `INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`, userID, hash)
+		if err != nil {
+			return fmt.Errorf("ошибка при сохранении кода восстановления: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TOTPRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	query := `
+		DELETE FROM recovery_codes
+		WHERE user_id = $1 AND code_hash = $2
+	`
+
+	err := execAffecting(ctx, r.db.DB, "mfa.recovery_code_not_found", "код восстановления не найден", query, userID, codeHash)
+	if err != nil {
+		return fmt.Errorf("ошибка при использовании кода восстановления: %w", err)
+	}
+
+	return nil
+}
+
+func (r *TOTPRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM recovery_codes
+		WHERE user_id = $1
+	`
+
+	var count int
+	if err := r.db.DB.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+		return 0, fmt.Errorf("ошибка при подсчете кодов восстановления: %w", err)
+	}
+
+	return count, nil
+}
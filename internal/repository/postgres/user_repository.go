@@ -2,8 +2,8 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
 	"fmt"
+	"time"
 
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/google/uuid"
@@ -21,12 +21,15 @@ func NewUserRepository(db *PostgresDB) *UserRepository {
 
 func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 	query := `
-		INSERT INTO users (id, username, email, password)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO users (id, username, email, password, email_verified, is_admin)
+		VALUES ($1, $2, $3, $4, $5, $6)
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, user.ID, user.Username, user.Email, user.Password)
+	_, err := r.db.DB.ExecContext(ctx, query, user.ID, user.Username, user.Email, user.Password, user.EmailVerified, user.IsAdmin)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewError(domain.ErrConflict, "user.email_taken", "пользователь с таким email уже существует")
+		}
 		return fmt.Errorf("ошибка в создании пользователя: %w", err)
 	}
 
@@ -35,7 +38,7 @@ func (r *UserRepository) Create(ctx context.Context, user *domain.User) error {
 
 func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password
+		SELECT id, username, email, password, email_verified, is_admin, tokens_valid_after
 		FROM users
 		WHERE id = $1
 	`
@@ -43,9 +46,9 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 	row := r.db.DB.QueryRowContext(ctx, query, id)
 
 	var user domain.User
-	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("пользователь не найден: %w", err)
+	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.EmailVerified, &user.IsAdmin, &user.TokensValidAfter); err != nil {
+		if err := notFound(err, "user.not_found", "пользователь не найден"); err != nil {
+			return nil, err
 		}
 		return nil, fmt.Errorf("ошибка в получении пользователя по ID: %w", err)
 	}
@@ -55,7 +58,7 @@ func (r *UserRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Use
 
 func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	query := `
-		SELECT id, username, email, password
+		SELECT id, username, email, password, email_verified, is_admin, tokens_valid_after
 		FROM users
 		WHERE email = $1
 	`
@@ -63,9 +66,9 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	row := r.db.DB.QueryRowContext(ctx, query, email)
 
 	var user domain.User
-	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("пользователь не найден: %w", err)
+	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.EmailVerified, &user.IsAdmin, &user.TokensValidAfter); err != nil {
+		if err := notFound(err, "user.not_found", "пользователь не найден"); err != nil {
+			return nil, err
 		}
 		return nil, fmt.Errorf("ошибка в получении пользователя по email: %w", err)
 	}
@@ -76,25 +79,88 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
 	query := `
 		UPDATE users
-		SET username = $2, email = $3, password = $4
+		SET username = $2, email = $3, email_verified = $4
 		WHERE id = $1
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, user.ID, user.Username, user.Email, user.Password)
+	err := execAffecting(ctx, r.db.DB, "user.not_found", "пользователь не найден", query, user.ID, user.Username, user.Email, user.EmailVerified)
 	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.NewError(domain.ErrConflict, "user.email_taken", "пользователь с таким email уже существует")
+		}
 		return fmt.Errorf("ошибка при обновлении пользователя: %w", err)
 	}
 
 	return nil
 }
 
+func (r *UserRepository) SetTokensValidAfter(ctx context.Context, id uuid.UUID, validAfter time.Time) error {
+	query := `
+		UPDATE users
+		SET tokens_valid_after = $2
+		WHERE id = $1
+	`
+
+	err := execAffecting(ctx, r.db.DB, "user.not_found", "пользователь не найден", query, id, validAfter)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве токенов пользователя: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	query := `
+		UPDATE users
+		SET email_verified = true
+		WHERE id = $1 AND email = $2
+	`
+
+	err := execAffecting(ctx, r.db.DB, "user.not_found", "пользователь не найден", query, id, email)
+	if err != nil {
+		return fmt.Errorf("ошибка при подтверждении email: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) ReplacePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error {
+	query := `
+		UPDATE users
+		SET password = $3
+		WHERE id = $1 AND password = $2
+	`
+
+	err := execAffecting(ctx, r.db.DB, "user.not_found", "пользователь не найден", query, id, oldHash, newHash)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении хеша пароля: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepository) SetAdmin(ctx context.Context, id uuid.UUID, isAdmin bool) error {
+	query := `
+		UPDATE users
+		SET is_admin = $2
+		WHERE id = $1
+	`
+
+	err := execAffecting(ctx, r.db.DB, "user.not_found", "пользователь не найден", query, id, isAdmin)
+	if err != nil {
+		return fmt.Errorf("ошибка при изменении прав администратора: %w", err)
+	}
+
+	return nil
+}
+
 func (r *UserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `
 		DELETE FROM users
 		WHERE id = $1
 	`
 
-	_, err := r.db.DB.ExecContext(ctx, query, id)
+	err := execAffecting(ctx, r.db.DB, "user.not_found", "пользователь не найден", query, id)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении пользователя: %w", err)
 	}
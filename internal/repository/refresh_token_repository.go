@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/google/uuid"
@@ -9,7 +10,11 @@ import (
 
 type RefreshTokenRepository interface {
 	Create(ctx context.Context, refreshToken *domain.RefreshToken) error
-	GetByToken(ctx context.Context, token string) (*domain.RefreshToken, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	// MarkUsed отмечает токен как обменянный. Если токен уже отмечен,
+	// возвращается ошибка категории domain.ErrConflict.
+	MarkUsed(ctx context.Context, id uuid.UUID, usedAt time.Time) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error
+	DeleteByFamilyID(ctx context.Context, familyID uuid.UUID) error
 }
@@ -0,0 +1,1225 @@
+// Package repotest содержит общий набор тестов, которому должна
+// соответствовать любая реализация интерфейсов пакета repository.
+//
+// Тесты не очищают хранилище и создают данные с уникальными email и
+// токенами, поэтому могут выполняться на общей базе данных.
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Repositories — репозитории одного хранилища, которые проверяет набор тестов.
+type Repositories struct {
+	Users         repository.UserRepository
+	Tasks         repository.TaskRepository
+	Labels        repository.LabelRepository
+	RefreshTokens repository.RefreshTokenRepository
+	Sessions      repository.SessionRepository
+	DenyList      repository.DenyListRepository
+
+	PasswordResets repository.PasswordResetRepository
+	TOTP           repository.TOTPRepository
+	LoginThrottles repository.LoginThrottleRepository
+	Audit          repository.AuditRepository
+	PersonalTokens repository.PersonalAccessTokenRepository
+	OAuthClients   repository.OAuthClientRepository
+	OAuthCodes     repository.OAuthCodeRepository
+
+	ExternalIdentities repository.ExternalIdentityRepository
+}
+
+// Run выполняет набор тестов. newRepos вызывается для каждого теста.
+func Run(t *testing.T, newRepos func(t *testing.T) Repositories) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, repos Repositories)
+	}{
+		{"UserCRUD", testUserCRUD},
+		{"UserNotFound", testUserNotFound},
+		{"UserEmailUnique", testUserEmailUnique},
+		{"UserTokensValidAfter", testUserTokensValidAfter},
+		{"UserEmailVerified", testUserEmailVerified},
+		{"UserReplacePasswordHash", testUserReplacePasswordHash},
+		{"UserAdmin", testUserAdmin},
+		{"LabelCRUD", testLabelCRUD},
+		{"LabelNotFound", testLabelNotFound},
+		{"LabelList", testLabelList},
+		{"TaskCRUD", testTaskCRUD},
+		{"TaskNotFound", testTaskNotFound},
+		{"TaskLabels", testTaskLabels},
+		{"TaskList", testTaskList},
+		{"TaskListPagination", testTaskListPagination},
+		{"RefreshTokenCRUD", testRefreshTokenCRUD},
+		{"RefreshTokenNotFound", testRefreshTokenNotFound},
+		{"RefreshTokenUnique", testRefreshTokenUnique},
+		{"RefreshTokenRotation", testRefreshTokenRotation},
+		{"SessionCRUD", testSessionCRUD},
+		{"SessionNotFound", testSessionNotFound},
+		{"SessionList", testSessionList},
+		{"SessionDeleteCascade", testSessionDeleteCascade},
+		{"DenyList", testDenyList},
+		{"PasswordResetConsume", testPasswordResetConsume},
+		{"PasswordResetDeleteAll", testPasswordResetDeleteAll},
+		{"TOTPSave", testTOTPSave},
+		{"TOTPUseStep", testTOTPUseStep},
+		{"RecoveryCodes", testRecoveryCodes},
+		{"LoginThrottle", testLoginThrottle},
+		{"LoginThrottleWindow", testLoginThrottleWindow},
+		{"LoginThrottlePrune", testLoginThrottlePrune},
+		{"AuditList", testAuditList},
+		{"PersonalTokenCRUD", testPersonalTokenCRUD},
+		{"PersonalTokenNotFound", testPersonalTokenNotFound},
+		{"PersonalTokenList", testPersonalTokenList},
+		{"OAuthClientCRUD", testOAuthClientCRUD},
+		{"OAuthClientList", testOAuthClientList},
+		{"OAuthCodeConsume", testOAuthCodeConsume},
+		{"ExternalIdentity", testExternalIdentity},
+		{"UserDeleteCascade", testUserDeleteCascade},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newRepos(t))
+		})
+	}
+}
+
+// now возвращает текущее время с точностью, которую сохраняет PostgreSQL.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+func createUser(t *testing.T, repos Repositories) *domain.User {
+	t.Helper()
+
+	id := uuid.New()
+	user := &domain.User{
+		ID:       id,
+		Username: "user",
+		Email:    id.String() + "@example.com",
+		Password: "hash",
+	}
+	require.NoError(t, repos.Users.Create(context.Background(), user))
+	return user
+}
+
+func createLabel(t *testing.T, repos Repositories, userID uuid.UUID, name string) *domain.Label {
+	t.Helper()
+
+	label := &domain.Label{ID: uuid.New(), Name: name, Color: "#ff0000", UserID: userID}
+	require.NoError(t, repos.Labels.Create(context.Background(), label))
+	return label
+}
+
+func newTask(userID uuid.UUID, title string, dueDate time.Time, labelIDs ...uuid.UUID) *domain.Task {
+	return &domain.Task{
+		ID:          uuid.New(),
+		Title:       title,
+		Description: "описание " + title,
+		DueDate:     dueDate,
+		Status:      domain.TaskStatusTodo,
+		CreatedAt:   now(),
+		UserID:      userID,
+		LabelIDs:    labelIDs,
+	}
+}
+
+func createTask(t *testing.T, repos Repositories, task *domain.Task) *domain.Task {
+	t.Helper()
+
+	require.NoError(t, repos.Tasks.Create(context.Background(), task))
+	return task
+}
+
+// assertTask сравнивает задачи с учетом часовых поясов и порядка меток.
+func assertTask(t *testing.T, expected, actual *domain.Task) {
+	t.Helper()
+
+	assert.Equal(t, expected.ID, actual.ID)
+	assert.Equal(t, expected.Title, actual.Title)
+	assert.Equal(t, expected.Description, actual.Description)
+	assert.True(t, expected.DueDate.Equal(actual.DueDate), "due_date: %v != %v", expected.DueDate, actual.DueDate)
+	assert.Equal(t, expected.Status, actual.Status)
+	if expected.CompletedAt == nil {
+		assert.Nil(t, actual.CompletedAt)
+	} else if assert.NotNil(t, actual.CompletedAt) {
+		assert.True(t, expected.CompletedAt.Equal(*actual.CompletedAt), "completed_at: %v != %v", *expected.CompletedAt, *actual.CompletedAt)
+	}
+	assert.True(t, expected.CreatedAt.Equal(actual.CreatedAt), "created_at: %v != %v", expected.CreatedAt, actual.CreatedAt)
+	assert.Equal(t, expected.UserID, actual.UserID)
+	assert.ElementsMatch(t, expected.LabelIDs, actual.LabelIDs)
+}
+
+func taskIDs(tasks []*domain.Task) []uuid.UUID {
+	ids := make([]uuid.UUID, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+func testUserCRUD(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+
+	found, err := repos.Users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user, found)
+
+	found, err = repos.Users.GetByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.Equal(t, user, found)
+
+	user.Username = "renamed"
+	user.Email = uuid.NewString() + "@example.com"
+	require.NoError(t, repos.Users.Update(ctx, user))
+	found, err = repos.Users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user, found)
+
+	require.NoError(t, repos.Users.Delete(ctx, user.ID))
+	_, err = repos.Users.GetByID(ctx, user.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func testUserNotFound(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	missing := &domain.User{ID: uuid.New(), Username: "ghost", Email: uuid.NewString() + "@example.com", Password: "hash"}
+
+	_, err := repos.Users.GetByID(ctx, missing.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.Users.GetByEmail(ctx, missing.Email)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Users.Update(ctx, missing), domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Users.Delete(ctx, missing.ID), domain.ErrNotFound)
+}
+
+func testUserEmailUnique(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	first := createUser(t, repos)
+	second := createUser(t, repos)
+
+	duplicate := &domain.User{ID: uuid.New(), Username: "copy", Email: first.Email, Password: "hash"}
+	assert.ErrorIs(t, repos.Users.Create(ctx, duplicate), domain.ErrConflict)
+
+	second.Email = first.Email
+	assert.ErrorIs(t, repos.Users.Update(ctx, second), domain.ErrConflict)
+}
+
+func testUserTokensValidAfter(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+
+	found, err := repos.Users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.TokensValidAfter)
+
+	validAfter := now()
+	require.NoError(t, repos.Users.SetTokensValidAfter(ctx, user.ID, validAfter))
+
+	// Обычное обновление не сбрасывает момент отзыва токенов
+	user.Username = "renamed"
+	require.NoError(t, repos.Users.Update(ctx, user))
+	found, err = repos.Users.GetByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	if assert.NotNil(t, found.TokensValidAfter) {
+		assert.True(t, validAfter.Equal(*found.TokensValidAfter))
+	}
+
+	assert.ErrorIs(t, repos.Users.SetTokensValidAfter(ctx, uuid.New(), now()), domain.ErrNotFound)
+}
+
+func testUserEmailVerified(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	assert.False(t, user.EmailVerified)
+
+	// Подтверждается только текущий адрес пользователя
+	assert.ErrorIs(t, repos.Users.MarkEmailVerified(ctx, user.ID, "old@example.com"), domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Users.MarkEmailVerified(ctx, uuid.New(), user.Email), domain.ErrNotFound)
+
+	require.NoError(t, repos.Users.MarkEmailVerified(ctx, user.ID, user.Email))
+	found, err := repos.Users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, found.EmailVerified)
+
+	found.EmailVerified = false
+	require.NoError(t, repos.Users.Update(ctx, found))
+	found, err = repos.Users.GetByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.False(t, found.EmailVerified)
+}
+
+func testUserReplacePasswordHash(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+
+	// Хеш заменяется, только если пароль не сменился с момента проверки
+	assert.ErrorIs(t, repos.Users.ReplacePasswordHash(ctx, user.ID, "other-hash", "new-hash"), domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Users.ReplacePasswordHash(ctx, uuid.New(), user.Password, "new-hash"), domain.ErrNotFound)
+
+	require.NoError(t, repos.Users.ReplacePasswordHash(ctx, user.ID, user.Password, "new-hash"))
+	found, err := repos.Users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", found.Password)
+	assert.Equal(t, user.Email, found.Email)
+
+	// Update с прочитанным ранее пользователем не возвращает прежний хеш
+	user.Username = "renamed"
+	require.NoError(t, repos.Users.Update(ctx, user))
+	found, err = repos.Users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "new-hash", found.Password)
+	assert.Equal(t, "renamed", found.Username)
+}
+
+func testUserAdmin(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	assert.False(t, user.IsAdmin)
+
+	require.NoError(t, repos.Users.SetAdmin(ctx, user.ID, true))
+	found, err := repos.Users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, found.IsAdmin)
+
+	// Update не меняет права администратора
+	found.IsAdmin = false
+	require.NoError(t, repos.Users.Update(ctx, found))
+	found, err = repos.Users.GetByEmail(ctx, user.Email)
+	require.NoError(t, err)
+	assert.True(t, found.IsAdmin)
+
+	require.NoError(t, repos.Users.SetAdmin(ctx, user.ID, false))
+	found, err = repos.Users.GetByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.False(t, found.IsAdmin)
+	assert.ErrorIs(t, repos.Users.SetAdmin(ctx, uuid.New(), true), domain.ErrNotFound)
+}
+
+func testLabelCRUD(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	label := createLabel(t, repos, user.ID, "работа")
+
+	found, err := repos.Labels.GetByID(ctx, label.ID)
+	require.NoError(t, err)
+	assert.Equal(t, label, found)
+
+	label.Name = "дом"
+	label.Color = "#00ff00"
+	require.NoError(t, repos.Labels.Update(ctx, label))
+	found, err = repos.Labels.GetByID(ctx, label.ID)
+	require.NoError(t, err)
+	assert.Equal(t, label, found)
+
+	all, err := repos.Labels.GetAllByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.Label{label}, all)
+
+	require.NoError(t, repos.Labels.Delete(ctx, label.ID))
+	_, err = repos.Labels.GetByID(ctx, label.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func testLabelNotFound(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	missing := &domain.Label{ID: uuid.New(), Name: "нет", Color: "#000000", UserID: uuid.New()}
+
+	_, err := repos.Labels.GetByID(ctx, missing.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Labels.Update(ctx, missing), domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Labels.Delete(ctx, missing.ID), domain.ErrNotFound)
+
+	labels, err := repos.Labels.GetAllByUserID(ctx, missing.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, labels)
+}
+
+func testLabelList(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	other := createUser(t, repos)
+	alpha := createLabel(t, repos, user.ID, "alpha")
+	beta := createLabel(t, repos, user.ID, "beta")
+	gamma := createLabel(t, repos, user.ID, "gamma")
+	createLabel(t, repos, other.ID, "alpha")
+
+	sort := repository.Sort{Field: repository.LabelSortName}
+	first, err := repos.Labels.List(ctx, user.ID, repository.LabelFilter{Sort: sort, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.Label{alpha, beta}, first)
+
+	after := &repository.Cursor{Sort: sort.String(), Value: repository.LabelSortValue(beta, sort.Field), ID: beta.ID}
+	rest, err := repos.Labels.List(ctx, user.ID, repository.LabelFilter{Sort: sort, After: after, Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.Label{gamma}, rest)
+
+	found, err := repos.Labels.List(ctx, user.ID, repository.LabelFilter{Search: "BET", Sort: sort})
+	require.NoError(t, err)
+	assert.Equal(t, []*domain.Label{beta}, found)
+}
+
+func testTaskCRUD(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	task := createTask(t, repos, newTask(user.ID, "Написать отчет", now().Add(24*time.Hour)))
+
+	found, err := repos.Tasks.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assertTask(t, task, found)
+
+	completedAt := now()
+	task.Title = "Отправить отчет"
+	task.Description = "новое описание"
+	task.DueDate = now().Add(48 * time.Hour)
+	task.Status = domain.TaskStatusDone
+	task.CompletedAt = &completedAt
+	require.NoError(t, repos.Tasks.Update(ctx, task))
+	found, err = repos.Tasks.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assertTask(t, task, found)
+
+	all, err := repos.Tasks.GetAllByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, all, 1)
+	assertTask(t, task, all[0])
+
+	require.NoError(t, repos.Tasks.Delete(ctx, task.ID))
+	_, err = repos.Tasks.GetByID(ctx, task.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func testTaskNotFound(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	missing := newTask(uuid.New(), "нет", now())
+
+	_, err := repos.Tasks.GetByID(ctx, missing.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Tasks.Update(ctx, missing), domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Tasks.Delete(ctx, missing.ID), domain.ErrNotFound)
+
+	tasks, err := repos.Tasks.GetAllByUserID(ctx, missing.UserID)
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+func testTaskLabels(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	work := createLabel(t, repos, user.ID, "работа")
+	home := createLabel(t, repos, user.ID, "дом")
+	task := createTask(t, repos, newTask(user.ID, "Задача", now(), work.ID, home.ID))
+
+	found, err := repos.Tasks.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []uuid.UUID{work.ID, home.ID}, found.LabelIDs)
+
+	// Обновление заменяет набор меток
+	task.LabelIDs = []uuid.UUID{home.ID}
+	require.NoError(t, repos.Tasks.Update(ctx, task))
+	found, err = repos.Tasks.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{home.ID}, found.LabelIDs)
+
+	// Удаленная метка отвязывается от задачи
+	require.NoError(t, repos.Labels.Delete(ctx, home.ID))
+	found, err = repos.Tasks.GetByID(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Empty(t, found.LabelIDs)
+	assert.NotNil(t, found.LabelIDs)
+}
+
+func testTaskList(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	other := createUser(t, repos)
+	work := createLabel(t, repos, user.ID, "работа")
+	base := now()
+
+	report := createTask(t, repos, newTask(user.ID, "Report", base.Add(1*time.Hour), work.ID))
+	review := newTask(user.ID, "Review", base.Add(2*time.Hour))
+	review.Status = domain.TaskStatusInProgress
+	createTask(t, repos, review)
+	plan := createTask(t, repos, newTask(user.ID, "Plan", base.Add(3*time.Hour)))
+	createTask(t, repos, newTask(other.ID, "Report", base.Add(1*time.Hour)))
+
+	sort := repository.Sort{Field: repository.TaskSortDueDate}
+	list := func(filter repository.TaskFilter) []uuid.UUID {
+		t.Helper()
+		filter.Sort = sort
+		tasks, err := repos.Tasks.List(ctx, user.ID, filter)
+		require.NoError(t, err)
+		return taskIDs(tasks)
+	}
+
+	dueBefore := base.Add(2 * time.Hour)
+	dueAfter := base.Add(1 * time.Hour)
+	assert.Equal(t, []uuid.UUID{report.ID, review.ID, plan.ID}, list(repository.TaskFilter{}))
+	assert.Equal(t, []uuid.UUID{report.ID}, list(repository.TaskFilter{DueBefore: &dueBefore}))
+	assert.Equal(t, []uuid.UUID{review.ID, plan.ID}, list(repository.TaskFilter{DueAfter: &dueAfter}))
+	assert.Equal(t, []uuid.UUID{report.ID}, list(repository.TaskFilter{LabelIDs: []uuid.UUID{work.ID, uuid.New()}}))
+	assert.Equal(t, []uuid.UUID{review.ID}, list(repository.TaskFilter{Statuses: []domain.TaskStatus{domain.TaskStatusInProgress}}))
+	assert.Equal(t, []uuid.UUID{report.ID}, list(repository.TaskFilter{Search: "REPORT"}))
+	assert.Equal(t, []uuid.UUID{plan.ID}, list(repository.TaskFilter{Search: "описание plan"}))
+	assert.Equal(t, []uuid.UUID{}, list(repository.TaskFilter{Search: "100%"}))
+}
+
+func testTaskListPagination(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	due := now()
+
+	// У задач одинаковый срок, поэтому порядок внутри страницы задает ID
+	var created []*domain.Task
+	for _, title := range []string{"a", "b", "c", "d", "e"} {
+		created = append(created, createTask(t, repos, newTask(user.ID, title, due)))
+	}
+
+	for _, sort := range []repository.Sort{
+		{Field: repository.TaskSortDueDate},
+		{Field: repository.TaskSortDueDate, Desc: true},
+		{Field: repository.TaskSortTitle, Desc: true},
+		{Field: repository.TaskSortCreatedAt},
+	} {
+		t.Run(sort.String(), func(t *testing.T) {
+			all, err := repos.Tasks.List(ctx, user.ID, repository.TaskFilter{Sort: sort})
+			require.NoError(t, err)
+			require.Len(t, all, len(created))
+
+			var paged []*domain.Task
+			var after *repository.Cursor
+			for {
+				page, err := repos.Tasks.List(ctx, user.ID, repository.TaskFilter{Sort: sort, After: after, Limit: 2})
+				require.NoError(t, err)
+				paged = append(paged, page...)
+				if len(page) < 2 {
+					break
+				}
+				last := page[len(page)-1]
+				after = &repository.Cursor{Sort: sort.String(), Value: repository.TaskSortValue(last, sort.Field), ID: last.ID}
+			}
+
+			assert.Equal(t, taskIDs(all), taskIDs(paged))
+		})
+	}
+}
+
+func newSession(userID uuid.UUID, lastUsedAt time.Time) *domain.Session {
+	return &domain.Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Device:     domain.Device{Name: "Ноутбук", UserAgent: "curl/8.0", IP: "127.0.0.1"},
+		CreatedAt:  lastUsedAt,
+		LastUsedAt: lastUsedAt,
+	}
+}
+
+func createSession(t *testing.T, repos Repositories, userID uuid.UUID) *domain.Session {
+	t.Helper()
+
+	session := newSession(userID, now())
+	require.NoError(t, repos.Sessions.Create(context.Background(), session))
+	return session
+}
+
+// newRefreshToken создает refresh токен в новой сессии пользователя.
+func newRefreshToken(t *testing.T, repos Repositories, userID uuid.UUID) *domain.RefreshToken {
+	t.Helper()
+
+	return &domain.RefreshToken{
+		ID:         uuid.New(),
+		UserID:     userID,
+		FamilyID:   createSession(t, repos, userID).ID,
+		TokenHash:  uuid.NewString(),
+		ExpiryDate: now().Add(time.Hour),
+	}
+}
+
+func testRefreshTokenCRUD(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	token := newRefreshToken(t, repos, user.ID)
+	require.NoError(t, repos.RefreshTokens.Create(ctx, token))
+
+	found, err := repos.RefreshTokens.GetByTokenHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, found.ID)
+	assert.Equal(t, token.UserID, found.UserID)
+	assert.Equal(t, token.FamilyID, found.FamilyID)
+	assert.True(t, token.ExpiryDate.Equal(found.ExpiryDate))
+	assert.Nil(t, found.UsedAt)
+
+	require.NoError(t, repos.RefreshTokens.Delete(ctx, token.ID))
+	_, err = repos.RefreshTokens.GetByTokenHash(ctx, token.TokenHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	first, second := newRefreshToken(t, repos, user.ID), newRefreshToken(t, repos, user.ID)
+	require.NoError(t, repos.RefreshTokens.Create(ctx, first))
+	require.NoError(t, repos.RefreshTokens.Create(ctx, second))
+	require.NoError(t, repos.RefreshTokens.DeleteAllByUserID(ctx, user.ID))
+	for _, token := range []*domain.RefreshToken{first, second} {
+		_, err = repos.RefreshTokens.GetByTokenHash(ctx, token.TokenHash)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	}
+}
+
+func testRefreshTokenNotFound(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	_, err := repos.RefreshTokens.GetByTokenHash(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, repos.RefreshTokens.Delete(ctx, uuid.New()), domain.ErrNotFound)
+	assert.NoError(t, repos.RefreshTokens.DeleteAllByUserID(ctx, uuid.New()))
+}
+
+func testRefreshTokenUnique(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	token := newRefreshToken(t, repos, user.ID)
+	require.NoError(t, repos.RefreshTokens.Create(ctx, token))
+
+	duplicate := newRefreshToken(t, repos, user.ID)
+	duplicate.TokenHash = token.TokenHash
+	assert.ErrorIs(t, repos.RefreshTokens.Create(ctx, duplicate), domain.ErrConflict)
+}
+
+func testRefreshTokenRotation(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	first := newRefreshToken(t, repos, user.ID)
+	second := newRefreshToken(t, repos, user.ID)
+	second.FamilyID = first.FamilyID
+	other := newRefreshToken(t, repos, user.ID)
+	for _, token := range []*domain.RefreshToken{first, second, other} {
+		require.NoError(t, repos.RefreshTokens.Create(ctx, token))
+	}
+
+	usedAt := now()
+	require.NoError(t, repos.RefreshTokens.MarkUsed(ctx, first.ID, usedAt))
+	found, err := repos.RefreshTokens.GetByTokenHash(ctx, first.TokenHash)
+	require.NoError(t, err)
+	if assert.NotNil(t, found.UsedAt) {
+		assert.True(t, usedAt.Equal(*found.UsedAt))
+	}
+
+	// Повторная отметка означает, что токен уже обменян
+	assert.ErrorIs(t, repos.RefreshTokens.MarkUsed(ctx, first.ID, now()), domain.ErrConflict)
+
+	require.NoError(t, repos.RefreshTokens.DeleteByFamilyID(ctx, first.FamilyID))
+	for _, token := range []*domain.RefreshToken{first, second} {
+		_, err = repos.RefreshTokens.GetByTokenHash(ctx, token.TokenHash)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	}
+	_, err = repos.RefreshTokens.GetByTokenHash(ctx, other.TokenHash)
+	assert.NoError(t, err)
+}
+
+func testSessionCRUD(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	session := createSession(t, repos, user.ID)
+
+	found, err := repos.Sessions.GetByID(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, session.UserID, found.UserID)
+	assert.Equal(t, session.Device, found.Device)
+	assert.True(t, session.CreatedAt.Equal(found.CreatedAt))
+	assert.True(t, session.LastUsedAt.Equal(found.LastUsedAt))
+
+	lastUsedAt := session.LastUsedAt.Add(time.Minute)
+	require.NoError(t, repos.Sessions.Touch(ctx, session.ID, lastUsedAt))
+	found, err = repos.Sessions.GetByID(ctx, session.ID)
+	require.NoError(t, err)
+	assert.True(t, lastUsedAt.Equal(found.LastUsedAt))
+	assert.True(t, session.CreatedAt.Equal(found.CreatedAt))
+
+	require.NoError(t, repos.Sessions.Delete(ctx, session.ID))
+	_, err = repos.Sessions.GetByID(ctx, session.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	createSession(t, repos, user.ID)
+	createSession(t, repos, user.ID)
+	require.NoError(t, repos.Sessions.DeleteAllByUserID(ctx, user.ID))
+	sessions, err := repos.Sessions.ListByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func testSessionNotFound(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	_, err := repos.Sessions.GetByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Sessions.Touch(ctx, uuid.New(), now()), domain.ErrNotFound)
+	assert.ErrorIs(t, repos.Sessions.Delete(ctx, uuid.New()), domain.ErrNotFound)
+	assert.NoError(t, repos.Sessions.DeleteAllByUserID(ctx, uuid.New()))
+}
+
+func testSessionList(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	other := createUser(t, repos)
+	base := now()
+	older := newSession(user.ID, base.Add(-time.Hour))
+	newer := newSession(user.ID, base)
+	for _, session := range []*domain.Session{older, newer, newSession(other.ID, base)} {
+		require.NoError(t, repos.Sessions.Create(ctx, session))
+	}
+
+	sessions, err := repos.Sessions.ListByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	if assert.Len(t, sessions, 2) {
+		assert.Equal(t, newer.ID, sessions[0].ID)
+		assert.Equal(t, older.ID, sessions[1].ID)
+	}
+}
+
+func testSessionDeleteCascade(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	token := newRefreshToken(t, repos, user.ID)
+	other := newRefreshToken(t, repos, user.ID)
+	for _, token := range []*domain.RefreshToken{token, other} {
+		require.NoError(t, repos.RefreshTokens.Create(ctx, token))
+	}
+
+	require.NoError(t, repos.Sessions.Delete(ctx, token.FamilyID))
+
+	_, err := repos.RefreshTokens.GetByTokenHash(ctx, token.TokenHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.RefreshTokens.GetByTokenHash(ctx, other.TokenHash)
+	assert.NoError(t, err)
+}
+
+func testDenyList(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	jti, expired := uuid.New(), uuid.New()
+	expiresAt := now().Add(time.Hour)
+	require.NoError(t, repos.DenyList.Add(ctx, jti, expiresAt))
+	assert.ErrorIs(t, repos.DenyList.Add(ctx, jti, expiresAt), domain.ErrConflict, "токен уже в списке")
+	require.NoError(t, repos.DenyList.Add(ctx, expired, now().Add(-time.Minute)))
+	require.NoError(t, repos.DenyList.Add(ctx, expired, now().Add(-time.Minute)), "запись об истекшем токене заменяется")
+
+	denied, err := repos.DenyList.Contains(ctx, jti, now())
+	require.NoError(t, err)
+	assert.True(t, denied)
+
+	denied, err = repos.DenyList.Contains(ctx, jti, expiresAt)
+	require.NoError(t, err)
+	assert.False(t, denied, "после истечения срока токен не хранится в списке")
+
+	denied, err = repos.DenyList.Contains(ctx, expired, now())
+	require.NoError(t, err)
+	assert.False(t, denied)
+
+	denied, err = repos.DenyList.Contains(ctx, uuid.New(), now())
+	require.NoError(t, err)
+	assert.False(t, denied)
+}
+
+func createPasswordResetToken(t *testing.T, repos Repositories, userID uuid.UUID) *domain.PasswordResetToken {
+	t.Helper()
+
+	token := &domain.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		TokenHash: uuid.NewString(),
+		ExpiresAt: now().Add(time.Hour),
+	}
+	require.NoError(t, repos.PasswordResets.Create(context.Background(), token))
+	return token
+}
+
+func testPasswordResetConsume(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	token := createPasswordResetToken(t, repos, user.ID)
+
+	consumed, err := repos.PasswordResets.Consume(ctx, token.TokenHash)
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, consumed.ID)
+	assert.Equal(t, user.ID, consumed.UserID)
+	assert.True(t, token.ExpiresAt.Equal(consumed.ExpiresAt))
+
+	_, err = repos.PasswordResets.Consume(ctx, token.TokenHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "токен используется только один раз")
+}
+
+func testPasswordResetDeleteAll(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	other := createUser(t, repos)
+	first := createPasswordResetToken(t, repos, user.ID)
+	second := createPasswordResetToken(t, repos, user.ID)
+	otherToken := createPasswordResetToken(t, repos, other.ID)
+
+	require.NoError(t, repos.PasswordResets.DeleteAllByUserID(ctx, user.ID))
+
+	for _, token := range []*domain.PasswordResetToken{first, second} {
+		_, err := repos.PasswordResets.Consume(ctx, token.TokenHash)
+		assert.ErrorIs(t, err, domain.ErrNotFound)
+	}
+	_, err := repos.PasswordResets.Consume(ctx, otherToken.TokenHash)
+	assert.NoError(t, err)
+}
+
+func createTOTP(t *testing.T, repos Repositories, userID uuid.UUID) *domain.TOTP {
+	t.Helper()
+
+	totp := &domain.TOTP{UserID: userID, Secret: "JBSWY3DPEHPK3PXP", CreatedAt: now()}
+	require.NoError(t, repos.TOTP.Save(context.Background(), totp))
+	return totp
+}
+
+func testTOTPSave(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+
+	_, err := repos.TOTP.Get(ctx, user.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	totp := createTOTP(t, repos, user.ID)
+	got, err := repos.TOTP.Get(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, totp.Secret, got.Secret)
+	assert.False(t, got.Confirmed)
+	assert.True(t, totp.CreatedAt.Equal(got.CreatedAt))
+
+	// Повторное сохранение заменяет секрет
+	totp.Secret = "KRSXG5CTMVRXEZLU"
+	totp.Confirmed = true
+	require.NoError(t, repos.TOTP.Save(ctx, totp))
+	got, err = repos.TOTP.Get(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "KRSXG5CTMVRXEZLU", got.Secret)
+	assert.True(t, got.Confirmed)
+
+	require.NoError(t, repos.TOTP.ReplaceRecoveryCodes(ctx, user.ID, []string{uuid.NewString()}))
+	require.NoError(t, repos.TOTP.Delete(ctx, user.ID))
+	_, err = repos.TOTP.Get(ctx, user.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	count, err := repos.TOTP.CountRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count, "коды восстановления удаляются вместе с секретом")
+	assert.ErrorIs(t, repos.TOTP.Delete(ctx, user.ID), domain.ErrNotFound)
+}
+
+func testTOTPUseStep(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	createTOTP(t, repos, user.ID)
+
+	require.NoError(t, repos.TOTP.UseStep(ctx, user.ID, 100))
+	assert.ErrorIs(t, repos.TOTP.UseStep(ctx, user.ID, 100), domain.ErrConflict, "код нельзя использовать дважды")
+	assert.ErrorIs(t, repos.TOTP.UseStep(ctx, user.ID, 99), domain.ErrConflict)
+	assert.NoError(t, repos.TOTP.UseStep(ctx, user.ID, 101))
+
+	got, err := repos.TOTP.Get(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(101), got.LastUsedStep)
+
+	assert.ErrorIs(t, repos.TOTP.UseStep(ctx, uuid.New(), 1), domain.ErrNotFound)
+}
+
+func testRecoveryCodes(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	other := createUser(t, repos)
+	first, second := uuid.NewString(), uuid.NewString()
+	require.NoError(t, repos.TOTP.ReplaceRecoveryCodes(ctx, user.ID, []string{first, second}))
+	require.NoError(t, repos.TOTP.ReplaceRecoveryCodes(ctx, other.ID, []string{first}))
+
+	require.NoError(t, repos.TOTP.UseRecoveryCode(ctx, user.ID, first))
+	assert.ErrorIs(t, repos.TOTP.UseRecoveryCode(ctx, user.ID, first), domain.ErrNotFound, "код используется только один раз")
+	count, err := repos.TOTP.CountRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+	count, err = repos.TOTP.CountRecoveryCodes(ctx, other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count, "коды других пользователей не затрагиваются")
+
+	// Новые коды заменяют прежние
+	third := uuid.NewString()
+	require.NoError(t, repos.TOTP.ReplaceRecoveryCodes(ctx, user.ID, []string{third}))
+	assert.ErrorIs(t, repos.TOTP.UseRecoveryCode(ctx, user.ID, second), domain.ErrNotFound)
+	assert.NoError(t, repos.TOTP.UseRecoveryCode(ctx, user.ID, third))
+}
+
+func testLoginThrottle(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	key := "account:" + uuid.NewString()
+	at := now()
+
+	_, err := repos.LoginThrottles.Get(ctx, key)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, repos.LoginThrottles.Lock(ctx, key, at), domain.ErrNotFound)
+
+	throttle, err := repos.LoginThrottles.RecordFailure(ctx, key, at, at.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, throttle.Failures)
+	throttle, err = repos.LoginThrottles.RecordFailure(ctx, key, at.Add(time.Second), at.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 2, throttle.Failures)
+	assert.True(t, at.Add(time.Second).Equal(throttle.LastFailureAt))
+	assert.Nil(t, throttle.LockedUntil)
+
+	until := at.Add(time.Minute)
+	require.NoError(t, repos.LoginThrottles.Lock(ctx, key, until))
+	found, err := repos.LoginThrottles.Get(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, 2, found.Failures)
+	require.NotNil(t, found.LockedUntil)
+	assert.True(t, until.Equal(*found.LockedUntil))
+	assert.True(t, found.Locked(at))
+	assert.False(t, found.Locked(until))
+
+	other := "ip:" + uuid.NewString()
+	_, err = repos.LoginThrottles.RecordFailure(ctx, other, at, at)
+	require.NoError(t, err)
+	require.NoError(t, repos.LoginThrottles.Delete(ctx, key, other, "missing"))
+	_, err = repos.LoginThrottles.Get(ctx, key)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.LoginThrottles.Get(ctx, other)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func testLoginThrottleWindow(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	key := "account:" + uuid.NewString()
+	at := now()
+
+	for i := 0; i < 3; i++ {
+		_, err := repos.LoginThrottles.RecordFailure(ctx, key, at, at.Add(-time.Hour))
+		require.NoError(t, err)
+	}
+
+	// Попытки раньше resetBefore не учитываются
+	later := at.Add(2 * time.Hour)
+	throttle, err := repos.LoginThrottles.RecordFailure(ctx, key, later, later.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, throttle.Failures)
+}
+
+func testLoginThrottlePrune(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	stale := "account:" + uuid.NewString()
+	locked := "ip:" + uuid.NewString()
+	key := "mfa:" + uuid.NewString()
+	at := now()
+
+	_, err := repos.LoginThrottles.RecordFailure(ctx, stale, at, at.Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = repos.LoginThrottles.RecordFailure(ctx, locked, at, at.Add(-time.Hour))
+	require.NoError(t, err)
+	require.NoError(t, repos.LoginThrottles.Lock(ctx, locked, at.Add(3*time.Hour)))
+
+	// Окно последней попытки истекло, блокировки нет
+	later := at.Add(2 * time.Hour)
+	_, err = repos.LoginThrottles.RecordFailure(ctx, key, later, later.Add(-time.Hour))
+	require.NoError(t, err)
+
+	_, err = repos.LoginThrottles.Get(ctx, stale)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "устаревший счетчик удаляется")
+	_, err = repos.LoginThrottles.Get(ctx, locked)
+	assert.NoError(t, err, "счетчик с действующей блокировкой сохраняется")
+	_, err = repos.LoginThrottles.Get(ctx, key)
+	assert.NoError(t, err)
+}
+
+func testAuditList(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	userID := uuid.New()
+	at := now()
+	first := &domain.AuditEvent{ID: uuid.New(), Type: domain.AuditLoginLockout, UserID: &userID, Subject: "account:alice@example.com", CreatedAt: at}
+	second := &domain.AuditEvent{ID: uuid.New(), Type: domain.AuditAccountUnlock, UserID: &userID, ActorID: &userID, Details: "снята администратором", CreatedAt: at.Add(time.Second)}
+	require.NoError(t, repos.Audit.Create(ctx, first))
+	require.NoError(t, repos.Audit.Create(ctx, second))
+	assert.ErrorIs(t, repos.Audit.Create(ctx, first), domain.ErrConflict)
+
+	events, err := repos.Audit.List(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.Equal(t, second.ID, events[0].ID, "сначала самые новые события")
+	assert.Equal(t, first.ID, events[1].ID)
+	assert.Equal(t, first.Subject, events[1].Subject)
+	assert.Nil(t, events[1].ActorID)
+	require.NotNil(t, events[0].ActorID)
+	assert.Equal(t, userID, *events[0].ActorID)
+	assert.True(t, second.CreatedAt.Equal(events[0].CreatedAt))
+
+	events, err = repos.Audit.List(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+func createPersonalToken(t *testing.T, repos Repositories, userID uuid.UUID, createdAt time.Time) *domain.PersonalAccessToken {
+	t.Helper()
+
+	token := &domain.PersonalAccessToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      "CI",
+		TokenHash: uuid.NewString(),
+		Scopes:    []string{"tasks:read", "tasks:write"},
+		CreatedAt: createdAt,
+	}
+	require.NoError(t, repos.PersonalTokens.Create(context.Background(), token))
+	return token
+}
+
+func testPersonalTokenCRUD(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	expiresAt := now().Add(time.Hour)
+	token := &domain.PersonalAccessToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		Name:      "Резервное копирование",
+		TokenHash: uuid.NewString(),
+		Scopes:    []string{"tasks:read"},
+		ExpiresAt: &expiresAt,
+		CreatedAt: now(),
+	}
+	require.NoError(t, repos.PersonalTokens.Create(ctx, token))
+
+	found, err := repos.PersonalTokens.GetByHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, found.ID)
+	assert.Equal(t, token.UserID, found.UserID)
+	assert.Equal(t, token.Name, found.Name)
+	assert.Equal(t, token.Scopes, found.Scopes)
+	require.NotNil(t, found.ExpiresAt)
+	assert.True(t, expiresAt.Equal(*found.ExpiresAt))
+	assert.Nil(t, found.LastUsedAt)
+	assert.True(t, token.CreatedAt.Equal(found.CreatedAt))
+
+	duplicate := *token
+	duplicate.ID = uuid.New()
+	assert.ErrorIs(t, repos.PersonalTokens.Create(ctx, &duplicate), domain.ErrConflict, "хеш токена уникален")
+
+	lastUsedAt := now().Add(time.Minute)
+	require.NoError(t, repos.PersonalTokens.Touch(ctx, token.ID, lastUsedAt))
+	found, err = repos.PersonalTokens.GetByID(ctx, token.ID)
+	require.NoError(t, err)
+	require.NotNil(t, found.LastUsedAt)
+	assert.True(t, lastUsedAt.Equal(*found.LastUsedAt))
+
+	require.NoError(t, repos.PersonalTokens.Delete(ctx, token.ID))
+	_, err = repos.PersonalTokens.GetByHash(ctx, token.TokenHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func testPersonalTokenNotFound(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+
+	_, err := repos.PersonalTokens.GetByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.PersonalTokens.GetByHash(ctx, uuid.NewString())
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, repos.PersonalTokens.Touch(ctx, uuid.New(), now()), domain.ErrNotFound)
+	assert.ErrorIs(t, repos.PersonalTokens.Delete(ctx, uuid.New()), domain.ErrNotFound)
+}
+
+func testPersonalTokenList(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	other := createUser(t, repos)
+	base := now()
+	older := createPersonalToken(t, repos, user.ID, base.Add(-time.Hour))
+	newer := createPersonalToken(t, repos, user.ID, base)
+	createPersonalToken(t, repos, other.ID, base)
+
+	tokens, err := repos.PersonalTokens.ListByUserID(ctx, user.ID)
+	require.NoError(t, err)
+	if assert.Len(t, tokens, 2) {
+		assert.Equal(t, newer.ID, tokens[0].ID)
+		assert.Equal(t, older.ID, tokens[1].ID)
+	}
+}
+
+func createOAuthClient(t *testing.T, repos Repositories, ownerID uuid.UUID, createdAt time.Time) *domain.OAuthClient {
+	t.Helper()
+
+	client := &domain.OAuthClient{
+		ID:           uuid.New(),
+		OwnerID:      ownerID,
+		Name:         "Отчеты",
+		SecretHash:   uuid.NewString(),
+		RedirectURIs: []string{"https://reports.example.com/callback"},
+		Scopes:       []string{"tasks:read"},
+		CreatedAt:    createdAt,
+	}
+	require.NoError(t, repos.OAuthClients.Create(context.Background(), client))
+	return client
+}
+
+func newOAuthCode(clientID, userID uuid.UUID) *domain.OAuthAuthorizationCode {
+	return &domain.OAuthAuthorizationCode{
+		CodeHash:      uuid.NewString(),
+		ClientID:      clientID,
+		UserID:        userID,
+		RedirectURI:   "https://reports.example.com/callback",
+		Scopes:        []string{"tasks:read"},
+		CodeChallenge: "challenge",
+		ExpiresAt:     now().Add(time.Minute),
+	}
+}
+
+func testOAuthClientCRUD(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	client := createOAuthClient(t, repos, user.ID, now())
+	public := &domain.OAuthClient{
+		ID:           uuid.New(),
+		OwnerID:      user.ID,
+		Name:         "CLI",
+		RedirectURIs: []string{"http://127.0.0.1/callback"},
+		Scopes:       []string{"tasks:read", "labels:read"},
+		CreatedAt:    now(),
+	}
+	require.NoError(t, repos.OAuthClients.Create(ctx, public))
+
+	found, err := repos.OAuthClients.GetByID(ctx, client.ID)
+	require.NoError(t, err)
+	assert.Equal(t, client.OwnerID, found.OwnerID)
+	assert.Equal(t, client.Name, found.Name)
+	assert.Equal(t, client.SecretHash, found.SecretHash)
+	assert.Equal(t, client.RedirectURIs, found.RedirectURIs)
+	assert.Equal(t, client.Scopes, found.Scopes)
+	assert.True(t, client.CreatedAt.Equal(found.CreatedAt))
+	found, err = repos.OAuthClients.GetByID(ctx, public.ID)
+	require.NoError(t, err)
+	assert.False(t, found.Confidential())
+
+	assert.ErrorIs(t, repos.OAuthClients.Create(ctx, client), domain.ErrConflict)
+
+	// Коды авторизации удаляются вместе с клиентом
+	code := newOAuthCode(client.ID, user.ID)
+	require.NoError(t, repos.OAuthCodes.Create(ctx, code))
+	require.NoError(t, repos.OAuthClients.Delete(ctx, client.ID))
+	_, err = repos.OAuthClients.GetByID(ctx, client.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.OAuthCodes.Consume(ctx, code.CodeHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.ErrorIs(t, repos.OAuthClients.Delete(ctx, client.ID), domain.ErrNotFound)
+}
+
+func testOAuthClientList(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	other := createUser(t, repos)
+	base := now()
+	older := createOAuthClient(t, repos, user.ID, base.Add(-time.Hour))
+	newer := createOAuthClient(t, repos, user.ID, base)
+	createOAuthClient(t, repos, other.ID, base)
+
+	clients, err := repos.OAuthClients.ListByOwnerID(ctx, user.ID)
+	require.NoError(t, err)
+	if assert.Len(t, clients, 2) {
+		assert.Equal(t, newer.ID, clients[0].ID)
+		assert.Equal(t, older.ID, clients[1].ID)
+	}
+}
+
+func testOAuthCodeConsume(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	client := createOAuthClient(t, repos, user.ID, now())
+	code := newOAuthCode(client.ID, user.ID)
+	require.NoError(t, repos.OAuthCodes.Create(ctx, code))
+
+	consumed, err := repos.OAuthCodes.Consume(ctx, code.CodeHash)
+	require.NoError(t, err)
+	assert.Equal(t, code.ClientID, consumed.ClientID)
+	assert.Equal(t, code.UserID, consumed.UserID)
+	assert.Equal(t, code.RedirectURI, consumed.RedirectURI)
+	assert.Equal(t, code.Scopes, consumed.Scopes)
+	assert.Equal(t, code.CodeChallenge, consumed.CodeChallenge)
+	assert.True(t, code.ExpiresAt.Equal(consumed.ExpiresAt))
+
+	_, err = repos.OAuthCodes.Consume(ctx, code.CodeHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "код используется один раз")
+}
+
+func testExternalIdentity(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	identity := &domain.ExternalIdentity{
+		Issuer:    "https://idp.example.com",
+		Subject:   uuid.NewString(),
+		UserID:    user.ID,
+		CreatedAt: now(),
+	}
+	require.NoError(t, repos.ExternalIdentities.Create(ctx, identity))
+
+	found, err := repos.ExternalIdentities.Get(ctx, identity.Issuer, identity.Subject)
+	require.NoError(t, err)
+	assert.Equal(t, identity.UserID, found.UserID)
+	assert.True(t, identity.CreatedAt.Equal(found.CreatedAt))
+
+	_, err = repos.ExternalIdentities.Get(ctx, "https://other.example.com", identity.Subject)
+	assert.ErrorIs(t, err, domain.ErrNotFound, "subject уникален только в пределах издателя")
+
+	other := createUser(t, repos)
+	duplicate := *identity
+	duplicate.UserID = other.ID
+	assert.ErrorIs(t, repos.ExternalIdentities.Create(ctx, &duplicate), domain.ErrConflict)
+}
+
+func testUserDeleteCascade(t *testing.T, repos Repositories) {
+	ctx := context.Background()
+	user := createUser(t, repos)
+	other := createUser(t, repos)
+	label := createLabel(t, repos, user.ID, "работа")
+	task := createTask(t, repos, newTask(user.ID, "Задача", now(), label.ID))
+	token := newRefreshToken(t, repos, user.ID)
+	require.NoError(t, repos.RefreshTokens.Create(ctx, token))
+	otherTask := createTask(t, repos, newTask(other.ID, "Чужая задача", now()))
+	otherSession := createSession(t, repos, other.ID)
+	resetToken := createPasswordResetToken(t, repos, user.ID)
+	createTOTP(t, repos, user.ID)
+	require.NoError(t, repos.TOTP.ReplaceRecoveryCodes(ctx, user.ID, []string{uuid.NewString()}))
+	personalToken := createPersonalToken(t, repos, user.ID, now())
+	client := createOAuthClient(t, repos, user.ID, now())
+	identity := &domain.ExternalIdentity{Issuer: "https://idp.example.com", Subject: uuid.NewString(), UserID: user.ID, CreatedAt: now()}
+	require.NoError(t, repos.ExternalIdentities.Create(ctx, identity))
+
+	require.NoError(t, repos.Users.Delete(ctx, user.ID))
+
+	_, err := repos.Tasks.GetByID(ctx, task.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.Labels.GetByID(ctx, label.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.RefreshTokens.GetByTokenHash(ctx, token.TokenHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.Sessions.GetByID(ctx, token.FamilyID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.PasswordResets.Consume(ctx, resetToken.TokenHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.TOTP.Get(ctx, user.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	count, err := repos.TOTP.CountRecoveryCodes(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	_, err = repos.PersonalTokens.GetByID(ctx, personalToken.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.OAuthClients.GetByID(ctx, client.ID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = repos.ExternalIdentities.Get(ctx, identity.Issuer, identity.Subject)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	_, err = repos.Tasks.GetByID(ctx, otherTask.ID)
+	assert.NoError(t, err)
+	_, err = repos.Sessions.GetByID(ctx, otherSession.ID)
+	assert.NoError(t, err)
+}
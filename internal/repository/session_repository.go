@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// SessionRepository определяет интерфейс для работы с сессиями пользователей.
+type SessionRepository interface {
+	Create(ctx context.Context, session *domain.Session) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Session, error)
+	// ListByUserID возвращает сессии пользователя, начиная с последней использованной.
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error)
+	Touch(ctx context.Context, id uuid.UUID, lastUsedAt time.Time) error
+	// Delete удаляет сессию вместе с ее refresh токенами.
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteAllByUserID(ctx context.Context, userID uuid.UUID) error
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/google/uuid"
@@ -11,7 +12,45 @@ import (
 type TaskRepository interface {
 	Create(ctx context.Context, task *domain.Task) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Task, error)
-	GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) // Получение всех задач пользователя
+	GetAllByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error)          // Получение всех задач пользователя
+	List(ctx context.Context, userID uuid.UUID, filter TaskFilter) ([]*domain.Task, error) // Постраничная выборка задач пользователя
 	Update(ctx context.Context, task *domain.Task) error
 	Delete(ctx context.Context, id uuid.UUID) error
 }
+
+// TaskFilter описывает параметры выборки задач пользователя.
+type TaskFilter struct {
+	DueBefore *time.Time          // Срок выполнения строго раньше указанного
+	DueAfter  *time.Time          // Срок выполнения строго позже указанного
+	LabelIDs  []uuid.UUID         // Задача привязана хотя бы к одной из меток
+	Statuses  []domain.TaskStatus // Задача находится в одном из статусов
+	Search    string              // Подстрока в названии или описании (без учета регистра)
+	Sort      Sort
+	After     *Cursor // Позиция, после которой начинается страница
+	Limit     int
+}
+
+// Поля сортировки задач.
+const (
+	TaskSortCreatedAt = "created_at"
+	TaskSortDueDate   = "due_date"
+	TaskSortTitle     = "title"
+)
+
+// ParseTaskSort разбирает параметр сортировки задач. Пустая строка означает
+// сортировку по времени создания.
+func ParseTaskSort(value string) (Sort, error) {
+	return parseSort(value, TaskSortCreatedAt, TaskSortCreatedAt, TaskSortDueDate, TaskSortTitle)
+}
+
+// TaskSortValue возвращает значение поля сортировки задачи для курсора.
+func TaskSortValue(task *domain.Task, field string) string {
+	switch field {
+	case TaskSortDueDate:
+		return task.DueDate.UTC().Format(time.RFC3339Nano)
+	case TaskSortTitle:
+		return task.Title
+	default:
+		return task.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/google/uuid"
+)
+
+// TOTPRepository определяет интерфейс для работы с секретами двухфакторной
+// аутентификации и кодами восстановления.
+type TOTPRepository interface {
+	// Save создает или заменяет секрет пользователя.
+	Save(ctx context.Context, totp *domain.TOTP) error
+	Get(ctx context.Context, userID uuid.UUID) (*domain.TOTP, error)
+	// UseStep запоминает принятый шаг TOTP. Если шаг не больше последнего
+	// принятого, возвращается ошибка domain.ErrConflict: код уже использован.
+	UseStep(ctx context.Context, userID uuid.UUID, step int64) error
+	// Delete удаляет секрет вместе с кодами восстановления.
+	Delete(ctx context.Context, userID uuid.UUID) error
+
+	// ReplaceRecoveryCodes заменяет коды восстановления пользователя.
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error
+	// UseRecoveryCode удаляет использованный код восстановления. Если кода
+	// нет, возвращается ошибка domain.ErrNotFound.
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/google/uuid"
@@ -12,6 +13,19 @@ type UserRepository interface {
 	Create(ctx context.Context, user *domain.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.User, error)
 	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	// Update сохраняет имя, email и признак подтверждения email. Пароль
+	// меняется только через ReplacePasswordHash.
 	Update(ctx context.Context, user *domain.User) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	// SetTokensValidAfter отзывает access токены пользователя, выданные
+	// раньше validAfter.
+	SetTokensValidAfter(ctx context.Context, id uuid.UUID, validAfter time.Time) error
+	// MarkEmailVerified отмечает email пользователя подтвержденным, если
+	// адрес пользователя все еще равен email.
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error
+	// ReplacePasswordHash заменяет хеш пароля, если он все еще равен
+	// oldHash, и возвращает domain.ErrNotFound, если пароль уже сменился.
+	ReplacePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error
+	// SetAdmin назначает или снимает права администратора. Update их не меняет.
+	SetAdmin(ctx context.Context, id uuid.UUID, isAdmin bool) error
 }
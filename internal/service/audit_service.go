@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+// AuditService определяет интерфейс журнала аудита событий безопасности.
+type AuditService interface {
+	// Record сохраняет событие. ID и время события заполняются, если не заданы.
+	Record(ctx context.Context, event *domain.AuditEvent) error
+	ListEvents(ctx context.Context, limit int) ([]*domain.AuditEvent, error)
+}
+
+// DefaultAuditService реализует интерфейс AuditService.
+type DefaultAuditService struct {
+	auditRepo repository.AuditRepository
+}
+
+// NewAuditService создает новый экземпляр DefaultAuditService.
+func NewAuditService(auditRepo repository.AuditRepository) *DefaultAuditService {
+	return &DefaultAuditService{auditRepo: auditRepo}
+}
+
+func (s *DefaultAuditService) Record(ctx context.Context, event *domain.AuditEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	}
+
+	if err := s.auditRepo.Create(ctx, event); err != nil {
+		return fmt.Errorf("ошибка при записи события аудита: %w", err)
+	}
+
+	log.Printf("audit: %s subject=%q %s", event.Type, event.Subject, event.Details)
+	return nil
+}
+
+func (s *DefaultAuditService) ListEvents(ctx context.Context, limit int) ([]*domain.AuditEvent, error) {
+	events, err := s.auditRepo.List(ctx, pageLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении событий аудита: %w", err)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,19 @@
+package service
+
+import (
+	"context"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/google/uuid"
+)
+
+// authorize проверяет, что ресурс принадлежит пользователю из контекста.
+// Для чужого ресурса возвращается notFound — та же ошибка, что и для
+// несуществующего, чтобы не раскрывать факт его существования.
+func authorize(ctx context.Context, ownerID uuid.UUID, notFound error) error {
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok || userID != ownerID {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/mail"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+)
+
+var (
+	// ErrInvalidVerificationToken возвращается для поддельной или истекшей
+	// ссылки подтверждения email, а также для ссылки на прежний адрес.
+	ErrInvalidVerificationToken = domain.NewError(domain.ErrValidation, "email.invalid_verification_token", "ссылка для подтверждения email недействительна или устарела")
+	// ErrEmailNotVerified возвращается при входе с неподтвержденным email,
+	// если подтверждение обязательно.
+	ErrEmailNotVerified = domain.NewError(domain.ErrForbidden, "email.not_verified", "email не подтвержден")
+)
+
+// EmailVerificationService определяет интерфейс для подтверждения email
+// пользователей по ссылке из письма.
+type EmailVerificationService interface {
+	// SendVerification отправляет пользователю ссылку для подтверждения его
+	// текущего email.
+	SendVerification(ctx context.Context, user *domain.User) error
+	// ResendVerification повторно отправляет ссылку. Для неизвестного или уже
+	// подтвержденного email ошибка не возвращается, чтобы не раскрывать,
+	// зарегистрирован ли он; по той же причине ошибка отправки письма только
+	// записывается в лог. Частые запросы по одному email или с одного
+	// IP-адреса отклоняются с ErrTooManyAttempts.
+	ResendVerification(ctx context.Context, email, ip string) error
+	// VerifyEmail подтверждает email по токену из ссылки и возвращает его.
+	VerifyEmail(ctx context.Context, token string) (string, error)
+	// CheckVerified возвращает ErrEmailNotVerified, если подтверждение email
+	// обязательно, а email пользователя не подтвержден.
+	CheckVerified(user *domain.User) error
+}
+
+// EmailVerificationConfig — параметры подтверждения email.
+type EmailVerificationConfig struct {
+	// URL — адрес GET /verify-email; токен передается в параметре token.
+	URL string
+	// Required запрещает вход пользователям с неподтвержденным email.
+	Required bool
+}
+
+// DefaultEmailVerificationService реализует интерфейс EmailVerificationService.
+type DefaultEmailVerificationService struct {
+	userRepo        repository.UserRepository
+	tokens          *auth.EmailTokens
+	mailer          mail.Mailer
+	throttleService LoginThrottleService
+	config          EmailVerificationConfig
+}
+
+// NewEmailVerificationService создает новый экземпляр DefaultEmailVerificationService.
+func NewEmailVerificationService(userRepo repository.UserRepository, tokens *auth.EmailTokens, mailer mail.Mailer, throttleService LoginThrottleService, config EmailVerificationConfig) *DefaultEmailVerificationService {
+	return &DefaultEmailVerificationService{userRepo: userRepo, tokens: tokens, mailer: mailer, throttleService: throttleService, config: config}
+}
+
+func (s *DefaultEmailVerificationService) SendVerification(ctx context.Context, user *domain.User) error {
+	token, err := s.tokens.Issue(user.ID, user.Email)
+	if err != nil {
+		return fmt.Errorf("ошибка при создании ссылки подтверждения email: %w", err)
+	}
+
+	link, err := url.Parse(s.config.URL)
+	if err != nil {
+		return fmt.Errorf("неверный адрес подтверждения email: %w", err)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Подтверждение email",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы подтвердить адрес %s, перейдите по ссылке:\n%s\n\n"+
+			"Если вы не регистрировались в Task Tracker, проигнорируйте это письмо.\n",
+			user.Username, user.Email, link),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		return fmt.Errorf("ошибка при отправке письма для подтверждения email: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DefaultEmailVerificationService) ResendVerification(ctx context.Context, email, ip string) error {
+	// Запросы учитываются до поиска пользователя, чтобы ограничение
+	// действовало одинаково для любого email
+	if err := s.throttleService.VerificationResendRequested(ctx, email, ip); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("ошибка при получении пользователя по email: %w", err)
+	}
+	if user.EmailVerified {
+		return nil
+	}
+
+	if err := s.SendVerification(ctx, user); err != nil {
+		log.Printf("failed to resend verification email to user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+func (s *DefaultEmailVerificationService) VerifyEmail(ctx context.Context, token string) (string, error) {
+	userID, email, err := s.tokens.Parse(token)
+	if err != nil {
+		return "", ErrInvalidVerificationToken
+	}
+
+	// Если email сменился после отправки ссылки, пользователь не найдется
+	if err := s.userRepo.MarkEmailVerified(ctx, userID, email); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return "", ErrInvalidVerificationToken
+		}
+		return "", fmt.Errorf("ошибка при подтверждении email: %w", err)
+	}
+
+	return email, nil
+}
+
+func (s *DefaultEmailVerificationService) CheckVerified(user *domain.User) error {
+	if s.config.Required && !user.EmailVerified {
+		return ErrEmailNotVerified
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockEmailVerificationService — mock-реализация EmailVerificationService.
+type MockEmailVerificationService struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationService) SendVerification(ctx context.Context, user *domain.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) ResendVerification(ctx context.Context, email, ip string) error {
+	args := m.Called(ctx, email, ip)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationService) VerifyEmail(ctx context.Context, token string) (string, error) {
+	args := m.Called(ctx, token)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockEmailVerificationService) CheckVerified(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+// newEmailVerificationFixture создает сервисы пользователей и подтверждения
+// email на хранилище в памяти.
+func newEmailVerificationFixture(t *testing.T, required bool) (*DefaultUserService, *DefaultEmailVerificationService, *recordingMailer) {
+	t.Helper()
+
+	tokens, err := auth.NewEmailTokens(auth.NewHMACKeySet("secret"), "task-tracker", time.Hour)
+	require.NoError(t, err)
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	mailer := &recordingMailer{}
+	throttle := NewLoginThrottleService(memory.NewLoginThrottleRepository(store), userRepo, NewAuditService(memory.NewAuditRepository(store)), testThrottleConfig)
+	verification := NewEmailVerificationService(userRepo, tokens, mailer, throttle, EmailVerificationConfig{
+		URL:      "http://localhost:8080/verify-email",
+		Required: required,
+	})
+	return NewUserService(userRepo, verification, newTestPasswords(t)), verification, mailer
+}
+
+func TestVerifyEmail(t *testing.T) {
+	// 1. Arrange
+	users, verification, mailer := newEmailVerificationFixture(t, false)
+	ctx := context.Background()
+	user, err := users.CreateUser(ctx, "alice", "alice@example.com", "password")
+	require.NoError(t, err)
+	require.Len(t, mailer.messages, 1)
+	assert.Equal(t, "alice@example.com", mailer.messages[0].To)
+
+	// 2. Act
+	email, err := verification.VerifyEmail(ctx, mailer.linkToken(t))
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", email)
+	found, err := users.GetUserByEmail(ctx, email)
+	require.NoError(t, err)
+	assert.True(t, found.EmailVerified)
+	assert.Equal(t, user.ID, found.ID)
+}
+
+func TestVerifyEmail_AfterEmailChange(t *testing.T) {
+	// 1. Arrange
+	users, verification, mailer := newEmailVerificationFixture(t, false)
+	user, err := users.CreateUser(context.Background(), "alice", "alice@example.com", "password")
+	require.NoError(t, err)
+	oldLink := mailer.linkToken(t)
+
+	ctx := auth.ContextWithUser(context.Background(), user.ID)
+	_, err = users.UpdateUser(ctx, user.ID, "alice", "alice@example.org")
+	require.NoError(t, err)
+
+	// 2. Act
+	_, err = verification.VerifyEmail(ctx, oldLink)
+
+	// 3. Assert: ссылка на прежний адрес не подходит, на новый отправлено письмо
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	require.Len(t, mailer.messages, 2)
+	assert.Equal(t, "alice@example.org", mailer.messages[1].To)
+	email, err := verification.VerifyEmail(ctx, mailer.linkToken(t))
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.org", email)
+}
+
+func TestVerifyEmail_InvalidToken(t *testing.T) {
+	// 1. Arrange
+	_, verification, _ := newEmailVerificationFixture(t, false)
+
+	// 2. Act
+	_, err := verification.VerifyEmail(context.Background(), "garbage")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+}
+
+func TestResendVerification(t *testing.T) {
+	// 1. Arrange
+	users, verification, mailer := newEmailVerificationFixture(t, false)
+	ctx := context.Background()
+	_, err := users.CreateUser(ctx, "alice", "alice@example.com", "password")
+	require.NoError(t, err)
+
+	// 2. Act
+	require.NoError(t, verification.ResendVerification(ctx, "alice@example.com", "10.0.0.1"))
+	require.NoError(t, verification.ResendVerification(ctx, "nobody@example.com", "10.0.0.1"))
+
+	// 3. Assert
+	require.Len(t, mailer.messages, 2, "письмо отправляется только зарегистрированному пользователю")
+	_, err = verification.VerifyEmail(ctx, mailer.linkToken(t))
+	require.NoError(t, err)
+	require.NoError(t, verification.ResendVerification(ctx, "alice@example.com", "10.0.0.1"))
+	assert.Len(t, mailer.messages, 2, "подтвержденному email письмо не отправляется")
+}
+
+func TestResendVerification_Throttled(t *testing.T) {
+	// 1. Arrange
+	users, verification, mailer := newEmailVerificationFixture(t, false)
+	ctx := context.Background()
+	_, err := users.CreateUser(ctx, "alice", "alice@example.com", "password")
+	require.NoError(t, err)
+	for i := 0; i < testThrottleConfig.MaxFailures; i++ {
+		require.NoError(t, verification.ResendVerification(ctx, "alice@example.com", "10.0.0.1"))
+		require.NoError(t, verification.ResendVerification(ctx, "nobody@example.com", "10.0.0.2"))
+	}
+
+	// 2. Act
+	err = verification.ResendVerification(ctx, "Alice@Example.com", "10.0.0.3")
+	unknown := verification.ResendVerification(ctx, "nobody@example.com", "10.0.0.3")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.ErrorIs(t, unknown, ErrTooManyAttempts, "ограничение не зависит от того, зарегистрирован ли email")
+	assert.Len(t, mailer.messages, 1+testThrottleConfig.MaxFailures)
+}
+
+func TestResendVerification_ThrottledByIP(t *testing.T) {
+	// 1. Arrange
+	users, verification, mailer := newEmailVerificationFixture(t, false)
+	ctx := context.Background()
+	_, err := users.CreateUser(ctx, "alice", "alice@example.com", "password")
+	require.NoError(t, err)
+	for i := 0; i < testThrottleConfig.MaxIPFailures; i++ {
+		require.NoError(t, verification.ResendVerification(ctx, fmt.Sprintf("user%d@example.com", i), "10.0.0.1"))
+	}
+
+	// 2. Act
+	err = verification.ResendVerification(ctx, "alice@example.com", "10.0.0.1")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.Len(t, mailer.messages, 1, "отправлено только письмо при регистрации")
+}
+
+func TestResendVerification_MailFailure(t *testing.T) {
+	// 1. Arrange
+	users, verification, mailer := newEmailVerificationFixture(t, false)
+	ctx := context.Background()
+	_, err := users.CreateUser(ctx, "alice", "alice@example.com", "password")
+	require.NoError(t, err)
+	mailer.err = errors.New("smtp unavailable")
+
+	// 2. Act
+	err = verification.ResendVerification(ctx, "alice@example.com", "10.0.0.1")
+	unknown := verification.ResendVerification(ctx, "nobody@example.com", "10.0.0.1")
+
+	// 3. Assert
+	assert.NoError(t, err, "ошибка отправки не раскрывает, зарегистрирован ли email")
+	assert.NoError(t, unknown)
+}
+
+func TestCheckVerified(t *testing.T) {
+	_, optional, _ := newEmailVerificationFixture(t, false)
+	_, required, _ := newEmailVerificationFixture(t, true)
+	unverified := &domain.User{ID: uuid.New()}
+	verified := &domain.User{ID: uuid.New(), EmailVerified: true}
+
+	assert.NoError(t, optional.CheckVerified(unverified))
+	assert.ErrorIs(t, required.CheckVerified(unverified), ErrEmailNotVerified)
+	assert.NoError(t, required.CheckVerified(verified))
+}
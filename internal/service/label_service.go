@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 
@@ -10,11 +11,15 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrLabelNotFound возвращается для несуществующих и чужих меток.
+var ErrLabelNotFound = domain.NewError(domain.ErrNotFound, "label.not_found", "метка не найдена")
+
 // LabelService определяет интерфейс для работы с метками.
 type LabelService interface {
 	CreateLabel(ctx context.Context, name, color string, userID uuid.UUID) (*domain.Label, error)
 	GetLabelByID(ctx context.Context, id uuid.UUID) (*domain.Label, error)
 	GetAllLabelsByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Label, error)
+	ListLabels(ctx context.Context, userID uuid.UUID, filter repository.LabelFilter) ([]*domain.Label, string, error)
 	UpdateLabel(ctx context.Context, id uuid.UUID, name, color string) (*domain.Label, error)
 	DeleteLabel(ctx context.Context, id uuid.UUID) error
 }
@@ -32,18 +37,21 @@ func NewLabelService(labelRepo repository.LabelRepository) *DefaultLabelService
 // CreateLabel создает новую метку.
 func (s *DefaultLabelService) CreateLabel(ctx context.Context, name, color string, userID uuid.UUID) (*domain.Label, error) {
 	if name == "" {
-		return nil, fmt.Errorf("необходимо указать название метки")
+		return nil, domain.NewFieldError("name", "label.name_required", "необходимо указать название метки")
 	}
 	if color == "" {
-		return nil, fmt.Errorf("необходимо указать цвет метки")
+		return nil, domain.NewFieldError("color", "label.color_required", "необходимо указать цвет метки")
 	}
 	if userID == uuid.Nil {
-		return nil, fmt.Errorf("необходимо указать пользователя")
+		return nil, domain.NewFieldError("user_id", "label.user_required", "необходимо указать пользователя")
+	}
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, err
 	}
 
 	hexColorRegex := regexp.MustCompile(`^#([0-9a-fA-F]{3}){1,2}$`)
 	if !hexColorRegex.MatchString(color) {
-		return nil, fmt.Errorf("неверный формат цвета (HEX)")
+		return nil, domain.NewFieldError("color", "label.invalid_color", "неверный формат цвета (HEX)")
 	}
 
 	label := &domain.Label{
@@ -61,14 +69,14 @@ func (s *DefaultLabelService) CreateLabel(ctx context.Context, name, color strin
 }
 
 func (s *DefaultLabelService) GetLabelByID(ctx context.Context, id uuid.UUID) (*domain.Label, error) {
-	label, err := s.labelRepo.GetByID(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при получении метки по ID: %w", err)
-	}
-	return label, nil
+	return s.getOwnedLabel(ctx, id)
 }
 
 func (s *DefaultLabelService) GetAllLabelsByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Label, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
 	labels, err := s.labelRepo.GetAllByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении меток пользователя: %w", err)
@@ -76,10 +84,40 @@ func (s *DefaultLabelService) GetAllLabelsByUserID(ctx context.Context, userID u
 	return labels, nil
 }
 
+// ListLabels возвращает страницу меток пользователя и курсор следующей страницы.
+// Пустой курсор означает, что страница последняя.
+func (s *DefaultLabelService) ListLabels(ctx context.Context, userID uuid.UUID, filter repository.LabelFilter) ([]*domain.Label, string, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, "", err
+	}
+
+	limit := pageLimit(filter.Limit)
+	filter.Limit = limit + 1 // Лишняя запись показывает, есть ли следующая страница
+
+	labels, err := s.labelRepo.List(ctx, userID, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка при получении меток пользователя: %w", err)
+	}
+
+	if len(labels) <= limit {
+		return labels, "", nil
+	}
+
+	labels = labels[:limit]
+	last := labels[limit-1]
+	nextCursor := repository.EncodeCursor(repository.Cursor{
+		Sort:  filter.Sort.String(),
+		Value: repository.LabelSortValue(last, filter.Sort.Field),
+		ID:    last.ID,
+	})
+
+	return labels, nextCursor, nil
+}
+
 func (s *DefaultLabelService) UpdateLabel(ctx context.Context, id uuid.UUID, name, color string) (*domain.Label, error) {
-	label, err := s.labelRepo.GetByID(ctx, id)
+	label, err := s.getOwnedLabel(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("метка не найдена")
+		return nil, err
 	}
 
 	label.Name = name
@@ -93,9 +131,28 @@ func (s *DefaultLabelService) UpdateLabel(ctx context.Context, id uuid.UUID, nam
 }
 
 func (s *DefaultLabelService) DeleteLabel(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.getOwnedLabel(ctx, id); err != nil {
+		return err
+	}
+
 	err := s.labelRepo.Delete(ctx, id)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении метки: %w", err)
 	}
 	return nil
 }
+
+// getOwnedLabel загружает метку и проверяет, что она принадлежит текущему пользователю.
+func (s *DefaultLabelService) getOwnedLabel(ctx context.Context, id uuid.UUID) (*domain.Label, error) {
+	label, err := s.labelRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrLabelNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении метки по ID: %w", err)
+	}
+	if err := authorize(ctx, label.UserID, ErrLabelNotFound); err != nil {
+		return nil, err
+	}
+	return label, nil
+}
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -39,6 +41,15 @@ func (m *MockLabelRepository) GetAllByUserID(ctx context.Context, userID uuid.UU
 	return labels, args.Error(1)
 }
 
+func (m *MockLabelRepository) List(ctx context.Context, userID uuid.UUID, filter repository.LabelFilter) ([]*domain.Label, error) {
+	args := m.Called(ctx, userID, filter)
+	labels, ok := args.Get(0).([]*domain.Label)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return labels, args.Error(1)
+}
+
 func (m *MockLabelRepository) Update(ctx context.Context, label *domain.Label) error {
 	args := m.Called(ctx, label)
 	return args.Error(0)
@@ -53,11 +64,11 @@ func TestCreateLabel(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	name := "Test Label"
 	color := "#FFFFFF"
-	userID := uuid.New()
 
 	// Настройка mock-репозитория
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Label")).Return(nil)
@@ -79,11 +90,11 @@ func TestCreateLabel_EmptyName(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	name := ""
 	color := "#FFFFFF"
-	userID := uuid.New()
 
 	// 2. Act
 	label, err := labelService.CreateLabel(ctx, name, color, userID)
@@ -100,11 +111,11 @@ func TestCreateLabel_InvalidColor(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	name := "Test Label"
 	color := "invalid-color"
-	userID := uuid.New()
 
 	// 2. Act
 	label, err := labelService.CreateLabel(ctx, name, color, userID)
@@ -113,6 +124,7 @@ func TestCreateLabel_InvalidColor(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, label)
 	assert.EqualError(t, err, "неверный формат цвета (HEX)")
+	assert.ErrorIs(t, err, domain.ErrValidation)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -121,14 +133,15 @@ func TestGetLabelByID(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	labelID := uuid.New()
 	expectedLabel := &domain.Label{
 		ID:     labelID,
 		Name:   "Test Label",
 		Color:  "#FFFFFF",
-		UserID: uuid.New(),
+		UserID: userID,
 	}
 
 	// Настройка mock-репозитория
@@ -148,7 +161,8 @@ func TestGetLabelByID_NotFound(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	labelID := uuid.New()
 
@@ -170,14 +184,15 @@ func TestUpdateLabel(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	labelID := uuid.New()
 	initialLabel := &domain.Label{
 		ID:     labelID,
 		Name:   "Old Label",
 		Color:  "#000000",
-		UserID: uuid.New(),
+		UserID: userID,
 	}
 	updatedName := "New Label"
 	updatedColor := "#FFFFFF"
@@ -204,14 +219,15 @@ func TestUpdateLabel_NotFound(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	labelID := uuid.New()
 	updatedName := "New Label"
 	updatedColor := "#FFFFFF"
 
 	// Настройка mock-репозитория
-	mockRepo.On("GetByID", mock.Anything, labelID).Return(nil, errors.New("label not found"))
+	mockRepo.On("GetByID", mock.Anything, labelID).Return(nil, domain.ErrNotFound)
 
 	// 2. Act
 	label, err := labelService.UpdateLabel(ctx, labelID, updatedName, updatedColor)
@@ -220,6 +236,7 @@ func TestUpdateLabel_NotFound(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, label)
 	assert.EqualError(t, err, "метка не найдена")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -228,11 +245,13 @@ func TestDeleteLabel(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	labelID := uuid.New()
 
 	// Настройка mock-репозитория
+	mockRepo.On("GetByID", mock.Anything, labelID).Return(&domain.Label{ID: labelID, UserID: userID}, nil)
 	mockRepo.On("Delete", mock.Anything, labelID).Return(nil)
 
 	// 2. Act
@@ -248,12 +267,14 @@ func TestDeleteLabel_Error(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	labelID := uuid.New()
 	expectedError := errors.New("delete error")
 
 	// Настройка mock-репозитория
+	mockRepo.On("GetByID", mock.Anything, labelID).Return(&domain.Label{ID: labelID, UserID: userID}, nil)
 	mockRepo.On("Delete", mock.Anything, labelID).Return(expectedError)
 
 	// 2. Act
@@ -266,13 +287,91 @@ func TestDeleteLabel_Error(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
-func TestGetAllLabelsByUserID(t *testing.T) {
+func TestGetLabelByID_ForeignLabel(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockLabelRepository)
+	labelService := NewLabelService(mockRepo)
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	labelID := uuid.New()
+
+	// Метка принадлежит другому пользователю
+	mockRepo.On("GetByID", mock.Anything, labelID).Return(&domain.Label{ID: labelID, Name: "Foreign", UserID: uuid.New()}, nil)
+
+	// 2. Act
+	label, err := labelService.GetLabelByID(ctx, labelID)
+
+	// 3. Assert
+	assert.Nil(t, label)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateLabel_ForeignLabel(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	labelID := uuid.New()
+
+	// Метка принадлежит другому пользователю
+	mockRepo.On("GetByID", mock.Anything, labelID).Return(&domain.Label{ID: labelID, Name: "Foreign", UserID: uuid.New()}, nil)
 
+	// 2. Act
+	label, err := labelService.UpdateLabel(ctx, labelID, "New Label", "#FFFFFF")
+
+	// 3. Assert
+	assert.Nil(t, label)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestDeleteLabel_ForeignLabel(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockLabelRepository)
+	labelService := NewLabelService(mockRepo)
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	labelID := uuid.New()
+
+	// Метка принадлежит другому пользователю
+	mockRepo.On("GetByID", mock.Anything, labelID).Return(&domain.Label{ID: labelID, UserID: uuid.New()}, nil)
+
+	// 2. Act
+	err := labelService.DeleteLabel(ctx, labelID)
+
+	// 3. Assert
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestGetAllLabelsByUserID_ForeignUser(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockLabelRepository)
+	labelService := NewLabelService(mockRepo)
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	// 2. Act
+	labels, err := labelService.GetAllLabelsByUserID(ctx, uuid.New())
+
+	// 3. Assert
+	assert.Nil(t, labels)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertNotCalled(t, "GetAllByUserID", mock.Anything, mock.Anything)
+}
+
+func TestGetAllLabelsByUserID(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockLabelRepository)
+	labelService := NewLabelService(mockRepo)
 	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
 	expectedLabels := []*domain.Label{
 		{ID: uuid.New(), Name: "Label 1", Color: "#FFFFFF", UserID: userID},
 		{ID: uuid.New(), Name: "Label 2", Color: "#000000", UserID: userID},
@@ -295,9 +394,9 @@ func TestGetAllLabelsByUserID_Error(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockLabelRepository)
 	labelService := NewLabelService(mockRepo)
-	ctx := context.Background()
-
 	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
 	expectedError := errors.New("get all error")
 
 	// Настройка mock-репозитория
@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrTooManyAttempts возвращается, пока вход временно заблокирован после
+// неудачных попыток. Параметр retry_after — через сколько секунд можно
+// повторить попытку (см. tooManyAttempts).
+var ErrTooManyAttempts = domain.NewError(domain.ErrTooManyRequests, "auth.too_many_attempts", "слишком много неудачных попыток, повторите через {retry_after} с")
+
+// tooManyAttempts возвращает ErrTooManyAttempts с временем до окончания блокировки.
+func tooManyAttempts(retryAfter time.Duration) error {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	return ErrTooManyAttempts.WithParams(map[string]string{"retry_after": strconv.Itoa(max(seconds, 1))})
+}
+
+// LoginThrottleService определяет интерфейс защиты входа от перебора.
+// Неудачные попытки считаются отдельно по email, IP-адресу клиента и
+// второму шагу входа пользователя, запросы писем сброса пароля и
+// подтверждения email — по email и IP-адресу. После заданного числа неудачных попыток
+// ключ блокируется; каждая следующая неудачная попытка после блокировки
+// удваивает ее срок.
+type LoginThrottleService interface {
+	// CheckLogin возвращает ErrTooManyAttempts, если вход по email или с
+	// IP-адреса заблокирован.
+	CheckLogin(ctx context.Context, email, ip string) error
+	LoginFailed(ctx context.Context, email, ip string) error
+	// LoginSucceeded сбрасывает счетчик email. Счетчик IP-адреса не
+	// сбрасывается, чтобы удачный вход в свой аккаунт не открывал перебор
+	// чужих.
+	LoginSucceeded(ctx context.Context, email string) error
+	// CheckMFA возвращает ErrTooManyAttempts, если проверка кодов второго
+	// шага пользователя заблокирована. Тот же счетчик ограничивает проверку
+	// текущего пароля при смене пароля и настроек двухфакторной
+	// аутентификации.
+	CheckMFA(ctx context.Context, userID uuid.UUID) error
+	MFAFailed(ctx context.Context, userID uuid.UUID) error
+	MFASucceeded(ctx context.Context, userID uuid.UUID) error
+	// PasswordResetRequested учитывает запрос ссылки для сброса пароля.
+	// Запросы считаются по email и IP-адресу так же, как неудачные попытки
+	// входа; пока один из них заблокирован, возвращается ErrTooManyAttempts.
+	PasswordResetRequested(ctx context.Context, email, ip string) error
+	// VerificationResendRequested учитывает запрос повторной отправки ссылки
+	// подтверждения email так же, как PasswordResetRequested.
+	VerificationResendRequested(ctx context.Context, email, ip string) error
+	// Unlock снимает блокировки входа пользователя. Вызывается администратором.
+	Unlock(ctx context.Context, userID uuid.UUID) error
+}
+
+// LoginThrottleConfig — параметры защиты входа от перебора.
+type LoginThrottleConfig struct {
+	// MaxFailures — число неудачных попыток по email или кодом второго шага,
+	// после которого вход блокируется.
+	MaxFailures int
+	// MaxIPFailures — то же для IP-адреса клиента.
+	MaxIPFailures int
+	// Lockout — срок первой блокировки, MaxLockout — наибольший срок.
+	Lockout    time.Duration
+	MaxLockout time.Duration
+	// FailureWindow — через сколько после последней неудачной попытки
+	// счетчик начинается заново.
+	FailureWindow time.Duration
+}
+
+// Validate проверяет параметры защиты входа.
+func (c LoginThrottleConfig) Validate() error {
+	if c.MaxFailures <= 0 || c.MaxIPFailures <= 0 {
+		return fmt.Errorf("неверное число неудачных попыток входа: %d, %d", c.MaxFailures, c.MaxIPFailures)
+	}
+	if c.Lockout <= 0 || c.MaxLockout < c.Lockout {
+		return fmt.Errorf("неверный срок блокировки входа: %s, %s", c.Lockout, c.MaxLockout)
+	}
+	if c.FailureWindow <= 0 {
+		return fmt.Errorf("неверный срок учета неудачных попыток входа: %s", c.FailureWindow)
+	}
+	return nil
+}
+
+// Ключи счетчиков неудачных попыток.
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+func mfaKey(userID uuid.UUID) string {
+	return "mfa:" + userID.String()
+}
+
+func resetKey(email string) string {
+	return "reset:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func resetIPKey(ip string) string {
+	return "reset-ip:" + ip
+}
+
+func verifyKey(email string) string {
+	return "verify:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func verifyIPKey(ip string) string {
+	return "verify-ip:" + ip
+}
+
+// DefaultLoginThrottleService реализует интерфейс LoginThrottleService.
+type DefaultLoginThrottleService struct {
+	throttleRepo repository.LoginThrottleRepository
+	userRepo     repository.UserRepository
+	auditService AuditService
+	config       LoginThrottleConfig
+	now          func() time.Time
+}
+
+// NewLoginThrottleService создает новый экземпляр DefaultLoginThrottleService.
+func NewLoginThrottleService(throttleRepo repository.LoginThrottleRepository, userRepo repository.UserRepository, auditService AuditService, config LoginThrottleConfig) *DefaultLoginThrottleService {
+	return &DefaultLoginThrottleService{
+		throttleRepo: throttleRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+func (s *DefaultLoginThrottleService) CheckLogin(ctx context.Context, email, ip string) error {
+	return s.check(ctx, accountKey(email), ipKey(ip))
+}
+
+func (s *DefaultLoginThrottleService) LoginFailed(ctx context.Context, email, ip string) error {
+	var userID *uuid.UUID
+	if user, err := s.userRepo.GetByEmail(ctx, email); err == nil {
+		userID = &user.ID
+	}
+
+	if err := s.fail(ctx, accountKey(email), s.config.MaxFailures, domain.AuditLoginLockout, userID); err != nil {
+		return err
+	}
+	return s.fail(ctx, ipKey(ip), s.config.MaxIPFailures, domain.AuditLoginLockout, nil)
+}
+
+func (s *DefaultLoginThrottleService) LoginSucceeded(ctx context.Context, email string) error {
+	return s.reset(ctx, accountKey(email))
+}
+
+func (s *DefaultLoginThrottleService) CheckMFA(ctx context.Context, userID uuid.UUID) error {
+	return s.check(ctx, mfaKey(userID))
+}
+
+func (s *DefaultLoginThrottleService) MFAFailed(ctx context.Context, userID uuid.UUID) error {
+	return s.fail(ctx, mfaKey(userID), s.config.MaxFailures, domain.AuditLoginLockout, &userID)
+}
+
+func (s *DefaultLoginThrottleService) MFASucceeded(ctx context.Context, userID uuid.UUID) error {
+	return s.reset(ctx, mfaKey(userID))
+}
+
+func (s *DefaultLoginThrottleService) PasswordResetRequested(ctx context.Context, email, ip string) error {
+	return s.request(ctx, resetKey(email), resetIPKey(ip))
+}
+
+func (s *DefaultLoginThrottleService) VerificationResendRequested(ctx context.Context, email, ip string) error {
+	return s.request(ctx, verifyKey(email), verifyIPKey(ip))
+}
+
+func (s *DefaultLoginThrottleService) Unlock(ctx context.Context, userID uuid.UUID) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("ошибка при получении пользователя по ID: %w", err)
+	}
+
+	if err := s.reset(ctx, accountKey(user.Email), mfaKey(user.ID), resetKey(user.Email), verifyKey(user.Email)); err != nil {
+		return err
+	}
+
+	event := &domain.AuditEvent{Type: domain.AuditAccountUnlock, UserID: &user.ID, Subject: accountKey(user.Email)}
+	if actorID, ok := auth.UserIDFromContext(ctx); ok {
+		event.ActorID = &actorID
+	}
+	return s.auditService.Record(ctx, event)
+}
+
+// check возвращает ErrTooManyAttempts с наибольшим оставшимся сроком
+// блокировки среди ключей.
+func (s *DefaultLoginThrottleService) check(ctx context.Context, keys ...string) error {
+	now := s.now()
+	var retryAfter time.Duration
+	for _, key := range keys {
+		throttle, err := s.throttleRepo.Get(ctx, key)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				continue
+			}
+			return fmt.Errorf("ошибка при проверке блокировки входа: %w", err)
+		}
+		if throttle.Locked(now) {
+			retryAfter = max(retryAfter, throttle.LockedUntil.Sub(now))
+		}
+	}
+
+	if retryAfter > 0 {
+		return tooManyAttempts(retryAfter)
+	}
+	return nil
+}
+
+// request учитывает запрос, отправляющий письмо на адрес emailKey, если ни
+// этот адрес, ни IP-адрес ipKey не заблокированы.
+func (s *DefaultLoginThrottleService) request(ctx context.Context, emailKey, ipKey string) error {
+	if err := s.check(ctx, emailKey, ipKey); err != nil {
+		return err
+	}
+
+	if err := s.fail(ctx, emailKey, s.config.MaxFailures, domain.AuditMailThrottle, nil); err != nil {
+		return err
+	}
+	return s.fail(ctx, ipKey, s.config.MaxIPFailures, domain.AuditMailThrottle, nil)
+}
+
+// fail учитывает неудачную попытку и блокирует ключ, если их число достигло
+// limit. О каждой блокировке делается запись типа eventType в журнале аудита.
+func (s *DefaultLoginThrottleService) fail(ctx context.Context, key string, limit int, eventType string, userID *uuid.UUID) error {
+	now := s.now().UTC()
+	throttle, err := s.throttleRepo.RecordFailure(ctx, key, now, now.Add(-s.config.FailureWindow))
+	if err != nil {
+		return fmt.Errorf("ошибка при учете неудачной попытки входа: %w", err)
+	}
+	if throttle.Failures < limit {
+		return nil
+	}
+
+	until := now.Add(s.lockout(throttle.Failures - limit))
+	if err := s.throttleRepo.Lock(ctx, key, until); err != nil {
+		return fmt.Errorf("ошибка при блокировке входа: %w", err)
+	}
+
+	return s.auditService.Record(ctx, &domain.AuditEvent{
+		Type:    eventType,
+		UserID:  userID,
+		Subject: key,
+		Details: fmt.Sprintf("failures=%d locked_until=%s", throttle.Failures, until.Format(time.RFC3339)),
+	})
+}
+
+// lockout возвращает срок блокировки после n неудачных попыток сверх
+// допустимых: Lockout, удвоенный n раз, но не больше MaxLockout.
+func (s *DefaultLoginThrottleService) lockout(n int) time.Duration {
+	lockout := s.config.Lockout
+	for i := 0; i < n && lockout < s.config.MaxLockout; i++ {
+		lockout *= 2
+	}
+	return min(lockout, s.config.MaxLockout)
+}
+
+func (s *DefaultLoginThrottleService) reset(ctx context.Context, keys ...string) error {
+	if err := s.throttleRepo.Delete(ctx, keys...); err != nil {
+		return fmt.Errorf("ошибка при сбросе счетчика попыток входа: %w", err)
+	}
+	return nil
+}
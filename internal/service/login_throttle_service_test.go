@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testThrottleConfig = LoginThrottleConfig{
+	MaxFailures:   3,
+	MaxIPFailures: 5,
+	Lockout:       time.Minute,
+	MaxLockout:    4 * time.Minute,
+	FailureWindow: time.Hour,
+}
+
+type throttleFixture struct {
+	service *DefaultLoginThrottleService
+	audit   *DefaultAuditService
+	user    *domain.User
+	now     time.Time
+}
+
+// newThrottleFixture создает сервис на хранилище в памяти и пользователя
+// alice@example.com. Время сервиса задается полем now.
+func newThrottleFixture(t *testing.T) *throttleFixture {
+	t.Helper()
+
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	f := &throttleFixture{audit: NewAuditService(memory.NewAuditRepository(store)), now: time.Now()}
+	f.service = NewLoginThrottleService(memory.NewLoginThrottleRepository(store), users, f.audit, testThrottleConfig)
+	f.service.now = func() time.Time { return f.now }
+
+	f.user = &domain.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com"}
+	require.NoError(t, users.Create(context.Background(), f.user))
+	return f
+}
+
+// fail учитывает n неудачных попыток входа.
+func (f *throttleFixture) fail(t *testing.T, email, ip string, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, f.service.LoginFailed(context.Background(), email, ip))
+	}
+}
+
+// retryAfter возвращает параметр retry_after ошибки ErrTooManyAttempts.
+func retryAfter(t *testing.T, err error) string {
+	t.Helper()
+
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+	var domainErr *domain.Error
+	require.ErrorAs(t, err, &domainErr)
+	return domainErr.Params["retry_after"]
+}
+
+func TestLoginThrottle_AccountLockout(t *testing.T) {
+	// 1. Arrange
+	f := newThrottleFixture(t)
+	ctx := context.Background()
+	f.fail(t, "alice@example.com", "10.0.0.1", testThrottleConfig.MaxFailures-1)
+	require.NoError(t, f.service.CheckLogin(ctx, "alice@example.com", "10.0.0.1"))
+
+	// 2. Act
+	f.fail(t, "alice@example.com", "10.0.0.2", 1)
+
+	// 3. Assert
+	err := f.service.CheckLogin(ctx, " Alice@Example.com", "10.0.0.3")
+	assert.Equal(t, "60", retryAfter(t, err), "email блокируется независимо от IP-адреса")
+	assert.NoError(t, f.service.CheckLogin(ctx, "bob@example.com", "10.0.0.1"))
+
+	events, err := f.audit.ListEvents(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, domain.AuditLoginLockout, events[0].Type)
+	assert.Equal(t, "account:alice@example.com", events[0].Subject)
+	require.NotNil(t, events[0].UserID)
+	assert.Equal(t, f.user.ID, *events[0].UserID)
+}
+
+func TestLoginThrottle_ExponentialBackoff(t *testing.T) {
+	// 1. Arrange
+	f := newThrottleFixture(t)
+	ctx := context.Background()
+	f.fail(t, "alice@example.com", "10.0.0.1", testThrottleConfig.MaxFailures)
+
+	var retries []string
+	for i := 0; i < 4; i++ {
+		// 2. Act
+		f.now = f.now.Add(10 * time.Minute)
+		require.NoError(t, f.service.CheckLogin(ctx, "alice@example.com", "10.0.0.1"))
+		f.fail(t, "alice@example.com", "10.0.0.1", 1)
+		retries = append(retries, retryAfter(t, f.service.CheckLogin(ctx, "alice@example.com", "10.0.0.1")))
+	}
+
+	// 3. Assert
+	assert.Equal(t, []string{"120", "240", "240", "240"}, retries, "срок удваивается до MaxLockout")
+}
+
+func TestLoginThrottle_IPLockout(t *testing.T) {
+	// 1. Arrange
+	f := newThrottleFixture(t)
+	ctx := context.Background()
+
+	// 2. Act: перебор разных аккаунтов с одного адреса
+	for i := 0; i < testThrottleConfig.MaxIPFailures; i++ {
+		f.fail(t, uuid.NewString()+"@example.com", "10.0.0.1", 1)
+	}
+
+	// 3. Assert
+	assert.ErrorIs(t, f.service.CheckLogin(ctx, "alice@example.com", "10.0.0.1"), ErrTooManyAttempts)
+	assert.NoError(t, f.service.CheckLogin(ctx, "alice@example.com", "10.0.0.2"))
+}
+
+func TestLoginThrottle_SuccessResetsAccount(t *testing.T) {
+	// 1. Arrange
+	f := newThrottleFixture(t)
+	ctx := context.Background()
+	f.fail(t, "alice@example.com", "10.0.0.1", testThrottleConfig.MaxFailures-1)
+
+	// 2. Act
+	require.NoError(t, f.service.LoginSucceeded(ctx, "alice@example.com"))
+	f.fail(t, "alice@example.com", "10.0.0.1", testThrottleConfig.MaxFailures-1)
+
+	// 3. Assert
+	assert.NoError(t, f.service.CheckLogin(ctx, "alice@example.com", "10.0.0.1"))
+}
+
+func TestLoginThrottle_FailureWindow(t *testing.T) {
+	// 1. Arrange
+	f := newThrottleFixture(t)
+	f.fail(t, "alice@example.com", "10.0.0.1", testThrottleConfig.MaxFailures-1)
+
+	// 2. Act
+	f.now = f.now.Add(testThrottleConfig.FailureWindow + time.Second)
+	f.fail(t, "alice@example.com", "10.0.0.1", 1)
+
+	// 3. Assert
+	assert.NoError(t, f.service.CheckLogin(context.Background(), "alice@example.com", "10.0.0.1"))
+}
+
+func TestLoginThrottle_Unlock(t *testing.T) {
+	// 1. Arrange
+	f := newThrottleFixture(t)
+	f.fail(t, "alice@example.com", "10.0.0.1", testThrottleConfig.MaxFailures)
+	for i := 0; i < testThrottleConfig.MaxFailures; i++ {
+		require.NoError(t, f.service.MFAFailed(context.Background(), f.user.ID))
+	}
+	adminID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), adminID)
+
+	// 2. Act
+	err := f.service.Unlock(ctx, f.user.ID)
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.NoError(t, f.service.CheckLogin(ctx, "alice@example.com", "10.0.0.2"))
+	assert.NoError(t, f.service.CheckMFA(ctx, f.user.ID))
+
+	events, err := f.audit.ListEvents(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, domain.AuditAccountUnlock, events[0].Type)
+	require.NotNil(t, events[0].ActorID)
+	assert.Equal(t, adminID, *events[0].ActorID)
+
+	assert.ErrorIs(t, f.service.Unlock(ctx, uuid.New()), ErrUserNotFound)
+}
+
+func TestLoginThrottleConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *LoginThrottleConfig)
+	}{
+		{"нет попыток", func(c *LoginThrottleConfig) { c.MaxFailures = 0 }},
+		{"нет попыток с IP", func(c *LoginThrottleConfig) { c.MaxIPFailures = -1 }},
+		{"нулевая блокировка", func(c *LoginThrottleConfig) { c.Lockout = 0 }},
+		{"наибольший срок меньше первого", func(c *LoginThrottleConfig) { c.MaxLockout = time.Second }},
+		{"нулевое окно", func(c *LoginThrottleConfig) { c.FailureWindow = 0 }},
+	}
+
+	require.NoError(t, testThrottleConfig.Validate())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := testThrottleConfig
+			tt.modify(&config)
+
+			assert.Error(t, config.Validate())
+		})
+	}
+}
+
+func TestLoginThrottle_PasswordReset(t *testing.T) {
+	// 1. Arrange
+	f := newThrottleFixture(t)
+	ctx := context.Background()
+	for i := 0; i < testThrottleConfig.MaxFailures; i++ {
+		require.NoError(t, f.service.PasswordResetRequested(ctx, "alice@example.com", "10.0.0.1"))
+	}
+
+	// 2. Act
+	err := f.service.PasswordResetRequested(ctx, "alice@example.com", "10.0.0.2")
+
+	// 3. Assert
+	assert.Equal(t, "60", retryAfter(t, err))
+	assert.NoError(t, f.service.CheckLogin(ctx, "alice@example.com", "10.0.0.1"), "запросы сброса не блокируют вход")
+	events, err := f.audit.ListEvents(ctx, 0)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, domain.AuditMailThrottle, events[0].Type, "ограничение писем не считается блокировкой входа")
+	assert.Equal(t, "reset:alice@example.com", events[0].Subject)
+
+	require.NoError(t, f.service.Unlock(ctx, f.user.ID))
+	assert.NoError(t, f.service.PasswordResetRequested(ctx, "alice@example.com", "10.0.0.2"), "администратор снимает и эту блокировку")
+}
+
+func TestLoginThrottle_VerificationResend(t *testing.T) {
+	// 1. Arrange
+	f := newThrottleFixture(t)
+	ctx := context.Background()
+	for i := 0; i < testThrottleConfig.MaxFailures; i++ {
+		require.NoError(t, f.service.VerificationResendRequested(ctx, "alice@example.com", "10.0.0.1"))
+	}
+
+	// 2. Act
+	err := f.service.VerificationResendRequested(ctx, "alice@example.com", "10.0.0.2")
+
+	// 3. Assert
+	assert.Equal(t, "60", retryAfter(t, err))
+	assert.NoError(t, f.service.PasswordResetRequested(ctx, "alice@example.com", "10.0.0.1"), "запросы разных писем считаются отдельно")
+	assert.NoError(t, f.service.CheckLogin(ctx, "alice@example.com", "10.0.0.1"))
+}
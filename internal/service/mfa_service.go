@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+// recoveryCodeCount — количество кодов восстановления, которые выдаются при
+// включении двухфакторной аутентификации.
+const recoveryCodeCount = 10
+
+var (
+	// ErrInvalidMFACode возвращается для неверного, уже использованного или
+	// пустого кода из приложения-аутентификатора или кода восстановления.
+	ErrInvalidMFACode = domain.NewFieldError("code", "mfa.invalid_code", "неверный код подтверждения")
+	// ErrMFAAlreadyEnabled возвращается при повторном включении двухфакторной
+	// аутентификации.
+	ErrMFAAlreadyEnabled = domain.NewError(domain.ErrConflict, "mfa.already_enabled", "двухфакторная аутентификация уже включена")
+	// ErrMFANotEnabled возвращается при попытке отключить двухфакторную
+	// аутентификацию или получить коды восстановления, если она не включена.
+	ErrMFANotEnabled = domain.NewError(domain.ErrConflict, "mfa.not_enabled", "двухфакторная аутентификация не включена")
+	// ErrMFAEnrollmentNotStarted возвращается при подтверждении, если секрет
+	// еще не создан.
+	ErrMFAEnrollmentNotStarted = domain.NewError(domain.ErrConflict, "mfa.enrollment_not_started", "подключение двухфакторной аутентификации не начато")
+	// ErrInvalidMFAChallenge возвращается для поддельного, истекшего или уже
+	// использованного токена второго шага входа.
+	ErrInvalidMFAChallenge = domain.NewError(domain.ErrUnauthorized, "mfa.invalid_challenge", "токен второго шага входа недействителен или устарел")
+)
+
+// TOTPEnrollment — секрет, который пользователь добавляет в
+// приложение-аутентификатор вручную или по QR-коду с URI.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// MFAStatus — состояние двухфакторной аутентификации пользователя.
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// MFAService определяет интерфейс для двухфакторной аутентификации по TOTP
+// (RFC 6238) с одноразовыми кодами восстановления.
+//
+// Там, где принимается code, подходит как код из приложения-аутентификатора,
+// так и код восстановления; код восстановления после использования удаляется.
+type MFAService interface {
+	Status(ctx context.Context, userID uuid.UUID) (*MFAStatus, error)
+	// IsEnabled сообщает, нужен ли пользователю второй шаг входа.
+	IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error)
+	// BeginTOTPEnrollment создает новый секрет. Двухфакторная аутентификация
+	// включается только после подтверждения кодом из приложения.
+	BeginTOTPEnrollment(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error)
+	// ConfirmTOTPEnrollment включает двухфакторную аутентификацию и
+	// возвращает коды восстановления. Коды показываются только один раз.
+	ConfirmTOTPEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error)
+	// DisableTOTP отключает двухфакторную аутентификацию. Требуется текущий
+	// пароль и код.
+	DisableTOTP(ctx context.Context, userID uuid.UUID, currentPassword, code string) error
+	// RegenerateRecoveryCodes заменяет коды восстановления новыми. Требуется
+	// текущий пароль и код.
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, currentPassword, code string) ([]string, error)
+	// NewChallenge выдает токен второго шага входа после проверки пароля.
+	NewChallenge(userID uuid.UUID) (string, error)
+	// CompleteChallenge проверяет токен второго шага входа и код и возвращает
+	// ID пользователя. Токен можно использовать только один раз.
+	CompleteChallenge(ctx context.Context, token, code string) (uuid.UUID, error)
+}
+
+// MFAConfig — параметры двухфакторной аутентификации.
+type MFAConfig struct {
+	// Issuer — название сервиса в приложении-аутентификаторе.
+	Issuer string
+}
+
+// DefaultMFAService реализует интерфейс MFAService.
+type DefaultMFAService struct {
+	userRepo     repository.UserRepository
+	totpRepo     repository.TOTPRepository
+	denyListRepo repository.DenyListRepository
+	throttle     LoginThrottleService
+	challenges   *auth.MFAChallenges
+	hasher       *auth.PasswordHasher
+	config       MFAConfig
+	now          func() time.Time
+}
+
+// NewMFAService создает новый экземпляр DefaultMFAService.
+func NewMFAService(userRepo repository.UserRepository, totpRepo repository.TOTPRepository, denyListRepo repository.DenyListRepository, throttle LoginThrottleService, challenges *auth.MFAChallenges, hasher *auth.PasswordHasher, config MFAConfig) *DefaultMFAService {
+	return &DefaultMFAService{
+		userRepo:     userRepo,
+		totpRepo:     totpRepo,
+		denyListRepo: denyListRepo,
+		throttle:     throttle,
+		challenges:   challenges,
+		hasher:       hasher,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+func (s *DefaultMFAService) Status(ctx context.Context, userID uuid.UUID) (*MFAStatus, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &MFAStatus{Enabled: enabled}
+	if enabled {
+		status.RecoveryCodesRemaining, err = s.totpRepo.CountRecoveryCodes(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return status, nil
+}
+
+func (s *DefaultMFAService) IsEnabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	totp, err := s.totpRepo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("ошибка при получении секрета TOTP: %w", err)
+	}
+
+	return totp.Confirmed, nil
+}
+
+func (s *DefaultMFAService) BeginTOTPEnrollment(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	// Неподтвержденный секрет из прошлой попытки заменяется новым
+	secret, err := auth.NewTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	totp := &domain.TOTP{UserID: userID, Secret: secret, CreatedAt: s.now().UTC()}
+	if err := s.totpRepo.Save(ctx, totp); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении секрета TOTP: %w", err)
+	}
+
+	return &TOTPEnrollment{Secret: secret, URI: auth.TOTPURI(s.config.Issuer, user.Email, secret)}, nil
+}
+
+func (s *DefaultMFAService) ConfirmTOTPEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	totp, err := s.totpRepo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrMFAEnrollmentNotStarted
+		}
+		return nil, fmt.Errorf("ошибка при получении секрета TOTP: %w", err)
+	}
+	if totp.Confirmed {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	// Коды восстановления еще не выданы, поэтому подходит только код из приложения
+	step, ok := auth.ValidateTOTP(totp.Secret, code, s.now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+	totp.Confirmed = true
+	totp.LastUsedStep = step
+	if err := s.totpRepo.Save(ctx, totp); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении секрета TOTP: %w", err)
+	}
+
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+func (s *DefaultMFAService) DisableTOTP(ctx context.Context, userID uuid.UUID, currentPassword, code string) error {
+	if err := s.reauthenticate(ctx, userID, currentPassword, code); err != nil {
+		return err
+	}
+
+	if err := s.totpRepo.Delete(ctx, userID); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrMFANotEnabled
+		}
+		return fmt.Errorf("ошибка при отключении двухфакторной аутентификации: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DefaultMFAService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, currentPassword, code string) ([]string, error) {
+	if err := s.reauthenticate(ctx, userID, currentPassword, code); err != nil {
+		return nil, err
+	}
+
+	return s.replaceRecoveryCodes(ctx, userID)
+}
+
+func (s *DefaultMFAService) NewChallenge(userID uuid.UUID) (string, error) {
+	token, err := s.challenges.Issue(userID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при создании токена второго шага входа: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *DefaultMFAService) CompleteChallenge(ctx context.Context, token, code string) (uuid.UUID, error) {
+	challenge, err := s.challenges.Parse(token)
+	if err != nil {
+		return uuid.Nil, ErrInvalidMFAChallenge
+	}
+	used, err := s.denyListRepo.Contains(ctx, challenge.ID, s.now())
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("ошибка при проверке токена второго шага входа: %w", err)
+	}
+	if used {
+		return uuid.Nil, ErrInvalidMFAChallenge
+	}
+
+	totp, err := s.totpRepo.Get(ctx, challenge.UserID)
+	if err != nil {
+		// Двухфакторную аутентификацию отключили после первого шага
+		if errors.Is(err, domain.ErrNotFound) {
+			return uuid.Nil, ErrInvalidMFAChallenge
+		}
+		return uuid.Nil, fmt.Errorf("ошибка при получении секрета TOTP: %w", err)
+	}
+	if !totp.Confirmed {
+		return uuid.Nil, ErrInvalidMFAChallenge
+	}
+	if err := s.verifyCode(ctx, totp, code); err != nil {
+		return uuid.Nil, err
+	}
+
+	// Токен отмечается использованным атомарно: из параллельных запросов с
+	// одним токеном сессию получает только первый
+	if err := s.denyListRepo.Add(ctx, challenge.ID, challenge.ExpiresAt); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return uuid.Nil, ErrInvalidMFAChallenge
+		}
+		return uuid.Nil, fmt.Errorf("ошибка при отзыве токена второго шага входа: %w", err)
+	}
+
+	return challenge.UserID, nil
+}
+
+// reauthenticate проверяет текущий пароль и код пользователя перед
+// изменением настроек двухфакторной аутентификации. Неверный пароль
+// учитывается той же защитой от перебора, что и неверный код, иначе
+// владелец access токена мог бы подбирать пароль без ограничений.
+func (s *DefaultMFAService) reauthenticate(ctx context.Context, userID uuid.UUID, currentPassword, code string) error {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return err
+	}
+
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.throttle.CheckMFA(ctx, user.ID); err != nil {
+		return err
+	}
+	if _, err := s.hasher.Verify(user.Password, currentPassword); err != nil {
+		if err := s.throttle.MFAFailed(ctx, user.ID); err != nil {
+			return err
+		}
+		return ErrWrongPassword
+	}
+
+	totp, err := s.totpRepo.Get(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrMFANotEnabled
+		}
+		return fmt.Errorf("ошибка при получении секрета TOTP: %w", err)
+	}
+	if !totp.Confirmed {
+		return ErrMFANotEnabled
+	}
+
+	return s.verifyCode(ctx, totp, code)
+}
+
+// verifyCode проверяет код из приложения-аутентификатора или код
+// восстановления и отмечает его использованным. Неверные коды учитываются
+// защитой от перебора: после нескольких ошибок проверка кодов пользователя
+// временно блокируется.
+func (s *DefaultMFAService) verifyCode(ctx context.Context, totp *domain.TOTP, code string) error {
+	if err := s.throttle.CheckMFA(ctx, totp.UserID); err != nil {
+		return err
+	}
+
+	ok, err := s.useCode(ctx, totp, code)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := s.throttle.MFAFailed(ctx, totp.UserID); err != nil {
+			return err
+		}
+		return ErrInvalidMFACode
+	}
+
+	return s.throttle.MFASucceeded(ctx, totp.UserID)
+}
+
+// useCode отмечает код использованным и сообщает, был ли он действителен.
+func (s *DefaultMFAService) useCode(ctx context.Context, totp *domain.TOTP, code string) (bool, error) {
+	if code == "" {
+		return false, nil
+	}
+
+	if step, ok := auth.ValidateTOTP(totp.Secret, code, s.now()); ok {
+		if err := s.totpRepo.UseStep(ctx, totp.UserID, step); err != nil {
+			if errors.Is(err, domain.ErrConflict) {
+				return false, nil
+			}
+			return false, fmt.Errorf("ошибка при использовании кода TOTP: %w", err)
+		}
+		return true, nil
+	}
+
+	if err := s.totpRepo.UseRecoveryCode(ctx, totp.UserID, auth.HashRecoveryCode(code)); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("ошибка при использовании кода восстановления: %w", err)
+	}
+	return true, nil
+}
+
+// replaceRecoveryCodes создает новые коды восстановления и сохраняет их хеши.
+func (s *DefaultMFAService) replaceRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := auth.NewRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes[i] = code
+		hashes[i] = auth.HashRecoveryCode(code)
+	}
+
+	if err := s.totpRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("ошибка при сохранении кодов восстановления: %w", err)
+	}
+
+	return codes, nil
+}
+
+func (s *DefaultMFAService) getUser(ctx context.Context, userID uuid.UUID) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении пользователя по ID: %w", err)
+	}
+
+	return user, nil
+}
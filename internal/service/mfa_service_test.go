@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mfaFixture struct {
+	service    *DefaultMFAService
+	challenges *auth.MFAChallenges
+	user       *domain.User
+	ctx        context.Context
+	now        time.Time
+}
+
+// newMFAFixture создает сервис на хранилище в памяти и пользователя с
+// паролем password123. Время сервиса задается полем now.
+func newMFAFixture(t *testing.T) *mfaFixture {
+	t.Helper()
+
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	challenges, err := auth.NewMFAChallenges(auth.NewHMACKeySet("secret"), "task-tracker", 5*time.Minute)
+	require.NoError(t, err)
+
+	f := &mfaFixture{challenges: challenges, now: time.Now()}
+	throttle := NewLoginThrottleService(memory.NewLoginThrottleRepository(store), users, NewAuditService(memory.NewAuditRepository(store)), testThrottleConfig)
+	throttle.now = func() time.Time { return f.now }
+	f.service = NewMFAService(users, memory.NewTOTPRepository(store), memory.NewDenyListRepository(store), throttle, challenges, newTestPasswords(t).Hasher, MFAConfig{Issuer: "Task Tracker"})
+	f.service.now = func() time.Time { return f.now }
+
+	f.user = &domain.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", Password: hashPassword(t, "password123")}
+	require.NoError(t, users.Create(context.Background(), f.user))
+	f.ctx = auth.ContextWithUser(context.Background(), f.user.ID)
+	return f
+}
+
+// code возвращает код TOTP для текущего времени фикстуры.
+func (f *mfaFixture) code(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := auth.TOTPCode(secret, auth.TOTPStep(f.now))
+	require.NoError(t, err)
+	return code
+}
+
+// nextStep переводит время фикстуры на следующий шаг TOTP.
+func (f *mfaFixture) nextStep() {
+	f.now = f.now.Add(30 * time.Second)
+}
+
+// enable включает двухфакторную аутентификацию и возвращает секрет и коды
+// восстановления.
+func (f *mfaFixture) enable(t *testing.T) (string, []string) {
+	t.Helper()
+
+	enrollment, err := f.service.BeginTOTPEnrollment(f.ctx, f.user.ID)
+	require.NoError(t, err)
+	codes, err := f.service.ConfirmTOTPEnrollment(f.ctx, f.user.ID, f.code(t, enrollment.Secret))
+	require.NoError(t, err)
+	f.nextStep()
+	return enrollment.Secret, codes
+}
+
+func TestBeginTOTPEnrollment(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+
+	// 2. Act
+	enrollment, err := f.service.BeginTOTPEnrollment(f.ctx, f.user.ID)
+
+	// 3. Assert
+	require.NoError(t, err)
+	uri, err := url.Parse(enrollment.URI)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "/Task Tracker:alice@example.com", uri.Path)
+	assert.Equal(t, enrollment.Secret, uri.Query().Get("secret"))
+
+	enabled, err := f.service.IsEnabled(f.ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled, "до подтверждения второй шаг входа не требуется")
+}
+
+func TestConfirmTOTPEnrollment(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	enrollment, err := f.service.BeginTOTPEnrollment(f.ctx, f.user.ID)
+	require.NoError(t, err)
+
+	// 2. Act
+	codes, err := f.service.ConfirmTOTPEnrollment(f.ctx, f.user.ID, f.code(t, enrollment.Secret))
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	status, err := f.service.Status(f.ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, &MFAStatus{Enabled: true, RecoveryCodesRemaining: recoveryCodeCount}, status)
+
+	_, err = f.service.BeginTOTPEnrollment(f.ctx, f.user.ID)
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+}
+
+func TestConfirmTOTPEnrollment_Errors(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+
+	// 2. Act
+	_, notStarted := f.service.ConfirmTOTPEnrollment(f.ctx, f.user.ID, "123456")
+	enrollment, err := f.service.BeginTOTPEnrollment(f.ctx, f.user.ID)
+	require.NoError(t, err)
+	code := f.code(t, enrollment.Secret)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	_, wrongCode := f.service.ConfirmTOTPEnrollment(f.ctx, f.user.ID, wrong)
+	_, foreign := f.service.ConfirmTOTPEnrollment(auth.ContextWithUser(context.Background(), uuid.New()), f.user.ID, code)
+
+	// 3. Assert
+	assert.ErrorIs(t, notStarted, ErrMFAEnrollmentNotStarted)
+	assert.ErrorIs(t, wrongCode, ErrInvalidMFACode)
+	assert.ErrorIs(t, foreign, ErrUserNotFound)
+}
+
+func TestCompleteChallenge(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	secret, _ := f.enable(t)
+	token, err := f.service.NewChallenge(f.user.ID)
+	require.NoError(t, err)
+
+	// 2. Act
+	userID, err := f.service.CompleteChallenge(context.Background(), token, f.code(t, secret))
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID, userID)
+
+	f.nextStep()
+	_, err = f.service.CompleteChallenge(context.Background(), token, f.code(t, secret))
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge, "токен второго шага используется один раз")
+}
+
+func TestCompleteChallenge_ConcurrentUse(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	secret, codes := f.enable(t)
+	token, err := f.service.NewChallenge(f.user.ID)
+	require.NoError(t, err)
+	// Разные верные коды, чтобы запросы не отсекались защитой от повтора кода
+	attempts := append([]string{f.code(t, secret)}, codes...)
+
+	// 2. Act
+	errs := make([]error, len(attempts))
+	var wg sync.WaitGroup
+	for i, code := range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = f.service.CompleteChallenge(context.Background(), token, code)
+		}()
+	}
+	wg.Wait()
+
+	// 3. Assert
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+	}
+	assert.Equal(t, 1, succeeded, "токен второго шага дает только одну сессию")
+}
+
+func TestCompleteChallenge_CodeReplay(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	secret, _ := f.enable(t)
+	code := f.code(t, secret)
+	first, err := f.service.NewChallenge(f.user.ID)
+	require.NoError(t, err)
+	second, err := f.service.NewChallenge(f.user.ID)
+	require.NoError(t, err)
+	_, err = f.service.CompleteChallenge(context.Background(), first, code)
+	require.NoError(t, err)
+
+	// 2. Act
+	_, err = f.service.CompleteChallenge(context.Background(), second, code)
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrInvalidMFACode, "код TOTP нельзя использовать дважды")
+}
+
+func TestCompleteChallenge_RecoveryCode(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	_, codes := f.enable(t)
+	first, err := f.service.NewChallenge(f.user.ID)
+	require.NoError(t, err)
+	second, err := f.service.NewChallenge(f.user.ID)
+	require.NoError(t, err)
+
+	// 2. Act
+	userID, err := f.service.CompleteChallenge(context.Background(), first, codes[0])
+	_, reused := f.service.CompleteChallenge(context.Background(), second, codes[0])
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID, userID)
+	assert.ErrorIs(t, reused, ErrInvalidMFACode, "код восстановления одноразовый")
+	status, err := f.service.Status(f.ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, recoveryCodeCount-1, status.RecoveryCodesRemaining)
+}
+
+func TestCompleteChallenge_InvalidToken(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	secret, _ := f.enable(t)
+	otherKeys, err := auth.NewMFAChallenges(auth.NewHMACKeySet("other"), "task-tracker", time.Minute)
+	require.NoError(t, err)
+	forged, err := otherKeys.Issue(f.user.ID)
+	require.NoError(t, err)
+
+	// 2. Act
+	_, err = f.service.CompleteChallenge(context.Background(), forged, f.code(t, secret))
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
+
+func TestCompleteChallenge_Lockout(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	secret, _ := f.enable(t)
+	token, err := f.service.NewChallenge(f.user.ID)
+	require.NoError(t, err)
+	for i := 0; i < testThrottleConfig.MaxFailures; i++ {
+		_, err := f.service.CompleteChallenge(context.Background(), token, "wrong-code")
+		require.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+
+	// 2. Act
+	_, locked := f.service.CompleteChallenge(context.Background(), token, f.code(t, secret))
+	f.now = f.now.Add(testThrottleConfig.Lockout)
+	f.nextStep()
+	userID, err := f.service.CompleteChallenge(context.Background(), token, f.code(t, secret))
+
+	// 3. Assert
+	assert.ErrorIs(t, locked, ErrTooManyAttempts, "во время блокировки не принимается даже верный код")
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID, userID)
+}
+
+func TestDisableTOTP(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	secret, _ := f.enable(t)
+	token, err := f.service.NewChallenge(f.user.ID)
+	require.NoError(t, err)
+
+	// 2. Act
+	wrongPassword := f.service.DisableTOTP(f.ctx, f.user.ID, "wrong-password", f.code(t, secret))
+	noCode := f.service.DisableTOTP(f.ctx, f.user.ID, "password123", "")
+	err = f.service.DisableTOTP(f.ctx, f.user.ID, "password123", f.code(t, secret))
+
+	// 3. Assert
+	assert.ErrorIs(t, wrongPassword, ErrWrongPassword)
+	assert.ErrorIs(t, noCode, ErrInvalidMFACode)
+	require.NoError(t, err)
+	enabled, err := f.service.IsEnabled(f.ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	_, err = f.service.CompleteChallenge(context.Background(), token, f.code(t, secret))
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge, "выданные токены второго шага перестают действовать")
+	assert.ErrorIs(t, f.service.DisableTOTP(f.ctx, f.user.ID, "password123", "123456"), ErrMFANotEnabled)
+}
+
+func TestDisableTOTP_PasswordLockout(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	secret, _ := f.enable(t)
+	for i := 0; i < testThrottleConfig.MaxFailures; i++ {
+		err := f.service.DisableTOTP(f.ctx, f.user.ID, "wrong-password", f.code(t, secret))
+		require.ErrorIs(t, err, ErrWrongPassword)
+	}
+
+	// 2. Act
+	locked := f.service.DisableTOTP(f.ctx, f.user.ID, "password123", f.code(t, secret))
+	_, regenerateLocked := f.service.RegenerateRecoveryCodes(f.ctx, f.user.ID, "password123", f.code(t, secret))
+	f.now = f.now.Add(testThrottleConfig.Lockout)
+	f.nextStep()
+	err := f.service.DisableTOTP(f.ctx, f.user.ID, "password123", f.code(t, secret))
+
+	// 3. Assert
+	assert.ErrorIs(t, locked, ErrTooManyAttempts, "во время блокировки не принимается даже верный пароль")
+	assert.ErrorIs(t, regenerateLocked, ErrTooManyAttempts)
+	assert.NoError(t, err)
+}
+
+func TestRegenerateRecoveryCodes(t *testing.T) {
+	// 1. Arrange
+	f := newMFAFixture(t)
+	secret, oldCodes := f.enable(t)
+
+	// 2. Act
+	codes, err := f.service.RegenerateRecoveryCodes(f.ctx, f.user.ID, "password123", f.code(t, secret))
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodeCount)
+	token, err := f.service.NewChallenge(f.user.ID)
+	require.NoError(t, err)
+	_, err = f.service.CompleteChallenge(context.Background(), token, oldCodes[0])
+	assert.ErrorIs(t, err, ErrInvalidMFACode, "прежние коды восстановления перестают действовать")
+	_, err = f.service.CompleteChallenge(context.Background(), token, codes[0])
+	assert.NoError(t, err)
+}
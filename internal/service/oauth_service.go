@@ -0,0 +1,547 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+// Способы получения токена (grant_type) и тип ответа авторизации.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeClientCredentials = "client_credentials"
+	ResponseTypeCode           = "code"
+	PKCEMethodS256             = "S256"
+)
+
+// Ошибки регистрации клиентов OAuth.
+var (
+	ErrOAuthClientNameRequired   = domain.NewFieldError("name", "oauth_client.name_required", "необходимо указать название клиента")
+	ErrOAuthRedirectURIRequired  = domain.NewFieldError("redirect_uris", "oauth_client.redirect_uri_required", "необходимо указать redirect_uri")
+	ErrOAuthInvalidClientURI     = domain.NewFieldError("redirect_uris", "oauth_client.invalid_redirect_uri", "неверный redirect_uri {uri}: нужен абсолютный адрес http или https без фрагмента")
+	ErrOAuthClientScopesRequired = domain.NewFieldError("scopes", "oauth_client.scopes_required", "необходимо указать разрешения клиента")
+	ErrOAuthClientInvalidScope   = domain.NewFieldError("scopes", "oauth_client.invalid_scope", "неизвестное разрешение {scope}")
+	// ErrOAuthClientNotFound возвращается для несуществующих и чужих клиентов.
+	ErrOAuthClientNotFound = domain.NewError(domain.ErrNotFound, "oauth_client.not_found", "клиент OAuth не найден")
+)
+
+// Ошибки протокола OAuth2. Код после префикса "oauth." совпадает с кодом
+// ошибки RFC 6749, который возвращается клиенту в поле error.
+var (
+	ErrOAuthInvalidRequest          = domain.NewError(domain.ErrValidation, "oauth.invalid_request", "в запросе нет обязательного параметра или он указан неверно")
+	ErrOAuthInvalidClient           = domain.NewError(domain.ErrUnauthorized, "oauth.invalid_client", "неизвестный клиент или неверный секрет")
+	ErrOAuthInvalidGrant            = domain.NewError(domain.ErrValidation, "oauth.invalid_grant", "код авторизации недействителен, устарел или выдан другому клиенту")
+	ErrOAuthUnauthorizedClient      = domain.NewError(domain.ErrValidation, "oauth.unauthorized_client", "клиенту не разрешено это действие")
+	ErrOAuthUnsupportedGrantType    = domain.NewError(domain.ErrValidation, "oauth.unsupported_grant_type", "неподдерживаемый grant_type")
+	ErrOAuthUnsupportedResponseType = domain.NewError(domain.ErrValidation, "oauth.unsupported_response_type", "неподдерживаемый response_type")
+	ErrOAuthInvalidScope            = domain.NewError(domain.ErrValidation, "oauth.invalid_scope", "запрошены разрешения, недоступные клиенту")
+	// ErrOAuthInvalidRedirectURI возвращается пользователю, а не клиенту:
+	// на незарегистрированный адрес перенаправлять нельзя.
+	ErrOAuthInvalidRedirectURI = domain.NewError(domain.ErrValidation, "oauth.invalid_redirect_uri", "redirect_uri не зарегистрирован для клиента")
+	ErrOAuthPKCERequired       = domain.NewError(domain.ErrValidation, "oauth.pkce_required", "требуется PKCE: code_challenge с методом S256")
+)
+
+// RegisterOAuthClient — параметры регистрации клиента OAuth.
+type RegisterOAuthClient struct {
+	Name         string
+	RedirectURIs []string
+	Scopes       []string
+	// Confidential — клиент с секретом (серверное приложение). Публичные
+	// клиенты (SPA, мобильные и консольные приложения) получают токены
+	// только по коду авторизации с PKCE.
+	Confidential bool
+}
+
+// AuthorizationRequest — параметры запроса авторизации (RFC 6749, раздел
+// 4.1.1, и RFC 7636).
+type AuthorizationRequest struct {
+	ClientID            string
+	RedirectURI         string
+	ResponseType        string
+	Scope               string
+	State               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// AuthorizationPrompt — данные экрана согласия: какой клиент и с какими
+// разрешениями просит доступ от имени пользователя.
+type AuthorizationPrompt struct {
+	Client      *domain.OAuthClient
+	RedirectURI string
+	Scopes      []string
+	State       string
+}
+
+// ClientCredentials — учетные данные клиента из заголовка Authorization
+// (Basic) или тела запроса. У публичного клиента Secret пуст.
+type ClientCredentials struct {
+	ID     string
+	Secret string
+}
+
+// TokenRequest — параметры запроса к конечной точке токенов.
+type TokenRequest struct {
+	Client       ClientCredentials
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	Scope        string
+}
+
+// OAuthToken — ответ конечной точки токенов (RFC 6749, раздел 5.1).
+type OAuthToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// TokenIntrospection — ответ интроспекции токена (RFC 7662, раздел 2.2).
+// Для недействительного токена заполнено только Active = false.
+type TokenIntrospection struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	Subject   string   `json:"sub,omitempty"`
+	Audience  []string `json:"aud,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	TokenID   string   `json:"jti,omitempty"`
+}
+
+// OAuthConfig — параметры сервера авторизации.
+type OAuthConfig struct {
+	// CodeTTL — срок действия кода авторизации.
+	CodeTTL time.Duration
+}
+
+// OAuthService определяет интерфейс сервера авторизации OAuth2: регистрацию
+// клиентов, выдачу кодов авторизации с согласия пользователя, выдачу,
+// интроспекцию и отзыв access токенов.
+type OAuthService interface {
+	// RegisterClient регистрирует клиента пользователя ownerID. Секрет
+	// конфиденциального клиента возвращается только здесь.
+	RegisterClient(ctx context.Context, ownerID uuid.UUID, params RegisterOAuthClient) (string, *domain.OAuthClient, error)
+	ListClients(ctx context.Context, ownerID uuid.UUID) ([]*domain.OAuthClient, error)
+	// DeleteClient удаляет клиента. Выданные ему access токены после этого
+	// отклоняет TokenRevocationService.CheckAccessToken.
+	DeleteClient(ctx context.Context, id uuid.UUID) error
+	// PrepareAuthorization проверяет запрос авторизации и возвращает данные
+	// для экрана согласия.
+	PrepareAuthorization(ctx context.Context, req AuthorizationRequest) (*AuthorizationPrompt, error)
+	// Authorize выдает код авторизации, если пользователь userID дал
+	// согласие, и возвращает адрес, на который нужно перенаправить
+	// пользователя. При отказе адрес содержит ошибку access_denied.
+	Authorize(ctx context.Context, userID uuid.UUID, req AuthorizationRequest, approved bool) (string, error)
+	Token(ctx context.Context, req TokenRequest) (*OAuthToken, error)
+	Introspect(ctx context.Context, client ClientCredentials, token string) (*TokenIntrospection, error)
+	Revoke(ctx context.Context, client ClientCredentials, token string) error
+}
+
+// DefaultOAuthService реализует интерфейс OAuthService.
+type DefaultOAuthService struct {
+	clientRepo        repository.OAuthClientRepository
+	codeRepo          repository.OAuthCodeRepository
+	revocationService TokenRevocationService
+	issuer            *auth.Issuer
+	config            OAuthConfig
+	now               func() time.Time
+}
+
+// NewOAuthService создает новый экземпляр DefaultOAuthService.
+func NewOAuthService(clientRepo repository.OAuthClientRepository, codeRepo repository.OAuthCodeRepository, revocationService TokenRevocationService, issuer *auth.Issuer, config OAuthConfig) *DefaultOAuthService {
+	return &DefaultOAuthService{
+		clientRepo:        clientRepo,
+		codeRepo:          codeRepo,
+		revocationService: revocationService,
+		issuer:            issuer,
+		config:            config,
+		now:               time.Now,
+	}
+}
+
+func (s *DefaultOAuthService) RegisterClient(ctx context.Context, ownerID uuid.UUID, params RegisterOAuthClient) (string, *domain.OAuthClient, error) {
+	if err := authorize(ctx, ownerID, ErrUserNotFound); err != nil {
+		return "", nil, err
+	}
+
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return "", nil, ErrOAuthClientNameRequired
+	}
+	if len(params.RedirectURIs) == 0 {
+		return "", nil, ErrOAuthRedirectURIRequired
+	}
+	for _, uri := range params.RedirectURIs {
+		if !validRedirectURI(uri) {
+			return "", nil, ErrOAuthInvalidClientURI.WithParams(map[string]string{"uri": uri})
+		}
+	}
+	if len(params.Scopes) == 0 {
+		return "", nil, ErrOAuthClientScopesRequired
+	}
+	for _, scope := range params.Scopes {
+		if !auth.ValidScope(scope) {
+			return "", nil, ErrOAuthClientInvalidScope.WithParams(map[string]string{"scope": scope})
+		}
+	}
+
+	client := &domain.OAuthClient{
+		ID:           uuid.New(),
+		OwnerID:      ownerID,
+		Name:         name,
+		RedirectURIs: slices.Compact(slices.Clone(params.RedirectURIs)),
+		Scopes:       normalizeScopes(params.Scopes),
+		CreatedAt:    s.now().UTC().Truncate(time.Microsecond),
+	}
+	var secret string
+	if params.Confidential {
+		var err error
+		if secret, err = auth.NewToken(); err != nil {
+			return "", nil, err
+		}
+		client.SecretHash = auth.HashToken(secret)
+	}
+
+	if err := s.clientRepo.Create(ctx, client); err != nil {
+		return "", nil, fmt.Errorf("ошибка при создании клиента OAuth: %w", err)
+	}
+
+	return secret, client, nil
+}
+
+func (s *DefaultOAuthService) ListClients(ctx context.Context, ownerID uuid.UUID) ([]*domain.OAuthClient, error) {
+	if err := authorize(ctx, ownerID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	clients, err := s.clientRepo.ListByOwnerID(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении клиентов OAuth пользователя: %w", err)
+	}
+
+	return clients, nil
+}
+
+func (s *DefaultOAuthService) DeleteClient(ctx context.Context, id uuid.UUID) error {
+	client, err := s.getClient(ctx, id, ErrOAuthClientNotFound)
+	if err != nil {
+		return err
+	}
+	if err := authorize(ctx, client.OwnerID, ErrOAuthClientNotFound); err != nil {
+		return err
+	}
+
+	if err := s.clientRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrOAuthClientNotFound
+		}
+		return fmt.Errorf("ошибка при удалении клиента OAuth: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DefaultOAuthService) PrepareAuthorization(ctx context.Context, req AuthorizationRequest) (*AuthorizationPrompt, error) {
+	clientID, err := uuid.Parse(req.ClientID)
+	if err != nil {
+		return nil, ErrOAuthClientNotFound
+	}
+	client, err := s.getClient(ctx, clientID, ErrOAuthClientNotFound)
+	if err != nil {
+		return nil, err
+	}
+
+	// Без единственного зарегистрированного адреса redirect_uri обязателен
+	redirectURI := req.RedirectURI
+	if redirectURI == "" && len(client.RedirectURIs) == 1 {
+		redirectURI = client.RedirectURIs[0]
+	}
+	if !slices.Contains(client.RedirectURIs, redirectURI) {
+		return nil, ErrOAuthInvalidRedirectURI
+	}
+
+	if req.ResponseType != ResponseTypeCode {
+		return nil, ErrOAuthUnsupportedResponseType
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != PKCEMethodS256 {
+		return nil, ErrOAuthPKCERequired
+	}
+	scopes, err := clientScopes(client, req.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuthorizationPrompt{Client: client, RedirectURI: redirectURI, Scopes: scopes, State: req.State}, nil
+}
+
+func (s *DefaultOAuthService) Authorize(ctx context.Context, userID uuid.UUID, req AuthorizationRequest, approved bool) (string, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return "", err
+	}
+	prompt, err := s.PrepareAuthorization(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	if prompt.State != "" {
+		params.Set("state", prompt.State)
+	}
+	if !approved {
+		params.Set("error", "access_denied")
+		return withQuery(prompt.RedirectURI, params), nil
+	}
+
+	value, err := auth.NewToken()
+	if err != nil {
+		return "", err
+	}
+	code := &domain.OAuthAuthorizationCode{
+		CodeHash:      auth.HashToken(value),
+		ClientID:      prompt.Client.ID,
+		UserID:        userID,
+		RedirectURI:   req.RedirectURI,
+		Scopes:        prompt.Scopes,
+		CodeChallenge: req.CodeChallenge,
+		ExpiresAt:     s.now().UTC().Add(s.config.CodeTTL),
+	}
+	if err := s.codeRepo.Create(ctx, code); err != nil {
+		return "", fmt.Errorf("ошибка при создании кода авторизации: %w", err)
+	}
+
+	params.Set("code", value)
+	return withQuery(prompt.RedirectURI, params), nil
+}
+
+func (s *DefaultOAuthService) Token(ctx context.Context, req TokenRequest) (*OAuthToken, error) {
+	client, err := s.authenticateClient(ctx, req.Client)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.GrantType {
+	case GrantTypeAuthorizationCode:
+		return s.exchangeCode(ctx, client, req)
+	case GrantTypeClientCredentials:
+		// Токен клиента действует от имени пользователя, который его
+		// зарегистрировал. Публичному клиенту нельзя доверить такой доступ
+		if !client.Confidential() {
+			return nil, ErrOAuthUnauthorizedClient
+		}
+		scopes, err := clientScopes(client, req.Scope)
+		if err != nil {
+			return nil, err
+		}
+		return s.issueToken(client, client.OwnerID, scopes)
+	case "":
+		return nil, ErrOAuthInvalidRequest
+	default:
+		return nil, ErrOAuthUnsupportedGrantType
+	}
+}
+
+// exchangeCode обменивает код авторизации на access токен. Код проверяется
+// вместе с redirect_uri и code_verifier (PKCE).
+func (s *DefaultOAuthService) exchangeCode(ctx context.Context, client *domain.OAuthClient, req TokenRequest) (*OAuthToken, error) {
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, ErrOAuthInvalidRequest
+	}
+
+	code, err := s.codeRepo.Consume(ctx, auth.HashToken(req.Code))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrOAuthInvalidGrant
+		}
+		return nil, fmt.Errorf("ошибка при использовании кода авторизации: %w", err)
+	}
+
+	if code.ClientID != client.ID || !s.now().Before(code.ExpiresAt) {
+		return nil, ErrOAuthInvalidGrant
+	}
+	// redirect_uri из запроса авторизации обязателен и должен совпадать
+	// точно; без него в запросе авторизации допустим только
+	// зарегистрированный адрес
+	if code.RedirectURI != "" && req.RedirectURI != code.RedirectURI {
+		return nil, ErrOAuthInvalidGrant
+	}
+	if code.RedirectURI == "" && req.RedirectURI != "" && !slices.Contains(client.RedirectURIs, req.RedirectURI) {
+		return nil, ErrOAuthInvalidGrant
+	}
+	if !auth.VerifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		return nil, ErrOAuthInvalidGrant
+	}
+
+	return s.issueToken(client, code.UserID, code.Scopes)
+}
+
+func (s *DefaultOAuthService) issueToken(client *domain.OAuthClient, userID uuid.UUID, scopes []string) (*OAuthToken, error) {
+	token, _, err := s.issuer.IssueForClient(userID, client.ID.String(), scopes)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при выдаче access токена: %w", err)
+	}
+
+	return &OAuthToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(s.issuer.TTL().Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// Introspect сообщает, действует ли access токен. Интроспекция доступна
+// только конфиденциальным клиентам и только для их собственных токенов:
+// чужие токены, в том числе токены сессий пользователей, считаются
+// недействительными, чтобы не раскрывать их владельца и разрешения.
+func (s *DefaultOAuthService) Introspect(ctx context.Context, credentials ClientCredentials, token string) (*TokenIntrospection, error) {
+	client, err := s.authenticateClient(ctx, credentials)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential() {
+		return nil, ErrOAuthUnauthorizedClient
+	}
+
+	inactive := &TokenIntrospection{Active: false}
+	claims, err := s.issuer.Parse(token)
+	if err != nil || claims.ClientID != client.ID.String() {
+		return inactive, nil
+	}
+	userID, _ := claims.UserID()
+	tokenID, _ := claims.TokenID()
+	if err := s.revocationService.CheckAccessToken(ctx, userID, tokenID, claims.SessionID, claims.ClientID, claims.IssuedAt.Time); err != nil {
+		if errors.Is(err, ErrAccessTokenRevoked) {
+			return inactive, nil
+		}
+		return nil, err
+	}
+
+	return &TokenIntrospection{
+		Active:    true,
+		Scope:     strings.Join(claims.Scopes, " "),
+		ClientID:  claims.ClientID,
+		TokenType: "Bearer",
+		ExpiresAt: claims.ExpiresAt.Unix(),
+		IssuedAt:  claims.IssuedAt.Unix(),
+		Subject:   claims.Subject,
+		Audience:  claims.Audience,
+		Issuer:    claims.Issuer,
+		TokenID:   claims.ID,
+	}, nil
+}
+
+// Revoke отзывает access токен, выданный клиенту. Неизвестные и
+// недействительные токены не считаются ошибкой (RFC 7009, раздел 2.2).
+func (s *DefaultOAuthService) Revoke(ctx context.Context, credentials ClientCredentials, token string) error {
+	client, err := s.authenticateClient(ctx, credentials)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return ErrOAuthInvalidRequest
+	}
+
+	claims, err := s.issuer.Parse(token)
+	if err != nil {
+		return nil
+	}
+	if claims.ClientID != client.ID.String() {
+		return ErrOAuthUnauthorizedClient
+	}
+
+	tokenID, _ := claims.TokenID()
+	return s.revocationService.RevokeAccessToken(ctx, tokenID, claims.ExpiresAt.Time)
+}
+
+// authenticateClient проверяет учетные данные клиента. Публичный клиент
+// передает только client_id, конфиденциальный — еще и секрет.
+func (s *DefaultOAuthService) authenticateClient(ctx context.Context, credentials ClientCredentials) (*domain.OAuthClient, error) {
+	clientID, err := uuid.Parse(credentials.ID)
+	if err != nil {
+		return nil, ErrOAuthInvalidClient
+	}
+	client, err := s.getClient(ctx, clientID, ErrOAuthInvalidClient)
+	if err != nil {
+		return nil, err
+	}
+
+	if !client.Confidential() {
+		if credentials.Secret != "" {
+			return nil, ErrOAuthInvalidClient
+		}
+		return client, nil
+	}
+	hash := auth.HashToken(credentials.Secret)
+	if subtle.ConstantTimeCompare([]byte(hash), []byte(client.SecretHash)) != 1 {
+		return nil, ErrOAuthInvalidClient
+	}
+	return client, nil
+}
+
+func (s *DefaultOAuthService) getClient(ctx context.Context, id uuid.UUID, notFound error) (*domain.OAuthClient, error) {
+	client, err := s.clientRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, notFound
+		}
+		return nil, fmt.Errorf("ошибка при получении клиента OAuth по ID: %w", err)
+	}
+	return client, nil
+}
+
+// clientScopes разбирает параметр scope (разрешения через пробел). Без
+// параметра запрашиваются все разрешения клиента.
+func clientScopes(client *domain.OAuthClient, scope string) ([]string, error) {
+	requested := strings.Fields(scope)
+	if len(requested) == 0 {
+		return client.Scopes, nil
+	}
+	for _, scope := range requested {
+		if !slices.Contains(client.Scopes, scope) {
+			return nil, ErrOAuthInvalidScope
+		}
+	}
+	return normalizeScopes(requested), nil
+}
+
+// normalizeScopes сортирует разрешения и убирает повторы.
+func normalizeScopes(scopes []string) []string {
+	sorted := slices.Clone(scopes)
+	slices.Sort(sorted)
+	return slices.Compact(sorted)
+}
+
+// validRedirectURI сообщает, можно ли зарегистрировать адрес возврата.
+func validRedirectURI(uri string) bool {
+	u, err := url.Parse(uri)
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != "" && u.Fragment == ""
+}
+
+// withQuery добавляет параметры к адресу с сохранением его собственных
+// параметров.
+func withQuery(uri string, params url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	query := u.Query()
+	for name, values := range params {
+		query[name] = values
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
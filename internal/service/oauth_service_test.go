@@ -0,0 +1,466 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCodeVerifier — code_verifier из примера RFC 7636.
+const testCodeVerifier = "dBjftJeZ4CVP-1rJ0Lr6ZWqIRhVBuTy8JEoYrmQ9bOkmOE4"
+
+type oauthFixture struct {
+	service *DefaultOAuthService
+	issuer  *auth.Issuer
+	user    *domain.User
+	ctx     context.Context
+	now     time.Time
+}
+
+// newOAuthFixture создает сервис на хранилище в памяти и пользователя.
+// Время сервиса задается полем now.
+func newOAuthFixture(t *testing.T) *oauthFixture {
+	t.Helper()
+
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	issuer, err := auth.NewIssuer(auth.NewHMACKeySet("secret"), auth.IssuerConfig{Issuer: "test", Audience: "test", TTL: time.Hour})
+	require.NoError(t, err)
+
+	f := &oauthFixture{issuer: issuer, now: time.Now().UTC()}
+	revocation := NewTokenRevocationService(users, memory.NewDenyListRepository(store), memory.NewOAuthClientRepository(store), memory.NewSessionRepository(store))
+	f.service = NewOAuthService(memory.NewOAuthClientRepository(store), memory.NewOAuthCodeRepository(store), revocation, issuer, OAuthConfig{CodeTTL: time.Minute})
+	f.service.now = func() time.Time { return f.now }
+
+	f.user = &domain.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com"}
+	require.NoError(t, users.Create(context.Background(), f.user))
+	f.ctx = auth.ContextWithUser(context.Background(), f.user.ID)
+	return f
+}
+
+// register регистрирует клиента с адресом возврата https://app.example.com/cb
+// и разрешениями на задачи.
+func (f *oauthFixture) register(t *testing.T, confidential bool) (string, *domain.OAuthClient) {
+	t.Helper()
+
+	secret, client, err := f.service.RegisterClient(f.ctx, f.user.ID, RegisterOAuthClient{
+		Name:         "Reports",
+		RedirectURIs: []string{"https://app.example.com/cb"},
+		Scopes:       []string{auth.ScopeTasksWrite, auth.ScopeTasksRead},
+		Confidential: confidential,
+	})
+	require.NoError(t, err)
+	return secret, client
+}
+
+// authorizationRequest возвращает запрос авторизации с PKCE для клиента.
+func authorizationRequest(client *domain.OAuthClient) AuthorizationRequest {
+	return AuthorizationRequest{
+		ClientID:            client.ID.String(),
+		ResponseType:        ResponseTypeCode,
+		Scope:               auth.ScopeTasksRead,
+		State:               "xyz",
+		CodeChallenge:       auth.PKCEChallenge(testCodeVerifier),
+		CodeChallengeMethod: PKCEMethodS256,
+	}
+}
+
+// code получает код авторизации с согласия пользователя.
+func (f *oauthFixture) code(t *testing.T, client *domain.OAuthClient) string {
+	t.Helper()
+
+	redirectTo, err := f.service.Authorize(f.ctx, f.user.ID, authorizationRequest(client), true)
+	require.NoError(t, err)
+	uri, err := url.Parse(redirectTo)
+	require.NoError(t, err)
+	return uri.Query().Get("code")
+}
+
+func TestRegisterClient(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+
+	// 2. Act
+	secret, client := f.register(t, true)
+	publicSecret, public := f.register(t, false)
+
+	// 3. Assert
+	assert.NotEmpty(t, secret)
+	assert.Equal(t, auth.HashToken(secret), client.SecretHash, "хранится только хеш секрета")
+	assert.True(t, client.Confidential())
+	assert.Equal(t, []string{auth.ScopeTasksRead, auth.ScopeTasksWrite}, client.Scopes)
+	assert.Empty(t, publicSecret)
+	assert.False(t, public.Confidential())
+
+	clients, err := f.service.ListClients(f.ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.Len(t, clients, 2)
+}
+
+func TestRegisterClient_Validation(t *testing.T) {
+	tests := []struct {
+		name   string
+		params RegisterOAuthClient
+		want   error
+	}{
+		{"без названия", RegisterOAuthClient{Name: " ", RedirectURIs: []string{"https://a.example"}, Scopes: []string{auth.ScopeTasksRead}}, ErrOAuthClientNameRequired},
+		{"без адреса возврата", RegisterOAuthClient{Name: "App", Scopes: []string{auth.ScopeTasksRead}}, ErrOAuthRedirectURIRequired},
+		{"относительный адрес", RegisterOAuthClient{Name: "App", RedirectURIs: []string{"/cb"}, Scopes: []string{auth.ScopeTasksRead}}, ErrOAuthInvalidClientURI},
+		{"адрес с фрагментом", RegisterOAuthClient{Name: "App", RedirectURIs: []string{"https://a.example/cb#x"}, Scopes: []string{auth.ScopeTasksRead}}, ErrOAuthInvalidClientURI},
+		{"без разрешений", RegisterOAuthClient{Name: "App", RedirectURIs: []string{"https://a.example"}}, ErrOAuthClientScopesRequired},
+		{"неизвестное разрешение", RegisterOAuthClient{Name: "App", RedirectURIs: []string{"https://a.example"}, Scopes: []string{"admin"}}, ErrOAuthClientInvalidScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Arrange
+			f := newOAuthFixture(t)
+
+			// 2. Act
+			_, _, err := f.service.RegisterClient(f.ctx, f.user.ID, tt.params)
+
+			// 3. Assert
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestDeleteClient(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	_, client := f.register(t, true)
+	stranger := auth.ContextWithUser(context.Background(), uuid.New())
+
+	// 2. Act
+	foreign := f.service.DeleteClient(stranger, client.ID)
+	err := f.service.DeleteClient(f.ctx, client.ID)
+
+	// 3. Assert
+	assert.ErrorIs(t, foreign, ErrOAuthClientNotFound)
+	require.NoError(t, err)
+	assert.ErrorIs(t, f.service.DeleteClient(f.ctx, client.ID), ErrOAuthClientNotFound)
+}
+
+func TestPrepareAuthorization(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	_, client := f.register(t, false)
+	req := authorizationRequest(client)
+	req.Scope = ""
+
+	// 2. Act
+	prompt, err := f.service.PrepareAuthorization(context.Background(), req)
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, client.ID, prompt.Client.ID)
+	assert.Equal(t, "https://app.example.com/cb", prompt.RedirectURI, "единственный адрес возврата подставляется сам")
+	assert.Equal(t, client.Scopes, prompt.Scopes, "без scope запрашиваются все разрешения клиента")
+	assert.Equal(t, "xyz", prompt.State)
+}
+
+func TestPrepareAuthorization_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(req *AuthorizationRequest)
+		want   error
+	}{
+		{"неизвестный клиент", func(req *AuthorizationRequest) { req.ClientID = uuid.NewString() }, ErrOAuthClientNotFound},
+		{"неверный client_id", func(req *AuthorizationRequest) { req.ClientID = "app" }, ErrOAuthClientNotFound},
+		{"чужой адрес возврата", func(req *AuthorizationRequest) { req.RedirectURI = "https://evil.example/cb" }, ErrOAuthInvalidRedirectURI},
+		{"неверный response_type", func(req *AuthorizationRequest) { req.ResponseType = "token" }, ErrOAuthUnsupportedResponseType},
+		{"без PKCE", func(req *AuthorizationRequest) { req.CodeChallenge = "" }, ErrOAuthPKCERequired},
+		{"метод plain", func(req *AuthorizationRequest) { req.CodeChallengeMethod = "plain" }, ErrOAuthPKCERequired},
+		{"лишнее разрешение", func(req *AuthorizationRequest) { req.Scope = auth.ScopeLabelsRead }, ErrOAuthInvalidScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Arrange
+			f := newOAuthFixture(t)
+			_, client := f.register(t, false)
+			req := authorizationRequest(client)
+			tt.modify(&req)
+
+			// 2. Act
+			_, err := f.service.PrepareAuthorization(context.Background(), req)
+
+			// 3. Assert
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestAuthorize_Denied(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	_, client := f.register(t, false)
+
+	// 2. Act
+	redirectTo, err := f.service.Authorize(f.ctx, f.user.ID, authorizationRequest(client), false)
+
+	// 3. Assert
+	require.NoError(t, err)
+	uri, err := url.Parse(redirectTo)
+	require.NoError(t, err)
+	assert.Equal(t, "access_denied", uri.Query().Get("error"))
+	assert.Equal(t, "xyz", uri.Query().Get("state"))
+	assert.Empty(t, uri.Query().Get("code"))
+}
+
+func TestToken_AuthorizationCode(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	_, client := f.register(t, false)
+	code := f.code(t, client)
+	req := TokenRequest{
+		Client:       ClientCredentials{ID: client.ID.String()},
+		GrantType:    GrantTypeAuthorizationCode,
+		Code:         code,
+		RedirectURI:  "https://app.example.com/cb",
+		CodeVerifier: testCodeVerifier,
+	}
+
+	// 2. Act
+	token, err := f.service.Token(context.Background(), req)
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer", token.TokenType)
+	assert.Equal(t, 3600, token.ExpiresIn)
+	assert.Equal(t, auth.ScopeTasksRead, token.Scope)
+	claims, err := f.issuer.Parse(token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID.String(), claims.Subject, "токен действует от имени пользователя")
+	assert.Equal(t, client.ID.String(), claims.ClientID)
+	assert.Equal(t, []string{auth.ScopeTasksRead}, claims.Scopes)
+
+	_, err = f.service.Token(context.Background(), req)
+	assert.ErrorIs(t, err, ErrOAuthInvalidGrant, "код авторизации одноразовый")
+}
+
+func TestToken_AuthorizationCodeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(f *oauthFixture, req *TokenRequest)
+		want   error
+	}{
+		{"неверный code_verifier", func(f *oauthFixture, req *TokenRequest) { req.CodeVerifier = strings.Repeat("a", 43) }, ErrOAuthInvalidGrant},
+		{"без code_verifier", func(f *oauthFixture, req *TokenRequest) { req.CodeVerifier = "" }, ErrOAuthInvalidRequest},
+		{"другой адрес возврата", func(f *oauthFixture, req *TokenRequest) { req.RedirectURI = "https://app.example.com/other" }, ErrOAuthInvalidGrant},
+		{"неизвестный код", func(f *oauthFixture, req *TokenRequest) { req.Code = "unknown" }, ErrOAuthInvalidGrant},
+		{"код устарел", func(f *oauthFixture, req *TokenRequest) { f.now = f.now.Add(time.Minute) }, ErrOAuthInvalidGrant},
+		{"неизвестный клиент", func(f *oauthFixture, req *TokenRequest) { req.Client.ID = uuid.NewString() }, ErrOAuthInvalidClient},
+		{"секрет у публичного клиента", func(f *oauthFixture, req *TokenRequest) { req.Client.Secret = "secret" }, ErrOAuthInvalidClient},
+		{"неизвестный grant_type", func(f *oauthFixture, req *TokenRequest) { req.GrantType = "password" }, ErrOAuthUnsupportedGrantType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Arrange
+			f := newOAuthFixture(t)
+			_, client := f.register(t, false)
+			req := TokenRequest{
+				Client:       ClientCredentials{ID: client.ID.String()},
+				GrantType:    GrantTypeAuthorizationCode,
+				Code:         f.code(t, client),
+				CodeVerifier: testCodeVerifier,
+			}
+			tt.modify(f, &req)
+
+			// 2. Act
+			_, err := f.service.Token(context.Background(), req)
+
+			// 3. Assert
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestToken_RedirectURIFromAuthorization(t *testing.T) {
+	tests := []struct {
+		name        string
+		authorized  string
+		redirectURI string
+		want        error
+	}{
+		{"совпадает", "https://app.example.com/cb", "https://app.example.com/cb", nil},
+		{"не передан при обмене", "https://app.example.com/cb", "", ErrOAuthInvalidGrant},
+		{"отличается", "https://app.example.com/cb", "https://app.example.com/cb/", ErrOAuthInvalidGrant},
+		{"не передан нигде", "", "", nil},
+		{"зарегистрированный при обмене", "", "https://app.example.com/cb", nil},
+		{"незарегистрированный при обмене", "", "https://evil.example.com/cb", ErrOAuthInvalidGrant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Arrange
+			f := newOAuthFixture(t)
+			_, client := f.register(t, false)
+			authorization := authorizationRequest(client)
+			authorization.RedirectURI = tt.authorized
+			redirectTo, err := f.service.Authorize(f.ctx, f.user.ID, authorization, true)
+			require.NoError(t, err)
+			uri, err := url.Parse(redirectTo)
+			require.NoError(t, err)
+
+			// 2. Act
+			_, err = f.service.Token(context.Background(), TokenRequest{
+				Client:       ClientCredentials{ID: client.ID.String()},
+				GrantType:    GrantTypeAuthorizationCode,
+				Code:         uri.Query().Get("code"),
+				RedirectURI:  tt.redirectURI,
+				CodeVerifier: testCodeVerifier,
+			})
+
+			// 3. Assert
+			if tt.want == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestToken_CodeOfAnotherClient(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	_, client := f.register(t, false)
+	_, other := f.register(t, false)
+	code := f.code(t, client)
+
+	// 2. Act
+	_, err := f.service.Token(context.Background(), TokenRequest{
+		Client:       ClientCredentials{ID: other.ID.String()},
+		GrantType:    GrantTypeAuthorizationCode,
+		Code:         code,
+		CodeVerifier: testCodeVerifier,
+	})
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrOAuthInvalidGrant)
+}
+
+func TestToken_ClientCredentials(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	secret, client := f.register(t, true)
+	_, public := f.register(t, false)
+
+	// 2. Act
+	token, err := f.service.Token(context.Background(), TokenRequest{
+		Client:    ClientCredentials{ID: client.ID.String(), Secret: secret},
+		GrantType: GrantTypeClientCredentials,
+	})
+	_, wrongSecret := f.service.Token(context.Background(), TokenRequest{
+		Client:    ClientCredentials{ID: client.ID.String(), Secret: "wrong"},
+		GrantType: GrantTypeClientCredentials,
+	})
+	_, publicErr := f.service.Token(context.Background(), TokenRequest{
+		Client:    ClientCredentials{ID: public.ID.String()},
+		GrantType: GrantTypeClientCredentials,
+	})
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, "tasks:read tasks:write", token.Scope)
+	claims, err := f.issuer.Parse(token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, f.user.ID.String(), claims.Subject, "токен клиента действует от имени владельца")
+	assert.ErrorIs(t, wrongSecret, ErrOAuthInvalidClient)
+	assert.ErrorIs(t, publicErr, ErrOAuthUnauthorizedClient)
+}
+
+func TestIntrospectAndRevoke(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	secret, client := f.register(t, true)
+	credentials := ClientCredentials{ID: client.ID.String(), Secret: secret}
+	token, err := f.service.Token(context.Background(), TokenRequest{Client: credentials, GrantType: GrantTypeClientCredentials, Scope: auth.ScopeTasksRead})
+	require.NoError(t, err)
+
+	// 2. Act
+	active, err := f.service.Introspect(context.Background(), credentials, token.AccessToken)
+	require.NoError(t, err)
+	revokeErr := f.service.Revoke(context.Background(), credentials, token.AccessToken)
+	revoked, err := f.service.Introspect(context.Background(), credentials, token.AccessToken)
+	require.NoError(t, err)
+
+	// 3. Assert
+	assert.True(t, active.Active)
+	assert.Equal(t, auth.ScopeTasksRead, active.Scope)
+	assert.Equal(t, client.ID.String(), active.ClientID)
+	assert.Equal(t, f.user.ID.String(), active.Subject)
+	require.NoError(t, revokeErr)
+	assert.Equal(t, &TokenIntrospection{Active: false}, revoked)
+}
+
+func TestIntrospect_Errors(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	secret, client := f.register(t, true)
+	_, public := f.register(t, false)
+
+	// 2. Act
+	invalid, err := f.service.Introspect(context.Background(), ClientCredentials{ID: client.ID.String(), Secret: secret}, "garbage")
+	_, publicErr := f.service.Introspect(context.Background(), ClientCredentials{ID: public.ID.String()}, "garbage")
+	_, wrongSecret := f.service.Introspect(context.Background(), ClientCredentials{ID: client.ID.String()}, "garbage")
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.False(t, invalid.Active, "недействительный токен не считается ошибкой")
+	assert.ErrorIs(t, publicErr, ErrOAuthUnauthorizedClient)
+	assert.ErrorIs(t, wrongSecret, ErrOAuthInvalidClient)
+}
+
+func TestIntrospect_ForeignToken(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	secret, client := f.register(t, true)
+	otherSecret, other := f.register(t, true)
+	token, err := f.service.Token(context.Background(), TokenRequest{Client: ClientCredentials{ID: client.ID.String(), Secret: secret}, GrantType: GrantTypeClientCredentials})
+	require.NoError(t, err)
+	sessionToken, _, err := f.issuer.Issue(f.user.ID, uuid.New(), nil)
+	require.NoError(t, err)
+	otherCredentials := ClientCredentials{ID: other.ID.String(), Secret: otherSecret}
+
+	// 2. Act
+	foreign, err := f.service.Introspect(context.Background(), otherCredentials, token.AccessToken)
+	require.NoError(t, err)
+	session, err := f.service.Introspect(context.Background(), otherCredentials, sessionToken)
+	require.NoError(t, err)
+
+	// 3. Assert
+	assert.Equal(t, &TokenIntrospection{Active: false}, foreign, "токен другого клиента")
+	assert.Equal(t, &TokenIntrospection{Active: false}, session, "токен сессии пользователя")
+}
+
+func TestRevoke_Errors(t *testing.T) {
+	// 1. Arrange
+	f := newOAuthFixture(t)
+	secret, client := f.register(t, true)
+	otherSecret, other := f.register(t, true)
+	token, err := f.service.Token(context.Background(), TokenRequest{Client: ClientCredentials{ID: client.ID.String(), Secret: secret}, GrantType: GrantTypeClientCredentials})
+	require.NoError(t, err)
+	otherCredentials := ClientCredentials{ID: other.ID.String(), Secret: otherSecret}
+
+	// 2. Act
+	invalid := f.service.Revoke(context.Background(), otherCredentials, "garbage")
+	foreign := f.service.Revoke(context.Background(), otherCredentials, token.AccessToken)
+	empty := f.service.Revoke(context.Background(), otherCredentials, "")
+
+	// 3. Assert
+	assert.NoError(t, invalid, "неизвестный токен отзывается без ошибки")
+	assert.ErrorIs(t, foreign, ErrOAuthUnauthorizedClient, "клиент отзывает только свои токены")
+	assert.ErrorIs(t, empty, ErrOAuthInvalidRequest)
+}
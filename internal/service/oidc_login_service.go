@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/oidc"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrOIDCLoginFailed возвращается, если вход через провайдера не удалось
+	// завершить: state не совпал или устарел, код авторизации не подошел или
+	// ID токен не прошел проверку.
+	ErrOIDCLoginFailed = domain.NewError(domain.ErrUnauthorized, "auth.oidc_failed", "не удалось выполнить вход через внешнего провайдера")
+	// ErrOIDCDenied возвращается, если провайдер вернул ошибку вместо кода
+	// авторизации, например пользователь отказался от входа.
+	ErrOIDCDenied = domain.NewError(domain.ErrUnauthorized, "auth.oidc_denied", "провайдер отклонил вход: {error}")
+	// ErrOIDCEmailUnverified возвращается, если учетная запись у провайдера
+	// еще не связана с пользователем, а ее email не подтвержден провайдером.
+	ErrOIDCEmailUnverified = domain.NewError(domain.ErrForbidden, "auth.oidc_email_unverified", "email не подтвержден провайдером")
+	// ErrOIDCNotProvisioned возвращается, если пользователя с email из ID
+	// токена нет, а автоматическое создание пользователей отключено.
+	ErrOIDCNotProvisioned = domain.NewError(domain.ErrForbidden, "auth.oidc_not_provisioned", "пользователь не зарегистрирован")
+	// ErrOIDCAccountUnverified возвращается, если пользователь с email из ID
+	// токена есть, но сам email у нас не подтвердил. Такой пользователь мог
+	// быть зарегистрирован кем угодно, поэтому связь не создается, пока
+	// владелец не подтвердит email по ссылке из письма.
+	ErrOIDCAccountUnverified = domain.NewError(domain.ErrConflict, "auth.oidc_account_unverified", "пользователь с таким email уже зарегистрирован, но email не подтвержден")
+)
+
+// OIDCLoginResult — пользователь, вошедший через провайдера.
+type OIDCLoginResult struct {
+	User *domain.User
+	// DeviceName — название устройства, переданное в начале входа.
+	DeviceName string
+}
+
+// OIDCLoginService определяет интерфейс входа через внешнего провайдера
+// OpenID Connect (authorization code flow с PKCE).
+type OIDCLoginService interface {
+	// Begin начинает вход и возвращает адрес запроса авторизации у
+	// провайдера и токен состояния, который клиент предъявляет в Complete.
+	Begin(ctx context.Context, deviceName string) (authURL, stateToken string, err error)
+	// StateTTL возвращает срок действия токена состояния.
+	StateTTL() time.Duration
+	// Complete проверяет state из адреса возврата, обменивает код
+	// авторизации на ID токен и возвращает связанного с учетной записью
+	// пользователя. Учетная запись без связи связывается с пользователем по
+	// email, подтвержденному и провайдером, и самим пользователем; если
+	// такого пользователя нет, он создается, когда это разрешено.
+	Complete(ctx context.Context, stateToken, state, code string) (*OIDCLoginResult, error)
+}
+
+// OIDCLoginConfig — параметры входа через провайдера.
+type OIDCLoginConfig struct {
+	// AutoProvision разрешает создавать пользователей при первом входе.
+	AutoProvision bool
+}
+
+// DefaultOIDCLoginService реализует интерфейс OIDCLoginService.
+type DefaultOIDCLoginService struct {
+	client       *oidc.Client
+	states       *auth.OIDCStates
+	userRepo     repository.UserRepository
+	identityRepo repository.ExternalIdentityRepository
+	auditService AuditService
+	config       OIDCLoginConfig
+	now          func() time.Time
+}
+
+// NewOIDCLoginService создает новый экземпляр DefaultOIDCLoginService.
+func NewOIDCLoginService(client *oidc.Client, states *auth.OIDCStates, userRepo repository.UserRepository, identityRepo repository.ExternalIdentityRepository, auditService AuditService, config OIDCLoginConfig) *DefaultOIDCLoginService {
+	return &DefaultOIDCLoginService{
+		client:       client,
+		states:       states,
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		auditService: auditService,
+		config:       config,
+		now:          time.Now,
+	}
+}
+
+func (s *DefaultOIDCLoginService) Begin(ctx context.Context, deviceName string) (string, string, error) {
+	login, stateToken, err := s.states.Issue(deviceName)
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка при создании состояния входа: %w", err)
+	}
+
+	authURL, err := s.client.AuthCodeURL(ctx, login.State, login.Nonce, auth.PKCEChallenge(login.CodeVerifier))
+	if err != nil {
+		return "", "", fmt.Errorf("ошибка при обращении к провайдеру OpenID Connect: %w", err)
+	}
+	return authURL, stateToken, nil
+}
+
+func (s *DefaultOIDCLoginService) StateTTL() time.Duration {
+	return s.states.TTL()
+}
+
+func (s *DefaultOIDCLoginService) Complete(ctx context.Context, stateToken, state, code string) (*OIDCLoginResult, error) {
+	login, err := s.states.Parse(stateToken, state)
+	if err != nil || code == "" {
+		return nil, ErrOIDCLoginFailed
+	}
+
+	rawIDToken, err := s.client.Exchange(ctx, code, login.CodeVerifier)
+	if err != nil {
+		return nil, ErrOIDCLoginFailed
+	}
+	idToken, err := s.client.Verify(ctx, rawIDToken, login.Nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidIDToken) {
+			return nil, ErrOIDCLoginFailed
+		}
+		return nil, fmt.Errorf("ошибка при проверке ID токена: %w", err)
+	}
+
+	user, err := s.resolveUser(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCLoginResult{User: user, DeviceName: login.DeviceName}, nil
+}
+
+// resolveUser возвращает пользователя, связанного с учетной записью у
+// провайдера, и при необходимости создает связь.
+func (s *DefaultOIDCLoginService) resolveUser(ctx context.Context, idToken *oidc.IDToken) (*domain.User, error) {
+	identity, err := s.identityRepo.Get(ctx, idToken.Issuer, idToken.Subject)
+	if err == nil {
+		user, err := s.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка при получении пользователя по ID: %w", err)
+		}
+		return user, nil
+	}
+	if !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("ошибка при получении связи с провайдером: %w", err)
+	}
+
+	// Связывать по email можно только адрес, который подтвердил провайдер,
+	// иначе чужой аккаунт у провайдера получил бы доступ к пользователю
+	if idToken.Email == "" || !idToken.EmailVerified {
+		return nil, ErrOIDCEmailUnverified
+	}
+
+	provisioned := false
+	user, err := s.userRepo.GetByEmail(ctx, idToken.Email)
+	switch {
+	case err == nil:
+		// Иначе зарегистрировавший чужой email сохранил бы пароль к
+		// пользователю, в который потом войдет владелец email
+		if !user.EmailVerified {
+			return nil, ErrOIDCAccountUnverified
+		}
+	case errors.Is(err, domain.ErrNotFound):
+		if !s.config.AutoProvision {
+			return nil, ErrOIDCNotProvisioned
+		}
+		if user, err = s.provisionUser(ctx, idToken); err != nil {
+			return nil, err
+		}
+		provisioned = true
+	default:
+		return nil, fmt.Errorf("ошибка при получении пользователя по email: %w", err)
+	}
+
+	identity = &domain.ExternalIdentity{
+		Issuer:    idToken.Issuer,
+		Subject:   idToken.Subject,
+		UserID:    user.ID,
+		CreatedAt: s.now().UTC().Truncate(time.Microsecond),
+	}
+	if err := s.identityRepo.Create(ctx, identity); err != nil {
+		return nil, fmt.Errorf("ошибка при создании связи с провайдером: %w", err)
+	}
+
+	err = s.auditService.Record(ctx, &domain.AuditEvent{
+		Type:    domain.AuditExternalIdentityLink,
+		UserID:  &user.ID,
+		Subject: user.Email,
+		Details: fmt.Sprintf("issuer=%s subject=%s provisioned=%t", identity.Issuer, identity.Subject, provisioned),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// provisionUser создает пользователя по ID токену. Пароль не задается:
+// такой пользователь входит только через провайдера, пока не восстановит
+// пароль по email.
+func (s *DefaultOIDCLoginService) provisionUser(ctx context.Context, idToken *oidc.IDToken) (*domain.User, error) {
+	user := &domain.User{
+		ID:            uuid.New(),
+		Username:      oidcUsername(idToken),
+		Email:         idToken.Email,
+		EmailVerified: true,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, ErrEmailTaken
+		}
+		return nil, fmt.Errorf("ошибка при создании пользователя: %w", err)
+	}
+	return user, nil
+}
+
+// oidcUsername выбирает имя пользователя из claims preferred_username или
+// name, а если их нет — из части email до @.
+func oidcUsername(idToken *oidc.IDToken) string {
+	for _, name := range []string{idToken.PreferredUsername, idToken.Name} {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	local, _, _ := strings.Cut(idToken.Email, "@")
+	return local
+}
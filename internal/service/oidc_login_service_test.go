@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/oidc"
+	"github.com/MosinEvgeny/task-tracker/internal/oidc/oidctest"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// oidcLoginFixture — сервис входа через провайдера на хранилище в памяти.
+type oidcLoginFixture struct {
+	service  *DefaultOIDCLoginService
+	provider *oidctest.Provider
+	users    *memory.UserRepository
+	audit    *memory.AuditRepository
+}
+
+func newOIDCLoginFixture(t *testing.T, autoProvision bool) *oidcLoginFixture {
+	t.Helper()
+
+	provider := oidctest.NewProvider(t, "task-tracker", "client-secret")
+	client, err := oidc.NewClient(oidc.Config{
+		IssuerURL:    provider.Issuer(),
+		ClientID:     "task-tracker",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/login/oidc/callback",
+		Scopes:       []string{"email", "profile"},
+	}, http.DefaultClient)
+	require.NoError(t, err)
+	states, err := auth.NewOIDCStates(auth.NewHMACKeySet("secret"), "task-tracker", 10*time.Minute)
+	require.NoError(t, err)
+
+	store := memory.NewStore()
+	users := memory.NewUserRepository(store)
+	audit := memory.NewAuditRepository(store)
+	service := NewOIDCLoginService(client, states, users, memory.NewExternalIdentityRepository(store),
+		NewAuditService(audit), OIDCLoginConfig{AutoProvision: autoProvision})
+	return &oidcLoginFixture{service: service, provider: provider, users: users, audit: audit}
+}
+
+// login проходит вход у провайдера так, как это делает браузер, и
+// завершает его.
+func (f *oidcLoginFixture) login(t *testing.T, deviceName string) (*OIDCLoginResult, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	authURL, stateToken, err := f.service.Begin(ctx, deviceName)
+	require.NoError(t, err)
+	callback := followAuthorization(t, authURL)
+	return f.service.Complete(ctx, stateToken, callback.Get("state"), callback.Get("code"))
+}
+
+// followAuthorization выполняет запрос авторизации и возвращает параметры
+// адреса возврата.
+func followAuthorization(t *testing.T, authURL string) url.Values {
+	t.Helper()
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.Get(authURL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return location.Query()
+}
+
+func TestOIDCLogin_AutoProvision(t *testing.T) {
+	// 1. Arrange
+	f := newOIDCLoginFixture(t, true)
+	f.provider.User = oidctest.User{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, Name: "Jane Doe", PreferredUsername: "jane"}
+
+	// 2. Act
+	result, err := f.login(t, "Ноутбук")
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, "Ноутбук", result.DeviceName)
+	assert.Equal(t, "jane", result.User.Username)
+	assert.Equal(t, "jane@example.com", result.User.Email)
+	assert.True(t, result.User.EmailVerified)
+	assert.Empty(t, result.User.Password, "у созданного пользователя нет пароля")
+
+	stored, err := f.users.GetByEmail(context.Background(), "jane@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, result.User.ID, stored.ID)
+
+	events, err := f.audit.List(context.Background(), 10)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	assert.Equal(t, domain.AuditExternalIdentityLink, events[0].Type)
+	assert.Contains(t, events[0].Details, "provisioned=true")
+
+	again, err := f.login(t, "")
+	require.NoError(t, err)
+	assert.Equal(t, result.User.ID, again.User.ID, "повторный вход находит пользователя по связи")
+}
+
+func TestOIDCLogin_LinksExistingUserByVerifiedEmail(t *testing.T) {
+	// 1. Arrange
+	f := newOIDCLoginFixture(t, false)
+	existing := &domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", Password: hashPassword(t, "password"), EmailVerified: true}
+	require.NoError(t, f.users.Create(context.Background(), existing))
+	f.provider.User = oidctest.User{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true}
+
+	// 2. Act
+	result, err := f.login(t, "")
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, result.User.ID)
+
+	// Связь сохраняется: после смены email у провайдера пользователь тот же
+	f.provider.User.Email = "jane.doe@example.com"
+	again, err := f.login(t, "")
+	require.NoError(t, err)
+	assert.Equal(t, existing.ID, again.User.ID)
+}
+
+func TestOIDCLogin_DoesNotLinkUnverifiedLocalAccount(t *testing.T) {
+	// 1. Arrange
+	f := newOIDCLoginFixture(t, true)
+	// Пользователя с чужим email зарегистрировал злоумышленник со своим паролем
+	squatter := &domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", Password: hashPassword(t, "attacker-password")}
+	require.NoError(t, f.users.Create(context.Background(), squatter))
+	f.provider.User = oidctest.User{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true}
+
+	// 2. Act
+	_, err := f.login(t, "")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrOIDCAccountUnverified)
+	stored, err := f.users.GetByID(context.Background(), squatter.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.EmailVerified, "email не подтверждается входом через провайдера")
+	events, err := f.audit.List(context.Background(), 10)
+	require.NoError(t, err)
+	assert.Empty(t, events, "связь не создается")
+}
+
+func TestOIDCLogin_RejectsUnverifiedEmail(t *testing.T) {
+	// 1. Arrange
+	f := newOIDCLoginFixture(t, true)
+	existing := &domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	require.NoError(t, f.users.Create(context.Background(), existing))
+	f.provider.User = oidctest.User{Subject: "attacker", Email: "jane@example.com", EmailVerified: false}
+
+	// 2. Act
+	_, err := f.login(t, "")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrOIDCEmailUnverified)
+}
+
+func TestOIDCLogin_AutoProvisionDisabled(t *testing.T) {
+	// 1. Arrange
+	f := newOIDCLoginFixture(t, false)
+	f.provider.User = oidctest.User{Subject: "248289761001", Email: "new@example.com", EmailVerified: true}
+
+	// 2. Act
+	_, err := f.login(t, "")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrOIDCNotProvisioned)
+	_, err = f.users.GetByEmail(context.Background(), "new@example.com")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestOIDCLogin_CompleteRejects(t *testing.T) {
+	// 1. Arrange
+	f := newOIDCLoginFixture(t, true)
+	ctx := context.Background()
+	authURL, stateToken, err := f.service.Begin(ctx, "")
+	require.NoError(t, err)
+	callback := followAuthorization(t, authURL)
+	_, otherStateToken, err := f.service.Begin(ctx, "")
+	require.NoError(t, err)
+
+	// 2. Act
+	_, wrongState := f.service.Complete(ctx, stateToken, "other-state", callback.Get("code"))
+	_, foreignToken := f.service.Complete(ctx, otherStateToken, callback.Get("state"), callback.Get("code"))
+	_, noCode := f.service.Complete(ctx, stateToken, callback.Get("state"), "")
+	_, wrongCode := f.service.Complete(ctx, stateToken, callback.Get("state"), "unknown-code")
+
+	// 3. Assert
+	assert.ErrorIs(t, wrongState, ErrOIDCLoginFailed)
+	assert.ErrorIs(t, foreignToken, ErrOIDCLoginFailed, "токен состояния другого входа")
+	assert.ErrorIs(t, noCode, ErrOIDCLoginFailed)
+	assert.ErrorIs(t, wrongCode, ErrOIDCLoginFailed)
+}
+
+func TestOIDCLogin_RejectsInvalidIDToken(t *testing.T) {
+	// 1. Arrange
+	f := newOIDCLoginFixture(t, true)
+	f.provider.ModifyClaims = func(claims jwt.MapClaims) { claims["nonce"] = "replayed" }
+
+	// 2. Act
+	_, err := f.login(t, "")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrOIDCLoginFailed)
+}
@@ -0,0 +1,18 @@
+package service
+
+// Размер страницы для постраничных выборок.
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 100
+)
+
+// pageLimit приводит запрошенный размер страницы к допустимому диапазону.
+func pageLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		return MaxPageSize
+	}
+	return limit
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/mail"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrWrongPassword возвращается, если текущий пароль указан неверно.
+	ErrWrongPassword = domain.NewFieldError("current_password", "password.wrong_current", "неверный текущий пароль")
+	// ErrNewPasswordRequired возвращается, если не указан новый пароль.
+	ErrNewPasswordRequired = domain.NewFieldError("new_password", "password.new_required", "необходимо указать новый пароль")
+	// ErrInvalidResetToken возвращается для неизвестного, уже использованного
+	// или истекшего токена сброса пароля.
+	ErrInvalidResetToken = domain.NewError(domain.ErrValidation, "password.invalid_reset_token", "ссылка для сброса пароля недействительна или устарела")
+	// ErrPasswordTooShort возвращается для нового пароля короче минимальной
+	// длины.
+	ErrPasswordTooShort = domain.NewError(domain.ErrValidation, "password.too_short", "пароль должен содержать не менее {min_length} символов")
+	// ErrPasswordTooLong возвращается для пароля длиннее, чем учитывает
+	// алгоритм хеширования.
+	ErrPasswordTooLong = domain.NewError(domain.ErrValidation, "password.too_long", "пароль слишком длинный")
+	// ErrPasswordBreached возвращается для нового пароля из списка утекших
+	// паролей.
+	ErrPasswordBreached = domain.NewError(domain.ErrValidation, "password.breached", "пароль встречается в утечках данных, выберите другой")
+)
+
+// errPasswordChanged возвращается setPassword, если пароль пользователя
+// сменили параллельно.
+var errPasswordChanged = errors.New("пароль пользователя изменен параллельно")
+
+// maxPasswordAttempts — сколько раз сброс пароля повторяется, если пароль
+// меняют параллельно.
+const maxPasswordAttempts = 3
+
+// Passwords хеширует пароли и проверяет стойкость новых паролей.
+type Passwords struct {
+	Hasher *auth.PasswordHasher
+	Policy *auth.PasswordPolicy
+}
+
+// hashNew проверяет новый пароль политикой стойкости и возвращает его хеш.
+// Ошибки политики относятся к полю field запроса.
+func (p Passwords) hashNew(field, password string) (string, error) {
+	err := p.Policy.Check(password)
+	if err == nil {
+		var hash string
+		if hash, err = p.Hasher.Hash(password); err == nil {
+			return hash, nil
+		}
+	}
+
+	var policyErr *domain.Error
+	switch {
+	case errors.Is(err, auth.ErrPasswordTooShort):
+		policyErr = ErrPasswordTooShort.WithParams(map[string]string{"min_length": strconv.Itoa(p.Policy.MinLength())})
+	case errors.Is(err, auth.ErrPasswordTooLong):
+		policyErr = ErrPasswordTooLong
+	case errors.Is(err, auth.ErrPasswordBreached):
+		policyErr = ErrPasswordBreached
+	default:
+		return "", fmt.Errorf("ошибка при хешировании пароля: %w", err)
+	}
+	return "", policyErr.WithFields(domain.FieldError{Field: field, Code: policyErr.Code, Message: policyErr.Message})
+}
+
+// PasswordService определяет интерфейс для смены и сброса пароля. После
+// смены пароля все сессии и access токены пользователя отзываются.
+type PasswordService interface {
+	ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error
+	// RequestPasswordReset отправляет на email ссылку для сброса пароля. Для
+	// неизвестного email ошибка не возвращается, чтобы не раскрывать,
+	// зарегистрирован ли он; по той же причине ошибка отправки письма только
+	// записывается в лог. Частые запросы по одному email или с одного
+	// IP-адреса отклоняются с ErrTooManyAttempts.
+	RequestPasswordReset(ctx context.Context, email, ip string) error
+	ResetPassword(ctx context.Context, token, newPassword string) error
+}
+
+// PasswordResetConfig — параметры сброса пароля.
+type PasswordResetConfig struct {
+	// URL — адрес страницы сброса пароля; токен передается в параметре token.
+	URL string
+	// TTL — срок действия ссылки.
+	TTL time.Duration
+}
+
+// DefaultPasswordService реализует интерфейс PasswordService.
+type DefaultPasswordService struct {
+	userRepo            repository.UserRepository
+	resetRepo           repository.PasswordResetRepository
+	refreshTokenService RefreshTokenService
+	revocationService   TokenRevocationService
+	throttleService     LoginThrottleService
+	passwords           Passwords
+	mailer              mail.Mailer
+	config              PasswordResetConfig
+}
+
+// NewPasswordService создает новый экземпляр DefaultPasswordService.
+func NewPasswordService(userRepo repository.UserRepository, resetRepo repository.PasswordResetRepository, refreshTokenService RefreshTokenService, revocationService TokenRevocationService, throttleService LoginThrottleService, passwords Passwords, mailer mail.Mailer, config PasswordResetConfig) *DefaultPasswordService {
+	return &DefaultPasswordService{
+		userRepo:            userRepo,
+		resetRepo:           resetRepo,
+		refreshTokenService: refreshTokenService,
+		revocationService:   revocationService,
+		throttleService:     throttleService,
+		passwords:           passwords,
+		mailer:              mailer,
+		config:              config,
+	}
+}
+
+func (s *DefaultPasswordService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return err
+	}
+	if newPassword == "" {
+		return ErrNewPasswordRequired
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("ошибка при получении пользователя по ID: %w", err)
+	}
+	// Неверный текущий пароль учитывается вместе с ошибками второго шага
+	// входа, чтобы владелец access токена не мог подбирать пароль
+	if err := s.throttleService.CheckMFA(ctx, user.ID); err != nil {
+		return err
+	}
+	if _, err := s.passwords.Hasher.Verify(user.Password, currentPassword); err != nil {
+		if err := s.throttleService.MFAFailed(ctx, user.ID); err != nil {
+			return err
+		}
+		return ErrWrongPassword
+	}
+	hash, err := s.passwords.hashNew("new_password", newPassword)
+	if err != nil {
+		return err
+	}
+
+	// Пароль сменился после проверки текущего: проверенный пароль устарел
+	if err := s.setPassword(ctx, user, hash); err != nil {
+		if errors.Is(err, errPasswordChanged) {
+			return ErrWrongPassword
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *DefaultPasswordService) RequestPasswordReset(ctx context.Context, email, ip string) error {
+	// Запросы учитываются до поиска пользователя, чтобы ограничение
+	// действовало одинаково для любого email
+	if err := s.throttleService.PasswordResetRequested(ctx, email, ip); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("ошибка при получении пользователя по email: %w", err)
+	}
+
+	// Действует только последняя отправленная ссылка
+	if err := s.resetRepo.DeleteAllByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	value, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+	token := &domain.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: auth.HashToken(value),
+		ExpiresAt: time.Now().UTC().Add(s.config.TTL),
+	}
+	if err := s.resetRepo.Create(ctx, token); err != nil {
+		return fmt.Errorf("ошибка при создании токена сброса пароля: %w", err)
+	}
+
+	link, err := s.resetLink(value)
+	if err != nil {
+		return err
+	}
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Сброс пароля",
+		Body: fmt.Sprintf("Здравствуйте, %s!\n\nЧтобы задать новый пароль, перейдите по ссылке:\n%s\n\n"+
+			"Ссылка действует %s и может быть использована один раз. Если вы не запрашивали сброс пароля, проигнорируйте это письмо.\n",
+			user.Username, link, s.config.TTL),
+	}
+	if err := s.mailer.Send(ctx, msg); err != nil {
+		log.Printf("failed to send password reset email to user %s: %v", user.ID, err)
+	}
+
+	return nil
+}
+
+func (s *DefaultPasswordService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if newPassword == "" {
+		return ErrNewPasswordRequired
+	}
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+	// Пароль проверяется до использования ссылки, чтобы слабый пароль не
+	// тратил ее
+	hash, err := s.passwords.hashNew("new_password", newPassword)
+	if err != nil {
+		return err
+	}
+
+	resetToken, err := s.resetRepo.Consume(ctx, auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrInvalidResetToken
+		}
+		return fmt.Errorf("ошибка при получении токена сброса пароля: %w", err)
+	}
+	if !time.Now().Before(resetToken.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	// Если пароль успели сменить или перехешировать параллельно, пользователь
+	// читается заново: ссылка уже использована и должна сработать
+	for attempt := 1; ; attempt++ {
+		user, err := s.userRepo.GetByID(ctx, resetToken.UserID)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return ErrInvalidResetToken
+			}
+			return fmt.Errorf("ошибка при получении пользователя по ID: %w", err)
+		}
+
+		err = s.setPassword(ctx, user, hash)
+		if !errors.Is(err, errPasswordChanged) || attempt == maxPasswordAttempts {
+			return err
+		}
+	}
+}
+
+// setPassword заменяет прочитанный хеш пароля пользователя хешем нового
+// пароля и завершает все сессии пользователя: удаляет refresh токены,
+// отзывает access токены и неиспользованные ссылки для сброса пароля. Если
+// пароль сменился после чтения пользователя, возвращается errPasswordChanged.
+func (s *DefaultPasswordService) setPassword(ctx context.Context, user *domain.User, hash string) error {
+	if err := s.userRepo.ReplacePasswordHash(ctx, user.ID, user.Password, hash); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return errPasswordChanged
+		}
+		return fmt.Errorf("ошибка при обновлении пароля: %w", err)
+	}
+	user.Password = hash
+
+	if err := s.refreshTokenService.DeleteAllRefreshTokensByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.revocationService.RevokeAllAccessTokens(ctx, user.ID); err != nil {
+		return err
+	}
+	if err := s.resetRepo.DeleteAllByUserID(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resetLink добавляет токен к адресу страницы сброса пароля.
+func (s *DefaultPasswordService) resetLink(token string) (string, error) {
+	link, err := url.Parse(s.config.URL)
+	if err != nil {
+		return "", fmt.Errorf("неверный адрес страницы сброса пароля: %w", err)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
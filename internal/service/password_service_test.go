@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/mail"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMailer запоминает отправленные письма. Если задано err, письма
+// не отправляются и возвращается эта ошибка.
+type recordingMailer struct {
+	messages []mail.Message
+	err      error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// linkToken извлекает параметр token из ссылки в последнем письме.
+func (m *recordingMailer) linkToken(t *testing.T) string {
+	t.Helper()
+
+	require.NotEmpty(t, m.messages)
+	link := regexp.MustCompile(`https?://\S+`).FindString(m.messages[len(m.messages)-1].Body)
+	parsed, err := url.Parse(link)
+	require.NoError(t, err)
+	return parsed.Query().Get("token")
+}
+
+// newTestPasswords возвращает хеширование паролей с быстрыми параметрами
+// argon2id и политику с минимальной длиной 8 символов, в списке утекших
+// паролей которой есть qwerty123.
+func newTestPasswords(t *testing.T) Passwords {
+	t.Helper()
+
+	hasher, err := auth.NewPasswordHasher(auth.PasswordHashConfig{
+		Algorithm:         auth.PasswordArgon2id,
+		Argon2Memory:      64,
+		Argon2Iterations:  1,
+		Argon2Parallelism: 1,
+	})
+	require.NoError(t, err)
+	policy, err := auth.NewPasswordPolicy(8)
+	require.NoError(t, err)
+	require.NoError(t, policy.AddBreached(strings.NewReader("qwerty123\n")))
+	return Passwords{Hasher: hasher, Policy: policy}
+}
+
+// hashPassword возвращает хеш пароля для тестовых пользователей.
+func hashPassword(t *testing.T, password string) string {
+	t.Helper()
+
+	hash, err := newTestPasswords(t).Hasher.Hash(password)
+	require.NoError(t, err)
+	return hash
+}
+
+// verifyPassword сравнивает пароль с хешем пароля пользователя.
+func verifyPassword(t *testing.T, user *domain.User, password string) error {
+	t.Helper()
+
+	_, err := newTestPasswords(t).Hasher.Verify(user.Password, password)
+	return err
+}
+
+type passwordFixture struct {
+	service       *DefaultPasswordService
+	refreshTokens *DefaultRefreshTokenService
+	users         *memory.UserRepository
+	resets        *memory.PasswordResetRepository
+	mailer        *recordingMailer
+	user          *domain.User
+	session       *domain.RefreshToken
+}
+
+// newPasswordFixture создает сервис на хранилище в памяти и пользователя с
+// паролем old-password и одной сессией.
+func newPasswordFixture(t *testing.T) *passwordFixture {
+	t.Helper()
+
+	store := memory.NewStore()
+	f := &passwordFixture{
+		users:  memory.NewUserRepository(store),
+		resets: memory.NewPasswordResetRepository(store),
+		mailer: &recordingMailer{},
+	}
+	f.refreshTokens = NewRefreshTokenService(memory.NewRefreshTokenRepository(store), memory.NewSessionRepository(store), time.Hour)
+	revocation := NewTokenRevocationService(f.users, memory.NewDenyListRepository(store), memory.NewOAuthClientRepository(store), memory.NewSessionRepository(store))
+	throttle := NewLoginThrottleService(memory.NewLoginThrottleRepository(store), f.users, NewAuditService(memory.NewAuditRepository(store)), testThrottleConfig)
+	f.service = NewPasswordService(f.users, f.resets, f.refreshTokens, revocation, throttle, newTestPasswords(t), f.mailer, PasswordResetConfig{
+		URL: "http://localhost:5173/reset-password",
+		TTL: time.Hour,
+	})
+
+	f.user = &domain.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com", Password: hashPassword(t, "old-password")}
+	require.NoError(t, f.users.Create(context.Background(), f.user))
+
+	var err error
+	f.session, err = f.refreshTokens.CreateRefreshToken(context.Background(), f.user.ID, domain.Device{})
+	require.NoError(t, err)
+	return f
+}
+
+// assertSignedOut проверяет, что пароль сменился, а сессии и access токены
+// пользователя отозваны.
+func (f *passwordFixture) assertSignedOut(t *testing.T, password string) {
+	t.Helper()
+
+	user, err := f.users.GetByID(context.Background(), f.user.ID)
+	require.NoError(t, err)
+	assert.NoError(t, verifyPassword(t, user, password))
+	assert.NotNil(t, user.TokensValidAfter)
+
+	_, err = f.refreshTokens.RotateRefreshToken(context.Background(), f.session.Token)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestChangePassword(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := auth.ContextWithUser(context.Background(), f.user.ID)
+
+	// 2. Act
+	err := f.service.ChangePassword(ctx, f.user.ID, "old-password", "new-password")
+
+	// 3. Assert
+	require.NoError(t, err)
+	f.assertSignedOut(t, "new-password")
+}
+
+func TestChangePassword_WrongCurrent(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := auth.ContextWithUser(context.Background(), f.user.ID)
+
+	// 2. Act
+	err := f.service.ChangePassword(ctx, f.user.ID, "wrong-password", "new-password")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	_, err = f.refreshTokens.RotateRefreshToken(context.Background(), f.session.Token)
+	assert.NoError(t, err, "сессии не отзываются")
+}
+
+func TestChangePassword_Lockout(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := auth.ContextWithUser(context.Background(), f.user.ID)
+	for i := 0; i < testThrottleConfig.MaxFailures; i++ {
+		err := f.service.ChangePassword(ctx, f.user.ID, "wrong-password", "new-password")
+		require.ErrorIs(t, err, ErrWrongPassword)
+	}
+
+	// 2. Act
+	err := f.service.ChangePassword(ctx, f.user.ID, "old-password", "new-password")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrTooManyAttempts, "во время блокировки не принимается даже верный пароль")
+	user, err := f.users.GetByID(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.NoError(t, verifyPassword(t, user, "old-password"))
+}
+
+func TestChangePassword_ForeignUser(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	// 2. Act
+	err := f.service.ChangePassword(ctx, f.user.ID, "old-password", "new-password")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestChangePassword_EmptyNewPassword(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := auth.ContextWithUser(context.Background(), f.user.ID)
+
+	// 2. Act
+	err := f.service.ChangePassword(ctx, f.user.ID, "old-password", "")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, domain.ErrValidation)
+}
+
+func TestResetPassword(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := context.Background()
+	require.NoError(t, f.service.RequestPasswordReset(ctx, f.user.Email, "10.0.0.1"))
+	require.Len(t, f.mailer.messages, 1)
+	assert.Equal(t, f.user.Email, f.mailer.messages[0].To)
+	token := f.mailer.linkToken(t)
+
+	// 2. Act
+	err := f.service.ResetPassword(ctx, token, "new-password")
+
+	// 3. Assert
+	require.NoError(t, err)
+	f.assertSignedOut(t, "new-password")
+	assert.ErrorIs(t, f.service.ResetPassword(ctx, token, "other-password"), ErrInvalidResetToken, "ссылка одноразовая")
+}
+
+func TestChangePassword_WeakNewPassword(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := auth.ContextWithUser(context.Background(), f.user.ID)
+
+	// 2. Act
+	err := f.service.ChangePassword(ctx, f.user.ID, "old-password", "qwerty123")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrPasswordBreached)
+	var domainErr *domain.Error
+	require.ErrorAs(t, err, &domainErr)
+	require.Len(t, domainErr.Fields, 1)
+	assert.Equal(t, "new_password", domainErr.Fields[0].Field)
+	_, err = f.refreshTokens.RotateRefreshToken(context.Background(), f.session.Token)
+	assert.NoError(t, err, "сессии не отзываются")
+}
+
+func TestResetPassword_WeakNewPassword(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := context.Background()
+	require.NoError(t, f.service.RequestPasswordReset(ctx, f.user.Email, "10.0.0.1"))
+	token := f.mailer.linkToken(t)
+
+	// 2. Act
+	err := f.service.ResetPassword(ctx, token, "short")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrPasswordTooShort)
+	require.NoError(t, f.service.ResetPassword(ctx, token, "new-password"), "ссылка не расходуется на отклоненный пароль")
+	f.assertSignedOut(t, "new-password")
+}
+
+func TestRequestPasswordReset_UnknownEmail(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+
+	// 2. Act
+	err := f.service.RequestPasswordReset(context.Background(), "nobody@example.com", "10.0.0.1")
+
+	// 3. Assert
+	assert.NoError(t, err)
+	assert.Empty(t, f.mailer.messages)
+}
+
+func TestRequestPasswordReset_ReplacesPreviousLink(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := context.Background()
+	require.NoError(t, f.service.RequestPasswordReset(ctx, f.user.Email, "10.0.0.1"))
+	first := f.mailer.linkToken(t)
+
+	// 2. Act
+	require.NoError(t, f.service.RequestPasswordReset(ctx, f.user.Email, "10.0.0.1"))
+
+	// 3. Assert
+	assert.ErrorIs(t, f.service.ResetPassword(ctx, first, "new-password"), ErrInvalidResetToken)
+	assert.NoError(t, f.service.ResetPassword(ctx, f.mailer.linkToken(t), "new-password"))
+}
+
+func TestRequestPasswordReset_Throttled(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := context.Background()
+	for i := 0; i < testThrottleConfig.MaxFailures; i++ {
+		require.NoError(t, f.service.RequestPasswordReset(ctx, f.user.Email, "10.0.0.1"))
+		require.NoError(t, f.service.RequestPasswordReset(ctx, "nobody@example.com", "10.0.0.2"))
+	}
+	link := f.mailer.linkToken(t)
+
+	// 2. Act
+	err := f.service.RequestPasswordReset(ctx, strings.ToUpper(f.user.Email), "10.0.0.3")
+	unknown := f.service.RequestPasswordReset(ctx, "nobody@example.com", "10.0.0.3")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.ErrorIs(t, unknown, ErrTooManyAttempts, "ограничение не зависит от того, зарегистрирован ли email")
+	assert.Len(t, f.mailer.messages, testThrottleConfig.MaxFailures)
+	assert.NoError(t, f.service.ResetPassword(ctx, link, "new-password"), "отклоненный запрос не отменяет прежнюю ссылку")
+}
+
+func TestRequestPasswordReset_ThrottledByIP(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := context.Background()
+	for i := 0; i < testThrottleConfig.MaxIPFailures; i++ {
+		require.NoError(t, f.service.RequestPasswordReset(ctx, fmt.Sprintf("user%d@example.com", i), "10.0.0.1"))
+	}
+
+	// 2. Act
+	err := f.service.RequestPasswordReset(ctx, f.user.Email, "10.0.0.1")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.Empty(t, f.mailer.messages)
+}
+
+func TestRequestPasswordReset_MailFailure(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	f.mailer.err = errors.New("smtp unavailable")
+
+	// 2. Act
+	err := f.service.RequestPasswordReset(context.Background(), f.user.Email, "10.0.0.1")
+	unknown := f.service.RequestPasswordReset(context.Background(), "nobody@example.com", "10.0.0.1")
+
+	// 3. Assert
+	assert.NoError(t, err, "ошибка отправки не раскрывает, зарегистрирован ли email")
+	assert.NoError(t, unknown)
+}
+
+func TestResetPassword_Expired(t *testing.T) {
+	// 1. Arrange
+	f := newPasswordFixture(t)
+	ctx := context.Background()
+	value, err := auth.NewToken()
+	require.NoError(t, err)
+	require.NoError(t, f.resets.Create(ctx, &domain.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    f.user.ID,
+		TokenHash: auth.HashToken(value),
+		ExpiresAt: time.Now().UTC().Add(-time.Minute),
+	}))
+
+	// 2. Act
+	err = f.service.ResetPassword(ctx, value, "new-password")
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+	user, err := f.users.GetByID(ctx, f.user.ID)
+	require.NoError(t, err)
+	assert.NoError(t, verifyPassword(t, user, "old-password"))
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+// PersonalAccessTokenPrefix — префикс персональных токенов доступа. По нему
+// токен отличается от JWT в заголовке Authorization и находится сканерами
+// секретов в коде и логах.
+const PersonalAccessTokenPrefix = "ttpat_"
+
+// lastUsedPrecision — точность отметки последнего использования токена.
+// Отметка обновляется не чаще одного раза за этот интервал, чтобы не
+// записывать в хранилище каждый запрос.
+const lastUsedPrecision = time.Minute
+
+// Ошибки персональных токенов доступа.
+var (
+	ErrTokenNameRequired   = domain.NewFieldError("name", "token.name_required", "необходимо указать название токена")
+	ErrTokenScopesRequired = domain.NewFieldError("scopes", "token.scopes_required", "необходимо указать разрешения токена")
+	ErrInvalidTokenScope   = domain.NewFieldError("scopes", "token.invalid_scope", "неизвестное разрешение {scope}")
+	ErrInvalidTokenExpiry  = domain.NewFieldError("expires_at", "token.invalid_expiry", "срок действия токена должен быть в будущем")
+	// ErrTokenNotFound возвращается для несуществующих и чужих токенов.
+	ErrTokenNotFound = domain.NewError(domain.ErrNotFound, "token.not_found", "токен доступа не найден")
+	// ErrInvalidPersonalToken возвращается для неизвестного или истекшего токена.
+	ErrInvalidPersonalToken = domain.NewError(domain.ErrUnauthorized, "auth.invalid_token", "Неверный токен")
+)
+
+// CreatePersonalAccessToken — параметры нового персонального токена.
+type CreatePersonalAccessToken struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time // nil — бессрочный токен
+}
+
+// PersonalAccessTokenService определяет интерфейс для работы с
+// персональными токенами доступа.
+type PersonalAccessTokenService interface {
+	// CreateToken выдает токен пользователю userID. Сам токен возвращается
+	// только здесь, в хранилище остается его хеш.
+	CreateToken(ctx context.Context, userID uuid.UUID, params CreatePersonalAccessToken) (string, *domain.PersonalAccessToken, error)
+	ListTokens(ctx context.Context, userID uuid.UUID) ([]*domain.PersonalAccessToken, error)
+	RevokeToken(ctx context.Context, id uuid.UUID) error
+	// Authenticate проверяет токен из заголовка Authorization и отмечает
+	// время его использования.
+	Authenticate(ctx context.Context, token string) (*domain.PersonalAccessToken, error)
+}
+
+// DefaultPersonalAccessTokenService реализует интерфейс PersonalAccessTokenService.
+type DefaultPersonalAccessTokenService struct {
+	tokenRepo repository.PersonalAccessTokenRepository
+	now       func() time.Time
+}
+
+// NewPersonalAccessTokenService создает новый экземпляр DefaultPersonalAccessTokenService.
+func NewPersonalAccessTokenService(tokenRepo repository.PersonalAccessTokenRepository) *DefaultPersonalAccessTokenService {
+	return &DefaultPersonalAccessTokenService{tokenRepo: tokenRepo, now: time.Now}
+}
+
+func (s *DefaultPersonalAccessTokenService) CreateToken(ctx context.Context, userID uuid.UUID, params CreatePersonalAccessToken) (string, *domain.PersonalAccessToken, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return "", nil, err
+	}
+
+	now := s.now().UTC().Truncate(time.Microsecond)
+	name := strings.TrimSpace(params.Name)
+	if name == "" {
+		return "", nil, ErrTokenNameRequired
+	}
+	if len(params.Scopes) == 0 {
+		return "", nil, ErrTokenScopesRequired
+	}
+	for _, scope := range params.Scopes {
+		if !auth.ValidScope(scope) {
+			return "", nil, ErrInvalidTokenScope.WithParams(map[string]string{"scope": scope})
+		}
+	}
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(now) {
+		return "", nil, ErrInvalidTokenExpiry
+	}
+
+	secret, err := auth.NewToken()
+	if err != nil {
+		return "", nil, err
+	}
+	value := PersonalAccessTokenPrefix + secret
+
+	scopes := slices.Clone(params.Scopes)
+	slices.Sort(scopes)
+	token := &domain.PersonalAccessToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		TokenHash: auth.HashToken(value),
+		Scopes:    slices.Compact(scopes),
+		CreatedAt: now,
+	}
+	if params.ExpiresAt != nil {
+		expiresAt := params.ExpiresAt.UTC().Truncate(time.Microsecond)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return "", nil, fmt.Errorf("ошибка при создании токена доступа: %w", err)
+	}
+
+	return value, token, nil
+}
+
+func (s *DefaultPersonalAccessTokenService) ListTokens(ctx context.Context, userID uuid.UUID) ([]*domain.PersonalAccessToken, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	tokens, err := s.tokenRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении токенов доступа пользователя: %w", err)
+	}
+
+	return tokens, nil
+}
+
+func (s *DefaultPersonalAccessTokenService) RevokeToken(ctx context.Context, id uuid.UUID) error {
+	token, err := s.tokenRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrTokenNotFound
+		}
+		return fmt.Errorf("ошибка при получении токена доступа по ID: %w", err)
+	}
+	if err := authorize(ctx, token.UserID, ErrTokenNotFound); err != nil {
+		return err
+	}
+
+	if err := s.tokenRepo.Delete(ctx, id); err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrTokenNotFound
+		}
+		return fmt.Errorf("ошибка при удалении токена доступа: %w", err)
+	}
+
+	return nil
+}
+
+func (s *DefaultPersonalAccessTokenService) Authenticate(ctx context.Context, value string) (*domain.PersonalAccessToken, error) {
+	if !strings.HasPrefix(value, PersonalAccessTokenPrefix) {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	token, err := s.tokenRepo.GetByHash(ctx, auth.HashToken(value))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrInvalidPersonalToken
+		}
+		return nil, fmt.Errorf("ошибка при получении токена доступа: %w", err)
+	}
+
+	now := s.now().UTC().Truncate(time.Microsecond)
+	if token.Expired(now) {
+		return nil, ErrInvalidPersonalToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedPrecision {
+		if err := s.tokenRepo.Touch(ctx, token.ID, now); err != nil {
+			return nil, fmt.Errorf("ошибка при обновлении токена доступа: %w", err)
+		}
+		token.LastUsedAt = &now
+	}
+
+	return token, nil
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type personalTokenFixture struct {
+	service *DefaultPersonalAccessTokenService
+	user    *domain.User
+	ctx     context.Context
+	now     time.Time
+}
+
+// newPersonalTokenFixture создает сервис на хранилище в памяти и
+// пользователя. Время сервиса задается полем now.
+func newPersonalTokenFixture(t *testing.T) *personalTokenFixture {
+	t.Helper()
+
+	store := memory.NewStore()
+	f := &personalTokenFixture{now: time.Now().UTC()}
+	f.service = NewPersonalAccessTokenService(memory.NewPersonalAccessTokenRepository(store))
+	f.service.now = func() time.Time { return f.now }
+
+	f.user = &domain.User{ID: uuid.New(), Username: "alice", Email: "alice@example.com"}
+	require.NoError(t, memory.NewUserRepository(store).Create(context.Background(), f.user))
+	f.ctx = auth.ContextWithUser(context.Background(), f.user.ID)
+	return f
+}
+
+func TestCreateToken(t *testing.T) {
+	// 1. Arrange
+	f := newPersonalTokenFixture(t)
+	expiresAt := f.now.Add(24 * time.Hour)
+
+	// 2. Act
+	value, token, err := f.service.CreateToken(f.ctx, f.user.ID, CreatePersonalAccessToken{
+		Name:      " CI ",
+		Scopes:    []string{auth.ScopeTasksWrite, auth.ScopeTasksRead, auth.ScopeTasksWrite},
+		ExpiresAt: &expiresAt,
+	})
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(value, PersonalAccessTokenPrefix))
+	assert.Equal(t, "CI", token.Name)
+	assert.Equal(t, []string{auth.ScopeTasksRead, auth.ScopeTasksWrite}, token.Scopes)
+	assert.Equal(t, auth.HashToken(value), token.TokenHash, "хранится только хеш токена")
+
+	tokens, err := f.service.ListTokens(f.ctx, f.user.ID)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, token.ID, tokens[0].ID)
+}
+
+func TestCreateToken_Validation(t *testing.T) {
+	f := newPersonalTokenFixture(t)
+	past := f.now.Add(-time.Minute)
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		params CreatePersonalAccessToken
+		err    error
+	}{
+		{"без названия", f.ctx, CreatePersonalAccessToken{Name: " ", Scopes: []string{auth.ScopeTasksRead}}, ErrTokenNameRequired},
+		{"без разрешений", f.ctx, CreatePersonalAccessToken{Name: "CI"}, ErrTokenScopesRequired},
+		{"неизвестное разрешение", f.ctx, CreatePersonalAccessToken{Name: "CI", Scopes: []string{"admin"}}, ErrInvalidTokenScope},
+		{"срок в прошлом", f.ctx, CreatePersonalAccessToken{Name: "CI", Scopes: []string{auth.ScopeTasksRead}, ExpiresAt: &past}, ErrInvalidTokenExpiry},
+		{"чужой пользователь", auth.ContextWithUser(context.Background(), uuid.New()), CreatePersonalAccessToken{Name: "CI", Scopes: []string{auth.ScopeTasksRead}}, ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 2. Act
+			_, _, err := f.service.CreateToken(tt.ctx, f.user.ID, tt.params)
+
+			// 3. Assert
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestAuthenticatePersonalToken(t *testing.T) {
+	// 1. Arrange
+	f := newPersonalTokenFixture(t)
+	value, created, err := f.service.CreateToken(f.ctx, f.user.ID, CreatePersonalAccessToken{Name: "CI", Scopes: []string{auth.ScopeLabelsRead}})
+	require.NoError(t, err)
+	f.now = f.now.Add(time.Hour)
+	firstUse := f.now.Truncate(time.Microsecond)
+
+	// 2. Act
+	token, err := f.service.Authenticate(context.Background(), value)
+	f.now = f.now.Add(lastUsedPrecision / 2)
+	_, again := f.service.Authenticate(context.Background(), value)
+	_, unknown := f.service.Authenticate(context.Background(), PersonalAccessTokenPrefix+"unknown")
+	_, jwt := f.service.Authenticate(context.Background(), "eyJhbGciOiJIUzI1NiJ9.e30.sig")
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, token.ID)
+	assert.Equal(t, f.user.ID, token.UserID)
+	assert.NoError(t, again)
+	assert.ErrorIs(t, unknown, ErrInvalidPersonalToken)
+	assert.ErrorIs(t, jwt, ErrInvalidPersonalToken)
+
+	tokens, err := f.service.ListTokens(f.ctx, f.user.ID)
+	require.NoError(t, err)
+	require.NotNil(t, tokens[0].LastUsedAt)
+	assert.True(t, firstUse.Equal(*tokens[0].LastUsedAt), "отметка обновляется не чаще раза в lastUsedPrecision")
+}
+
+func TestAuthenticatePersonalToken_Expired(t *testing.T) {
+	// 1. Arrange
+	f := newPersonalTokenFixture(t)
+	expiresAt := f.now.Add(time.Hour)
+	value, _, err := f.service.CreateToken(f.ctx, f.user.ID, CreatePersonalAccessToken{Name: "CI", Scopes: []string{auth.ScopeTasksRead}, ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	// 2. Act
+	_, valid := f.service.Authenticate(context.Background(), value)
+	f.now = expiresAt
+	_, expired := f.service.Authenticate(context.Background(), value)
+
+	// 3. Assert
+	assert.NoError(t, valid)
+	assert.ErrorIs(t, expired, ErrInvalidPersonalToken)
+}
+
+func TestRevokeToken(t *testing.T) {
+	// 1. Arrange
+	f := newPersonalTokenFixture(t)
+	value, token, err := f.service.CreateToken(f.ctx, f.user.ID, CreatePersonalAccessToken{Name: "CI", Scopes: []string{auth.ScopeTasksRead}})
+	require.NoError(t, err)
+
+	// 2. Act
+	foreign := f.service.RevokeToken(auth.ContextWithUser(context.Background(), uuid.New()), token.ID)
+	err = f.service.RevokeToken(f.ctx, token.ID)
+
+	// 3. Assert
+	assert.ErrorIs(t, foreign, ErrTokenNotFound)
+	require.NoError(t, err)
+	_, err = f.service.Authenticate(context.Background(), value)
+	assert.ErrorIs(t, err, ErrInvalidPersonalToken)
+	assert.ErrorIs(t, f.service.RevokeToken(f.ctx, token.ID), ErrTokenNotFound)
+}
@@ -2,55 +2,86 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"time"
 
-	"os"
-
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/MosinEvgeny/task-tracker/internal/repository"
 	"github.com/google/uuid"
-	"github.com/joho/godotenv"
 )
 
-// RefreshTokenService определяет интерфейс для работы с refresh токенами.
+// Ошибки обмена refresh токена, которые отдаются клиенту с кодом 401.
+var (
+	ErrInvalidRefreshToken = domain.NewError(domain.ErrUnauthorized, "auth.invalid_refresh_token", "Неверный refresh токен")
+	ErrRefreshTokenExpired = domain.NewError(domain.ErrUnauthorized, "auth.refresh_token_expired", "Срок действия refresh токена истек")
+	ErrRefreshTokenReused  = domain.NewError(domain.ErrUnauthorized, "auth.refresh_token_reused", "Refresh токен уже использован, сессия завершена")
+)
+
+// ErrSessionNotFound возвращается для несуществующих и чужих сессий.
+var ErrSessionNotFound = domain.NewError(domain.ErrNotFound, "session.not_found", "сессия не найдена")
+
+// RefreshTokenService определяет интерфейс для работы с refresh токенами и
+// сессиями, к которым они относятся.
 type RefreshTokenService interface {
-	CreateRefreshToken(ctx context.Context, userID uuid.UUID) (*domain.RefreshToken, error)
+	CreateRefreshToken(ctx context.Context, userID uuid.UUID, device domain.Device) (*domain.RefreshToken, error)
 	GetRefreshToken(ctx context.Context, token string) (*domain.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, token string) (*domain.RefreshToken, error)
 	DeleteRefreshToken(ctx context.Context, id uuid.UUID) error
 	DeleteAllRefreshTokensByUserID(ctx context.Context, userID uuid.UUID) error
+	ListSessions(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error)
+	RevokeSession(ctx context.Context, id uuid.UUID) error
 }
 
 // DefaultRefreshTokenService реализует интерфейс RefreshTokenService.
 type DefaultRefreshTokenService struct {
 	refreshTokenRepo repository.RefreshTokenRepository
+	sessionRepo      repository.SessionRepository
+	ttl              time.Duration
 }
 
 // NewRefreshTokenService создает новый экземпляр DefaultRefreshTokenService.
-func NewRefreshTokenService(refreshTokenRepo repository.RefreshTokenRepository) *DefaultRefreshTokenService {
-	return &DefaultRefreshTokenService{refreshTokenRepo: refreshTokenRepo}
+// ttl — срок действия сессии: refresh токены, полученные при ротации,
+// действуют до того же момента, что и первый токен.
+func NewRefreshTokenService(refreshTokenRepo repository.RefreshTokenRepository, sessionRepo repository.SessionRepository, ttl time.Duration) *DefaultRefreshTokenService {
+	return &DefaultRefreshTokenService{refreshTokenRepo: refreshTokenRepo, sessionRepo: sessionRepo, ttl: ttl}
 }
 
-// CreateRefreshToken создает новый refresh токен.
-func (s *DefaultRefreshTokenService) CreateRefreshToken(ctx context.Context, userID uuid.UUID) (*domain.RefreshToken, error) {
-	err := godotenv.Load()
-	if err != nil {
-		fmt.Println("Error loading .env file")
-		return nil, err
+// CreateRefreshToken начинает новую сессию на устройстве device и выдает
+// первый refresh токен ее семейства.
+func (s *DefaultRefreshTokenService) CreateRefreshToken(ctx context.Context, userID uuid.UUID, device domain.Device) (*domain.RefreshToken, error) {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	session := &domain.Session{
+		ID:         uuid.New(),
+		UserID:     userID,
+		Device:     device,
+		CreatedAt:  now,
+		LastUsedAt: now,
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, fmt.Errorf("ошибка при создании сессии: %w", err)
 	}
 
-	RefreshTokenExpireTime := os.Getenv("REFRESH_TOKEN_EXPIRE_TIME")
-	duration, err := time.ParseDuration(RefreshTokenExpireTime)
+	return s.issue(ctx, userID, session.ID, now.Add(s.ttl))
+}
+
+// issue создает refresh токен семейства familyID со случайным значением и
+// сохраняет его хеш.
+func (s *DefaultRefreshTokenService) issue(ctx context.Context, userID, familyID uuid.UUID, expiryDate time.Time) (*domain.RefreshToken, error) {
+	token, err := auth.NewToken()
 	if err != nil {
-		fmt.Println("Error parsing duration:", err)
 		return nil, err
 	}
 
 	refreshToken := &domain.RefreshToken{
 		ID:         uuid.New(),
 		UserID:     userID,
-		Token:      uuid.New().String(),            // Генерируем случайный токен
-		ExpiryDate: time.Now().UTC().Add(duration), // Срок действия 7 дней
+		FamilyID:   familyID,
+		Token:      token,
+		TokenHash:  auth.HashToken(token),
+		ExpiryDate: expiryDate,
 	}
 
 	if err := s.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
@@ -62,7 +93,7 @@ func (s *DefaultRefreshTokenService) CreateRefreshToken(ctx context.Context, use
 
 // GetRefreshToken получает refresh токен по токену.
 func (s *DefaultRefreshTokenService) GetRefreshToken(ctx context.Context, token string) (*domain.RefreshToken, error) {
-	refreshToken, err := s.refreshTokenRepo.GetByToken(ctx, token)
+	refreshToken, err := s.refreshTokenRepo.GetByTokenHash(ctx, auth.HashToken(token))
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении refresh токена: %w", err)
 	}
@@ -70,6 +101,63 @@ func (s *DefaultRefreshTokenService) GetRefreshToken(ctx context.Context, token
 	return refreshToken, nil
 }
 
+// RotateRefreshToken обменивает refresh токен на новый токен того же
+// семейства. Старый токен отмечается как использованный; его повторное
+// предъявление считается кражей и завершает все семейство. Новый токен
+// наследует срок действия семейства, поэтому ротация не продлевает сессию.
+func (s *DefaultRefreshTokenService) RotateRefreshToken(ctx context.Context, token string) (*domain.RefreshToken, error) {
+	current, err := s.refreshTokenRepo.GetByTokenHash(ctx, auth.HashToken(token))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, fmt.Errorf("ошибка при получении refresh токена: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if current.ExpiryDate.Before(now) {
+		return nil, ErrRefreshTokenExpired
+	}
+
+	if current.UsedAt != nil {
+		return nil, s.revokeFamily(ctx, current)
+	}
+	if err := s.refreshTokenRepo.MarkUsed(ctx, current.ID, now); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			// Токен успели обменять параллельно
+			return nil, s.revokeFamily(ctx, current)
+		}
+		return nil, fmt.Errorf("ошибка при ротации refresh токена: %w", err)
+	}
+	if err := s.sessionRepo.Touch(ctx, current.FamilyID, now); err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении сессии: %w", err)
+	}
+
+	return s.issue(ctx, current.UserID, current.FamilyID, current.ExpiryDate)
+}
+
+// revokeFamily удаляет все токены семейства при повторном использовании
+// токена и возвращает ошибку для клиента. Вместе с сессией перестают
+// действовать и ее access токены (см. TokenRevocationService.CheckAccessToken).
+func (s *DefaultRefreshTokenService) revokeFamily(ctx context.Context, reused *domain.RefreshToken) error {
+	log.Printf("refresh token reuse detected: user %s, family %s", reused.UserID, reused.FamilyID)
+	if err := s.deleteSession(ctx, reused.FamilyID); err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// deleteSession удаляет сессию вместе с ее refresh токенами.
+func (s *DefaultRefreshTokenService) deleteSession(ctx context.Context, id uuid.UUID) error {
+	if err := s.refreshTokenRepo.DeleteByFamilyID(ctx, id); err != nil {
+		return fmt.Errorf("ошибка при отзыве семейства refresh токенов: %w", err)
+	}
+	if err := s.sessionRepo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("ошибка при удалении сессии: %w", err)
+	}
+	return nil
+}
+
 // DeleteRefreshToken удаляет refresh токен.
 func (s *DefaultRefreshTokenService) DeleteRefreshToken(ctx context.Context, id uuid.UUID) error {
 	err := s.refreshTokenRepo.Delete(ctx, id)
@@ -80,12 +168,48 @@ func (s *DefaultRefreshTokenService) DeleteRefreshToken(ctx context.Context, id
 	return nil
 }
 
-// DeleteAllRefreshTokensByUserID удаляет все refresh токены пользователя.
+// DeleteAllRefreshTokensByUserID завершает все сессии пользователя и удаляет
+// все его refresh токены.
 func (s *DefaultRefreshTokenService) DeleteAllRefreshTokensByUserID(ctx context.Context, userID uuid.UUID) error {
 	err := s.refreshTokenRepo.DeleteAllByUserID(ctx, userID)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении всех refresh токенов пользователя: %w", err)
 	}
 
+	if err := s.sessionRepo.DeleteAllByUserID(ctx, userID); err != nil {
+		return fmt.Errorf("ошибка при удалении сессий пользователя: %w", err)
+	}
+
 	return nil
 }
+
+// ListSessions возвращает активные сессии пользователя.
+func (s *DefaultRefreshTokenService) ListSessions(ctx context.Context, userID uuid.UUID) ([]*domain.Session, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.sessionRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении сессий пользователя: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию текущего пользователя и отзывает ее refresh
+// и access токены. Остальные сессии пользователя продолжают работать.
+func (s *DefaultRefreshTokenService) RevokeSession(ctx context.Context, id uuid.UUID) error {
+	session, err := s.sessionRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrSessionNotFound
+		}
+		return fmt.Errorf("ошибка при получении сессии по ID: %w", err)
+	}
+	if err := authorize(ctx, session.UserID, ErrSessionNotFound); err != nil {
+		return err
+	}
+
+	return s.deleteSession(ctx, id)
+}
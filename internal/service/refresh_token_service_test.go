@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRefreshTokenFixture создает сервис на хранилище в памяти и сохраняет в
+// нем сессию с первым refresh токеном ее семейства.
+func newRefreshTokenFixture(t *testing.T, expiryDate time.Time) (*DefaultRefreshTokenService, *memory.RefreshTokenRepository, *domain.RefreshToken) {
+	t.Helper()
+
+	store := memory.NewStore()
+	user := &domain.User{ID: uuid.New(), Username: "user", Email: "user@example.com", Password: "hash"}
+	require.NoError(t, memory.NewUserRepository(store).Create(context.Background(), user))
+
+	sessionRepo := memory.NewSessionRepository(store)
+	createdAt := time.Now().UTC().Add(-time.Minute)
+	session := &domain.Session{ID: uuid.New(), UserID: user.ID, CreatedAt: createdAt, LastUsedAt: createdAt}
+	require.NoError(t, sessionRepo.Create(context.Background(), session))
+
+	repo := memory.NewRefreshTokenRepository(store)
+	value, err := auth.NewToken()
+	require.NoError(t, err)
+	token := &domain.RefreshToken{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		FamilyID:   session.ID,
+		Token:      value,
+		TokenHash:  auth.HashToken(value),
+		ExpiryDate: expiryDate,
+	}
+	require.NoError(t, repo.Create(context.Background(), token))
+
+	return NewRefreshTokenService(repo, sessionRepo, time.Hour), repo, token
+}
+
+func TestCreateRefreshToken(t *testing.T) {
+	// 1. Arrange
+	service, repo, token := newRefreshTokenFixture(t, time.Now().UTC().Add(time.Hour))
+	ctx := context.Background()
+	device := domain.Device{Name: "Телефон", UserAgent: "curl/8.0", IP: "192.0.2.1"}
+	before := time.Now().UTC()
+
+	// 2. Act
+	created, err := service.CreateRefreshToken(ctx, token.UserID, device)
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.NotEqual(t, token.FamilyID, created.FamilyID)
+	assert.WithinDuration(t, before.Add(time.Hour), created.ExpiryDate, time.Second)
+
+	session, err := service.sessionRepo.GetByID(ctx, created.FamilyID)
+	require.NoError(t, err)
+	assert.Equal(t, device, session.Device)
+	_, err = repo.GetByTokenHash(ctx, auth.HashToken(created.Token))
+	assert.NoError(t, err)
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	// 1. Arrange
+	expiryDate := time.Now().UTC().Add(time.Hour)
+	service, repo, token := newRefreshTokenFixture(t, expiryDate)
+	ctx := context.Background()
+
+	// 2. Act
+	rotated, err := service.RotateRefreshToken(ctx, token.Token)
+
+	// 3. Assert
+	require.NoError(t, err)
+	assert.NotEqual(t, token.Token, rotated.Token)
+	assert.Equal(t, token.UserID, rotated.UserID)
+	assert.Equal(t, token.FamilyID, rotated.FamilyID)
+	assert.Equal(t, expiryDate, rotated.ExpiryDate)
+
+	session, err := service.sessionRepo.GetByID(ctx, token.FamilyID)
+	require.NoError(t, err)
+	assert.True(t, session.LastUsedAt.After(session.CreatedAt))
+
+	old, err := repo.GetByTokenHash(ctx, token.TokenHash)
+	require.NoError(t, err)
+	assert.NotNil(t, old.UsedAt)
+
+	// Хранится только хеш нового токена
+	stored, err := repo.GetByTokenHash(ctx, auth.HashToken(rotated.Token))
+	require.NoError(t, err)
+	assert.Equal(t, rotated.ID, stored.ID)
+	assert.Empty(t, stored.Token)
+}
+
+func TestRotateRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	// 1. Arrange
+	service, repo, token := newRefreshTokenFixture(t, time.Now().UTC().Add(time.Hour))
+	ctx := context.Background()
+	rotated, err := service.RotateRefreshToken(ctx, token.Token)
+	require.NoError(t, err)
+
+	// 2. Act
+	_, err = service.RotateRefreshToken(ctx, token.Token)
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+	_, err = repo.GetByTokenHash(ctx, rotated.TokenHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = service.RotateRefreshToken(ctx, rotated.Token)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = service.sessionRepo.GetByID(ctx, token.FamilyID)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestRotateRefreshToken_Expired(t *testing.T) {
+	// 1. Arrange
+	service, _, token := newRefreshTokenFixture(t, time.Now().UTC().Add(-time.Minute))
+
+	// 2. Act
+	_, err := service.RotateRefreshToken(context.Background(), token.Token)
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrRefreshTokenExpired)
+}
+
+func TestRotateRefreshToken_Unknown(t *testing.T) {
+	// 1. Arrange
+	service, _, _ := newRefreshTokenFixture(t, time.Now().UTC().Add(time.Hour))
+
+	// 2. Act
+	_, err := service.RotateRefreshToken(context.Background(), uuid.NewString())
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestListSessions(t *testing.T) {
+	// 1. Arrange
+	service, _, token := newRefreshTokenFixture(t, time.Now().UTC().Add(time.Hour))
+	ctx := auth.ContextWithUser(context.Background(), token.UserID)
+
+	// 2. Act
+	sessions, err := service.ListSessions(ctx, token.UserID)
+
+	// 3. Assert
+	require.NoError(t, err)
+	if assert.Len(t, sessions, 1) {
+		assert.Equal(t, token.FamilyID, sessions[0].ID)
+	}
+
+	_, err = service.ListSessions(auth.ContextWithUser(context.Background(), uuid.New()), token.UserID)
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestRevokeSession(t *testing.T) {
+	// 1. Arrange
+	service, repo, token := newRefreshTokenFixture(t, time.Now().UTC().Add(time.Hour))
+	ctx := auth.ContextWithUser(context.Background(), token.UserID)
+
+	// 2. Act
+	err := service.RevokeSession(ctx, token.FamilyID)
+
+	// 3. Assert
+	require.NoError(t, err)
+	_, err = repo.GetByTokenHash(ctx, token.TokenHash)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	_, err = service.RotateRefreshToken(ctx, token.Token)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	assert.ErrorIs(t, service.RevokeSession(ctx, token.FamilyID), ErrSessionNotFound)
+}
+
+func TestRevokeSession_Foreign(t *testing.T) {
+	// 1. Arrange
+	service, repo, token := newRefreshTokenFixture(t, time.Now().UTC().Add(time.Hour))
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	// 2. Act
+	err := service.RevokeSession(ctx, token.FamilyID)
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	_, err = repo.GetByTokenHash(ctx, token.TokenHash)
+	assert.NoError(t, err)
+}
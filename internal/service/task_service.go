@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,32 +11,47 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrTaskNotFound возвращается для несуществующих и чужих задач.
+var ErrTaskNotFound = domain.NewError(domain.ErrNotFound, "task.not_found", "задача не найдена")
+
 // TaskService определяет интерфейс для работы с задачами.
 type TaskService interface {
-	CreateTask(ctx context.Context, title, description string, dueDate time.Time, userID uuid.UUID) (*domain.Task, error)
+	CreateTask(ctx context.Context, title, description string, dueDate time.Time, userID uuid.UUID, labelIDs []uuid.UUID) (*domain.Task, error)
 	GetTaskByID(ctx context.Context, id uuid.UUID) (*domain.Task, error)
 	GetAllTasksByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error)
-	UpdateTask(ctx context.Context, id uuid.UUID, title, description string, dueDate time.Time) (*domain.Task, error)
+	ListTasks(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter) ([]*domain.Task, string, error)
+	UpdateTask(ctx context.Context, id uuid.UUID, title, description string, dueDate time.Time, labelIDs []uuid.UUID) (*domain.Task, error)
 	DeleteTask(ctx context.Context, id uuid.UUID) error
+	TransitionTask(ctx context.Context, id uuid.UUID, status domain.TaskStatus) (*domain.Task, error)
 }
 
 // DefaultTaskService реализует интерфейс TaskService.
 type DefaultTaskService struct {
-	taskRepo repository.TaskRepository
+	taskRepo  repository.TaskRepository
+	labelRepo repository.LabelRepository
+	workflow  *domain.Workflow
 }
 
 // NewTaskService создает новый экземпляр DefaultTaskService.
-func NewTaskService(taskRepo repository.TaskRepository) *DefaultTaskService {
-	return &DefaultTaskService{taskRepo: taskRepo}
+func NewTaskService(taskRepo repository.TaskRepository, labelRepo repository.LabelRepository, workflow *domain.Workflow) *DefaultTaskService {
+	return &DefaultTaskService{taskRepo: taskRepo, labelRepo: labelRepo, workflow: workflow}
 }
 
 // CreateTask создает новую задачу.
-func (s *DefaultTaskService) CreateTask(ctx context.Context, title, description string, dueDate time.Time, userID uuid.UUID) (*domain.Task, error) {
+func (s *DefaultTaskService) CreateTask(ctx context.Context, title, description string, dueDate time.Time, userID uuid.UUID, labelIDs []uuid.UUID) (*domain.Task, error) {
 	if title == "" {
-		return nil, fmt.Errorf("необходимо указать название задачи")
+		return nil, domain.NewFieldError("title", "task.title_required", "необходимо указать название задачи")
 	}
 	if userID == uuid.Nil {
-		return nil, fmt.Errorf("необходимо указать пользователя")
+		return nil, domain.NewFieldError("user_id", "task.user_required", "необходимо указать пользователя")
+	}
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
+	labelIDs, err := s.checkLabels(ctx, userID, labelIDs)
+	if err != nil {
+		return nil, err
 	}
 
 	task := &domain.Task{
@@ -43,7 +59,10 @@ func (s *DefaultTaskService) CreateTask(ctx context.Context, title, description
 		Title:       title,
 		Description: description,
 		DueDate:     dueDate,
+		Status:      s.workflow.Initial,
+		CreatedAt:   time.Now().UTC().Truncate(time.Microsecond),
 		UserID:      userID,
+		LabelIDs:    labelIDs,
 	}
 
 	if err := s.taskRepo.Create(ctx, task); err != nil {
@@ -54,14 +73,14 @@ func (s *DefaultTaskService) CreateTask(ctx context.Context, title, description
 }
 
 func (s *DefaultTaskService) GetTaskByID(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
-	task, err := s.taskRepo.GetByID(ctx, id)
-	if err != nil {
-		return nil, fmt.Errorf("ошибка при получении задачи по ID: %w", err)
-	}
-	return task, nil
+	return s.getOwnedTask(ctx, id)
 }
 
 func (s *DefaultTaskService) GetAllTasksByUserID(ctx context.Context, userID uuid.UUID) ([]*domain.Task, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
 	tasks, err := s.taskRepo.GetAllByUserID(ctx, userID)
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении задач пользователя: %w", err)
@@ -69,10 +88,55 @@ func (s *DefaultTaskService) GetAllTasksByUserID(ctx context.Context, userID uui
 	return tasks, nil
 }
 
-func (s *DefaultTaskService) UpdateTask(ctx context.Context, id uuid.UUID, title, description string, dueDate time.Time) (*domain.Task, error) {
-	task, err := s.taskRepo.GetByID(ctx, id)
+// ListTasks возвращает страницу задач пользователя и курсор следующей страницы.
+// Пустой курсор означает, что страница последняя.
+func (s *DefaultTaskService) ListTasks(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter) ([]*domain.Task, string, error) {
+	if err := authorize(ctx, userID, ErrUserNotFound); err != nil {
+		return nil, "", err
+	}
+	for _, status := range filter.Statuses {
+		if !s.workflow.Has(status) {
+			return nil, "", unknownStatusError(status)
+		}
+	}
+
+	limit := pageLimit(filter.Limit)
+	filter.Limit = limit + 1 // Лишняя запись показывает, есть ли следующая страница
+
+	tasks, err := s.taskRepo.List(ctx, userID, filter)
+	if err != nil {
+		return nil, "", fmt.Errorf("ошибка при получении задач пользователя: %w", err)
+	}
+
+	if len(tasks) <= limit {
+		return tasks, "", nil
+	}
+
+	tasks = tasks[:limit]
+	last := tasks[limit-1]
+	nextCursor := repository.EncodeCursor(repository.Cursor{
+		Sort:  filter.Sort.String(),
+		Value: repository.TaskSortValue(last, filter.Sort.Field),
+		ID:    last.ID,
+	})
+
+	return tasks, nextCursor, nil
+}
+
+// UpdateTask обновляет задачу. Если labelIDs равен nil, метки задачи не меняются,
+// пустой срез снимает все метки.
+func (s *DefaultTaskService) UpdateTask(ctx context.Context, id uuid.UUID, title, description string, dueDate time.Time, labelIDs []uuid.UUID) (*domain.Task, error) {
+	task, err := s.getOwnedTask(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("задача не найдена")
+		return nil, err
+	}
+
+	if labelIDs != nil {
+		labelIDs, err = s.checkLabels(ctx, task.UserID, labelIDs)
+		if err != nil {
+			return nil, err
+		}
+		task.LabelIDs = labelIDs
 	}
 
 	task.Title = title
@@ -87,9 +151,91 @@ func (s *DefaultTaskService) UpdateTask(ctx context.Context, id uuid.UUID, title
 }
 
 func (s *DefaultTaskService) DeleteTask(ctx context.Context, id uuid.UUID) error {
+	if _, err := s.getOwnedTask(ctx, id); err != nil {
+		return err
+	}
+
 	err := s.taskRepo.Delete(ctx, id)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении задачи: %w", err)
 	}
 	return nil
 }
+
+// TransitionTask переводит задачу в новый статус, если переход разрешен
+// рабочим процессом. При переходе в статус выполнения фиксируется время
+// выполнения, при выходе из него — сбрасывается.
+func (s *DefaultTaskService) TransitionTask(ctx context.Context, id uuid.UUID, status domain.TaskStatus) (*domain.Task, error) {
+	if !s.workflow.Has(status) {
+		return nil, unknownStatusError(status)
+	}
+
+	task, err := s.getOwnedTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.workflow.CanTransition(task.Status, status) {
+		return nil, domain.NewError(domain.ErrConflict, "task.invalid_transition", fmt.Sprintf("недопустимый переход из статуса %s в %s", task.Status, status)).
+			WithParams(map[string]string{"from": string(task.Status), "to": string(status)})
+	}
+
+	task.Status = status
+	if s.workflow.IsCompleted(status) {
+		completedAt := time.Now().UTC().Truncate(time.Microsecond)
+		task.CompletedAt = &completedAt
+	} else {
+		task.CompletedAt = nil
+	}
+
+	if err := s.taskRepo.Update(ctx, task); err != nil {
+		return nil, fmt.Errorf("ошибка при обновлении задачи: %w", err)
+	}
+
+	return task, nil
+}
+
+// getOwnedTask загружает задачу и проверяет, что она принадлежит текущему пользователю.
+func (s *DefaultTaskService) getOwnedTask(ctx context.Context, id uuid.UUID) (*domain.Task, error) {
+	task, err := s.taskRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении задачи по ID: %w", err)
+	}
+	if err := authorize(ctx, task.UserID, ErrTaskNotFound); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// checkLabels убирает повторы из списка меток и проверяет, что все метки
+// существуют и принадлежат пользователю.
+func (s *DefaultTaskService) checkLabels(ctx context.Context, userID uuid.UUID, labelIDs []uuid.UUID) ([]uuid.UUID, error) {
+	unique := make([]uuid.UUID, 0, len(labelIDs))
+	seen := make(map[uuid.UUID]struct{}, len(labelIDs))
+	for _, labelID := range labelIDs {
+		if _, ok := seen[labelID]; ok {
+			continue
+		}
+		seen[labelID] = struct{}{}
+
+		label, err := s.labelRepo.GetByID(ctx, labelID)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("ошибка при получении метки по ID: %w", err)
+		}
+		if err != nil || label.UserID != userID {
+			return nil, domain.NewFieldError("label_ids", "task.label_not_found", fmt.Sprintf("метка %s не найдена", labelID)).
+				WithParams(map[string]string{"id": labelID.String()})
+		}
+		unique = append(unique, labelID)
+	}
+	return unique, nil
+}
+
+// unknownStatusError возвращает ошибку валидации для статуса, которого нет в рабочем процессе.
+func unknownStatusError(status domain.TaskStatus) error {
+	return domain.NewFieldError("status", "task.unknown_status", fmt.Sprintf("неизвестный статус задачи: %s", status)).
+		WithParams(map[string]string{"status": string(status)})
+}
@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -40,6 +42,15 @@ func (m *MockTaskRepository) GetAllByUserID(ctx context.Context, userID uuid.UUI
 	return tasks, args.Error(1)
 }
 
+func (m *MockTaskRepository) List(ctx context.Context, userID uuid.UUID, filter repository.TaskFilter) ([]*domain.Task, error) {
+	args := m.Called(ctx, userID, filter)
+	tasks, ok := args.Get(0).([]*domain.Task)
+	if !ok {
+		return nil, args.Error(1)
+	}
+	return tasks, args.Error(1)
+}
+
 func (m *MockTaskRepository) Update(ctx context.Context, task *domain.Task) error {
 	args := m.Called(ctx, task)
 	return args.Error(0)
@@ -53,19 +64,19 @@ func (m *MockTaskRepository) Delete(ctx context.Context, id uuid.UUID) error {
 func TestCreateTask(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	title := "Test Task"
 	description := "Test Description"
 	dueDate := time.Now()
-	userID := uuid.New()
 
 	// Настройка mock-репозитория
 	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Task")).Return(nil)
 
 	// 2. Act
-	task, err := taskService.CreateTask(ctx, title, description, dueDate, userID)
+	task, err := taskService.CreateTask(ctx, title, description, dueDate, userID, nil)
 
 	// 3. Assert
 	assert.NoError(t, err)
@@ -74,6 +85,8 @@ func TestCreateTask(t *testing.T) {
 	assert.Equal(t, description, task.Description)
 	assert.Equal(t, dueDate, task.DueDate)
 	assert.Equal(t, userID, task.UserID)
+	assert.Equal(t, domain.TaskStatusTodo, task.Status)
+	assert.Nil(t, task.CompletedAt)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -81,30 +94,84 @@ func TestCreateTask(t *testing.T) {
 func TestCreateTask_EmptyTitle(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	title := ""
 	description := "Test Description"
 	dueDate := time.Now()
-	userID := uuid.New()
 
 	// 2. Act
-	task, err := taskService.CreateTask(ctx, title, description, dueDate, userID)
+	task, err := taskService.CreateTask(ctx, title, description, dueDate, userID, nil)
 
 	// 3. Assert
 	assert.Error(t, err)
 	assert.Nil(t, task)
 	assert.EqualError(t, err, "необходимо указать название задачи")
+	assert.ErrorIs(t, err, domain.ErrValidation)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateTask_WithLabels(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	taskService := NewTaskService(mockRepo, mockLabelRepo, domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	labelID := uuid.New()
+
+	// Настройка mock-репозиториев
+	mockLabelRepo.On("GetByID", mock.Anything, labelID).Return(&domain.Label{ID: labelID, UserID: userID}, nil).Once()
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(task *domain.Task) bool {
+		return len(task.LabelIDs) == 1 && task.LabelIDs[0] == labelID
+	})).Return(nil)
+
+	// 2. Act
+	task, err := taskService.CreateTask(ctx, "Test Task", "", time.Now(), userID, []uuid.UUID{labelID, labelID})
+
+	// 3. Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []uuid.UUID{labelID}, task.LabelIDs)
 
 	mockRepo.AssertExpectations(t)
+	mockLabelRepo.AssertExpectations(t)
+}
+
+func TestCreateTask_ForeignLabel(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	taskService := NewTaskService(mockRepo, mockLabelRepo, domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	labelID := uuid.New()
+
+	// Метка принадлежит другому пользователю
+	mockLabelRepo.On("GetByID", mock.Anything, labelID).Return(&domain.Label{ID: labelID, UserID: uuid.New()}, nil)
+
+	// 2. Act
+	task, err := taskService.CreateTask(ctx, "Test Task", "", time.Now(), userID, []uuid.UUID{labelID})
+
+	// 3. Assert
+	assert.Error(t, err)
+	assert.Nil(t, task)
+	assert.EqualError(t, err, "метка "+labelID.String()+" не найдена")
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockLabelRepo.AssertExpectations(t)
 }
 
 func TestGetTaskByID(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	taskID := uuid.New()
 	expectedTask := &domain.Task{
@@ -112,7 +179,7 @@ func TestGetTaskByID(t *testing.T) {
 		Title:       "Test Task",
 		Description: "Test Description",
 		DueDate:     time.Now(),
-		UserID:      uuid.New(),
+		UserID:      userID,
 	}
 
 	// Настройка mock-репозитория
@@ -131,8 +198,9 @@ func TestGetTaskByID(t *testing.T) {
 func TestGetTaskByID_NotFound(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	taskID := uuid.New()
 
@@ -150,11 +218,33 @@ func TestGetTaskByID_NotFound(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetTaskByID_Missing(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	taskID := uuid.New()
+
+	// Настройка mock-репозитория
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(nil, domain.NewError(domain.ErrNotFound, "task.not_found", "задача не найдена"))
+
+	// 2. Act
+	task, err := taskService.GetTaskByID(ctx, taskID)
+
+	// 3. Assert
+	assert.Nil(t, task)
+	assert.Equal(t, ErrTaskNotFound, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUpdateTask(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	taskID := uuid.New()
 	initialTask := &domain.Task{
@@ -162,7 +252,7 @@ func TestUpdateTask(t *testing.T) {
 		Title:       "Old Title",
 		Description: "Old Description",
 		DueDate:     time.Now(),
-		UserID:      uuid.New(),
+		UserID:      userID,
 	}
 	updatedTitle := "New Title"
 	updatedDescription := "New Description"
@@ -175,7 +265,7 @@ func TestUpdateTask(t *testing.T) {
 	})).Return(nil)
 
 	// 2. Act
-	task, err := taskService.UpdateTask(ctx, taskID, updatedTitle, updatedDescription, updatedDueDate)
+	task, err := taskService.UpdateTask(ctx, taskID, updatedTitle, updatedDescription, updatedDueDate, nil)
 
 	// 3. Assert
 	assert.NoError(t, err)
@@ -187,11 +277,45 @@ func TestUpdateTask(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestUpdateTask_ReplaceLabels(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	mockLabelRepo := new(MockLabelRepository)
+	taskService := NewTaskService(mockRepo, mockLabelRepo, domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	taskID := uuid.New()
+	initialTask := &domain.Task{
+		ID:       taskID,
+		Title:    "Old Title",
+		UserID:   userID,
+		LabelIDs: []uuid.UUID{uuid.New()},
+	}
+
+	// Настройка mock-репозитория
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(initialTask, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(task *domain.Task) bool {
+		return len(task.LabelIDs) == 0
+	})).Return(nil)
+
+	// 2. Act
+	task, err := taskService.UpdateTask(ctx, taskID, "New Title", "", time.Now(), []uuid.UUID{})
+
+	// 3. Assert
+	assert.NoError(t, err)
+	assert.Empty(t, task.LabelIDs)
+
+	mockRepo.AssertExpectations(t)
+	mockLabelRepo.AssertExpectations(t)
+}
+
 func TestUpdateTask_NotFound(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	taskID := uuid.New()
 	updatedTitle := "New Title"
@@ -199,15 +323,16 @@ func TestUpdateTask_NotFound(t *testing.T) {
 	updatedDueDate := time.Now().Add(time.Hour * 24)
 
 	// Настройка mock-репозитория
-	mockRepo.On("GetByID", mock.Anything, taskID).Return(nil, errors.New("task not found"))
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(nil, domain.ErrNotFound)
 
 	// 2. Act
-	task, err := taskService.UpdateTask(ctx, taskID, updatedTitle, updatedDescription, updatedDueDate)
+	task, err := taskService.UpdateTask(ctx, taskID, updatedTitle, updatedDescription, updatedDueDate, nil)
 
 	// 3. Assert
 	assert.Error(t, err)
 	assert.Nil(t, task)
 	assert.EqualError(t, err, "задача не найдена")
+	assert.ErrorIs(t, err, domain.ErrNotFound)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -215,12 +340,14 @@ func TestUpdateTask_NotFound(t *testing.T) {
 func TestDeleteTask(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	taskID := uuid.New()
 
 	// Настройка mock-репозитория
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(&domain.Task{ID: taskID, UserID: userID}, nil)
 	mockRepo.On("Delete", mock.Anything, taskID).Return(nil)
 
 	// 2. Act
@@ -235,13 +362,15 @@ func TestDeleteTask(t *testing.T) {
 func TestDeleteTask_Error(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
 
 	taskID := uuid.New()
 	expectedError := errors.New("delete error")
 
 	// Настройка mock-репозитория
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(&domain.Task{ID: taskID, UserID: userID}, nil)
 	mockRepo.On("Delete", mock.Anything, taskID).Return(expectedError)
 
 	// 2. Act
@@ -254,13 +383,94 @@ func TestDeleteTask_Error(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
-func TestGetAllTasksByUserID(t *testing.T) {
+func TestGetTaskByID_ForeignTask(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	taskID := uuid.New()
+	foreignTask := &domain.Task{ID: taskID, Title: "Foreign Task", UserID: uuid.New()}
+
+	// Задача принадлежит другому пользователю
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(foreignTask, nil)
+
+	// 2. Act
+	task, err := taskService.GetTaskByID(ctx, taskID)
+
+	// 3. Assert
+	assert.Nil(t, task)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateTask_ForeignTask(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	taskID := uuid.New()
+	foreignTask := &domain.Task{ID: taskID, Title: "Foreign Task", UserID: uuid.New()}
+
+	// Задача принадлежит другому пользователю
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(foreignTask, nil)
+
+	// 2. Act
+	task, err := taskService.UpdateTask(ctx, taskID, "New Title", "", time.Now(), nil)
+
+	// 3. Assert
+	assert.Nil(t, task)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+	assert.Equal(t, "Foreign Task", foreignTask.Title)
+
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestDeleteTask_ForeignTask(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	taskID := uuid.New()
+
+	// Задача принадлежит другому пользователю
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(&domain.Task{ID: taskID, UserID: uuid.New()}, nil)
+
+	// 2. Act
+	err := taskService.DeleteTask(ctx, taskID)
+
+	// 3. Assert
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestCreateTask_ForeignUser(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	// 2. Act
+	task, err := taskService.CreateTask(ctx, "Test Task", "", time.Now(), uuid.New(), nil)
+
+	// 3. Assert
+	assert.Nil(t, task)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
 
+func TestGetAllTasksByUserID(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
 	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
 	expectedTasks := []*domain.Task{
 		{ID: uuid.New(), Title: "Task 1", Description: "Description 1", DueDate: time.Now(), UserID: userID},
 		{ID: uuid.New(), Title: "Task 2", Description: "Description 2", DueDate: time.Now(), UserID: userID},
@@ -282,10 +492,10 @@ func TestGetAllTasksByUserID(t *testing.T) {
 func TestGetAllTasksByUserID_Error(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockTaskRepository)
-	taskService := NewTaskService(mockRepo)
-	ctx := context.Background()
-
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
 	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
 	expectedError := errors.New("get all error")
 
 	// Настройка mock-репозитория
@@ -301,3 +511,156 @@ func TestGetAllTasksByUserID_Error(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestListTasks_NextCursor(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	dueDate := time.Date(2025, 3, 15, 12, 0, 0, 0, time.UTC)
+	tasks := []*domain.Task{
+		{ID: uuid.New(), Title: "Task 1", DueDate: dueDate, UserID: userID},
+		{ID: uuid.New(), Title: "Task 2", DueDate: dueDate.Add(time.Hour), UserID: userID},
+		{ID: uuid.New(), Title: "Task 3", DueDate: dueDate.Add(2 * time.Hour), UserID: userID},
+	}
+	sort := repository.Sort{Field: repository.TaskSortDueDate}
+
+	// Сервис запрашивает на одну запись больше размера страницы
+	mockRepo.On("List", mock.Anything, userID, mock.MatchedBy(func(filter repository.TaskFilter) bool {
+		return filter.Limit == 3 && filter.Sort == sort
+	})).Return(tasks, nil)
+
+	// 2. Act
+	page, nextCursor, err := taskService.ListTasks(ctx, userID, repository.TaskFilter{Sort: sort, Limit: 2})
+
+	// 3. Assert
+	assert.NoError(t, err)
+	assert.Equal(t, tasks[:2], page)
+
+	cursor, err := repository.DecodeCursor(nextCursor, sort)
+	assert.NoError(t, err)
+	assert.Equal(t, tasks[1].ID, cursor.ID)
+	assert.Equal(t, "2025-03-15T13:00:00Z", cursor.Value)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestListTasks_LastPage(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	tasks := []*domain.Task{{ID: uuid.New(), Title: "Task 1", UserID: userID}}
+
+	// Настройка mock-репозитория
+	mockRepo.On("List", mock.Anything, userID, mock.MatchedBy(func(filter repository.TaskFilter) bool {
+		return filter.Limit == DefaultPageSize+1
+	})).Return(tasks, nil)
+
+	// 2. Act
+	page, nextCursor, err := taskService.ListTasks(ctx, userID, repository.TaskFilter{})
+
+	// 3. Assert
+	assert.NoError(t, err)
+	assert.Equal(t, tasks, page)
+	assert.Empty(t, nextCursor)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransitionTask_Done(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	taskID := uuid.New()
+	initialTask := &domain.Task{ID: taskID, Title: "Test Task", Status: domain.TaskStatusInProgress, UserID: userID}
+
+	// Настройка mock-репозитория
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(initialTask, nil)
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(task *domain.Task) bool {
+		return task.Status == domain.TaskStatusDone && task.CompletedAt != nil
+	})).Return(nil)
+
+	// 2. Act
+	task, err := taskService.TransitionTask(ctx, taskID, domain.TaskStatusDone)
+
+	// 3. Assert
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TaskStatusDone, task.Status)
+	assert.NotNil(t, task.CompletedAt)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransitionTask_Reopen(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	taskID := uuid.New()
+	completedAt := time.Now()
+	initialTask := &domain.Task{ID: taskID, Status: domain.TaskStatusDone, CompletedAt: &completedAt, UserID: userID}
+
+	// Настройка mock-репозитория
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(initialTask, nil)
+	mockRepo.On("Update", mock.Anything, mock.AnythingOfType("*domain.Task")).Return(nil)
+
+	// 2. Act
+	task, err := taskService.TransitionTask(ctx, taskID, domain.TaskStatusInProgress)
+
+	// 3. Assert
+	assert.NoError(t, err)
+	assert.Equal(t, domain.TaskStatusInProgress, task.Status)
+	assert.Nil(t, task.CompletedAt)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransitionTask_NotAllowed(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	taskID := uuid.New()
+
+	// Настройка mock-репозитория
+	mockRepo.On("GetByID", mock.Anything, taskID).Return(&domain.Task{ID: taskID, Status: domain.TaskStatusTodo, UserID: userID}, nil)
+
+	// 2. Act
+	task, err := taskService.TransitionTask(ctx, taskID, domain.TaskStatusDone)
+
+	// 3. Assert
+	assert.Nil(t, task)
+	assert.EqualError(t, err, "недопустимый переход из статуса todo в done")
+	assert.ErrorIs(t, err, domain.ErrConflict)
+
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestTransitionTask_UnknownStatus(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockTaskRepository)
+	taskService := NewTaskService(mockRepo, new(MockLabelRepository), domain.DefaultWorkflow())
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	// 2. Act
+	task, err := taskService.TransitionTask(ctx, uuid.New(), "archived")
+
+	// 3. Assert
+	assert.Nil(t, task)
+	assert.EqualError(t, err, "неизвестный статус задачи: archived")
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ErrAccessTokenRevoked возвращается для отозванного access токена.
+var ErrAccessTokenRevoked = domain.NewError(domain.ErrUnauthorized, "auth.token_revoked", "токен отозван")
+
+// TokenRevocationService отзывает access токены до истечения их срока
+// действия: по одному (deny-list по claim jti) или все токены пользователя,
+// выданные до определенного момента. Токены сессии отзываются завершением
+// сессии, токены клиента OAuth — удалением клиента.
+type TokenRevocationService interface {
+	RevokeAccessToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error
+	RevokeAllAccessTokens(ctx context.Context, userID uuid.UUID) error
+	// CheckAccessToken проверяет токен с claims sub, jti, sid (пустой у
+	// токенов без сессии), client_id (пустой у токенов сессий) и iat.
+	CheckAccessToken(ctx context.Context, userID, jti uuid.UUID, sessionID, clientID string, issuedAt time.Time) error
+}
+
+// DefaultTokenRevocationService реализует интерфейс TokenRevocationService.
+type DefaultTokenRevocationService struct {
+	userRepo     repository.UserRepository
+	denyListRepo repository.DenyListRepository
+	clientRepo   repository.OAuthClientRepository
+	sessionRepo  repository.SessionRepository
+}
+
+// NewTokenRevocationService создает новый экземпляр DefaultTokenRevocationService.
+func NewTokenRevocationService(userRepo repository.UserRepository, denyListRepo repository.DenyListRepository, clientRepo repository.OAuthClientRepository, sessionRepo repository.SessionRepository) *DefaultTokenRevocationService {
+	return &DefaultTokenRevocationService{userRepo: userRepo, denyListRepo: denyListRepo, clientRepo: clientRepo, sessionRepo: sessionRepo}
+}
+
+// RevokeAccessToken добавляет токен в deny-list до истечения его срока
+// действия. Повторный отзыв не считается ошибкой.
+func (s *DefaultTokenRevocationService) RevokeAccessToken(ctx context.Context, jti uuid.UUID, expiresAt time.Time) error {
+	if err := s.denyListRepo.Add(ctx, jti, expiresAt); err != nil && !errors.Is(err, domain.ErrConflict) {
+		return fmt.Errorf("ошибка при отзыве access токена: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAllAccessTokens отзывает все access токены пользователя, выданные до
+// текущего момента.
+func (s *DefaultTokenRevocationService) RevokeAllAccessTokens(ctx context.Context, userID uuid.UUID) error {
+	err := s.userRepo.SetTokensValidAfter(ctx, userID, time.Now().UTC().Truncate(time.Microsecond))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("ошибка при отзыве access токенов пользователя: %w", err)
+	}
+
+	return nil
+}
+
+// CheckAccessToken возвращает ErrAccessTokenRevoked, если токен находится в
+// deny-list, выдан до отзыва всех токенов пользователя, пользователь удален,
+// сессия токена завершена или токен выдан клиенту OAuth, которого уже
+// удалили.
+func (s *DefaultTokenRevocationService) CheckAccessToken(ctx context.Context, userID, jti uuid.UUID, sessionID, clientID string, issuedAt time.Time) error {
+	denied, err := s.denyListRepo.Contains(ctx, jti, time.Now())
+	if err != nil {
+		return fmt.Errorf("ошибка при проверке access токена: %w", err)
+	}
+	if denied {
+		return ErrAccessTokenRevoked
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return ErrAccessTokenRevoked
+		}
+		return fmt.Errorf("ошибка при получении пользователя по ID: %w", err)
+	}
+	if user.TokensValidAfter != nil && issuedAt.Before(*user.TokensValidAfter) {
+		return ErrAccessTokenRevoked
+	}
+
+	if sessionID != "" {
+		id, err := uuid.Parse(sessionID)
+		if err != nil {
+			return ErrAccessTokenRevoked
+		}
+		session, err := s.sessionRepo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return ErrAccessTokenRevoked
+			}
+			return fmt.Errorf("ошибка при получении сессии по ID: %w", err)
+		}
+		if session.UserID != user.ID {
+			return ErrAccessTokenRevoked
+		}
+	}
+
+	if clientID != "" {
+		id, err := uuid.Parse(clientID)
+		if err != nil {
+			return ErrAccessTokenRevoked
+		}
+		if _, err := s.clientRepo.GetByID(ctx, id); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return ErrAccessTokenRevoked
+			}
+			return fmt.Errorf("ошибка при получении клиента OAuth: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MosinEvgeny/task-tracker/internal/domain"
+	"github.com/MosinEvgeny/task-tracker/internal/repository/memory"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTokenRevocationFixture создает сервис на хранилище в памяти и
+// сохраняет в нем пользователя.
+func newTokenRevocationFixture(t *testing.T) (*DefaultTokenRevocationService, *domain.User) {
+	t.Helper()
+
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	user := &domain.User{ID: uuid.New(), Username: "user", Email: "user@example.com", Password: "hash"}
+	require.NoError(t, userRepo.Create(context.Background(), user))
+
+	return NewTokenRevocationService(userRepo, memory.NewDenyListRepository(store), memory.NewOAuthClientRepository(store), memory.NewSessionRepository(store)), user
+}
+
+func TestCheckAccessToken_DenyList(t *testing.T) {
+	// 1. Arrange
+	service, user := newTokenRevocationFixture(t)
+	ctx := context.Background()
+	jti, other := uuid.New(), uuid.New()
+	issuedAt := time.Now()
+	require.NoError(t, service.RevokeAccessToken(ctx, jti, time.Now().Add(time.Hour)))
+
+	// 2. Act
+	err := service.CheckAccessToken(ctx, user.ID, jti, "", "", issuedAt)
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrAccessTokenRevoked)
+	assert.NoError(t, service.CheckAccessToken(ctx, user.ID, other, "", "", issuedAt))
+}
+
+func TestCheckAccessToken_DenyListExpired(t *testing.T) {
+	// 1. Arrange
+	service, user := newTokenRevocationFixture(t)
+	ctx := context.Background()
+	jti := uuid.New()
+	require.NoError(t, service.RevokeAccessToken(ctx, jti, time.Now().Add(-time.Minute)))
+
+	// 2. Act
+	err := service.CheckAccessToken(ctx, user.ID, jti, "", "", time.Now())
+
+	// 3. Assert
+	assert.NoError(t, err)
+}
+
+func TestCheckAccessToken_RevokeAll(t *testing.T) {
+	// 1. Arrange
+	service, user := newTokenRevocationFixture(t)
+	ctx := context.Background()
+	issuedBefore := time.Now().Add(-time.Second)
+
+	// 2. Act
+	require.NoError(t, service.RevokeAllAccessTokens(ctx, user.ID))
+
+	// 3. Assert
+	assert.ErrorIs(t, service.CheckAccessToken(ctx, user.ID, uuid.New(), "", "", issuedBefore), ErrAccessTokenRevoked)
+	assert.NoError(t, service.CheckAccessToken(ctx, user.ID, uuid.New(), "", "", time.Now().Add(time.Second)))
+}
+
+func TestCheckAccessToken_DeletedUser(t *testing.T) {
+	// 1. Arrange
+	service, _ := newTokenRevocationFixture(t)
+
+	// 2. Act
+	err := service.CheckAccessToken(context.Background(), uuid.New(), uuid.New(), "", "", time.Now())
+
+	// 3. Assert
+	assert.ErrorIs(t, err, ErrAccessTokenRevoked)
+}
+
+func TestCheckAccessToken_DeletedClient(t *testing.T) {
+	// 1. Arrange
+	service, user := newTokenRevocationFixture(t)
+	ctx := context.Background()
+	client := &domain.OAuthClient{ID: uuid.New(), OwnerID: user.ID, Name: "Reports", RedirectURIs: []string{"https://app.example.com/cb"}, Scopes: []string{"tasks:read"}, CreatedAt: time.Now().UTC()}
+	require.NoError(t, service.clientRepo.Create(ctx, client))
+	issuedAt := time.Now()
+
+	// 2. Act
+	active := service.CheckAccessToken(ctx, user.ID, uuid.New(), "", client.ID.String(), issuedAt)
+	require.NoError(t, service.clientRepo.Delete(ctx, client.ID))
+	deleted := service.CheckAccessToken(ctx, user.ID, uuid.New(), "", client.ID.String(), issuedAt)
+
+	// 3. Assert
+	assert.NoError(t, active)
+	assert.ErrorIs(t, deleted, ErrAccessTokenRevoked, "токены удаленного клиента больше не действуют")
+}
+
+func TestCheckAccessToken_RevokedSession(t *testing.T) {
+	// 1. Arrange
+	service, user := newTokenRevocationFixture(t)
+	ctx := context.Background()
+	session := &domain.Session{ID: uuid.New(), UserID: user.ID, CreatedAt: time.Now().UTC(), LastUsedAt: time.Now().UTC()}
+	require.NoError(t, service.sessionRepo.Create(ctx, session))
+	other := &domain.User{ID: uuid.New(), Username: "other", Email: "other@example.com", Password: "hash"}
+	require.NoError(t, service.userRepo.Create(ctx, other))
+	issuedAt := time.Now()
+
+	// 2. Act
+	active := service.CheckAccessToken(ctx, user.ID, uuid.New(), session.ID.String(), "", issuedAt)
+	foreign := service.CheckAccessToken(ctx, other.ID, uuid.New(), session.ID.String(), "", issuedAt)
+	require.NoError(t, service.sessionRepo.Delete(ctx, session.ID))
+	revoked := service.CheckAccessToken(ctx, user.ID, uuid.New(), session.ID.String(), "", issuedAt)
+
+	// 3. Assert
+	assert.NoError(t, active)
+	assert.ErrorIs(t, foreign, ErrAccessTokenRevoked, "сессия другого пользователя")
+	assert.ErrorIs(t, revoked, ErrAccessTokenRevoked, "токены завершенной сессии больше не действуют")
+}
@@ -2,14 +2,23 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
 	"regexp"
 
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/MosinEvgeny/task-tracker/internal/repository"
 	"github.com/google/uuid"
 )
 
+// ErrUserNotFound возвращается при обращении к несуществующему или чужому пользователю.
+var ErrUserNotFound = domain.NewError(domain.ErrNotFound, "user.not_found", "пользователь не найден")
+
+// ErrEmailTaken возвращается, если email уже занят другим пользователем.
+var ErrEmailTaken = domain.NewError(domain.ErrConflict, "user.email_taken", "пользователь с таким email уже существует")
+
 // UserService определяет интерфейс для работы с пользователями.
 type UserService interface {
 	CreateUser(ctx context.Context, username, email, password string) (*domain.User, error)
@@ -17,53 +26,96 @@ type UserService interface {
 	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
 	UpdateUser(ctx context.Context, id uuid.UUID, username, email string) (*domain.User, error)
 	DeleteUser(ctx context.Context, id uuid.UUID) error
+	// VerifyPassword сравнивает пароль с хешем пароля пользователя и
+	// возвращает auth.ErrPasswordMismatch, если он не совпадает. Хеш,
+	// созданный другим алгоритмом или с устаревшими параметрами, после
+	// успешной проверки заменяется новым.
+	VerifyPassword(ctx context.Context, user *domain.User, password string) error
 }
 
-// DefaultUserService реализует интерфейс UserService.
+// DefaultUserService реализует интерфейс UserService. После регистрации и
+// смены email пользователю отправляется ссылка для подтверждения адреса.
 type DefaultUserService struct {
-	userRepo repository.UserRepository
+	userRepo            repository.UserRepository
+	verificationService EmailVerificationService
+	passwords           Passwords
 }
 
 // NewUserService создает новый экземпляр DefaultUserService.
-func NewUserService(userRepo repository.UserRepository) *DefaultUserService {
-	return &DefaultUserService{userRepo: userRepo}
+func NewUserService(userRepo repository.UserRepository, verificationService EmailVerificationService, passwords Passwords) *DefaultUserService {
+	return &DefaultUserService{userRepo: userRepo, verificationService: verificationService, passwords: passwords}
 }
 
-func (s *DefaultUserService) CreateUser(ctx context.Context, username, email, password string) (*domain.User, error) {
-	if username == "" || email == "" || password == "" {
-		return nil, fmt.Errorf("необходимо заполнить все поля")
+// emailRegex — допустимый формат email пользователя.
+var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
+
+// userField — поле запроса пользователя для проверки заполненности.
+type userField struct{ name, value string }
+
+// validateUserFields проверяет, что все поля заполнены, а email имеет
+// верный формат.
+func validateUserFields(email string, fields ...userField) error {
+	var missing []domain.FieldError
+	for _, field := range fields {
+		if field.value == "" {
+			missing = append(missing, domain.FieldError{Field: field.name, Code: "field_required", Message: "поле обязательно для заполнения"})
+		}
+	}
+	if len(missing) > 0 {
+		return domain.NewError(domain.ErrValidation, "user.fields_required", "необходимо заполнить все поля").WithFields(missing...)
 	}
 
-	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
 	if !emailRegex.MatchString(email) {
-		return nil, fmt.Errorf("неверный формат email")
+		return domain.NewFieldError("email", "user.invalid_email", "неверный формат email")
+	}
+	return nil
+}
+
+func (s *DefaultUserService) CreateUser(ctx context.Context, username, email, password string) (*domain.User, error) {
+	if err := validateUserFields(email, userField{"username", username}, userField{"email", email}, userField{"password", password}); err != nil {
+		return nil, err
+	}
+	hash, err := s.passwords.hashNew("password", password)
+	if err != nil {
+		return nil, err
 	}
 
 	existingUser, err := s.userRepo.GetByEmail(ctx, email)
 	if err == nil && existingUser != nil {
-		return nil, fmt.Errorf("пользователь с таким email уже существует")
+		return nil, ErrEmailTaken
+	}
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		return nil, fmt.Errorf("ошибка при проверке email: %w", err)
 	}
 
 	user := &domain.User{
 		ID:       uuid.New(),
 		Username: username,
 		Email:    email,
-	}
-
-	if err := user.HashPassword(password); err != nil {
-		return nil, fmt.Errorf("ошибка при хешировании пароля: %w", err)
+		Password: hash,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, ErrEmailTaken
+		}
 		return nil, fmt.Errorf("ошибка при создании пользователя: %w", err)
 	}
 
+	s.sendVerification(ctx, user)
 	return user, nil
 }
 
 func (s *DefaultUserService) GetUserByID(ctx context.Context, id uuid.UUID) (*domain.User, error) {
+	if err := authorize(ctx, id, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
 		return nil, fmt.Errorf("ошибка при получении пользователя по ID: %w", err)
 	}
 	return user, nil
@@ -72,31 +124,99 @@ func (s *DefaultUserService) GetUserByID(ctx context.Context, id uuid.UUID) (*do
 func (s *DefaultUserService) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
 		return nil, fmt.Errorf("ошибка при получении пользователя по email: %w", err)
 	}
 	return user, nil
 }
 
 func (s *DefaultUserService) UpdateUser(ctx context.Context, id uuid.UUID, username, email string) (*domain.User, error) {
+	if err := authorize(ctx, id, ErrUserNotFound); err != nil {
+		return nil, err
+	}
+	if err := validateUserFields(email, userField{"username", username}, userField{"email", email}); err != nil {
+		return nil, err
+	}
+
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("пользователь не найден")
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("ошибка при получении пользователя по ID: %w", err)
 	}
 
+	// Новый адрес нужно подтвердить заново
+	emailChanged := user.Email != email
 	user.Username = username
 	user.Email = email
+	if emailChanged {
+		user.EmailVerified = false
+	}
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, ErrEmailTaken
+		}
 		return nil, fmt.Errorf("ошибка при обновлении пользователя: %w", err)
 	}
 
+	if emailChanged {
+		s.sendVerification(ctx, user)
+	}
 	return user, nil
 }
 
 func (s *DefaultUserService) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	if err := authorize(ctx, id, ErrUserNotFound); err != nil {
+		return err
+	}
+
 	err := s.userRepo.Delete(ctx, id)
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении пользователя: %w", err)
 	}
 	return nil
 }
+
+func (s *DefaultUserService) VerifyPassword(ctx context.Context, user *domain.User, password string) error {
+	rehash, err := s.passwords.Hasher.Verify(user.Password, password)
+	if err != nil {
+		if !errors.Is(err, auth.ErrPasswordMismatch) {
+			log.Printf("failed to verify password hash of user %s: %v", user.ID, err)
+		}
+		return auth.ErrPasswordMismatch
+	}
+	if rehash {
+		s.rehashPassword(ctx, user, password)
+	}
+	return nil
+}
+
+// rehashPassword заменяет хеш пароля хешем с текущими параметрами. Ошибка
+// только записывается в лог: вход уже выполнен, а хеш заменится при
+// следующем входе.
+func (s *DefaultUserService) rehashPassword(ctx context.Context, user *domain.User, password string) {
+	hash, err := s.passwords.Hasher.Hash(password)
+	if err != nil {
+		log.Printf("failed to rehash password of user %s: %v", user.ID, err)
+		return
+	}
+	// Хеш заменяется, только если пароль не сменили во время входа
+	if err := s.userRepo.ReplacePasswordHash(ctx, user.ID, user.Password, hash); err != nil {
+		log.Printf("failed to rehash password of user %s: %v", user.ID, err)
+		return
+	}
+	user.Password = hash
+}
+
+// sendVerification отправляет ссылку для подтверждения email. Ошибка
+// отправки только записывается в лог: пользователь уже сохранен и может
+// запросить ссылку повторно.
+func (s *DefaultUserService) sendVerification(ctx context.Context, user *domain.User) {
+	if err := s.verificationService.SendVerification(ctx, user); err != nil {
+		log.Printf("failed to send verification email to user %s: %v", user.ID, err)
+	}
+}
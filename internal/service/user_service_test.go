@@ -3,12 +3,16 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/MosinEvgeny/task-tracker/internal/auth"
 	"github.com/MosinEvgeny/task-tracker/internal/domain"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockUserRepository - это mock для UserRepository.
@@ -49,10 +53,31 @@ func (m *MockUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) SetTokensValidAfter(ctx context.Context, id uuid.UUID, validAfter time.Time) error {
+	args := m.Called(ctx, id, validAfter)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, email string) error {
+	args := m.Called(ctx, id, email)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ReplacePasswordHash(ctx context.Context, id uuid.UUID, oldHash, newHash string) error {
+	args := m.Called(ctx, id, oldHash, newHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetAdmin(ctx context.Context, id uuid.UUID, isAdmin bool) error {
+	args := m.Called(ctx, id, isAdmin)
+	return args.Error(0)
+}
+
 func TestCreateUser(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	mockVerification := new(MockEmailVerificationService)
+	userService := NewUserService(mockRepo, mockVerification, newTestPasswords(t))
 	ctx := context.Background()
 
 	username := "testuser"
@@ -66,11 +91,14 @@ func TestCreateUser(t *testing.T) {
 	}
 
 	// Настройка mock-репозитория
-	mockRepo.On("GetByEmail", ctx, email).Return(nil, errors.New("user not found")) // Имитируем, что пользователя с таким email не существует
+	mockRepo.On("GetByEmail", ctx, email).Return(nil, domain.ErrNotFound) // Имитируем, что пользователя с таким email не существует
 	mockRepo.On("Create", ctx, mock.MatchedBy(func(user *domain.User) bool {
 		expectedUser.ID = user.ID // Сохраняем ID, чтобы потом сравнить
 		return user.Username == username && user.Email == email
 	})).Return(nil)
+	mockVerification.On("SendVerification", ctx, mock.MatchedBy(func(user *domain.User) bool {
+		return user.Email == email && !user.EmailVerified
+	})).Return(nil)
 
 	// 2. Act
 	user, err := userService.CreateUser(ctx, username, email, password)
@@ -83,12 +111,13 @@ func TestCreateUser(t *testing.T) {
 	assert.NotEmpty(t, user.Password) // Проверяем, что пароль был захэширован
 
 	mockRepo.AssertExpectations(t) // Проверяем, что все ожидаемые вызовы mock-методов были выполнены
+	mockVerification.AssertExpectations(t)
 }
 
 func TestCreateUser_InvalidEmail(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
 	ctx := context.Background()
 
 	username := "testuser"
@@ -102,6 +131,7 @@ func TestCreateUser_InvalidEmail(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, user)
 	assert.EqualError(t, err, "неверный формат email")
+	assert.ErrorIs(t, err, domain.ErrValidation)
 
 	mockRepo.AssertExpectations(t) // Проверяем, что mock-методы не вызывались
 }
@@ -109,7 +139,7 @@ func TestCreateUser_InvalidEmail(t *testing.T) {
 func TestCreateUser_ExistingEmail(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
 	ctx := context.Background()
 
 	username := "testuser"
@@ -126,6 +156,7 @@ func TestCreateUser_ExistingEmail(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, user)
 	assert.EqualError(t, err, "пользователь с таким email уже существует")
+	assert.ErrorIs(t, err, domain.ErrConflict)
 
 	mockRepo.AssertExpectations(t) // Проверяем, что вызовы mock-методов соответствуют ожиданиям
 }
@@ -133,10 +164,11 @@ func TestCreateUser_ExistingEmail(t *testing.T) {
 func TestGetUserByID(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
 	ctx := context.Background()
 
 	userID := uuid.New()
+	ctx = auth.ContextWithUser(ctx, userID)
 	expectedUser := &domain.User{ID: userID, Username: "testuser", Email: "test@example.com"}
 
 	// Настройка mock-репозитория
@@ -155,10 +187,11 @@ func TestGetUserByID(t *testing.T) {
 func TestGetUserByID_NotFound(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
 	ctx := context.Background()
 
 	userID := uuid.New()
+	ctx = auth.ContextWithUser(ctx, userID)
 
 	// Настройка mock-репозитория
 	mockRepo.On("GetByID", ctx, userID).Return(nil, errors.New("user not found"))
@@ -174,21 +207,46 @@ func TestGetUserByID_NotFound(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestGetUserByID_DatabaseError(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+
+	// Настройка mock-репозитория
+	mockRepo.On("GetByID", ctx, userID).Return(nil, errors.New("connection refused"))
+
+	// 2. Act
+	user, err := userService.GetUserByID(ctx, userID)
+
+	// 3. Assert
+	assert.Nil(t, user)
+	assert.NotErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestUpdateUser(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	mockVerification := new(MockEmailVerificationService)
+	userService := NewUserService(mockRepo, mockVerification, newTestPasswords(t))
 	ctx := context.Background()
 
 	userID := uuid.New()
-	initialUser := &domain.User{ID: userID, Username: "olduser", Email: "old@example.com"}
+	ctx = auth.ContextWithUser(ctx, userID)
+	initialUser := &domain.User{ID: userID, Username: "olduser", Email: "old@example.com", EmailVerified: true}
 	updatedUsername := "newuser"
 	updatedEmail := "new@example.com"
 
 	// Настройка mock-репозитория
 	mockRepo.On("GetByID", ctx, userID).Return(initialUser, nil)
 	mockRepo.On("Update", ctx, mock.MatchedBy(func(user *domain.User) bool {
-		return user.ID == userID && user.Username == updatedUsername && user.Email == updatedEmail
+		return user.ID == userID && user.Username == updatedUsername && user.Email == updatedEmail && !user.EmailVerified
+	})).Return(nil)
+	mockVerification.On("SendVerification", ctx, mock.MatchedBy(func(user *domain.User) bool {
+		return user.Email == updatedEmail
 	})).Return(nil)
 
 	// 2. Act
@@ -201,20 +259,82 @@ func TestUpdateUser(t *testing.T) {
 	assert.Equal(t, updatedEmail, user.Email)
 
 	mockRepo.AssertExpectations(t)
+	mockVerification.AssertExpectations(t)
+}
+
+func TestUpdateUser_SameEmail(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	userID := uuid.New()
+	ctx := auth.ContextWithUser(context.Background(), userID)
+	initialUser := &domain.User{ID: userID, Username: "olduser", Email: "old@example.com", EmailVerified: true}
+
+	mockRepo.On("GetByID", ctx, userID).Return(initialUser, nil)
+	mockRepo.On("Update", ctx, mock.MatchedBy(func(user *domain.User) bool {
+		return user.Username == "newuser" && user.EmailVerified
+	})).Return(nil)
+
+	// 2. Act
+	user, err := userService.UpdateUser(ctx, userID, "newuser", "old@example.com")
+
+	// 3. Assert: подтверждение не сбрасывается и письмо не отправляется
+	assert.NoError(t, err)
+	assert.True(t, user.EmailVerified)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateUser_InvalidEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		email string
+		code  string
+	}{
+		{"неверный формат", "invalid-email", "user.invalid_email"},
+		{"пустой email", "", "user.fields_required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 1. Arrange
+			mockRepo := new(MockUserRepository)
+			mockVerification := new(MockEmailVerificationService)
+			userService := NewUserService(mockRepo, mockVerification, newTestPasswords(t))
+			userID := uuid.New()
+			ctx := auth.ContextWithUser(context.Background(), userID)
+
+			// 2. Act
+			user, err := userService.UpdateUser(ctx, userID, "newuser", tt.email)
+
+			// 3. Assert
+			assert.Nil(t, user)
+			var domainErr *domain.Error
+			require.ErrorAs(t, err, &domainErr)
+			assert.Equal(t, tt.code, domainErr.Code)
+			require.NotEmpty(t, domainErr.Fields)
+			assert.Equal(t, "email", domainErr.Fields[0].Field)
+
+			// Пользователь не сохраняется, письмо не отправляется
+			mockRepo.AssertExpectations(t)
+			mockVerification.AssertExpectations(t)
+		})
+	}
 }
 
 func TestUpdateUser_NotFound(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
 	ctx := context.Background()
 
 	userID := uuid.New()
+	ctx = auth.ContextWithUser(ctx, userID)
 	updatedUsername := "newuser"
 	updatedEmail := "new@example.com"
 
 	// Настройка mock-репозитория
-	mockRepo.On("GetByID", ctx, userID).Return(nil, errors.New("user not found"))
+	mockRepo.On("GetByID", ctx, userID).Return(nil, domain.ErrNotFound)
 
 	// 2. Act
 	user, err := userService.UpdateUser(ctx, userID, updatedUsername, updatedEmail)
@@ -230,10 +350,11 @@ func TestUpdateUser_NotFound(t *testing.T) {
 func TestDeleteUser(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
 	ctx := context.Background()
 
 	userID := uuid.New()
+	ctx = auth.ContextWithUser(ctx, userID)
 
 	// Настройка mock-репозитория
 	mockRepo.On("Delete", ctx, userID).Return(nil)
@@ -250,10 +371,11 @@ func TestDeleteUser(t *testing.T) {
 func TestDeleteUser_Error(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
 	ctx := context.Background()
 
 	userID := uuid.New()
+	ctx = auth.ContextWithUser(ctx, userID)
 	expectedError := errors.New("delete error")
 
 	// Настройка mock-репозитория
@@ -269,10 +391,80 @@ func TestDeleteUser_Error(t *testing.T) {
 	mockRepo.AssertExpectations(t)
 }
 
+func TestCreateUser_DuplicateOnInsert(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	ctx := context.Background()
+
+	email := "test@example.com"
+
+	// Email заняли между проверкой и вставкой, сработало ограничение уникальности
+	mockRepo.On("GetByEmail", ctx, email).Return(nil, domain.ErrNotFound)
+	mockRepo.On("Create", ctx, mock.AnythingOfType("*domain.User")).Return(domain.NewError(domain.ErrConflict, "user.email_taken", "duplicate key"))
+
+	// 2. Act
+	user, err := userService.CreateUser(ctx, "testuser", email, "password")
+
+	// 3. Assert
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, domain.ErrConflict)
+	assert.EqualError(t, err, "пользователь с таким email уже существует")
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGetUserByID_ForeignUser(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	// 2. Act
+	user, err := userService.GetUserByID(ctx, uuid.New())
+
+	// 3. Assert
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertNotCalled(t, "GetByID", mock.Anything, mock.Anything)
+}
+
+func TestUpdateUser_ForeignUser(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	// 2. Act
+	user, err := userService.UpdateUser(ctx, uuid.New(), "newuser", "new@example.com")
+
+	// 3. Assert
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestDeleteUser_ForeignUser(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	ctx := auth.ContextWithUser(context.Background(), uuid.New())
+
+	// 2. Act
+	err := userService.DeleteUser(ctx, uuid.New())
+
+	// 3. Assert
+	assert.ErrorIs(t, err, domain.ErrNotFound)
+
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
 func TestGetUserByEmail(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
 	ctx := context.Background()
 
 	email := "test@example.com"
@@ -294,7 +486,7 @@ func TestGetUserByEmail(t *testing.T) {
 func TestGetUserByEmail_NotFound(t *testing.T) {
 	// 1. Arrange
 	mockRepo := new(MockUserRepository)
-	userService := NewUserService(mockRepo)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
 	ctx := context.Background()
 
 	email := "test@example.com"
@@ -312,3 +504,97 @@ func TestGetUserByEmail_NotFound(t *testing.T) {
 
 	mockRepo.AssertExpectations(t)
 }
+
+func TestCreateUser_WeakPassword(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	ctx := context.Background()
+
+	// 2. Act
+	_, short := userService.CreateUser(ctx, "testuser", "test@example.com", "short")
+	_, breached := userService.CreateUser(ctx, "testuser", "test@example.com", "qwerty123")
+
+	// 3. Assert
+	assert.ErrorIs(t, short, ErrPasswordTooShort)
+	var domainErr *domain.Error
+	if assert.ErrorAs(t, short, &domainErr) {
+		assert.Equal(t, "8", domainErr.Params["min_length"])
+		assert.Equal(t, []domain.FieldError{{Field: "password", Code: "password.too_short", Message: ErrPasswordTooShort.Message}}, domainErr.Fields)
+	}
+	assert.ErrorIs(t, breached, ErrPasswordBreached)
+
+	mockRepo.AssertExpectations(t) // Пользователь не создается
+}
+
+func TestVerifyPassword(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	ctx := context.Background()
+	user := &domain.User{ID: uuid.New(), Password: hashPassword(t, "password")}
+
+	// 2. Act
+	ok := userService.VerifyPassword(ctx, user, "password")
+	wrong := userService.VerifyPassword(ctx, user, "wrong")
+	empty := userService.VerifyPassword(ctx, &domain.User{ID: uuid.New()}, "")
+
+	// 3. Assert
+	assert.NoError(t, ok)
+	assert.ErrorIs(t, wrong, auth.ErrPasswordMismatch)
+	assert.ErrorIs(t, empty, auth.ErrPasswordMismatch, "у пользователя без пароля вход по паролю невозможен")
+
+	mockRepo.AssertExpectations(t) // Хеш с текущими параметрами не заменяется
+}
+
+func TestVerifyPassword_RehashesOutdatedHash(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	ctx := context.Background()
+
+	bcryptHasher, err := auth.NewPasswordHasher(auth.PasswordHashConfig{Algorithm: auth.PasswordBcrypt, BcryptCost: 4})
+	assert.NoError(t, err)
+	oldHash, err := bcryptHasher.Hash("password")
+	assert.NoError(t, err)
+	user := &domain.User{ID: uuid.New(), Password: oldHash}
+
+	mockRepo.On("ReplacePasswordHash", ctx, user.ID, oldHash, mock.MatchedBy(func(hash string) bool {
+		return strings.HasPrefix(hash, "$argon2id$")
+	})).Return(nil)
+
+	// 2. Act
+	err = userService.VerifyPassword(ctx, user, "password")
+
+	// 3. Assert
+	assert.NoError(t, err)
+	assert.NoError(t, verifyPassword(t, user, "password"))
+	assert.True(t, strings.HasPrefix(user.Password, "$argon2id$"))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestVerifyPassword_RehashFailureKeepsLogin(t *testing.T) {
+	// 1. Arrange
+	mockRepo := new(MockUserRepository)
+	userService := NewUserService(mockRepo, new(MockEmailVerificationService), newTestPasswords(t))
+	ctx := context.Background()
+
+	bcryptHasher, err := auth.NewPasswordHasher(auth.PasswordHashConfig{Algorithm: auth.PasswordBcrypt, BcryptCost: 4})
+	assert.NoError(t, err)
+	oldHash, err := bcryptHasher.Hash("password")
+	assert.NoError(t, err)
+	user := &domain.User{ID: uuid.New(), Password: oldHash}
+
+	// Пароль сменили во время входа
+	mockRepo.On("ReplacePasswordHash", ctx, user.ID, oldHash, mock.Anything).Return(domain.ErrNotFound)
+
+	// 2. Act
+	err = userService.VerifyPassword(ctx, user, "password")
+
+	// 3. Assert
+	assert.NoError(t, err)
+	assert.Equal(t, oldHash, user.Password)
+
+	mockRepo.AssertExpectations(t)
+}